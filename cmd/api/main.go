@@ -23,13 +23,14 @@ import (
 	"backend/internal/config"
 	"backend/internal/database"
 	"backend/internal/server"
+	"backend/internal/worker"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
-func gracefulShutdown(apiServer *http.Server, done chan bool) {
+func gracefulShutdown(apiServer *http.Server, workers []*worker.PeriodicWorker, done chan bool) {
 	// Create context that listens for the interrupt signal from the OS.
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -48,6 +49,12 @@ func gracefulShutdown(apiServer *http.Server, done chan bool) {
 		slog.Error("Server forced to shutdown with error: ", slog.Any("error", err))
 	}
 
+	for _, w := range workers {
+		if err := w.Stop(ctx); err != nil {
+			slog.Error("Worker forced to stop with error: ", slog.String("worker", w.Name()), slog.Any("error", err))
+		}
+	}
+
 	slog.Info("Server exiting")
 
 	// Notify the main goroutine that the shutdown is complete
@@ -65,8 +72,21 @@ func main() {
 	database.Migrate("")
 
 	apiServer := server.NewServer()
+
+	workers := []*worker.PeriodicWorker{
+		worker.NewPaymentReconciler(worker.ReconcileIntervalFromEnv()),
+		worker.NewInvoiceMatcher(worker.InvoiceMatchIntervalFromEnv()),
+		worker.NewBillingScheduler(worker.BillingIntervalFromEnv()),
+		worker.NewIncomingPaymentIndexer(worker.IncomingIndexIntervalFromEnv()),
+		worker.NewWebhookDispatcher(worker.WebhookDeliveryIntervalFromEnv()),
+		worker.NewIdempotencyJanitor(worker.IdempotencyCleanupIntervalFromEnv()),
+	}
+	for _, w := range workers {
+		w.Start()
+	}
+
 	done := make(chan bool, 1)
-	go gracefulShutdown(apiServer, done)
+	go gracefulShutdown(apiServer, workers, done)
 
 	err := apiServer.ListenAndServe()
 	if err != nil && !errors.Is(err, http.ErrServerClosed) {
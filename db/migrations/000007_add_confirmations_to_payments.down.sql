@@ -0,0 +1,3 @@
+ALTER TABLE payments
+    DROP COLUMN IF EXISTS required_confirmations,
+    DROP COLUMN IF EXISTS confirmations;
@@ -0,0 +1,3 @@
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS confirmations BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS required_confirmations BIGINT NOT NULL DEFAULT 0;
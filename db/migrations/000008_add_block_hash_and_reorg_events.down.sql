@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_payments_block_number;
+DROP TABLE IF EXISTS payment_reorg_events;
+ALTER TABLE payments DROP COLUMN IF EXISTS block_hash;
@@ -0,0 +1,14 @@
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS block_hash TEXT;
+
+CREATE TABLE payment_reorg_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    block_number BIGINT NOT NULL,
+    orphaned_block_hash TEXT NOT NULL,
+    canonical_block_hash TEXT NOT NULL,
+    previous_status VARCHAR(20) NOT NULL,
+    detected_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_payment_reorg_events_payment_id ON payment_reorg_events(payment_id);
+CREATE INDEX idx_payments_block_number ON payments(block_number);
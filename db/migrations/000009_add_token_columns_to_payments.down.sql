@@ -0,0 +1,3 @@
+ALTER TABLE payments
+    DROP COLUMN IF EXISTS token_decimals,
+    DROP COLUMN IF EXISTS token_address;
@@ -0,0 +1,3 @@
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS token_address TEXT;
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS token_decimals SMALLINT NOT NULL DEFAULT 18;
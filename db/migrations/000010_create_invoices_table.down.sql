@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_payments_invoice_id;
+ALTER TABLE payments DROP COLUMN IF EXISTS invoice_id;
+
+DROP TABLE IF EXISTS invoices;
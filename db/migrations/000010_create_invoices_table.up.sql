@@ -0,0 +1,23 @@
+CREATE TABLE invoices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recipient_address TEXT NOT NULL,
+    amount NUMERIC NOT NULL,
+    paid_amount NUMERIC NOT NULL DEFAULT 0,
+    currency VARCHAR(10) NOT NULL DEFAULT 'ETH',
+    token_address TEXT,
+    token_decimals SMALLINT NOT NULL DEFAULT 18,
+    memo TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    expires_at TIMESTAMP NOT NULL,
+    paid_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_invoices_user_id ON invoices(user_id);
+CREATE INDEX idx_invoices_recipient_status ON invoices(LOWER(recipient_address), status);
+
+-- Payments matched to an invoice
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS invoice_id UUID REFERENCES invoices(id) ON DELETE SET NULL;
+CREATE INDEX idx_payments_invoice_id ON payments(invoice_id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS payment_intents;
+
+DROP INDEX IF EXISTS idx_wallet_address_phone_preferred;
+ALTER TABLE wallet_address_phone DROP COLUMN IF EXISTS is_preferred;
@@ -0,0 +1,25 @@
+-- A phone number's preferred wallet receives pay-by-phone payments
+ALTER TABLE wallet_address_phone ADD COLUMN IF NOT EXISTS is_preferred BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE UNIQUE INDEX idx_wallet_address_phone_preferred ON wallet_address_phone(phone_number) WHERE is_preferred;
+
+CREATE TABLE payment_intents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recipient_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recipient_phone TEXT NOT NULL,
+    recipient_address TEXT NOT NULL,
+    amount NUMERIC NOT NULL,
+    currency VARCHAR(10) NOT NULL DEFAULT 'ETH',
+    token_address TEXT,
+    token_decimals SMALLINT NOT NULL DEFAULT 18,
+    description TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    payment_id UUID REFERENCES payments(id) ON DELETE SET NULL,
+    transaction_hash TEXT,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_payment_intents_payer_id ON payment_intents(payer_id);
+CREATE INDEX idx_payment_intents_recipient_id ON payment_intents(recipient_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_payments_user_amount;
+DROP INDEX IF EXISTS idx_payments_user_created_at;
@@ -0,0 +1,3 @@
+-- Keyset pagination of a user's payment history by each sort field
+CREATE INDEX idx_payments_user_created_at ON payments(user_id, created_at DESC, id DESC);
+CREATE INDEX idx_payments_user_amount ON payments(user_id, amount DESC, id DESC);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_payments_user_direction;
+
+-- Incoming payments found by the indexer have no counterpart before this migration
+DELETE FROM payments WHERE direction = 'incoming' AND invoice_id IS NULL;
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_transaction_hash_user_id_direction_key;
+ALTER TABLE payments ADD CONSTRAINT payments_transaction_hash_user_id_key UNIQUE (transaction_hash, user_id);
+ALTER TABLE payments DROP COLUMN IF EXISTS direction;
@@ -0,0 +1,12 @@
+-- Payments received by a user's wallets are recorded alongside those they send
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS direction VARCHAR(10) NOT NULL DEFAULT 'outgoing';
+
+-- Invoice payments are received by the invoice owner
+UPDATE payments SET direction = 'incoming' WHERE invoice_id IS NOT NULL;
+
+-- A user who pays one of their own wallets has both an outgoing and an
+-- incoming payment for the same transaction
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_transaction_hash_user_id_key;
+ALTER TABLE payments ADD CONSTRAINT payments_transaction_hash_user_id_direction_key UNIQUE (transaction_hash, user_id, direction);
+
+CREATE INDEX idx_payments_user_direction ON payments(user_id, direction);
@@ -0,0 +1,19 @@
+-- Running total of confirmed refunds, kept on the payment so lists need no join
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS refunded_amount NUMERIC NOT NULL DEFAULT 0;
+
+CREATE TABLE refunds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    from_address TEXT NOT NULL,
+    to_address TEXT NOT NULL,
+    amount NUMERIC NOT NULL,
+    transaction_hash TEXT NOT NULL,
+    block_number BIGINT,
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    -- A refund transaction can only be counted once per user
+    CONSTRAINT refunds_transaction_hash_user_id_key UNIQUE (transaction_hash, user_id)
+);
+
+CREATE INDEX idx_refunds_payment_id ON refunds(payment_id);
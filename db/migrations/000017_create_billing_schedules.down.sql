@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_payments_billing_period_id;
+ALTER TABLE payments DROP COLUMN IF EXISTS billing_period_id;
+DROP TABLE IF EXISTS billing_periods;
+DROP TABLE IF EXISTS billing_schedules;
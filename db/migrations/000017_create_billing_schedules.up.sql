@@ -0,0 +1,47 @@
+-- Recurring charges a merchant bills to a payer's wallet
+CREATE TABLE billing_schedules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    payer_address TEXT NOT NULL,
+    payee_address TEXT NOT NULL,
+    amount NUMERIC NOT NULL,
+    currency VARCHAR(10) NOT NULL DEFAULT 'ETH',
+    token_address TEXT,
+    token_decimals SMALLINT NOT NULL DEFAULT 18,
+    billing_interval VARCHAR(20) NOT NULL,
+    grace_period_days INTEGER NOT NULL DEFAULT 0,
+    description TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP,
+    periods_generated INTEGER NOT NULL DEFAULT 0,
+    next_period_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_billing_schedules_user_id ON billing_schedules(user_id);
+CREATE INDEX idx_billing_schedules_next_period ON billing_schedules(next_period_at) WHERE status = 'active';
+
+-- One payment request per schedule and billing period
+CREATE TABLE billing_periods (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    schedule_id UUID NOT NULL REFERENCES billing_schedules(id) ON DELETE CASCADE,
+    period_number INTEGER NOT NULL,
+    period_start TIMESTAMP NOT NULL,
+    period_end TIMESTAMP NOT NULL,
+    due_at TIMESTAMP NOT NULL,
+    amount NUMERIC NOT NULL,
+    paid_amount NUMERIC NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'due',
+    paid_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (schedule_id, period_number)
+);
+
+CREATE INDEX idx_billing_periods_status_due_at ON billing_periods(status, due_at);
+
+-- Payments matched to a billing period
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS billing_period_id UUID REFERENCES billing_periods(id) ON DELETE SET NULL;
+CREATE INDEX idx_payments_billing_period_id ON payments(billing_period_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS split_shares;
+DROP TABLE IF EXISTS expense_splits;
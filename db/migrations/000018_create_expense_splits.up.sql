@@ -0,0 +1,36 @@
+-- Group expenses an organizer splits among other users
+CREATE TABLE expense_splits (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    payee_address TEXT NOT NULL,
+    total_amount NUMERIC NOT NULL,
+    currency VARCHAR(10) NOT NULL DEFAULT 'ETH',
+    token_address TEXT,
+    token_decimals SMALLINT NOT NULL DEFAULT 18,
+    split_method VARCHAR(20) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_expense_splits_user_id ON expense_splits(user_id);
+
+-- What each participant owes the organizer, settled by a payment from them
+CREATE TABLE split_shares (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    split_id UUID NOT NULL REFERENCES expense_splits(id) ON DELETE CASCADE,
+    participant_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount NUMERIC NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'outstanding',
+    payment_id UUID REFERENCES payments(id) ON DELETE SET NULL,
+    transaction_hash TEXT,
+    settled_at TIMESTAMP,
+    reminder_count INTEGER NOT NULL DEFAULT 0,
+    last_reminded_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (split_id, participant_id)
+);
+
+CREATE INDEX idx_split_shares_participant_id ON split_shares(participant_id);
+CREATE INDEX idx_split_shares_payment_id ON split_shares(payment_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_contacts_user_address;
+DROP TABLE IF EXISTS contacts;
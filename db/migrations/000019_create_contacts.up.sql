@@ -0,0 +1,15 @@
+-- Per-user address book of labeled payment counterparties
+CREATE TABLE contacts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    address TEXT NOT NULL,
+    phone_number TEXT,
+    username TEXT,
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Addresses are compared case-insensitively, as checksum casing varies
+CREATE UNIQUE INDEX idx_contacts_user_address ON contacts(user_id, LOWER(address));
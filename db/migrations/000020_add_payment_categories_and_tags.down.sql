@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_payments_tags;
+DROP INDEX IF EXISTS idx_payments_category_id;
+ALTER TABLE payments
+    DROP COLUMN IF EXISTS tags,
+    DROP COLUMN IF EXISTS category_id;
+DROP INDEX IF EXISTS idx_payment_categories_user_name;
+DROP TABLE IF EXISTS payment_categories;
@@ -0,0 +1,19 @@
+-- User-defined categories payments can be filed under
+CREATE TABLE payment_categories (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Category names are unique per user regardless of casing
+CREATE UNIQUE INDEX idx_payment_categories_user_name ON payment_categories(user_id, LOWER(name));
+
+-- Deleting a category leaves its payments uncategorized
+ALTER TABLE payments
+    ADD COLUMN category_id UUID REFERENCES payment_categories(id) ON DELETE SET NULL,
+    ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_payments_category_id ON payments(category_id);
+CREATE INDEX idx_payments_tags ON payments USING GIN (tags);
@@ -0,0 +1,7 @@
+ALTER TABLE payments
+    DROP COLUMN IF EXISTS fiat_quoted_at,
+    DROP COLUMN IF EXISTS fiat_source,
+    DROP COLUMN IF EXISTS fiat_value,
+    DROP COLUMN IF EXISTS fiat_rate,
+    DROP COLUMN IF EXISTS fiat_currency;
+ALTER TABLE users DROP COLUMN IF EXISTS display_currency;
@@ -0,0 +1,11 @@
+-- Fiat currency payments are valued and totalled in for each user
+ALTER TABLE users ADD COLUMN display_currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+
+-- Fiat value of a payment at the rate quoted when it was recorded, and again
+-- when it confirmed. All NULL for payments that could not be valued.
+ALTER TABLE payments
+    ADD COLUMN fiat_currency VARCHAR(3),
+    ADD COLUMN fiat_rate NUMERIC,
+    ADD COLUMN fiat_value NUMERIC,
+    ADD COLUMN fiat_source VARCHAR(50),
+    ADD COLUMN fiat_quoted_at TIMESTAMP;
@@ -0,0 +1,6 @@
+-- The gas limits and offered prices cleared by the up migration are not
+-- restored; gas_used and gas_price keep the receipt values.
+ALTER TABLE payments
+    DROP COLUMN IF EXISTS gas_fee,
+    DROP COLUMN IF EXISTS priority_fee_per_gas,
+    DROP COLUMN IF EXISTS base_fee_per_gas;
@@ -0,0 +1,20 @@
+-- Fee breakdown of a payment's transaction from its receipt, per gas in wei:
+-- gas_price is the effective price paid, the block's base fee (burned) plus
+-- the priority fee paid to the block producer. Both are NULL on chains
+-- without a base fee, and for payments recorded before they were tracked.
+ALTER TABLE payments
+    ADD COLUMN base_fee_per_gas NUMERIC,
+    ADD COLUMN priority_fee_per_gas NUMERIC;
+
+-- Payments recorded so far hold the transaction's gas limit and offered
+-- price rather than what its receipt says was paid. Clear them so they are
+-- not reported as fees; the payment reconciler reads them again from the
+-- receipts.
+UPDATE payments
+SET gas_used = NULL, gas_price = NULL
+WHERE gas_used IS NOT NULL OR gas_price IS NOT NULL;
+
+-- Total fee paid for the transaction in wei, kept in step with gas_used and
+-- gas_price by the database
+ALTER TABLE payments
+    ADD COLUMN gas_fee NUMERIC GENERATED ALWAYS AS (gas_used * gas_price) STORED;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS prepared_payments;
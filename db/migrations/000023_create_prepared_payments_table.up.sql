@@ -0,0 +1,28 @@
+-- Unsigned transactions prepared for a user's wallet to sign, bound to the
+-- payment recorded once the signed transaction is broadcast. The nonce is
+-- reserved for the sending wallet until the preparation expires.
+CREATE TABLE prepared_payments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    from_address TEXT NOT NULL,
+    to_address TEXT NOT NULL,
+    amount NUMERIC NOT NULL,
+    currency VARCHAR(10) NOT NULL DEFAULT 'ETH',
+    token_address TEXT,
+    token_decimals SMALLINT NOT NULL DEFAULT 18,
+    description TEXT,
+    chain_id BIGINT NOT NULL,
+    nonce BIGINT NOT NULL,
+    gas_limit BIGINT NOT NULL,
+    max_fee_per_gas NUMERIC NOT NULL,
+    max_priority_fee_per_gas NUMERIC NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    payment_id UUID REFERENCES payments(id) ON DELETE SET NULL,
+    transaction_hash TEXT,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_prepared_payments_user_id ON prepared_payments(user_id);
+CREATE INDEX idx_prepared_payments_pending_nonces ON prepared_payments(LOWER(from_address), nonce) WHERE status = 'pending';
@@ -0,0 +1 @@
+ALTER TABLE payments ADD CONSTRAINT payments_transaction_hash_key UNIQUE (transaction_hash);
@@ -0,0 +1,4 @@
+-- The same transaction can be recorded once per user and direction, e.g. by
+-- the payer and by the merchant whose invoice it settled. Databases that
+-- created the invoices table with this change have already dropped it.
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_transaction_hash_key;
@@ -0,0 +1,7 @@
+-- Progress of background block scanners. Databases that created the invoices
+-- table with this table already have it.
+CREATE TABLE IF NOT EXISTS chain_scan_cursors (
+    name TEXT PRIMARY KEY,
+    block_number BIGINT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -1,5 +1,6 @@
 // --- Global State ---
 let accessToken = null;
+let paymentStream = null;
 let provider;
 const API_BASE_URL = "http://localhost:8080";
 const GANACHE_CHAIN_ID = "0x0539"; // 1337 in hex
@@ -117,6 +118,7 @@ async function logout() {
   } catch (error) {
     console.error("Logout failed, clearing session anyway.", error);
   } finally {
+    closePaymentStream();
     accessToken = null;
     dashboardSection.classList.add("hidden");
     dashboardSection.innerHTML = ""; // Clear dashboard content
@@ -152,9 +154,49 @@ function renderDashboard() {
   // Initial data load
   fetchPaymentStats();
   fetchPaymentHistory();
+  openPaymentStream();
   initEthers();
 }
 
+// --- Live Payment Updates ---
+// The server pushes new payments and status changes, so the history no longer
+// needs to be polled. EventSource cannot send headers; the token goes in the query.
+function openPaymentStream() {
+  closePaymentStream();
+  if (!accessToken) return;
+
+  const stream = new EventSource(
+    API_BASE_URL +
+      "/payments/stream?access_token=" +
+      encodeURIComponent(accessToken)
+  );
+  const onPaymentEvent = () => {
+    fetchPaymentStats();
+    fetchPaymentHistory();
+  };
+  stream.addEventListener("payment.created", onPaymentEvent);
+  stream.addEventListener("payment.status_changed", onPaymentEvent);
+  stream.onerror = () => {
+    // EventSource reconnects by itself unless the server rejected the
+    // request, e.g. because the access token expired
+    if (stream.readyState !== EventSource.CLOSED) return;
+    setTimeout(async () => {
+      if (paymentStream !== stream) return;
+      await apiCall("/payments/stats"); // Refreshes the access token if needed
+      openPaymentStream();
+      onPaymentEvent(); // Catch up on anything missed while disconnected
+    }, 5000);
+  };
+  paymentStream = stream;
+}
+
+function closePaymentStream() {
+  if (paymentStream) {
+    paymentStream.close();
+    paymentStream = null;
+  }
+}
+
 async function fetchBalances() {
   const walletInfoEl = document.getElementById("wallet-info");
   walletInfoEl.innerHTML = `<p aria-busy="true">Fetching balances...</p>`;
@@ -187,7 +229,13 @@ async function fetchPaymentStats() {
     statsEl.innerHTML = `
                 <p><strong>Total Payments:</strong> ${stats.total_payments}</p>
                 <p><strong>Confirmed:</strong> ${stats.confirmed}</p>
-                <p><strong>Pending:</strong> ${stats.pending}</p>
+                <p><strong>Pending:</strong> ${stats.pending}</p>${
+                  stats.fiat
+                    ? `
+                <p><strong>Sent:</strong> ${stats.fiat.sent_formatted} ${stats.fiat.currency}</p>
+                <p><strong>Received:</strong> ${stats.fiat.received_formatted} ${stats.fiat.currency}</p>`
+                    : ""
+                }
             `;
   } catch (error) {
     statsEl.innerHTML = `<p class="error">Could not load stats.</p>`;
@@ -207,9 +255,23 @@ async function fetchPaymentHistory() {
         "<table><thead><tr><th>To</th><th>Amount</th><th>Status</th><th>Date</th></tr></thead><tbody>";
       result.payments.forEach((p) => {
         html += `<tr>
-                        <td><small>${p.to_address}</small></td>
-                        <td>${ethers.formatEther(p.amount)} ETH</td>
-                        <td>${p.status}</td>
+                        <td>${
+                          p.to_contact ? `${p.to_contact}<br>` : ""
+                        }<small>${p.to_address}</small></td>
+                        <td>${p.amount_formatted} ${p.currency}${
+                          p.fiat
+                            ? `<br><small>${p.fiat.value_formatted} ${p.fiat.currency}</small>`
+                            : ""
+                        }</td>
+                        <td>${
+                          p.status === "confirming"
+                            ? `confirming (${p.confirmations}/${p.required_confirmations})`
+                            : p.status
+                        }${
+                          p.refund_status
+                            ? ` <small>(${p.refund_status.replace("_", " ")})</small>`
+                            : ""
+                        }</td>
                         <td>${new Date(p.created_at).toLocaleString()}</td>
                     </tr>`;
       });
@@ -350,11 +412,37 @@ async function sendPayment(e) {
   }
 
   try {
-    paymentResponseEl.innerHTML = `<p aria-busy="true">Please confirm transaction in MetaMask...</p>`;
+    // Warn before paying an address that resembles a saved contact
+    const checkResponse = await apiCall(
+      `/contacts/check?address=${encodeURIComponent(toAddress)}`
+    );
+    const check = await checkResponse.json();
+    if (checkResponse.ok && check.warning && !confirm(`${check.warning}\n\nSend anyway?`)) {
+      paymentResponseEl.innerHTML = `<p class="error">Payment cancelled.</p>`;
+      return;
+    }
+
     const signer = await provider.getSigner();
+    const value = ethers.parseEther(amountEth);
+
+    // Offer the standard suggested fees, and stop early if the wallet cannot
+    // cover the amount plus the maximum fee
+    const feesResponse = await apiCall(
+      `/chain/fees?to=${encodeURIComponent(toAddress)}&amount=${value}&from=${encodeURIComponent(signer.address)}`
+    );
+    const fees = await feesResponse.json();
+    if (!feesResponse.ok) throw new Error(fees.error);
+    if (!fees.sufficient_balance) {
+      throw new Error(`Insufficient balance: sending needs up to ${fees.eth_needed_formatted} ETH including fees.`);
+    }
+
+    paymentResponseEl.innerHTML = `<p aria-busy="true">Please confirm transaction in MetaMask...</p>`;
     const tx = await signer.sendTransaction({
       to: toAddress,
-      value: ethers.parseEther(amountEth),
+      value,
+      gasLimit: BigInt(fees.gas_limit),
+      maxFeePerGas: BigInt(fees.standard.max_fee_per_gas),
+      maxPriorityFeePerGas: BigInt(fees.standard.max_priority_fee_per_gas),
     });
 
     paymentResponseEl.innerHTML = `<p aria-busy="true">Waiting for confirmation...</p>`;
@@ -362,7 +450,7 @@ async function sendPayment(e) {
 
     const response = await apiCall("/payments", "POST", {
       to_address: toAddress,
-      amount: ethers.parseEther(amountEth).toString(),
+      amount: value.toString(),
       transaction_hash: tx.hash,
       description: description,
     });
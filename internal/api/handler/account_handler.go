@@ -2,6 +2,7 @@ package handler
 
 import (
 	"backend/internal/model"
+	"backend/internal/repository"
 	"backend/internal/service"
 	"backend/internal/utils"
 	"errors"
@@ -95,6 +96,42 @@ func UpdateEmailHandler(c *gin.Context) {
 	JSONSuccess(c, http.StatusOK, gin.H{"message": "Email updated successfully"})
 }
 
+// UpdateDisplayCurrencyHandler sets the fiat currency payments are valued in
+func UpdateDisplayCurrencyHandler(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		JSONError(c, http.StatusUnauthorized, err.Error(), err)
+		return
+	}
+
+	var req model.UpdateDisplayCurrencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		JSONError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	currency, err := service.UpdateDisplayCurrencyService(c.Request.Context(), userID, req)
+	if err != nil {
+		if errors.Is(err, repository.ErrorUserNotFound) {
+			JSONError(c, http.StatusUnauthorized, err.Error(), err)
+			return
+		}
+		if errors.Is(err, repository.ErrorDatabase) {
+			slog.Error("Handler: UpdateDisplayCurrency failed unexpectedly", slog.String("userID", userID.String()), slog.Any("error", err))
+			JSONError(c, http.StatusInternalServerError, "Failed to update display currency", err)
+			return
+		}
+
+		JSONError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, gin.H{
+		"message":          "Display currency updated successfully",
+		"display_currency": currency,
+	})
+}
+
 func DeleteAccountHandler(c *gin.Context) {
 	userID, err := getUserIDFromContext(c)
 	if err != nil {
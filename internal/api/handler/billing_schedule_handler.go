@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateBillingScheduleHandler godoc
+//
+//	@Summary		Create Billing Schedule
+//	@Description	Bills a payer's wallet weekly or monthly into one of the user's wallets. Each interval generates a payment request that is settled by transfers from the payer and becomes overdue when unpaid after its grace period.
+//	@Tags			schedules
+//	@Accept			json
+//	@Produce		json
+//	@Param			scheduleRequest	body		model.CreateBillingScheduleRequest	true	"Billing schedule details"
+//	@Success		201				{object}	model.BillingScheduleResponse		"Billing schedule created successfully"
+//	@Failure		400				{string}	string								"Validation error or bad request"
+//	@Failure		401				{string}	string								"Unauthorized"
+//	@Failure		500				{string}	string								"Internal server error"
+//	@Router			/schedules [post]
+//	@Security		BearerAuth
+func CreateBillingScheduleHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	var req model.CreateBillingScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid request payload: "+err.Error(), err)
+		return
+	}
+
+	schedule, err := service.CreateBillingSchedule(c.Request.Context(), userIDStr, &req)
+	if err != nil {
+		slog.Error("Failed to create billing schedule", slog.Any("error", err), slog.String("userID", userIDStr))
+		JSONError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusCreated, gin.H{
+		"message":  "Billing schedule created successfully",
+		"schedule": schedule,
+	})
+}
+
+// GetUserBillingSchedulesHandler godoc
+//
+//	@Summary		Get User Billing Schedules
+//	@Description	Retrieves the authenticated user's billing schedules, newest first
+//	@Tags			schedules
+//	@Produce		json
+//	@Param			status	query		string							false	"Filter by schedule status"
+//	@Success		200		{array}		model.BillingScheduleResponse	"List of billing schedules"
+//	@Failure		400		{string}	string							"Invalid query parameters"
+//	@Failure		401		{string}	string							"Unauthorized"
+//	@Failure		500		{string}	string							"Internal server error"
+//	@Router			/schedules [get]
+//	@Security		BearerAuth
+func GetUserBillingSchedulesHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	var query model.BillingScheduleQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid query parameters: "+err.Error(), err)
+		return
+	}
+
+	if query.Status != nil && !query.Status.IsValid() {
+		JSONError(c, http.StatusBadRequest, "Invalid status filter", nil)
+		return
+	}
+
+	schedules, err := service.GetUserBillingSchedules(c.Request.Context(), userIDStr, &query)
+	if err != nil {
+		slog.Error("Failed to get user billing schedules", slog.Any("error", err), slog.String("userID", userIDStr))
+		JSONError(c, http.StatusInternalServerError, "Failed to retrieve billing schedules", err)
+		return
+	}
+	JSONSuccess(c, http.StatusOK, schedules)
+}
+
+// GetBillingScheduleHandler godoc
+//
+//	@Summary		Get Billing Schedule
+//	@Description	Retrieves one of the user's billing schedules with its periods and the payments matched to each
+//	@Tags			schedules
+//	@Produce		json
+//	@Param			id	path		string							true	"Billing schedule ID"
+//	@Success		200	{object}	model.BillingScheduleResponse	"Billing schedule details"
+//	@Failure		400	{string}	string							"Invalid billing schedule ID"
+//	@Failure		401	{string}	string							"Unauthorized"
+//	@Failure		404	{string}	string							"Billing schedule not found"
+//	@Failure		500	{string}	string							"Internal server error"
+//	@Router			/schedules/{id} [get]
+//	@Security		BearerAuth
+func GetBillingScheduleHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	scheduleID := c.Param("id")
+	if _, err := uuid.Parse(scheduleID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid billing schedule ID format", err)
+		return
+	}
+
+	schedule, err := service.GetBillingSchedule(c.Request.Context(), userIDStr, scheduleID)
+	if err != nil {
+		if errors.Is(err, repository.ErrorBillingScheduleNotFound) {
+			JSONError(c, http.StatusNotFound, "Billing schedule not found", err)
+			return
+		}
+
+		slog.Error("Failed to get billing schedule", slog.Any("error", err), slog.String("scheduleID", scheduleID))
+		JSONError(c, http.StatusInternalServerError, "Failed to retrieve billing schedule", err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, schedule)
+}
+
+// CancelBillingScheduleHandler godoc
+//
+//	@Summary		Cancel Billing Schedule
+//	@Description	Stops an active billing schedule from generating further periods. Periods already generated can still be paid.
+//	@Tags			schedules
+//	@Produce		json
+//	@Param			id	path		string							true	"Billing schedule ID"
+//	@Success		200	{object}	model.BillingScheduleResponse	"Cancelled billing schedule"
+//	@Failure		400	{string}	string							"Invalid billing schedule ID"
+//	@Failure		401	{string}	string							"Unauthorized"
+//	@Failure		404	{string}	string							"Billing schedule not found"
+//	@Failure		409	{string}	string							"Billing schedule is not active"
+//	@Failure		500	{string}	string							"Internal server error"
+//	@Router			/schedules/{id}/cancel [post]
+//	@Security		BearerAuth
+func CancelBillingScheduleHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	scheduleID := c.Param("id")
+	if _, err := uuid.Parse(scheduleID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid billing schedule ID format", err)
+		return
+	}
+
+	schedule, err := service.CancelBillingSchedule(c.Request.Context(), userIDStr, scheduleID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrorBillingScheduleNotFound):
+			JSONError(c, http.StatusNotFound, "Billing schedule not found", err)
+		case errors.Is(err, repository.ErrorBillingScheduleNotCancelable):
+			JSONError(c, http.StatusConflict, err.Error(), err)
+		default:
+			slog.Error("Failed to cancel billing schedule", slog.Any("error", err), slog.String("scheduleID", scheduleID))
+			JSONError(c, http.StatusInternalServerError, "Failed to cancel billing schedule", err)
+		}
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, gin.H{
+		"message":  "Billing schedule cancelled",
+		"schedule": schedule,
+	})
+}
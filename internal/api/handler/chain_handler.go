@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"backend/internal/ethclient"
+	"backend/internal/middleware"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetChainFeesHandler godoc
+//
+//	@Summary		Get Fee Suggestions
+//	@Description	Suggests EIP-1559 fees (max_fee_per_gas, max_priority_fee_per_gas) for slow, standard and fast transactions from the priority fees paid in recent blocks. Given a recipient, also estimates the gas and cost of sending it amount wei, the ETH the sending wallet needs at the chosen speed and whether it holds enough.
+//	@Tags			chain
+//	@Produce		json
+//	@Param			to		query		string				false	"Recipient address to estimate a transfer to"
+//	@Param			amount	query		string				false	"Amount to send in wei (default: 0)"
+//	@Param			from	query		string				false	"Sending wallet, one of the user's (default: their preferred wallet)"
+//	@Param			speed	query		string				false	"slow, standard (default) or fast"
+//	@Success		200		{object}	model.FeeEstimate	"Fee suggestions"
+//	@Failure		400		{string}	string				"Invalid query parameters"
+//	@Failure		401		{string}	string				"Unauthorized"
+//	@Failure		403		{string}	string				"Wallet not owned by the user"
+//	@Failure		500		{string}	string				"Internal server error"
+//	@Router			/chain/fees [get]
+//	@Security		BearerAuth
+func GetChainFeesHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	var query model.FeeEstimateQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid query parameters: "+err.Error(), err)
+		return
+	}
+
+	estimate, err := service.GetFeeEstimate(c.Request.Context(), userIDStr, &query)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidFeeEstimateQuery), errors.Is(err, repository.ErrorWalletAddressNotFound):
+			JSONError(c, http.StatusBadRequest, err.Error(), err)
+		case errors.Is(err, service.ErrWalletNotOwned):
+			JSONError(c, http.StatusForbidden, "You don't own this wallet address", err)
+		case errors.Is(err, ethclient.ErrEthereumRPCURLNotConfigured):
+			JSONError(c, http.StatusServiceUnavailable, "Blockchain connection is not configured", err)
+		default:
+			slog.Error("Failed to estimate fees", slog.Any("error", err), slog.String("userID", userIDStr))
+			JSONError(c, http.StatusInternalServerError, "Failed to estimate fees", err)
+		}
+		return
+	}
+	JSONSuccess(c, http.StatusOK, estimate)
+}
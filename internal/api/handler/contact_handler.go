@@ -0,0 +1,314 @@
+package handler
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateContactHandler godoc
+//
+//	@Summary		Create Contact
+//	@Description	Saves a labeled address to the user's address book. Payments to or from it show the contact's name, and it can be paid by contact_id.
+//	@Tags			contacts
+//	@Accept			json
+//	@Produce		json
+//	@Param			contactRequest	body		model.ContactRequest	true	"Contact details"
+//	@Success		201				{object}	model.ContactResponse	"Contact created successfully"
+//	@Failure		400				{string}	string					"Validation error or bad request"
+//	@Failure		401				{string}	string					"Unauthorized"
+//	@Failure		409				{string}	string					"A contact with this address already exists"
+//	@Failure		500				{string}	string					"Internal server error"
+//	@Router			/contacts [post]
+//	@Security		BearerAuth
+func CreateContactHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	var req model.ContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid request payload: "+err.Error(), err)
+		return
+	}
+
+	contact, err := service.CreateContact(c.Request.Context(), userIDStr, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrorContactAddressExists):
+			JSONError(c, http.StatusConflict, err.Error(), err)
+		case errors.Is(err, repository.ErrorDatabase):
+			slog.Error("Failed to create contact", slog.Any("error", err), slog.String("userID", userIDStr))
+			JSONError(c, http.StatusInternalServerError, "Failed to create contact", err)
+		default:
+			JSONError(c, http.StatusBadRequest, err.Error(), err)
+		}
+		return
+	}
+
+	JSONSuccess(c, http.StatusCreated, gin.H{
+		"message": "Contact created successfully",
+		"contact": contact,
+	})
+}
+
+// GetContactsHandler godoc
+//
+//	@Summary		Get Contacts
+//	@Description	Retrieves the user's contacts ordered by name
+//	@Tags			contacts
+//	@Produce		json
+//	@Param			search	query		string					false	"Filter by name, address, phone number or username"
+//	@Success		200		{array}		model.ContactResponse	"List of contacts"
+//	@Failure		400		{string}	string					"Invalid query parameters"
+//	@Failure		401		{string}	string					"Unauthorized"
+//	@Failure		500		{string}	string					"Internal server error"
+//	@Router			/contacts [get]
+//	@Security		BearerAuth
+func GetContactsHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	var query model.ContactQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid query parameters: "+err.Error(), err)
+		return
+	}
+
+	contacts, err := service.GetContacts(c.Request.Context(), userIDStr, &query)
+	if err != nil {
+		slog.Error("Failed to get contacts", slog.Any("error", err), slog.String("userID", userIDStr))
+		JSONError(c, http.StatusInternalServerError, "Failed to retrieve contacts", err)
+		return
+	}
+	JSONSuccess(c, http.StatusOK, contacts)
+}
+
+// CheckAddressHandler godoc
+//
+//	@Summary		Check Address
+//	@Description	Compares an address about to be paid with the user's contacts. A warning is returned when it closely resembles a contact's address without being it, a sign of address poisoning.
+//	@Tags			contacts
+//	@Produce		json
+//	@Param			address	query		string						true	"Address to check"
+//	@Success		200		{object}	model.AddressCheckResponse	"Address check result"
+//	@Failure		400		{string}	string						"Invalid address"
+//	@Failure		401		{string}	string						"Unauthorized"
+//	@Failure		500		{string}	string						"Internal server error"
+//	@Router			/contacts/check [get]
+//	@Security		BearerAuth
+func CheckAddressHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	address := c.Query("address")
+	if address == "" {
+		JSONError(c, http.StatusBadRequest, "address query parameter is required", nil)
+		return
+	}
+
+	check, err := service.CheckAddress(c.Request.Context(), userIDStr, address)
+	if err != nil {
+		if errors.Is(err, repository.ErrorDatabase) {
+			slog.Error("Failed to check address", slog.Any("error", err), slog.String("userID", userIDStr))
+			JSONError(c, http.StatusInternalServerError, "Failed to check address", err)
+			return
+		}
+		JSONError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, check)
+}
+
+// GetContactHandler godoc
+//
+//	@Summary		Get Contact
+//	@Description	Retrieves one of the user's contacts
+//	@Tags			contacts
+//	@Produce		json
+//	@Param			id	path		string					true	"Contact ID"
+//	@Success		200	{object}	model.ContactResponse	"Contact details"
+//	@Failure		400	{string}	string					"Invalid contact ID"
+//	@Failure		401	{string}	string					"Unauthorized"
+//	@Failure		404	{string}	string					"Contact not found"
+//	@Failure		500	{string}	string					"Internal server error"
+//	@Router			/contacts/{id} [get]
+//	@Security		BearerAuth
+func GetContactHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	contactID := c.Param("id")
+	if _, err := uuid.Parse(contactID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid contact ID format", err)
+		return
+	}
+
+	contact, err := service.GetContact(c.Request.Context(), userIDStr, contactID)
+	if err != nil {
+		if errors.Is(err, repository.ErrorContactNotFound) {
+			JSONError(c, http.StatusNotFound, "Contact not found", err)
+			return
+		}
+
+		slog.Error("Failed to get contact", slog.Any("error", err), slog.String("contactID", contactID))
+		JSONError(c, http.StatusInternalServerError, "Failed to retrieve contact", err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, contact)
+}
+
+// UpdateContactHandler godoc
+//
+//	@Summary		Update Contact
+//	@Description	Replaces the details of one of the user's contacts
+//	@Tags			contacts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		string					true	"Contact ID"
+//	@Param			contactRequest	body		model.ContactRequest	true	"Contact details"
+//	@Success		200				{object}	model.ContactResponse	"Contact updated successfully"
+//	@Failure		400				{string}	string					"Validation error or bad request"
+//	@Failure		401				{string}	string					"Unauthorized"
+//	@Failure		404				{string}	string					"Contact not found"
+//	@Failure		409				{string}	string					"A contact with this address already exists"
+//	@Failure		500				{string}	string					"Internal server error"
+//	@Router			/contacts/{id} [put]
+//	@Security		BearerAuth
+func UpdateContactHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	contactID := c.Param("id")
+	if _, err := uuid.Parse(contactID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid contact ID format", err)
+		return
+	}
+
+	var req model.ContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid request payload: "+err.Error(), err)
+		return
+	}
+
+	contact, err := service.UpdateContact(c.Request.Context(), userIDStr, contactID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrorContactNotFound):
+			JSONError(c, http.StatusNotFound, "Contact not found", err)
+		case errors.Is(err, repository.ErrorContactAddressExists):
+			JSONError(c, http.StatusConflict, err.Error(), err)
+		case errors.Is(err, repository.ErrorDatabase):
+			slog.Error("Failed to update contact", slog.Any("error", err), slog.String("contactID", contactID))
+			JSONError(c, http.StatusInternalServerError, "Failed to update contact", err)
+		default:
+			JSONError(c, http.StatusBadRequest, err.Error(), err)
+		}
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, gin.H{
+		"message": "Contact updated successfully",
+		"contact": contact,
+	})
+}
+
+// DeleteContactHandler godoc
+//
+//	@Summary		Delete Contact
+//	@Description	Removes one of the user's contacts. Payments to or from its address are no longer labeled with its name.
+//	@Tags			contacts
+//	@Produce		json
+//	@Param			id	path		string				true	"Contact ID"
+//	@Success		200	{object}	map[string]string	"Contact deleted"
+//	@Failure		400	{string}	string				"Invalid contact ID"
+//	@Failure		401	{string}	string				"Unauthorized"
+//	@Failure		404	{string}	string				"Contact not found"
+//	@Failure		500	{string}	string				"Internal server error"
+//	@Router			/contacts/{id} [delete]
+//	@Security		BearerAuth
+func DeleteContactHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	contactID := c.Param("id")
+	if _, err := uuid.Parse(contactID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid contact ID format", err)
+		return
+	}
+
+	if err := service.DeleteContact(c.Request.Context(), userIDStr, contactID); err != nil {
+		if errors.Is(err, repository.ErrorContactNotFound) {
+			JSONError(c, http.StatusNotFound, "Contact not found", err)
+			return
+		}
+
+		slog.Error("Failed to delete contact", slog.Any("error", err), slog.String("contactID", contactID))
+		JSONError(c, http.StatusInternalServerError, "Failed to delete contact", err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, gin.H{"message": "Contact deleted successfully"})
+}
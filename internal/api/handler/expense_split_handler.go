@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateExpenseSplitHandler godoc
+//
+//	@Summary		Create Expense Split
+//	@Description	Splits a group expense equally or by custom shares among other users, identified by username or phone number. Each participant settles their share by creating a payment with its split_share_id.
+//	@Tags			splits
+//	@Accept			json
+//	@Produce		json
+//	@Param			splitRequest	body		model.CreateExpenseSplitRequest	true	"Expense split details"
+//	@Success		201				{object}	model.ExpenseSplitResponse		"Expense split created successfully"
+//	@Failure		400				{string}	string							"Validation error or bad request"
+//	@Failure		401				{string}	string							"Unauthorized"
+//	@Failure		500				{string}	string							"Internal server error"
+//	@Router			/splits [post]
+//	@Security		BearerAuth
+func CreateExpenseSplitHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	var req model.CreateExpenseSplitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid request payload: "+err.Error(), err)
+		return
+	}
+
+	split, err := service.CreateExpenseSplit(c.Request.Context(), userIDStr, &req)
+	if err != nil {
+		slog.Error("Failed to create expense split", slog.Any("error", err), slog.String("userID", userIDStr))
+		if errors.Is(err, repository.ErrorDatabase) {
+			JSONError(c, http.StatusInternalServerError, "Failed to create expense split", err)
+			return
+		}
+		JSONError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusCreated, gin.H{
+		"message": "Expense split created successfully",
+		"split":   split,
+	})
+}
+
+// GetUserExpenseSplitsHandler godoc
+//
+//	@Summary		Get User Expense Splits
+//	@Description	Retrieves the expense splits the authenticated user organized, newest first, with the settlement state of every share
+//	@Tags			splits
+//	@Produce		json
+//	@Param			status	query		string						false	"Filter by split status (open or settled)"
+//	@Success		200		{array}		model.ExpenseSplitResponse	"List of expense splits"
+//	@Failure		400		{string}	string						"Invalid query parameters"
+//	@Failure		401		{string}	string						"Unauthorized"
+//	@Failure		500		{string}	string						"Internal server error"
+//	@Router			/splits [get]
+//	@Security		BearerAuth
+func GetUserExpenseSplitsHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	var query model.ExpenseSplitQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid query parameters: "+err.Error(), err)
+		return
+	}
+
+	if query.Status != nil && !query.Status.IsValid() {
+		JSONError(c, http.StatusBadRequest, "Invalid status filter", nil)
+		return
+	}
+
+	splits, err := service.GetUserExpenseSplits(c.Request.Context(), userIDStr, &query)
+	if err != nil {
+		slog.Error("Failed to get user expense splits", slog.Any("error", err), slog.String("userID", userIDStr))
+		JSONError(c, http.StatusInternalServerError, "Failed to retrieve expense splits", err)
+		return
+	}
+	JSONSuccess(c, http.StatusOK, splits)
+}
+
+// GetOwedSplitSharesHandler godoc
+//
+//	@Summary		Get Owed Split Shares
+//	@Description	Retrieves the shares of other users' expense splits that the authenticated user owes, newest first, with where to pay each and any reminders received
+//	@Tags			splits
+//	@Produce		json
+//	@Param			status	query		string						false	"Filter by share status (outstanding, pending or settled)"
+//	@Success		200		{array}		model.OwedShareResponse		"List of owed shares"
+//	@Failure		400		{string}	string						"Invalid query parameters"
+//	@Failure		401		{string}	string						"Unauthorized"
+//	@Failure		500		{string}	string						"Internal server error"
+//	@Router			/splits/owed [get]
+//	@Security		BearerAuth
+func GetOwedSplitSharesHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	var query model.OwedShareQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid query parameters: "+err.Error(), err)
+		return
+	}
+
+	if query.Status != nil && !query.Status.IsValid() {
+		JSONError(c, http.StatusBadRequest, "Invalid status filter", nil)
+		return
+	}
+
+	shares, err := service.GetOwedSplitShares(c.Request.Context(), userIDStr, &query)
+	if err != nil {
+		slog.Error("Failed to get owed split shares", slog.Any("error", err), slog.String("userID", userIDStr))
+		JSONError(c, http.StatusInternalServerError, "Failed to retrieve owed shares", err)
+		return
+	}
+	JSONSuccess(c, http.StatusOK, shares)
+}
+
+// GetExpenseSplitHandler godoc
+//
+//	@Summary		Get Expense Split
+//	@Description	Retrieves one of the user's expense splits with the settlement state of every share
+//	@Tags			splits
+//	@Produce		json
+//	@Param			id	path		string						true	"Expense split ID"
+//	@Success		200	{object}	model.ExpenseSplitResponse	"Expense split details"
+//	@Failure		400	{string}	string						"Invalid expense split ID"
+//	@Failure		401	{string}	string						"Unauthorized"
+//	@Failure		404	{string}	string						"Expense split not found"
+//	@Failure		500	{string}	string						"Internal server error"
+//	@Router			/splits/{id} [get]
+//	@Security		BearerAuth
+func GetExpenseSplitHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	splitID := c.Param("id")
+	if _, err := uuid.Parse(splitID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid expense split ID format", err)
+		return
+	}
+
+	split, err := service.GetExpenseSplit(c.Request.Context(), userIDStr, splitID)
+	if err != nil {
+		if errors.Is(err, repository.ErrorExpenseSplitNotFound) {
+			JSONError(c, http.StatusNotFound, "Expense split not found", err)
+			return
+		}
+
+		slog.Error("Failed to get expense split", slog.Any("error", err), slog.String("splitID", splitID))
+		JSONError(c, http.StatusInternalServerError, "Failed to retrieve expense split", err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, split)
+}
+
+// RemindSplitParticipantsHandler godoc
+//
+//	@Summary		Remind Split Participants
+//	@Description	Reminds the participants whose shares are still outstanding. Each share is reminded at most once a day; participants see the reminders on the shares they owe.
+//	@Tags			splits
+//	@Produce		json
+//	@Param			id	path		string						true	"Expense split ID"
+//	@Success		200	{array}		model.SplitShareResponse	"Reminded shares"
+//	@Failure		400	{string}	string						"Invalid expense split ID"
+//	@Failure		401	{string}	string						"Unauthorized"
+//	@Failure		404	{string}	string						"Expense split not found"
+//	@Failure		409	{string}	string						"No outstanding shares"
+//	@Failure		429	{string}	string						"Participants were already reminded recently"
+//	@Failure		500	{string}	string						"Internal server error"
+//	@Router			/splits/{id}/remind [post]
+//	@Security		BearerAuth
+func RemindSplitParticipantsHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	splitID := c.Param("id")
+	if _, err := uuid.Parse(splitID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid expense split ID format", err)
+		return
+	}
+
+	shares, err := service.RemindSplitParticipants(c.Request.Context(), userIDStr, splitID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrorExpenseSplitNotFound):
+			JSONError(c, http.StatusNotFound, "Expense split not found", err)
+		case errors.Is(err, repository.ErrorNoOutstandingShares):
+			JSONError(c, http.StatusConflict, err.Error(), err)
+		case errors.Is(err, service.ErrSplitRecentlyReminded):
+			JSONError(c, http.StatusTooManyRequests, err.Error(), err)
+		default:
+			slog.Error("Failed to remind split participants", slog.Any("error", err), slog.String("splitID", splitID))
+			JSONError(c, http.StatusInternalServerError, "Failed to remind participants", err)
+		}
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, gin.H{
+		"message": "Participants reminded",
+		"shares":  shares,
+	})
+}
@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateInvoiceHandler godoc
+//
+//	@Summary		Create Invoice
+//	@Description	Creates an invoice payable to one of the user's wallets. Incoming transfers are matched to it automatically.
+//	@Tags			invoices
+//	@Accept			json
+//	@Produce		json
+//	@Param			invoiceRequest	body		model.CreateInvoiceRequest	true	"Invoice details"
+//	@Success		201				{object}	model.InvoiceResponse		"Invoice created successfully"
+//	@Failure		400				{string}	string						"Validation error or bad request"
+//	@Failure		401				{string}	string						"Unauthorized"
+//	@Failure		500				{string}	string						"Internal server error"
+//	@Router			/invoices [post]
+//	@Security		BearerAuth
+func CreateInvoiceHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	var req model.CreateInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid request payload: "+err.Error(), err)
+		return
+	}
+
+	invoice, err := service.CreateInvoice(c.Request.Context(), userIDStr, &req)
+	if err != nil {
+		slog.Error("Failed to create invoice", slog.Any("error", err), slog.String("userID", userIDStr))
+		JSONError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusCreated, gin.H{
+		"message": "Invoice created successfully",
+		"invoice": invoice,
+	})
+}
+
+// GetUserInvoicesHandler godoc
+//
+//	@Summary		Get User Invoices
+//	@Description	Retrieves the authenticated user's invoices, newest first
+//	@Tags			invoices
+//	@Produce		json
+//	@Param			status	query		string					false	"Filter by invoice status"
+//	@Success		200		{array}		model.InvoiceResponse	"List of invoices"
+//	@Failure		400		{string}	string					"Invalid query parameters"
+//	@Failure		401		{string}	string					"Unauthorized"
+//	@Failure		500		{string}	string					"Internal server error"
+//	@Router			/invoices [get]
+//	@Security		BearerAuth
+func GetUserInvoicesHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	var query model.InvoiceQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid query parameters: "+err.Error(), err)
+		return
+	}
+
+	if query.Status != nil && !query.Status.IsValid() {
+		JSONError(c, http.StatusBadRequest, "Invalid status filter", nil)
+		return
+	}
+
+	invoices, err := service.GetUserInvoices(c.Request.Context(), userIDStr, &query)
+	if err != nil {
+		slog.Error("Failed to get user invoices", slog.Any("error", err), slog.String("userID", userIDStr))
+		JSONError(c, http.StatusInternalServerError, "Failed to retrieve invoices", err)
+		return
+	}
+	JSONSuccess(c, http.StatusOK, invoices)
+}
+
+// GetInvoiceHandler godoc
+//
+//	@Summary		Get Invoice
+//	@Description	Retrieves one of the user's invoices together with the payments matched to it
+//	@Tags			invoices
+//	@Produce		json
+//	@Param			id	path		string					true	"Invoice ID"
+//	@Success		200	{object}	model.InvoiceResponse	"Invoice details"
+//	@Failure		400	{string}	string					"Invalid invoice ID"
+//	@Failure		401	{string}	string					"Unauthorized"
+//	@Failure		404	{string}	string					"Invoice not found"
+//	@Failure		500	{string}	string					"Internal server error"
+//	@Router			/invoices/{id} [get]
+//	@Security		BearerAuth
+func GetInvoiceHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	invoiceID := c.Param("id")
+	if _, err := uuid.Parse(invoiceID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid invoice ID format", err)
+		return
+	}
+
+	invoice, err := service.GetInvoice(c.Request.Context(), userIDStr, invoiceID)
+	if err != nil {
+		if errors.Is(err, repository.ErrorInvoiceNotFound) {
+			JSONError(c, http.StatusNotFound, "Invoice not found", err)
+			return
+		}
+
+		slog.Error("Failed to get invoice", slog.Any("error", err), slog.String("invoiceID", invoiceID))
+		JSONError(c, http.StatusInternalServerError, "Failed to retrieve invoice", err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, invoice)
+}
+
+// CancelInvoiceHandler godoc
+//
+//	@Summary		Cancel Invoice
+//	@Description	Cancels an open invoice so that no further payments are matched to it
+//	@Tags			invoices
+//	@Produce		json
+//	@Param			id	path		string					true	"Invoice ID"
+//	@Success		200	{object}	model.InvoiceResponse	"Cancelled invoice"
+//	@Failure		400	{string}	string					"Invalid invoice ID"
+//	@Failure		401	{string}	string					"Unauthorized"
+//	@Failure		404	{string}	string					"Invoice not found"
+//	@Failure		409	{string}	string					"Invoice is not open"
+//	@Failure		500	{string}	string					"Internal server error"
+//	@Router			/invoices/{id}/cancel [post]
+//	@Security		BearerAuth
+func CancelInvoiceHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	invoiceID := c.Param("id")
+	if _, err := uuid.Parse(invoiceID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid invoice ID format", err)
+		return
+	}
+
+	invoice, err := service.CancelInvoice(c.Request.Context(), userIDStr, invoiceID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrorInvoiceNotFound):
+			JSONError(c, http.StatusNotFound, "Invoice not found", err)
+		case errors.Is(err, repository.ErrorInvoiceNotCancelable):
+			JSONError(c, http.StatusConflict, err.Error(), err)
+		default:
+			slog.Error("Failed to cancel invoice", slog.Any("error", err), slog.String("invoiceID", invoiceID))
+			JSONError(c, http.StatusInternalServerError, "Failed to cancel invoice", err)
+		}
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, gin.H{
+		"message": "Invoice cancelled",
+		"invoice": invoice,
+	})
+}
+
+// GetSharedInvoiceHandler godoc
+//
+//	@Summary		Get Shared Invoice
+//	@Description	Public view of an invoice for the payer; no authentication required
+//	@Tags			invoices
+//	@Produce		json
+//	@Param			id	path		string						true	"Invoice ID"
+//	@Success		200	{object}	model.SharedInvoiceResponse	"Invoice details for the payer"
+//	@Failure		400	{string}	string						"Invalid invoice ID"
+//	@Failure		404	{string}	string						"Invoice not found"
+//	@Failure		500	{string}	string						"Internal server error"
+//	@Router			/invoices/{id}/share [get]
+func GetSharedInvoiceHandler(c *gin.Context) {
+	invoiceID := c.Param("id")
+	if _, err := uuid.Parse(invoiceID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid invoice ID format", err)
+		return
+	}
+
+	invoice, err := service.GetSharedInvoice(c.Request.Context(), invoiceID)
+	if err != nil {
+		if errors.Is(err, repository.ErrorInvoiceNotFound) {
+			JSONError(c, http.StatusNotFound, "Invoice not found", err)
+			return
+		}
+
+		slog.Error("Failed to get shared invoice", slog.Any("error", err), slog.String("invoiceID", invoiceID))
+		JSONError(c, http.StatusInternalServerError, "Failed to retrieve invoice", err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, invoice)
+}
@@ -0,0 +1,332 @@
+package handler
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreatePaymentCategoryHandler godoc
+//
+//	@Summary		Create Payment Category
+//	@Description	Creates a category the user can file payments under
+//	@Tags			categories
+//	@Accept			json
+//	@Produce		json
+//	@Param			categoryRequest	body		model.PaymentCategoryRequest	true	"Category details"
+//	@Success		201				{object}	model.PaymentCategoryResponse	"Category created successfully"
+//	@Failure		400				{string}	string							"Validation error or bad request"
+//	@Failure		401				{string}	string							"Unauthorized"
+//	@Failure		409				{string}	string							"A category with this name already exists"
+//	@Failure		500				{string}	string							"Internal server error"
+//	@Router			/categories [post]
+//	@Security		BearerAuth
+func CreatePaymentCategoryHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	var req model.PaymentCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid request payload: "+err.Error(), err)
+		return
+	}
+
+	category, err := service.CreatePaymentCategory(c.Request.Context(), userIDStr, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrorPaymentCategoryExists):
+			JSONError(c, http.StatusConflict, err.Error(), err)
+		case errors.Is(err, repository.ErrorDatabase):
+			slog.Error("Failed to create payment category", slog.Any("error", err), slog.String("userID", userIDStr))
+			JSONError(c, http.StatusInternalServerError, "Failed to create category", err)
+		default:
+			JSONError(c, http.StatusBadRequest, err.Error(), err)
+		}
+		return
+	}
+
+	JSONSuccess(c, http.StatusCreated, gin.H{
+		"message":  "Category created successfully",
+		"category": category,
+	})
+}
+
+// GetPaymentCategoriesHandler godoc
+//
+//	@Summary		Get Payment Categories
+//	@Description	Retrieves the user's payment categories ordered by name
+//	@Tags			categories
+//	@Produce		json
+//	@Success		200	{array}		model.PaymentCategoryResponse	"List of categories"
+//	@Failure		401	{string}	string							"Unauthorized"
+//	@Failure		500	{string}	string							"Internal server error"
+//	@Router			/categories [get]
+//	@Security		BearerAuth
+func GetPaymentCategoriesHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	categories, err := service.GetPaymentCategories(c.Request.Context(), userIDStr)
+	if err != nil {
+		slog.Error("Failed to get payment categories", slog.Any("error", err), slog.String("userID", userIDStr))
+		JSONError(c, http.StatusInternalServerError, "Failed to retrieve categories", err)
+		return
+	}
+	JSONSuccess(c, http.StatusOK, categories)
+}
+
+// UpdatePaymentCategoryHandler godoc
+//
+//	@Summary		Update Payment Category
+//	@Description	Renames one of the user's payment categories
+//	@Tags			categories
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		string							true	"Category ID"
+//	@Param			categoryRequest	body		model.PaymentCategoryRequest	true	"Category details"
+//	@Success		200				{object}	model.PaymentCategoryResponse	"Category updated successfully"
+//	@Failure		400				{string}	string							"Validation error or bad request"
+//	@Failure		401				{string}	string							"Unauthorized"
+//	@Failure		404				{string}	string							"Category not found"
+//	@Failure		409				{string}	string							"A category with this name already exists"
+//	@Failure		500				{string}	string							"Internal server error"
+//	@Router			/categories/{id} [put]
+//	@Security		BearerAuth
+func UpdatePaymentCategoryHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	categoryID := c.Param("id")
+	if _, err := uuid.Parse(categoryID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid category ID format", err)
+		return
+	}
+
+	var req model.PaymentCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid request payload: "+err.Error(), err)
+		return
+	}
+
+	category, err := service.UpdatePaymentCategory(c.Request.Context(), userIDStr, categoryID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrorPaymentCategoryNotFound):
+			JSONError(c, http.StatusNotFound, "Category not found", err)
+		case errors.Is(err, repository.ErrorPaymentCategoryExists):
+			JSONError(c, http.StatusConflict, err.Error(), err)
+		case errors.Is(err, repository.ErrorDatabase):
+			slog.Error("Failed to update payment category", slog.Any("error", err), slog.String("categoryID", categoryID))
+			JSONError(c, http.StatusInternalServerError, "Failed to update category", err)
+		default:
+			JSONError(c, http.StatusBadRequest, err.Error(), err)
+		}
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, gin.H{
+		"message":  "Category updated successfully",
+		"category": category,
+	})
+}
+
+// DeletePaymentCategoryHandler godoc
+//
+//	@Summary		Delete Payment Category
+//	@Description	Removes one of the user's payment categories. Payments filed under it become uncategorized.
+//	@Tags			categories
+//	@Produce		json
+//	@Param			id	path		string				true	"Category ID"
+//	@Success		200	{object}	map[string]string	"Category deleted"
+//	@Failure		400	{string}	string				"Invalid category ID"
+//	@Failure		401	{string}	string				"Unauthorized"
+//	@Failure		404	{string}	string				"Category not found"
+//	@Failure		500	{string}	string				"Internal server error"
+//	@Router			/categories/{id} [delete]
+//	@Security		BearerAuth
+func DeletePaymentCategoryHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	categoryID := c.Param("id")
+	if _, err := uuid.Parse(categoryID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid category ID format", err)
+		return
+	}
+
+	if err := service.DeletePaymentCategory(c.Request.Context(), userIDStr, categoryID); err != nil {
+		if errors.Is(err, repository.ErrorPaymentCategoryNotFound) {
+			JSONError(c, http.StatusNotFound, "Category not found", err)
+			return
+		}
+
+		slog.Error("Failed to delete payment category", slog.Any("error", err), slog.String("categoryID", categoryID))
+		JSONError(c, http.StatusInternalServerError, "Failed to delete category", err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, gin.H{"message": "Category deleted successfully"})
+}
+
+// UpdatePaymentAnnotationsHandler godoc
+//
+//	@Summary		Update Payment Annotations
+//	@Description	Replaces the category and tags of a payment. Omitting category_id leaves the payment uncategorized and omitting tags clears them. Tags are lowercased and deduplicated.
+//	@Tags			payments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id					path		string									true	"Payment ID"
+//	@Param			annotationsRequest	body		model.UpdatePaymentAnnotationsRequest	true	"Category and tags"
+//	@Success		200					{object}	model.PaymentResponse					"Payment annotations updated"
+//	@Failure		400					{string}	string									"Validation error or bad request"
+//	@Failure		401					{string}	string									"Unauthorized"
+//	@Failure		404					{string}	string									"Payment or category not found"
+//	@Failure		500					{string}	string									"Internal server error"
+//	@Router			/payments/{id}/annotations [put]
+//	@Security		BearerAuth
+func UpdatePaymentAnnotationsHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	paymentID := c.Param("id")
+	if _, err := uuid.Parse(paymentID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid payment ID format", err)
+		return
+	}
+
+	var req model.UpdatePaymentAnnotationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid request payload: "+err.Error(), err)
+		return
+	}
+
+	payment, err := service.UpdatePaymentAnnotations(c.Request.Context(), userIDStr, paymentID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrorPaymentNotFound):
+			JSONError(c, http.StatusNotFound, "Payment not found", err)
+		case errors.Is(err, repository.ErrorPaymentCategoryNotFound):
+			JSONError(c, http.StatusNotFound, "Category not found", err)
+		case errors.Is(err, repository.ErrorDatabase):
+			slog.Error("Failed to update payment annotations", slog.Any("error", err), slog.String("paymentID", paymentID))
+			JSONError(c, http.StatusInternalServerError, "Failed to update payment", err)
+		default:
+			JSONError(c, http.StatusBadRequest, err.Error(), err)
+		}
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, payment)
+}
+
+// SearchPaymentsHandler godoc
+//
+//	@Summary		Search Payments
+//	@Description	Full-text search over the user's payments: description, tags, category, contact names and addresses. Addresses also match by substring. Accepts the filters, sorting and pagination of the payment list.
+//	@Tags			payments
+//	@Produce		json
+//	@Param			q				query		string						true	"Search query, e.g. \"office -rent\" or \"\"team lunch\"\""
+//	@Param			status			query		string						false	"Filter by payment status"
+//	@Param			direction		query		string						false	"Filter by direction: incoming or outgoing"
+//	@Param			currency		query		string						false	"Filter by currency"
+//	@Param			created_after	query		string						false	"Only payments created at or after this RFC 3339 time"
+//	@Param			created_before	query		string						false	"Only payments created before this RFC 3339 time"
+//	@Param			counterparty	query		string						false	"Only payments from or to this address"
+//	@Param			min_amount		query		string						false	"Minimum amount in the currency's smallest unit"
+//	@Param			max_amount		query		string						false	"Maximum amount in the currency's smallest unit"
+//	@Param			category_id		query		string						false	"Only payments filed under this category"
+//	@Param			tag				query		string						false	"Only payments with this tag"
+//	@Param			sort_by			query		string						false	"Sort field: created_at (default) or amount"
+//	@Param			sort_order		query		string						false	"Sort order: desc (default) or asc"
+//	@Param			cursor			query		string						false	"next_cursor from the previous page; overrides page"
+//	@Param			page			query		int							false	"Page number (default: 1)"
+//	@Param			page_size		query		int							false	"Page size (default: 20, max: 100)"
+//	@Success		200				{object}	model.PaymentListResponse	"Matching payments"
+//	@Failure		400				{string}	string						"Missing search query or invalid query parameters"
+//	@Failure		401				{string}	string						"Unauthorized"
+//	@Failure		500				{string}	string						"Internal server error"
+//	@Router			/payments/search [get]
+//	@Security		BearerAuth
+func SearchPaymentsHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	var query model.PaymentQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid query parameters: "+err.Error(), err)
+		return
+	}
+
+	payments, err := service.SearchPayments(c.Request.Context(), userIDStr, &query)
+	if err != nil {
+		if errors.Is(err, repository.ErrorInvalidPaymentQuery) {
+			JSONError(c, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		slog.Error("Failed to search payments", slog.Any("error", err), slog.String("userID", userIDStr))
+		JSONError(c, http.StatusInternalServerError, "Failed to search payments", err)
+		return
+	}
+	JSONSuccess(c, http.StatusOK, payments)
+}
@@ -2,12 +2,16 @@ package handler
 
 import (
 	"errors"
+	"backend/internal/ethclient"
+	"backend/internal/export"
 	"backend/internal/middleware"
 	"backend/internal/model"
 	"backend/internal/service"
 	"backend/internal/repository"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -16,14 +20,18 @@ import (
 // CreatePaymentHandler godoc
 //
 //	@Summary		Create Payment
-//	@Description	Creates a new payment record after verifying the transaction on blockchain
+//	@Description	Creates a new payment record after verifying the transaction on blockchain. Submitting an already recorded transaction returns its payment. A warning is included when the recipient closely resembles a saved contact. With an Idempotency-Key, a retry returns the original response with the Idempotent-Replayed header set.
 //	@Tags			payments
 //	@Accept			json
 //	@Produce		json
 //	@Param			paymentRequest	body		model.CreatePaymentRequest	true	"Payment creation details"
+//	@Param			Idempotency-Key	header		string						false	"Key under which the response is kept, so the request can be retried safely"
 //	@Success		201				{object}	model.PaymentResponse		"Payment created successfully"
 //	@Failure		400				{string}	string						"Validation error or bad request"
 //	@Failure		401				{string}	string						"Unauthorized"
+//	@Failure		404				{string}	string						"Payment intent, split share, contact or category not found"
+//	@Failure		409				{string}	string						"Payment intent no longer payable, split share already paid or idempotent request still in progress"
+//	@Failure		422				{string}	string						"Idempotency key reused for a different request"
 //	@Failure		500				{string}	string						"Internal server error"
 //	@Router			/payments [post]
 //	@Security		BearerAuth
@@ -50,18 +58,127 @@ func CreatePaymentHandler(c *gin.Context) {
 	if err != nil {
 		slog.Error("Failed to create payment", slog.Any("error", err), slog.String("userID", userIDStr))
 		// Map known repository errors
-		if errors.Is(err, repository.ErrorDuplicateTransaction) {
+		if errors.Is(err, repository.ErrorDuplicateTransaction) || errors.Is(err, repository.ErrorPaymentIntentNotPayable) || errors.Is(err, repository.ErrorSplitShareNotPayable) || errors.Is(err, repository.ErrorPreparedPaymentNotBindable) {
 			JSONError(c, http.StatusConflict, err.Error(), err)
 			return
 		}
+		if errors.Is(err, repository.ErrorPaymentIntentNotFound) {
+			JSONError(c, http.StatusNotFound, "Payment intent not found", err)
+			return
+		}
+		if errors.Is(err, repository.ErrorSplitShareNotFound) {
+			JSONError(c, http.StatusNotFound, "Split share not found", err)
+			return
+		}
+		if errors.Is(err, repository.ErrorPreparedPaymentNotFound) {
+			JSONError(c, http.StatusNotFound, "Prepared payment not found", err)
+			return
+		}
+		if errors.Is(err, repository.ErrorContactNotFound) {
+			JSONError(c, http.StatusNotFound, "Contact not found", err)
+			return
+		}
+		if errors.Is(err, repository.ErrorPaymentCategoryNotFound) {
+			JSONError(c, http.StatusNotFound, "Category not found", err)
+			return
+		}
 		JSONError(c, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
-	JSONSuccess(c, http.StatusCreated, gin.H{
+	response := gin.H{
 		"message": "Payment created successfully",
 		"payment": payment,
-	})
+	}
+
+	// Paying a lookalike of a saved contact suggests a poisoned address
+	check, err := service.CheckAddress(c.Request.Context(), userIDStr, payment.ToAddress)
+	if err != nil {
+		slog.Warn("Failed to check payment address", slog.Any("error", err), slog.String("userID", userIDStr))
+	} else if check.Warning != nil {
+		response["warning"] = *check.Warning
+	}
+
+	JSONSuccess(c, http.StatusCreated, response)
+}
+
+// RelayPaymentHandler godoc
+//
+//	@Summary		Relay Payment
+//	@Description	Broadcasts a signed transaction from one of the user's wallets and records its pending payment. The transaction must be signed for this chain and pay the requested amount to the requested recipient; its payment is recorded first and marked failed if the node rejects the transaction. Pass prepared_payment_id for a transaction signed from POST /payments/prepare. Relaying an already recorded transaction returns its payment. Transactions paying an address that resembles a saved contact are refused unless acknowledge_warning is set.
+//	@Tags			payments
+//	@Accept			json
+//	@Produce		json
+//	@Param			relayRequest	body		model.RelayPaymentRequest	true	"Signed transaction and the payment it makes"
+//	@Param			Idempotency-Key	header		string						false	"Key under which the response is kept, so the request can be retried safely"
+//	@Success		201				{object}	model.PaymentResponse		"Payment relayed successfully"
+//	@Failure		400				{string}	string						"Invalid transaction, validation error or transaction rejected by the node"
+//	@Failure		401				{string}	string						"Unauthorized"
+//	@Failure		403				{string}	string						"Transaction not signed by one of the user's wallets"
+//	@Failure		404				{string}	string						"Prepared payment, contact or category not found"
+//	@Failure		409				{string}	string						"Prepared payment already has a payment, recipient resembles a saved contact, or idempotent request still in progress"
+//	@Failure		422				{string}	string						"Idempotency key reused for a different request"
+//	@Failure		500				{string}	string						"Internal server error"
+//	@Failure		503				{string}	string						"Blockchain connection is not configured"
+//	@Router			/payments/relay [post]
+//	@Security		BearerAuth
+func RelayPaymentHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	var req model.RelayPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid request payload: "+err.Error(), err)
+		return
+	}
+
+	payment, err := service.RelayPayment(c.Request.Context(), userIDStr, &req)
+	if err != nil {
+		slog.Error("Failed to relay payment", slog.Any("error", err), slog.String("userID", userIDStr))
+		switch {
+		case errors.Is(err, service.ErrWalletNotOwned):
+			JSONError(c, http.StatusForbidden, err.Error(), err)
+		case errors.Is(err, repository.ErrorPreparedPaymentNotBindable), errors.Is(err, service.ErrLookalikeRecipient):
+			JSONError(c, http.StatusConflict, err.Error(), err)
+		case errors.Is(err, repository.ErrorPreparedPaymentNotFound):
+			JSONError(c, http.StatusNotFound, "Prepared payment not found", err)
+		case errors.Is(err, repository.ErrorContactNotFound):
+			JSONError(c, http.StatusNotFound, "Contact not found", err)
+		case errors.Is(err, repository.ErrorPaymentCategoryNotFound):
+			JSONError(c, http.StatusNotFound, "Category not found", err)
+		case errors.Is(err, ethclient.ErrEthereumRPCURLNotConfigured):
+			JSONError(c, http.StatusServiceUnavailable, "Blockchain connection is not configured", err)
+		case errors.Is(err, repository.ErrorDatabase):
+			JSONError(c, http.StatusInternalServerError, "Failed to relay payment", err)
+		default:
+			JSONError(c, http.StatusBadRequest, err.Error(), err)
+		}
+		return
+	}
+
+	response := gin.H{
+		"message": "Payment relayed successfully",
+		"payment": payment,
+	}
+
+	// Paying a lookalike of a saved contact suggests a poisoned address
+	check, err := service.CheckAddress(c.Request.Context(), userIDStr, payment.ToAddress)
+	if err != nil {
+		slog.Warn("Failed to check payment address", slog.Any("error", err), slog.String("userID", userIDStr))
+	} else if check.Warning != nil {
+		response["warning"] = *check.Warning
+	}
+
+	JSONSuccess(c, http.StatusCreated, response)
 }
 
 // GetPaymentHandler godoc
@@ -118,14 +235,26 @@ func GetPaymentHandler(c *gin.Context) {
 //	@Description	Retrieves paginated list of payments for the authenticated user
 //	@Tags			payments
 //	@Produce		json
-//	@Param			status		query		string						false	"Filter by payment status"
-//	@Param			currency	query		string						false	"Filter by currency"
-//	@Param			page		query		int							false	"Page number (default: 1)"
-//	@Param			page_size	query		int							false	"Page size (default: 20, max: 100)"
-//	@Success		200			{object}	model.PaymentListResponse	"List of payments"
-//	@Failure		400			{string}	string						"Invalid query parameters"
-//	@Failure		401			{string}	string						"Unauthorized"
-//	@Failure		500			{string}	string						"Internal server error"
+//	@Param			status			query		string						false	"Filter by payment status"
+//	@Param			direction		query		string						false	"Filter by direction: incoming or outgoing"
+//	@Param			currency		query		string						false	"Filter by currency"
+//	@Param			created_after	query		string						false	"Only payments created at or after this RFC 3339 time"
+//	@Param			created_before	query		string						false	"Only payments created before this RFC 3339 time"
+//	@Param			counterparty	query		string						false	"Only payments from or to this address"
+//	@Param			min_amount		query		string						false	"Minimum amount in the currency's smallest unit"
+//	@Param			max_amount		query		string						false	"Maximum amount in the currency's smallest unit"
+//	@Param			category_id		query		string						false	"Only payments filed under this category"
+//	@Param			tag				query		string						false	"Only payments with this tag"
+//	@Param			q				query		string						false	"Full-text search over description, tags, category, contact names and addresses"
+//	@Param			sort_by			query		string						false	"Sort field: created_at (default) or amount"
+//	@Param			sort_order		query		string						false	"Sort order: desc (default) or asc"
+//	@Param			cursor			query		string						false	"next_cursor from the previous page; overrides page"
+//	@Param			page			query		int							false	"Page number (default: 1)"
+//	@Param			page_size		query		int							false	"Page size (default: 20, max: 100)"
+//	@Success		200				{object}	model.PaymentListResponse	"List of payments"
+//	@Failure		400				{string}	string						"Invalid query parameters"
+//	@Failure		401				{string}	string						"Unauthorized"
+//	@Failure		500				{string}	string						"Internal server error"
 //	@Router			/payments [get]
 //	@Security		BearerAuth
 func GetUserPaymentsHandler(c *gin.Context) {
@@ -149,6 +278,10 @@ func GetUserPaymentsHandler(c *gin.Context) {
 
 	payments, err := service.GetUserPayments(c.Request.Context(), userIDStr, &query)
 	if err != nil {
+		if errors.Is(err, repository.ErrorInvalidPaymentQuery) {
+			JSONError(c, http.StatusBadRequest, err.Error(), err)
+			return
+		}
 		slog.Error("Failed to get user payments", slog.Any("error", err), slog.String("userID", userIDStr))
 		JSONError(c, http.StatusInternalServerError, "Failed to retrieve payments", err)
 		return
@@ -162,12 +295,13 @@ func GetUserPaymentsHandler(c *gin.Context) {
 //	@Description	Retrieves a payment by its blockchain transaction hash
 //	@Tags			payments
 //	@Produce		json
-//	@Param			hash	path		string					true	"Transaction Hash"
-//	@Success		200		{object}	model.PaymentResponse	"Payment details"
-//	@Failure		400		{string}	string					"Invalid transaction hash"
-//	@Failure		401		{string}	string					"Unauthorized"
-//	@Failure		404		{string}	string					"Payment not found"
-//	@Failure		500		{string}	string					"Internal server error"
+//	@Param			hash		path		string					true	"Transaction Hash"
+//	@Param			direction	query		string					false	"incoming or outgoing (default: outgoing if recorded, else incoming)"
+//	@Success		200			{object}	model.PaymentResponse	"Payment details"
+//	@Failure		400			{string}	string					"Invalid transaction hash or direction"
+//	@Failure		401			{string}	string					"Unauthorized"
+//	@Failure		404			{string}	string					"Payment not found"
+//	@Failure		500			{string}	string					"Internal server error"
 //	@Router			/payments/tx/{hash} [get]
 //	@Security		BearerAuth
 func GetPaymentByTransactionHashHandler(c *gin.Context) {
@@ -195,12 +329,22 @@ func GetPaymentByTransactionHashHandler(c *gin.Context) {
 		return
 	}
 
-	payment, err := service.GetPaymentByTransactionHash(c.Request.Context(), userIDStr, txHash)
+	var direction *model.PaymentDirection
+	if value := c.Query("direction"); value != "" {
+		d := model.PaymentDirection(value)
+		direction = &d
+	}
+
+	payment, err := service.GetPaymentByTransactionHash(c.Request.Context(), userIDStr, txHash, direction)
 	if err != nil {
 		if errors.Is(err, repository.ErrorPaymentNotFound) {
 			JSONError(c, http.StatusNotFound, "Payment not found", err)
 			return
 		}
+		if errors.Is(err, repository.ErrorInvalidPaymentQuery) {
+			JSONError(c, http.StatusBadRequest, err.Error(), err)
+			return
+		}
 		slog.Error("Failed to get payment by transaction hash", slog.Any("error", err), slog.String("txHash", txHash))
 		JSONError(c, http.StatusInternalServerError, "Failed to retrieve payment", err)
 		return
@@ -258,15 +402,70 @@ func RefreshPaymentStatusHandler(c *gin.Context) {
 	})
 }
 
-// GetPaymentStatsHandler godoc
+// CancelPaymentHandler godoc
 //
-//	@Summary		Get Payment Statistics
-//	@Description	Retrieves payment statistics for the authenticated user
+//	@Summary		Cancel Payment
+//	@Description	Cancels a pending payment whose transaction was never mined and is no longer in the mempool, e.g. because it was dropped or replaced
 //	@Tags			payments
 //	@Produce		json
-//	@Success		200	{object}	map[string]interface{}	"Payment statistics"
+//	@Param			id	path		string					true	"Payment ID"
+//	@Success		200	{object}	model.PaymentResponse	"Cancelled payment"
+//	@Failure		400	{string}	string					"Invalid payment ID"
 //	@Failure		401	{string}	string					"Unauthorized"
+//	@Failure		404	{string}	string					"Payment not found"
+//	@Failure		409	{string}	string					"Payment is not pending, or its transaction was mined or is still waiting to be mined"
 //	@Failure		500	{string}	string					"Internal server error"
+//	@Router			/payments/{id}/cancel [post]
+//	@Security		BearerAuth
+func CancelPaymentHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	paymentID := c.Param("id")
+	if _, err := uuid.Parse(paymentID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid payment ID format", err)
+		return
+	}
+
+	payment, err := service.CancelPayment(c.Request.Context(), userIDStr, paymentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrorPaymentNotFound):
+			JSONError(c, http.StatusNotFound, "Payment not found", err)
+		case errors.Is(err, repository.ErrorInvalidStatusTransition), errors.Is(err, service.ErrPaymentAlreadyMined),
+			errors.Is(err, service.ErrPaymentTransactionPending):
+			JSONError(c, http.StatusConflict, err.Error(), err)
+		default:
+			slog.Error("Failed to cancel payment", slog.Any("error", err), slog.String("paymentID", paymentID))
+			JSONError(c, http.StatusInternalServerError, "Failed to cancel payment", err)
+		}
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, gin.H{
+		"message": "Payment cancelled",
+		"payment": payment,
+	})
+}
+
+// GetPaymentStatsHandler godoc
+//
+//	@Summary		Get Payment Statistics
+//	@Description	Retrieves all-time payment statistics for the authenticated user, including the fiat value of confirmed payments in the user's display currency
+//	@Tags			payments
+//	@Produce		json
+//	@Success		200	{object}	model.PaymentStats	"Payment statistics"
+//	@Failure		401	{string}	string				"Unauthorized"
+//	@Failure		500	{string}	string				"Internal server error"
 //	@Router			/payments/stats [get]
 //	@Security		BearerAuth
 func GetPaymentStatsHandler(c *gin.Context) {
@@ -290,3 +489,178 @@ func GetPaymentStatsHandler(c *gin.Context) {
 	}
 	JSONSuccess(c, http.StatusOK, stats)
 }
+
+// GetPaymentAnalyticsHandler godoc
+//
+//	@Summary		Get Payment Analytics
+//	@Description	Aggregates the authenticated user's payments over a date range: totals per status and currency, a time series, top counterparties, average confirmation time and gas spent
+//	@Tags			payments
+//	@Produce		json
+//	@Param			from				query		string					false	"Start of the range, RFC 3339 (default: 30 days before to)"
+//	@Param			to					query		string					false	"End of the range, exclusive, RFC 3339 (default: now)"
+//	@Param			interval			query		string					false	"Series bucket: day (default), week or month"
+//	@Param			currency			query		string					false	"Only payments in this currency"
+//	@Param			top_counterparties	query		int						false	"Number of top counterparties (default: 5, max: 50)"
+//	@Success		200					{object}	model.PaymentAnalytics	"Payment analytics"
+//	@Failure		400					{string}	string					"Invalid query parameters"
+//	@Failure		401					{string}	string					"Unauthorized"
+//	@Failure		500					{string}	string					"Internal server error"
+//	@Router			/payments/analytics [get]
+//	@Security		BearerAuth
+func GetPaymentAnalyticsHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	var query model.AnalyticsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid query parameters: "+err.Error(), err)
+		return
+	}
+
+	analytics, err := service.GetPaymentAnalytics(c.Request.Context(), userIDStr, &query)
+	if err != nil {
+		if errors.Is(err, repository.ErrorInvalidAnalyticsQuery) {
+			JSONError(c, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		slog.Error("Failed to get payment analytics", slog.Any("error", err), slog.String("userID", userIDStr))
+		JSONError(c, http.StatusInternalServerError, "Failed to retrieve payment analytics", err)
+		return
+	}
+	JSONSuccess(c, http.StatusOK, analytics)
+}
+
+// GetFeeReportHandler godoc
+//
+//	@Summary		Get Fee Report
+//	@Description	Sums the transaction fees the authenticated user paid for outgoing payments over a date range, by month, with the average fee per payment. Fees are in wei, split into the burned base fee and the priority fee where known.
+//	@Tags			payments
+//	@Produce		json
+//	@Param			from	query		string			false	"Start of the range, RFC 3339 (default: a year before to)"
+//	@Param			to		query		string			false	"End of the range, exclusive, RFC 3339 (default: now)"
+//	@Success		200		{object}	model.FeeReport	"Fee report"
+//	@Failure		400		{string}	string			"Invalid query parameters"
+//	@Failure		401		{string}	string			"Unauthorized"
+//	@Failure		500		{string}	string			"Internal server error"
+//	@Router			/payments/fees [get]
+//	@Security		BearerAuth
+func GetFeeReportHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	var query model.FeeReportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid query parameters: "+err.Error(), err)
+		return
+	}
+
+	report, err := service.GetFeeReport(c.Request.Context(), userIDStr, &query)
+	if err != nil {
+		if errors.Is(err, repository.ErrorInvalidAnalyticsQuery) {
+			JSONError(c, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		slog.Error("Failed to get fee report", slog.Any("error", err), slog.String("userID", userIDStr))
+		JSONError(c, http.StatusInternalServerError, "Failed to retrieve fee report", err)
+		return
+	}
+	JSONSuccess(c, http.StatusOK, report)
+}
+
+// ExportPaymentsHandler godoc
+//
+//	@Summary		Export Payments
+//	@Description	Streams the authenticated user's payment history as a file, with the same filters and sorting as the payment list. OFX statements hold a single currency: ETH unless currency is given.
+//	@Tags			payments
+//	@Produce		text/csv
+//	@Produce		application/x-ndjson
+//	@Produce		application/x-ofx
+//	@Param			format			query		string	false	"File format: csv (default), ndjson or ofx"
+//	@Param			status			query		string	false	"Filter by payment status"
+//	@Param			direction		query		string	false	"Filter by direction: incoming or outgoing"
+//	@Param			currency		query		string	false	"Filter by currency"
+//	@Param			created_after	query		string	false	"Only payments created at or after this RFC 3339 time"
+//	@Param			created_before	query		string	false	"Only payments created before this RFC 3339 time"
+//	@Param			counterparty	query		string	false	"Only payments from or to this address"
+//	@Param			min_amount		query		string	false	"Minimum amount in the currency's smallest unit"
+//	@Param			max_amount		query		string	false	"Maximum amount in the currency's smallest unit"
+//	@Param			sort_by			query		string	false	"Sort field: created_at (default) or amount"
+//	@Param			sort_order		query		string	false	"Sort order: desc (default) or asc"
+//	@Success		200				{file}		file	"Payment history file"
+//	@Failure		400				{string}	string	"Invalid query parameters"
+//	@Failure		401				{string}	string	"Unauthorized"
+//	@Failure		500				{string}	string	"Internal server error"
+//	@Router			/payments/export [get]
+//	@Security		BearerAuth
+func ExportPaymentsHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	format, err := export.ParseFormat(c.DefaultQuery("format", string(export.FormatCSV)))
+	if err != nil {
+		JSONError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	var query model.PaymentQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid query parameters: "+err.Error(), err)
+		return
+	}
+
+	filename := fmt.Sprintf("payments-%s.%s", time.Now().UTC().Format("20060102"), format.FileExtension())
+	header := c.Writer.Header()
+	header.Set("Content-Type", format.ContentType())
+	header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// Exports of long histories take longer than the server's write timeout
+	clearWriteDeadline(c)
+
+	err = service.ExportPayments(c.Request.Context(), userIDStr, format, &query, c.Writer)
+	if err == nil {
+		return
+	}
+
+	slog.Error("Failed to export payments", slog.Any("error", err), slog.String("userID", userIDStr))
+
+	// Once part of the file has been sent the status can no longer change
+	if c.Writer.Written() {
+		_ = c.Error(err)
+		return
+	}
+
+	header.Del("Content-Type")
+	header.Del("Content-Disposition")
+	if errors.Is(err, repository.ErrorInvalidPaymentQuery) {
+		JSONError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	JSONError(c, http.StatusInternalServerError, "Failed to export payments", err)
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreatePaymentIntentHandler godoc
+//
+//	@Summary		Create Payment Intent
+//	@Description	Resolves a phone number to its owner's preferred wallet and returns the transaction to sign. Pass the intent ID as intent_id when creating the payment.
+//	@Tags			payments
+//	@Accept			json
+//	@Produce		json
+//	@Param			intentRequest	body		model.CreatePaymentIntentRequest	true	"Payment intent details"
+//	@Success		201				{object}	model.PaymentIntentResponse			"Payment intent created successfully"
+//	@Failure		400				{string}	string								"Validation error or bad request"
+//	@Failure		401				{string}	string								"Unauthorized"
+//	@Failure		404				{string}	string								"No user or wallet for the phone number"
+//	@Failure		500				{string}	string								"Internal server error"
+//	@Router			/payments/intents [post]
+//	@Security		BearerAuth
+func CreatePaymentIntentHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	var req model.CreatePaymentIntentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid request payload: "+err.Error(), err)
+		return
+	}
+
+	intent, err := service.CreatePaymentIntent(c.Request.Context(), userIDStr, &req)
+	if err != nil {
+		slog.Error("Failed to create payment intent", slog.Any("error", err), slog.String("userID", userIDStr))
+		if errors.Is(err, repository.ErrorUserNotFound) || errors.Is(err, repository.ErrorWalletAddressNotFound) {
+			JSONError(c, http.StatusNotFound, err.Error(), err)
+			return
+		}
+		JSONError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusCreated, gin.H{
+		"message": "Payment intent created successfully",
+		"intent":  intent,
+	})
+}
+
+// GetUserPaymentIntentsHandler godoc
+//
+//	@Summary		Get User Payment Intents
+//	@Description	Retrieves the payment intents the authenticated user sent or received, newest first
+//	@Tags			payments
+//	@Produce		json
+//	@Success		200	{array}		model.PaymentIntentResponse	"List of payment intents"
+//	@Failure		401	{string}	string						"Unauthorized"
+//	@Failure		500	{string}	string						"Internal server error"
+//	@Router			/payments/intents [get]
+//	@Security		BearerAuth
+func GetUserPaymentIntentsHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	intents, err := service.GetUserPaymentIntents(c.Request.Context(), userIDStr)
+	if err != nil {
+		slog.Error("Failed to get payment intents", slog.Any("error", err), slog.String("userID", userIDStr))
+		JSONError(c, http.StatusInternalServerError, "Failed to retrieve payment intents", err)
+		return
+	}
+	JSONSuccess(c, http.StatusOK, intents)
+}
+
+// GetPaymentIntentHandler godoc
+//
+//	@Summary		Get Payment Intent
+//	@Description	Retrieves a payment intent sent or received by the authenticated user
+//	@Tags			payments
+//	@Produce		json
+//	@Param			id	path		string						true	"Payment intent ID"
+//	@Success		200	{object}	model.PaymentIntentResponse	"Payment intent details"
+//	@Failure		400	{string}	string						"Invalid payment intent ID"
+//	@Failure		401	{string}	string						"Unauthorized"
+//	@Failure		404	{string}	string						"Payment intent not found"
+//	@Failure		500	{string}	string						"Internal server error"
+//	@Router			/payments/intents/{id} [get]
+//	@Security		BearerAuth
+func GetPaymentIntentHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	intentID := c.Param("id")
+	if _, err := uuid.Parse(intentID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid payment intent ID format", err)
+		return
+	}
+
+	intent, err := service.GetPaymentIntent(c.Request.Context(), userIDStr, intentID)
+	if err != nil {
+		if errors.Is(err, repository.ErrorPaymentIntentNotFound) {
+			JSONError(c, http.StatusNotFound, "Payment intent not found", err)
+			return
+		}
+
+		slog.Error("Failed to get payment intent", slog.Any("error", err), slog.String("intentID", intentID))
+		JSONError(c, http.StatusInternalServerError, "Failed to retrieve payment intent", err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, intent)
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/pubsub"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// streamKeepAliveInterval is how often an idle stream sends a keep-alive,
+	// so that proxies do not close it
+	streamKeepAliveInterval = 15 * time.Second
+
+	// streamWriteTimeout bounds a single WebSocket write
+	streamWriteTimeout = 10 * time.Second
+
+	// streamPongTimeout is how long a WebSocket client may go without
+	// answering a ping
+	streamPongTimeout = 2 * streamKeepAliveInterval
+)
+
+// streamUpgrader upgrades payment stream requests to WebSocket connections.
+// Clients authenticate with a bearer token rather than cookies, so a
+// cross-origin page cannot open a stream on a user's behalf and any origin is
+// accepted.
+var streamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// StreamPaymentsHandler godoc
+//
+//	@Summary		Stream Payment Events
+//	@Description	Pushes the user's payment events as Server-Sent Events: new payments (payment.created) and status transitions (payment.status_changed). Browsers that cannot set headers may pass the token as access_token. A stream that falls behind is closed; reconnect and reload the payment list.
+//	@Tags			payments
+//	@Produce		text/event-stream
+//	@Param			access_token	query		string						false	"Access token, if not sent in the Authorization header"
+//	@Success		200				{object}	model.PaymentEvent			"Stream of payment events"
+//	@Failure		401				{string}	string						"Unauthorized"
+//	@Router			/payments/stream [get]
+//	@Security		BearerAuth
+func StreamPaymentsHandler(c *gin.Context) {
+	userUUID, ok := streamUserID(c)
+	if !ok {
+		return
+	}
+
+	sub := pubsub.Default().Subscribe(userUUID)
+	defer sub.Close()
+
+	// The stream stays open well past the server's write timeout
+	clearWriteDeadline(c)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+	c.Status(http.StatusOK)
+
+	// Send the headers right away so the client knows the stream is open
+	fmt.Fprint(c.Writer, ": connected\n\n")
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	ctx := c.Request.Context()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case event, open := <-sub.Events():
+			if !open {
+				return false
+			}
+			c.SSEvent(string(event.Type), event)
+			return true
+		case <-keepAlive.C:
+			_, err := fmt.Fprint(w, ": keep-alive\n\n")
+			return err == nil
+		}
+	})
+}
+
+// StreamPaymentsWebSocketHandler godoc
+//
+//	@Summary		Stream Payment Events over WebSocket
+//	@Description	WebSocket variant of /payments/stream. Each text message is a JSON payment event; messages sent by the client are ignored.
+//	@Tags			payments
+//	@Param			access_token	query		string	false	"Access token, if not sent in the Authorization header"
+//	@Success		101				{string}	string	"Switching protocols"
+//	@Failure		401				{string}	string	"Unauthorized"
+//	@Router			/payments/ws [get]
+//	@Security		BearerAuth
+func StreamPaymentsWebSocketHandler(c *gin.Context) {
+	userUUID, ok := streamUserID(c)
+	if !ok {
+		return
+	}
+
+	conn, err := streamUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already written an error response
+		slog.Warn("Failed to upgrade payment stream", slog.Any("error", err), slog.String("userID", userUUID.String()))
+		return
+	}
+	defer conn.Close()
+
+	sub := pubsub.Default().Subscribe(userUUID)
+	defer sub.Close()
+
+	// Read in the background to process pongs and notice when the client
+	// goes away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(streamPongTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(streamPongTimeout))
+		})
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	keepAlive := time.NewTicker(streamKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event, open := <-sub.Events():
+			conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if !open {
+				conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "stream fell behind, reconnect"))
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// streamUserID reads the authenticated user of a stream request, writing an
+// error response if there is none
+func streamUserID(c *gin.Context) (uuid.UUID, bool) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return uuid.Nil, false
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return uuid.Nil, false
+	}
+
+	userUUID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", err)
+		return uuid.Nil, false
+	}
+
+	return userUUID, true
+}
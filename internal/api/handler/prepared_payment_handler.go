@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"backend/internal/ethclient"
+	"backend/internal/middleware"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PreparePaymentHandler godoc
+//
+//	@Summary		Prepare Payment
+//	@Description	Checks that one of the user's wallets can cover an ETH or ERC-20 payment and its fees, and returns a ready-to-sign EIP-1559 transaction with the nonce, chain ID, gas limit and fees filled in from the node. The nonce is reserved until the preparation expires. Once the signed transaction is broadcast, pass the preparation ID as prepared_payment_id when creating the payment.
+//	@Tags			payments
+//	@Accept			json
+//	@Produce		json
+//	@Param			Idempotency-Key		header		string							false	"Key under which the response is kept, so the request can be retried safely"
+//	@Param			prepareRequest		body		model.PreparePaymentRequest		true	"Payment to prepare"
+//	@Success		201					{object}	model.PreparedPaymentResponse	"Payment prepared successfully"
+//	@Failure		400					{string}	string							"Validation error, insufficient balance or bad request"
+//	@Failure		401					{string}	string							"Unauthorized"
+//	@Failure		403					{string}	string							"Wallet not owned by the user"
+//	@Failure		500					{string}	string							"Internal server error"
+//	@Failure		503					{string}	string							"Blockchain connection is not configured"
+//	@Router			/payments/prepare [post]
+//	@Security		BearerAuth
+func PreparePaymentHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	var req model.PreparePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid request payload: "+err.Error(), err)
+		return
+	}
+
+	prepared, err := service.PreparePayment(c.Request.Context(), userIDStr, &req)
+	if err != nil {
+		slog.Error("Failed to prepare payment", slog.Any("error", err), slog.String("userID", userIDStr))
+		switch {
+		case errors.Is(err, service.ErrWalletNotOwned):
+			JSONError(c, http.StatusForbidden, "You don't own this wallet address", err)
+		case errors.Is(err, ethclient.ErrEthereumRPCURLNotConfigured):
+			JSONError(c, http.StatusServiceUnavailable, "Blockchain connection is not configured", err)
+		case errors.Is(err, repository.ErrorDatabase):
+			JSONError(c, http.StatusInternalServerError, "Failed to prepare payment", err)
+		default:
+			JSONError(c, http.StatusBadRequest, err.Error(), err)
+		}
+		return
+	}
+
+	JSONSuccess(c, http.StatusCreated, gin.H{
+		"message":          "Payment prepared successfully",
+		"prepared_payment": prepared,
+	})
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateRefundHandler godoc
+//
+//	@Summary		Record Refund
+//	@Description	Records a refund of a confirmed payment. The refund transaction must have as many confirmations as the payment required and return the payment's currency from the original recipient to the original sender; the amount it returns is refunded. Partial refunds are tallied until they reach the payment amount.
+//	@Tags			payments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		string						true	"Payment ID"
+//	@Param			refundRequest	body		model.CreateRefundRequest	true	"Refund transaction"
+//	@Success		201				{object}	model.RefundResponse		"Refund recorded"
+//	@Failure		400				{string}	string						"Validation error or transaction is not a refund of the payment"
+//	@Failure		401				{string}	string						"Unauthorized"
+//	@Failure		404				{string}	string						"Payment not found"
+//	@Failure		409				{string}	string						"Payment not confirmed, refund already recorded or exceeds the payment amount"
+//	@Failure		500				{string}	string						"Internal server error"
+//	@Router			/payments/{id}/refunds [post]
+//	@Security		BearerAuth
+func CreateRefundHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	paymentID := c.Param("id")
+	if _, err := uuid.Parse(paymentID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid payment ID format", err)
+		return
+	}
+
+	var req model.CreateRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid request payload: "+err.Error(), err)
+		return
+	}
+
+	refund, payment, err := service.CreateRefund(c.Request.Context(), userIDStr, paymentID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrorPaymentNotFound):
+			JSONError(c, http.StatusNotFound, "Payment not found", err)
+		case errors.Is(err, repository.ErrorPaymentNotRefundable),
+			errors.Is(err, repository.ErrorDuplicateRefund),
+			errors.Is(err, repository.ErrorRefundExceedsPayment):
+			JSONError(c, http.StatusConflict, err.Error(), err)
+		case errors.Is(err, repository.ErrorDatabase):
+			slog.Error("Failed to record refund", slog.Any("error", err), slog.String("paymentID", paymentID))
+			JSONError(c, http.StatusInternalServerError, "Failed to record refund", err)
+		default:
+			JSONError(c, http.StatusBadRequest, err.Error(), err)
+		}
+		return
+	}
+
+	JSONSuccess(c, http.StatusCreated, gin.H{
+		"message": "Refund recorded",
+		"refund":  refund,
+		"payment": payment,
+	})
+}
+
+// GetPaymentRefundsHandler godoc
+//
+//	@Summary		List Payment Refunds
+//	@Description	Lists the refunds recorded against a payment, oldest first
+//	@Tags			payments
+//	@Produce		json
+//	@Param			id	path		string							true	"Payment ID"
+//	@Success		200	{object}	model.PaymentRefundsResponse	"Payment and its refunds"
+//	@Failure		400	{string}	string							"Invalid payment ID"
+//	@Failure		401	{string}	string							"Unauthorized"
+//	@Failure		404	{string}	string							"Payment not found"
+//	@Failure		500	{string}	string							"Internal server error"
+//	@Router			/payments/{id}/refunds [get]
+//	@Security		BearerAuth
+func GetPaymentRefundsHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	paymentID := c.Param("id")
+	if _, err := uuid.Parse(paymentID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid payment ID format", err)
+		return
+	}
+
+	refunds, err := service.GetPaymentRefunds(c.Request.Context(), userIDStr, paymentID)
+	if err != nil {
+		if errors.Is(err, repository.ErrorPaymentNotFound) {
+			JSONError(c, http.StatusNotFound, "Payment not found", err)
+			return
+		}
+		slog.Error("Failed to get payment refunds", slog.Any("error", err), slog.String("paymentID", paymentID))
+		JSONError(c, http.StatusInternalServerError, "Failed to get payment refunds", err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, refunds)
+}
@@ -1,6 +1,11 @@
 package handler
 
 import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -18,3 +23,12 @@ func JSONError(c *gin.Context, status int, message string, err error) {
 func JSONSuccess(c *gin.Context, status int, payload any) {
 	c.JSON(status, payload)
 }
+
+// clearWriteDeadline lifts the server's write timeout for a response that is
+// streamed for longer, such as an event stream or a large export
+func clearWriteDeadline(c *gin.Context) {
+	err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		slog.Warn("Failed to clear write deadline", slog.Any("error", err))
+	}
+}
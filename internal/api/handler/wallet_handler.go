@@ -204,17 +204,12 @@ func GetWalletBalanceHandler(c *gin.Context) {
 		return
 	}
 
-	balanceEther, err := ethClient.GetETHBalanceInEther(c, address)
-	if err != nil {
-		slog.Error("Failed to convert balance to ether", slog.Any("error", err))
-		JSONError(c, http.StatusInternalServerError, "Failed to get balance", err)
-		return
-	}
+	balance := model.NewAmount(balanceWei)
 
 	JSONSuccess(c, http.StatusOK, gin.H{
 		"address":     address,
-		"balance_wei": balanceWei.String(),
-		"balance_eth": fmt.Sprintf("%.6f", balanceEther),
+		"balance_wei": balance.String(),
+		"balance_eth": balance.FormatFixed(model.Ether, 6),
 		"formatted":   ethclient.FormatBalanceForDisplay(balanceWei, 4),
 	})
 }
@@ -276,7 +271,7 @@ func GetUserWalletBalancesHandler(c *gin.Context) {
 	}
 
 	var walletBalances []map[string]any
-	totalWei := balances[userWallets[0]].Uint64() * 0 // Initialize to 0
+	var totalWei model.Amount
 
 	for _, address := range userWallets {
 		balance, exists := balances[address]
@@ -284,23 +279,22 @@ func GetUserWalletBalancesHandler(c *gin.Context) {
 			continue
 		}
 
-		balanceEther := ethclient.WeiToEther(balance)
-		etherFloat, _ := balanceEther.Float64()
+		amount := model.NewAmount(balance)
 
 		walletBalances = append(walletBalances, map[string]any{
 			"address":     address,
-			"balance_wei": balance.String(),
-			"balance_eth": fmt.Sprintf("%.6f", etherFloat),
+			"balance_wei": amount.String(),
+			"balance_eth": amount.FormatFixed(model.Ether, 6),
 			"formatted":   ethclient.FormatBalanceForDisplay(balance, 4),
 		})
 
-		// Add to total (using big.Int arithmetic would be better for precision)
-		totalWei += balance.Uint64()
+		totalWei = totalWei.Add(amount)
 	}
 
 	JSONSuccess(c, http.StatusOK, gin.H{
 		"wallets":       walletBalances,
-		"total_balance": fmt.Sprintf("%.6f ETH", float64(totalWei)/1e18),
+		"total_balance": totalWei.FormatFixed(model.Ether, 6) + " ETH",
+		"total_wei":     totalWei.String(),
 		"wallet_count":  len(walletBalances),
 	})
 }
@@ -314,3 +308,55 @@ func equalAddresses(addr1, addr2 string) bool {
 	}
 	return addr1 == addr2 // Simple comparison, could use common.HexToAddress for better normalization
 }
+
+// SetPreferredWalletHandler godoc
+//
+//	@Summary		Set Preferred Wallet
+//	@Description	Chooses which connected wallet receives payments sent to the user's phone number
+//	@Tags			wallet
+//	@Accept			json
+//	@Produce		json
+//	@Param			preferredRequest	body		model.SetPreferredWalletRequest	true	"Wallet to prefer"
+//	@Success		200					{object}	map[string]interface{}			"Preferred wallet updated"
+//	@Failure		400					{string}	string							"Invalid request payload"
+//	@Failure		401					{string}	string							"Unauthorized"
+//	@Failure		404					{string}	string							"Wallet not connected to this account"
+//	@Failure		500					{string}	string							"Internal server error"
+//	@Router			/wallet/preferred [put]
+//	@Security		BearerAuth
+func SetPreferredWalletHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	var req model.SetPreferredWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid request payload: "+err.Error(), err)
+		return
+	}
+
+	err := service.SetPreferredWallet(c.Request.Context(), userIDStr, req.Address)
+	if err != nil {
+		if errors.Is(err, repository.ErrorWalletAddressNotFound) {
+			JSONError(c, http.StatusNotFound, "Wallet is not connected to this account", err)
+			return
+		}
+		slog.Error("Failed to set preferred wallet", slog.Any("error", err), slog.String("userID", userIDStr))
+		JSONError(c, http.StatusInternalServerError, "Failed to set preferred wallet", err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, gin.H{
+		"success":       true,
+		"walletAddress": req.Address,
+		"message":       "Preferred wallet updated",
+	})
+}
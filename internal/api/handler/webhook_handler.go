@@ -0,0 +1,307 @@
+package handler
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateWebhookEndpointHandler godoc
+//
+//	@Summary		Create Webhook Endpoint
+//	@Description	Registers a URL to receive signed payment lifecycle events. The URL must resolve to public addresses. The signing secret is only returned in this response.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			webhookRequest	body		model.CreateWebhookEndpointRequest	true	"Webhook endpoint details"
+//	@Success		201				{object}	model.WebhookEndpointResponse		"Webhook endpoint created successfully"
+//	@Failure		400				{string}	string								"Validation error or bad request"
+//	@Failure		401				{string}	string								"Unauthorized"
+//	@Failure		500				{string}	string								"Internal server error"
+//	@Router			/webhooks [post]
+//	@Security		BearerAuth
+func CreateWebhookEndpointHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	var req model.CreateWebhookEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid request payload: "+err.Error(), err)
+		return
+	}
+
+	endpoint, err := service.CreateWebhookEndpoint(c.Request.Context(), userIDStr, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidWebhookURL) || errors.Is(err, service.ErrPrivateWebhookTarget) || errors.Is(err, service.ErrInvalidWebhookEventType) {
+			JSONError(c, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+
+		slog.Error("Failed to create webhook endpoint", slog.Any("error", err), slog.String("userID", userIDStr))
+		JSONError(c, http.StatusInternalServerError, "Failed to create webhook endpoint", err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusCreated, gin.H{
+		"message": "Webhook endpoint created successfully",
+		"webhook": endpoint,
+	})
+}
+
+// GetWebhookEndpointsHandler godoc
+//
+//	@Summary		Get Webhook Endpoints
+//	@Description	Retrieves the authenticated user's webhook endpoints
+//	@Tags			webhooks
+//	@Produce		json
+//	@Success		200	{array}		model.WebhookEndpointResponse	"List of webhook endpoints"
+//	@Failure		401	{string}	string							"Unauthorized"
+//	@Failure		500	{string}	string							"Internal server error"
+//	@Router			/webhooks [get]
+//	@Security		BearerAuth
+func GetWebhookEndpointsHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	endpoints, err := service.GetWebhookEndpoints(c.Request.Context(), userIDStr)
+	if err != nil {
+		slog.Error("Failed to get webhook endpoints", slog.Any("error", err), slog.String("userID", userIDStr))
+		JSONError(c, http.StatusInternalServerError, "Failed to retrieve webhook endpoints", err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, endpoints)
+}
+
+// GetWebhookEndpointHandler godoc
+//
+//	@Summary		Get Webhook Endpoint
+//	@Description	Retrieves one of the user's webhook endpoints
+//	@Tags			webhooks
+//	@Produce		json
+//	@Param			id	path		string							true	"Webhook endpoint ID"
+//	@Success		200	{object}	model.WebhookEndpointResponse	"Webhook endpoint details"
+//	@Failure		400	{string}	string							"Invalid webhook endpoint ID"
+//	@Failure		401	{string}	string							"Unauthorized"
+//	@Failure		404	{string}	string							"Webhook endpoint not found"
+//	@Failure		500	{string}	string							"Internal server error"
+//	@Router			/webhooks/{id} [get]
+//	@Security		BearerAuth
+func GetWebhookEndpointHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	endpointID := c.Param("id")
+	if _, err := uuid.Parse(endpointID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid webhook endpoint ID format", err)
+		return
+	}
+
+	endpoint, err := service.GetWebhookEndpoint(c.Request.Context(), userIDStr, endpointID)
+	if err != nil {
+		if errors.Is(err, repository.ErrorWebhookEndpointNotFound) {
+			JSONError(c, http.StatusNotFound, "Webhook endpoint not found", err)
+			return
+		}
+
+		slog.Error("Failed to get webhook endpoint", slog.Any("error", err), slog.String("endpointID", endpointID))
+		JSONError(c, http.StatusInternalServerError, "Failed to retrieve webhook endpoint", err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, endpoint)
+}
+
+// DeleteWebhookEndpointHandler godoc
+//
+//	@Summary		Delete Webhook Endpoint
+//	@Description	Removes one of the user's webhook endpoints. Events not yet delivered to it are dropped.
+//	@Tags			webhooks
+//	@Produce		json
+//	@Param			id	path		string				true	"Webhook endpoint ID"
+//	@Success		200	{object}	map[string]string	"Webhook endpoint deleted"
+//	@Failure		400	{string}	string				"Invalid webhook endpoint ID"
+//	@Failure		401	{string}	string				"Unauthorized"
+//	@Failure		404	{string}	string				"Webhook endpoint not found"
+//	@Failure		500	{string}	string				"Internal server error"
+//	@Router			/webhooks/{id} [delete]
+//	@Security		BearerAuth
+func DeleteWebhookEndpointHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	endpointID := c.Param("id")
+	if _, err := uuid.Parse(endpointID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid webhook endpoint ID format", err)
+		return
+	}
+
+	if err := service.DeleteWebhookEndpoint(c.Request.Context(), userIDStr, endpointID); err != nil {
+		if errors.Is(err, repository.ErrorWebhookEndpointNotFound) {
+			JSONError(c, http.StatusNotFound, "Webhook endpoint not found", err)
+			return
+		}
+
+		slog.Error("Failed to delete webhook endpoint", slog.Any("error", err), slog.String("endpointID", endpointID))
+		JSONError(c, http.StatusInternalServerError, "Failed to delete webhook endpoint", err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, gin.H{"message": "Webhook endpoint deleted successfully"})
+}
+
+// GetWebhookDeliveriesHandler godoc
+//
+//	@Summary		Get Webhook Deliveries
+//	@Description	Retrieves the delivery log of one of the user's webhook endpoints, newest first, with every attempt made
+//	@Tags			webhooks
+//	@Produce		json
+//	@Param			id		path		string					true	"Webhook endpoint ID"
+//	@Param			status	query		string					false	"Filter by delivery status (pending, succeeded, failed)"
+//	@Param			limit	query		int						false	"Maximum number of deliveries (1-200, default 50)"
+//	@Success		200		{array}		model.WebhookDelivery	"List of deliveries"
+//	@Failure		400		{string}	string					"Invalid webhook endpoint ID or query parameters"
+//	@Failure		401		{string}	string					"Unauthorized"
+//	@Failure		404		{string}	string					"Webhook endpoint not found"
+//	@Failure		500		{string}	string					"Internal server error"
+//	@Router			/webhooks/{id}/deliveries [get]
+//	@Security		BearerAuth
+func GetWebhookDeliveriesHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	endpointID := c.Param("id")
+	if _, err := uuid.Parse(endpointID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid webhook endpoint ID format", err)
+		return
+	}
+
+	var query model.WebhookDeliveryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid query parameters: "+err.Error(), err)
+		return
+	}
+
+	if query.Status != nil {
+		switch *query.Status {
+		case model.WebhookDeliveryPending, model.WebhookDeliverySucceeded, model.WebhookDeliveryFailed:
+		default:
+			JSONError(c, http.StatusBadRequest, "Invalid status filter", nil)
+			return
+		}
+	}
+
+	deliveries, err := service.GetWebhookDeliveries(c.Request.Context(), userIDStr, endpointID, &query)
+	if err != nil {
+		if errors.Is(err, repository.ErrorWebhookEndpointNotFound) {
+			JSONError(c, http.StatusNotFound, "Webhook endpoint not found", err)
+			return
+		}
+
+		slog.Error("Failed to get webhook deliveries", slog.Any("error", err), slog.String("endpointID", endpointID))
+		JSONError(c, http.StatusInternalServerError, "Failed to retrieve webhook deliveries", err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusOK, deliveries)
+}
+
+// RedeliverWebhookHandler godoc
+//
+//	@Summary		Redeliver Webhook
+//	@Description	Queues a webhook delivery to be sent again right away with a fresh set of retries
+//	@Tags			webhooks
+//	@Produce		json
+//	@Param			id	path		string					true	"Webhook delivery ID"
+//	@Success		202	{object}	model.WebhookDelivery	"Delivery queued"
+//	@Failure		400	{string}	string					"Invalid webhook delivery ID"
+//	@Failure		401	{string}	string					"Unauthorized"
+//	@Failure		404	{string}	string					"Webhook delivery not found"
+//	@Failure		500	{string}	string					"Internal server error"
+//	@Router			/webhooks/deliveries/{id}/redeliver [post]
+//	@Security		BearerAuth
+func RedeliverWebhookHandler(c *gin.Context) {
+	userID, exists := c.Get(string(middleware.UserIDKey))
+	if !exists {
+		JSONError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		JSONError(c, http.StatusInternalServerError, "Invalid user ID in context", nil)
+		return
+	}
+
+	deliveryID := c.Param("id")
+	if _, err := uuid.Parse(deliveryID); err != nil {
+		JSONError(c, http.StatusBadRequest, "Invalid webhook delivery ID format", err)
+		return
+	}
+
+	delivery, err := service.RedeliverWebhook(c.Request.Context(), userIDStr, deliveryID)
+	if err != nil {
+		if errors.Is(err, repository.ErrorWebhookDeliveryNotFound) {
+			JSONError(c, http.StatusNotFound, "Webhook delivery not found", err)
+			return
+		}
+
+		slog.Error("Failed to redeliver webhook", slog.Any("error", err), slog.String("deliveryID", deliveryID))
+		JSONError(c, http.StatusInternalServerError, "Failed to redeliver webhook", err)
+		return
+	}
+
+	JSONSuccess(c, http.StatusAccepted, delivery)
+}
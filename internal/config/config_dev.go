@@ -7,4 +7,8 @@ import "github.com/gin-gonic/gin"
 const (
 	SecureCookie = true
 	AppMode      = gin.DebugMode
+
+	// DefaultRequiredConfirmations is the number of blocks a payment must be
+	// buried under before it is confirmed, unless overridden by the environment
+	DefaultRequiredConfirmations = 1
 )
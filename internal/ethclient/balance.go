@@ -1,6 +1,7 @@
 package ethclient
 
 import (
+	"backend/internal/model"
 	"context"
 	"fmt"
 	"log/slog"
@@ -124,17 +125,10 @@ func NormalizeAddress(address string) string {
 	return common.HexToAddress(address).Hex()
 }
 
-// FormatBalanceForDisplay formats a Wei balance for display purposes
+// FormatBalanceForDisplay formats a Wei balance for display purposes,
+// truncating it to the given number of decimal places
 func FormatBalanceForDisplay(balanceWei *big.Int, decimals int) string {
-	if balanceWei == nil {
-		return "0"
-	}
-
-	balanceEther := WeiToEther(balanceWei)
-	format := fmt.Sprintf("%%.%df ETH", decimals)
-	result, _ := balanceEther.Float64()
-
-	return fmt.Sprintf(format, result)
+	return model.NewAmount(balanceWei).FormatFixed(model.Ether, decimals) + " ETH"
 }
 
 // GetBalanceChange calculates the balance change between two blocks
@@ -7,7 +7,9 @@ import (
 	"log/slog"
 	"math/big"
 	"os"
+	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -100,6 +102,16 @@ func (c *Client) GetBlockNumber(ctx context.Context) (uint64, error) {
 	return blockNumber, nil
 }
 
+// GetChainID gets the chain ID transactions must be signed for
+func (c *Client) GetChainID(ctx context.Context) (*big.Int, error) {
+	chainID, err := c.client.ChainID(ctx)
+	if err != nil {
+		slog.Error("Failed to get chain ID", slog.Any("error", err))
+		return nil, err
+	}
+	return chainID, nil
+}
+
 // GetBlock gets block information by number
 func (c *Client) GetBlock(ctx context.Context, blockNumber *big.Int) (*types.Block, error) {
 	block, err := c.client.BlockByNumber(ctx, blockNumber)
@@ -110,6 +122,30 @@ func (c *Client) GetBlock(ctx context.Context, blockNumber *big.Int) (*types.Blo
 	return block, nil
 }
 
+// GetBlockTime returns when the block at the given height was produced
+func (c *Client) GetBlockTime(ctx context.Context, blockNumber uint64) (time.Time, error) {
+	header, err := c.client.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		slog.Error("Failed to get block header", slog.Any("error", err), slog.Uint64("blockNumber", blockNumber))
+		return time.Time{}, err
+	}
+	return time.Unix(int64(header.Time), 0), nil
+}
+
+// GetCanonicalBlockHash returns the hash of the canonical block at the given
+// height. found is false if the chain has no block at that height, e.g. after
+// a reorganization shortened it.
+func (c *Client) GetCanonicalBlockHash(ctx context.Context, blockNumber int64) (hash string, found bool, err error) {
+	block, err := c.GetBlock(ctx, big.NewInt(blockNumber))
+	if err != nil {
+		if errors.Is(err, ethereum.NotFound) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return block.Hash().Hex(), true, nil
+}
+
 // IsTransactionConfirmed checks if a transaction is confirmed (has receipt)
 func (c *Client) IsTransactionConfirmed(ctx context.Context, txHash string) (bool, error) {
 	_, err := c.GetTransactionReceipt(ctx, txHash)
@@ -120,34 +156,46 @@ func (c *Client) IsTransactionConfirmed(ctx context.Context, txHash string) (boo
 	return true, nil
 }
 
-// WaitForConfirmation waits for a transaction to be confirmed
+// confirmationPollInterval is how often WaitForConfirmation checks the chain head
+const confirmationPollInterval = 3 * time.Second
+
+// WaitForConfirmation waits until a transaction has at least the given number
+// of confirmations (the block containing it counts as the first), polling the
+// chain until then. It returns early with ctx's error if ctx is done first.
 func (c *Client) WaitForConfirmation(ctx context.Context, txHash string, confirmations uint64) (*types.Receipt, error) {
 	hash := common.HexToHash(txHash)
 
-	// First, get the transaction receipt
-	receipt, err := c.client.TransactionReceipt(ctx, hash)
-	if err != nil {
-		return nil, err
-	}
+	ticker := time.NewTicker(confirmationPollInterval)
+	defer ticker.Stop()
 
-	// If no confirmations required, return immediately
-	if confirmations == 0 {
-		return receipt, nil
-	}
+	for {
+		receipt, err := c.client.TransactionReceipt(ctx, hash)
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			return nil, err
+		}
 
-	// Get current block number
-	currentBlock, err := c.client.BlockNumber(ctx)
-	if err != nil {
-		return nil, err
-	}
+		if receipt != nil {
+			// If no confirmations required, return immediately
+			if confirmations == 0 {
+				return receipt, nil
+			}
+
+			currentBlock, err := c.client.BlockNumber(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			// Check if we have enough confirmations
+			txBlock := receipt.BlockNumber.Uint64()
+			if currentBlock >= txBlock+confirmations-1 {
+				return receipt, nil
+			}
+		}
 
-	// Check if we have enough confirmations
-	txBlock := receipt.BlockNumber.Uint64()
-	if currentBlock >= txBlock+confirmations-1 {
-		return receipt, nil
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
 	}
-
-	// In a real implementation, you might want to poll for confirmations
-	// For now, we'll just return the receipt as is
-	return receipt, nil
 }
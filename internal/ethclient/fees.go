@@ -0,0 +1,128 @@
+package ethclient
+
+import (
+	"backend/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// TransferGas is the gas used by a plain ETH transfer to an account
+	// without code
+	TransferGas uint64 = 21000
+
+	// feeHistoryBlocks is how many recent blocks fee suggestions are based on
+	feeHistoryBlocks = 20
+)
+
+// feeHistoryPercentiles are the percentiles of the priority fees paid in
+// each block, weighted by gas, that the slow, standard and fast suggestions
+// are based on
+var feeHistoryPercentiles = []float64{10, 50, 90}
+
+// SuggestFees suggests EIP-1559 fee parameters for each speed from the
+// priority fees paid in recent blocks. On nodes without eth_feeHistory every
+// speed is offered the node's suggested legacy gas price.
+func (c *Client) SuggestFees(ctx context.Context) (*model.FeeSuggestions, error) {
+	history, err := c.client.FeeHistory(ctx, feeHistoryBlocks, nil, feeHistoryPercentiles)
+	if err == nil {
+		var suggestions *model.FeeSuggestions
+		if suggestions, err = FeeSuggestionsFromHistory(history); err == nil {
+			return suggestions, nil
+		}
+	}
+	slog.Warn("Failed to get fee history, falling back to gas price", slog.Any("error", err))
+
+	gasPrice, err := c.GetGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gas price: %w", err)
+	}
+	blockNumber, err := c.GetBlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	price := model.NewAmount(gasPrice)
+	legacy := model.FeeSuggestion{MaxFeePerGas: price, MaxPriorityFeePerGas: price}
+	return &model.FeeSuggestions{
+		BlockNumber: blockNumber,
+		Slow:        legacy,
+		Standard:    legacy,
+		Fast:        legacy,
+	}, nil
+}
+
+// FeeSuggestionsFromHistory derives fee suggestions from an eth_feeHistory
+// result requested with feeHistoryPercentiles. Each speed offers the median
+// of its percentile's priority fee over the non-empty blocks, and a max fee
+// of twice the next block's base fee on top, which covers six consecutive
+// full blocks of base fee increases.
+func FeeSuggestionsFromHistory(history *ethereum.FeeHistory) (*model.FeeSuggestions, error) {
+	// BaseFee holds one entry more than the blocks: the next block's
+	if len(history.BaseFee) == 0 || len(history.BaseFee) != len(history.Reward)+1 {
+		return nil, errors.New("fee history has no base fees")
+	}
+	nextBaseFee := history.BaseFee[len(history.BaseFee)-1]
+
+	suggestions := &model.FeeSuggestions{BaseFee: model.NewAmount(nextBaseFee)}
+	if history.OldestBlock != nil && len(history.Reward) > 0 {
+		suggestions.BlockNumber = history.OldestBlock.Uint64() + uint64(len(history.Reward)) - 1
+	}
+
+	tiers := []*model.FeeSuggestion{&suggestions.Slow, &suggestions.Standard, &suggestions.Fast}
+	for i, tier := range tiers {
+		var rewards []*big.Int
+		for block, reward := range history.Reward {
+			// Empty blocks report zero rewards, which would drag the
+			// suggestions down
+			if block < len(history.GasUsedRatio) && history.GasUsedRatio[block] == 0 {
+				continue
+			}
+			if i < len(reward) && reward[i] != nil {
+				rewards = append(rewards, reward[i])
+			}
+		}
+
+		priorityFee := medianFee(rewards)
+		tier.MaxPriorityFeePerGas = model.NewAmount(priorityFee)
+		tier.MaxFeePerGas = model.NewAmount(new(big.Int).Add(new(big.Int).Mul(nextBaseFee, big.NewInt(2)), priorityFee))
+	}
+
+	return suggestions, nil
+}
+
+// medianFee returns the median of fees, the lower one of the middle two for
+// an even count, and zero without fees
+func medianFee(fees []*big.Int) *big.Int {
+	if len(fees) == 0 {
+		return new(big.Int)
+	}
+
+	sorted := slices.Clone(fees)
+	slices.SortFunc(sorted, func(a, b *big.Int) int { return a.Cmp(b) })
+	return new(big.Int).Set(sorted[(len(sorted)-1)/2])
+}
+
+// EstimateTransferGas estimates the gas of sending value wei from one
+// address to another. Estimation fails for senders who cannot afford the
+// value, so a plain transfer's gas is assumed in that case when the
+// recipient has no code.
+func (c *Client) EstimateTransferGas(ctx context.Context, from, to string, value *big.Int) (uint64, error) {
+	gas, err := c.EstimateGas(ctx, common.HexToAddress(from), common.HexToAddress(to), value)
+	if err == nil {
+		return gas, nil
+	}
+
+	code, codeErr := c.client.CodeAt(ctx, common.HexToAddress(to), nil)
+	if codeErr != nil || len(code) > 0 {
+		return 0, fmt.Errorf("failed to estimate gas: %w", err)
+	}
+	return TransferGas, nil
+}
@@ -0,0 +1,283 @@
+package ethclient
+
+import (
+	"backend/internal/model"
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// erc20ABI is the subset of the ERC-20 interface we need for verification
+const erc20ABI = `[
+	{"constant":true,"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"type":"function"},
+	{"constant":true,"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"type":"function"},
+	{"constant":true,"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"type":"function"},
+	{"constant":false,"inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"name":"transfer","outputs":[{"name":"","type":"bool"}],"type":"function"}
+]`
+
+var (
+	parsedERC20ABI = mustParseABI(erc20ABI)
+
+	// transferEventTopic is keccak256("Transfer(address,address,uint256)")
+	transferEventTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+)
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(fmt.Sprintf("invalid ABI definition: %v", err))
+	}
+	return parsed
+}
+
+// GetTokenMetadata reads the symbol and decimals of an ERC-20 token contract
+func (c *Client) GetTokenMetadata(ctx context.Context, tokenAddress string) (*model.Token, error) {
+	if !common.IsHexAddress(tokenAddress) {
+		return nil, fmt.Errorf("invalid token address: %s", tokenAddress)
+	}
+	contract := common.HexToAddress(tokenAddress)
+
+	decimalsOut, err := c.callERC20(ctx, contract, "decimals")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token decimals: %w", err)
+	}
+
+	decimals, ok := decimalsOut[0].(uint8)
+	if !ok {
+		return nil, fmt.Errorf("unexpected decimals type %T", decimalsOut[0])
+	}
+
+	symbolOut, err := c.callERC20(ctx, contract, "symbol")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token symbol: %w", err)
+	}
+
+	symbol, ok := symbolOut[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected symbol type %T", symbolOut[0])
+	}
+
+	return &model.Token{
+		Symbol:   symbol,
+		Address:  contract.Hex(),
+		Decimals: decimals,
+	}, nil
+}
+
+// GetTokenBalance returns the balance of an ERC-20 token held by an address,
+// in the token's smallest unit
+func (c *Client) GetTokenBalance(ctx context.Context, tokenAddress, owner string) (*big.Int, error) {
+	if !common.IsHexAddress(tokenAddress) {
+		return nil, fmt.Errorf("invalid token address: %s", tokenAddress)
+	}
+
+	out, err := c.callERC20(ctx, common.HexToAddress(tokenAddress), "balanceOf", common.HexToAddress(owner))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token balance: %w", err)
+	}
+
+	balance, ok := out[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("unexpected balance type %T", out[0])
+	}
+	return balance, nil
+}
+
+func (c *Client) callERC20(ctx context.Context, contract common.Address, method string, args ...any) ([]any, error) {
+	data, err := parsedERC20ABI.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := c.client.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: data}, nil)
+	if err != nil {
+		slog.Error("Failed to call token contract", slog.Any("error", err), slog.String("contract", contract.Hex()), slog.String("method", method))
+		return nil, err
+	}
+
+	if len(output) == 0 {
+		return nil, fmt.Errorf("address %s is not an ERC-20 token contract", contract.Hex())
+	}
+
+	return parsedERC20ABI.Unpack(method, output)
+}
+
+// decodeTransferLogs extracts every ERC-20 Transfer event from a set of logs
+func decodeTransferLogs(logs []*types.Log) []model.TokenTransfer {
+	var transfers []model.TokenTransfer
+	for _, log := range logs {
+		// Logs removed by a reorganization no longer apply
+		if log.Removed {
+			continue
+		}
+
+		// Transfer(address indexed from, address indexed to, uint256 value).
+		// ERC-721 uses the same signature with an indexed token ID and no
+		// data, which the length checks below exclude.
+		if len(log.Topics) != 3 || log.Topics[0] != transferEventTopic || len(log.Data) != 32 {
+			continue
+		}
+
+		logIndex := log.Index
+		blockNumber := log.BlockNumber
+		transfers = append(transfers, model.TokenTransfer{
+			Token:           log.Address.Hex(),
+			From:            common.BytesToAddress(log.Topics[1].Bytes()).Hex(),
+			To:              common.BytesToAddress(log.Topics[2].Bytes()).Hex(),
+			Value:           model.NewAmount(new(big.Int).SetBytes(log.Data)),
+			LogIndex:        &logIndex,
+			BlockNumber:     &blockNumber,
+			TransactionHash: log.TxHash.Hex(),
+		})
+	}
+	return transfers
+}
+
+// FilterTokenTransfers returns the Transfer events emitted by the given token
+// contracts to any of the recipients between two blocks (inclusive)
+func (c *Client) FilterTokenTransfers(ctx context.Context, fromBlock, toBlock uint64, tokens []string, recipients []string) ([]model.TokenTransfer, error) {
+	if len(tokens) == 0 || len(recipients) == 0 {
+		return nil, nil
+	}
+
+	contracts := make([]common.Address, len(tokens))
+	for i, token := range tokens {
+		contracts[i] = common.HexToAddress(token)
+	}
+
+	recipientTopics := make([]common.Hash, len(recipients))
+	for i, recipient := range recipients {
+		recipientTopics[i] = common.BytesToHash(common.HexToAddress(recipient).Bytes())
+	}
+
+	query := ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(fromBlock),
+		ToBlock:   new(big.Int).SetUint64(toBlock),
+		Addresses: contracts,
+		Topics:    [][]common.Hash{{transferEventTopic}, nil, recipientTopics},
+	}
+
+	logs, err := c.client.FilterLogs(ctx, query)
+	if err != nil {
+		slog.Error("Failed to filter token transfer logs", slog.Any("error", err),
+			slog.Uint64("fromBlock", fromBlock), slog.Uint64("toBlock", toBlock))
+		return nil, err
+	}
+
+	logPointers := make([]*types.Log, len(logs))
+	for i := range logs {
+		logPointers[i] = &logs[i]
+	}
+
+	return decodeTransferLogs(logPointers), nil
+}
+
+// EncodeTokenTransfer builds the calldata of an ERC-20 transfer(to, value) call
+func EncodeTokenTransfer(to string, value *big.Int) ([]byte, error) {
+	if !common.IsHexAddress(to) {
+		return nil, fmt.Errorf("invalid recipient address: %s", to)
+	}
+	return parsedERC20ABI.Pack("transfer", common.HexToAddress(to), value)
+}
+
+// decodeTransferCall decodes a direct ERC-20 transfer(to, value) call. It is
+// used for transactions that have not been mined yet and so have no logs.
+func decodeTransferCall(tx *types.Transaction, sender string) []model.TokenTransfer {
+	method := parsedERC20ABI.Methods["transfer"]
+	data := tx.Data()
+	if tx.To() == nil || len(data) < 4 || !bytes.Equal(data[:4], method.ID) {
+		return nil
+	}
+
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil || len(args) != 2 {
+		return nil
+	}
+
+	to, okTo := args[0].(common.Address)
+	value, okValue := args[1].(*big.Int)
+	if !okTo || !okValue {
+		return nil
+	}
+
+	return []model.TokenTransfer{{
+		Token: tx.To().Hex(),
+		From:  sender,
+		To:    to.Hex(),
+		Value: model.NewAmount(value),
+	}}
+}
+
+// ValidateTokenTransferForPayment checks that a transaction moved exactly the
+// requested amount of the token from the user's wallet to the requested
+// recipient, and returns the matching transfer
+func (c *Client) ValidateTokenTransferForPayment(ctx context.Context, txDetails *model.TransactionDetails, req *model.CreatePaymentRequest, fromAddress string, token *model.Token) (*model.TokenTransfer, error) {
+	if !equalAddresses(txDetails.From, fromAddress) {
+		return nil, fmt.Errorf("transaction from address %s does not match user's wallet %s", txDetails.From, fromAddress)
+	}
+
+	// Check transaction status (1 = success, 0 = failed)
+	if txDetails.BlockNumber != nil && txDetails.Status == 0 {
+		return nil, fmt.Errorf("transaction failed on blockchain")
+	}
+
+	reqValue, err := model.ParseAmount(req.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("invalid requested amount: %w", err)
+	}
+
+	transfer := MatchTokenTransfer(txDetails.TokenTransfers, token.Address, fromAddress, req.ToAddress, reqValue)
+	if transfer == nil {
+		return nil, fmt.Errorf("transaction has no %s transfer of %s from %s to %s",
+			token.Symbol, reqValue.Format(model.Unit(token.Decimals)), fromAddress, req.ToAddress)
+	}
+
+	return transfer, nil
+}
+
+// MatchTokenTransfer finds the transfer of exactly value tokens from one
+// address to another, or returns nil
+func MatchTokenTransfer(transfers []model.TokenTransfer, tokenAddress, from, to string, value model.Amount) *model.TokenTransfer {
+	for i := range transfers {
+		transfer := &transfers[i]
+		if !equalAddresses(transfer.Token, tokenAddress) ||
+			!equalAddresses(transfer.From, from) ||
+			!equalAddresses(transfer.To, to) {
+			continue
+		}
+
+		if transfer.Value.Cmp(value) == 0 {
+			return transfer
+		}
+	}
+	return nil
+}
+
+// SumTokenTransfers totals the tokens moved from one address to another
+// across the given transfers
+func SumTokenTransfers(transfers []model.TokenTransfer, tokenAddress, from, to string) model.Amount {
+	var total model.Amount
+	for _, transfer := range transfers {
+		if equalAddresses(transfer.Token, tokenAddress) &&
+			equalAddresses(transfer.From, from) &&
+			equalAddresses(transfer.To, to) {
+			total = total.Add(transfer.Value)
+		}
+	}
+	return total
+}
+
+// FormatTokenAmount renders an amount in the token's smallest unit as a
+// decimal string using the token's decimals, e.g. 1500000 with 6 decimals is "1.5"
+func FormatTokenAmount(value *big.Int, decimals uint8) string {
+	return model.NewAmount(value).Format(model.Unit(decimals))
+}
@@ -3,14 +3,17 @@ package ethclient
 import (
 	"backend/internal/model"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math/big"
-	"strconv"
+	"strings"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 // VerifyTransaction verifies a transaction and returns detailed information
@@ -20,7 +23,9 @@ func (c *Client) VerifyTransaction(ctx context.Context, txHash string) (*model.T
 	// Get transaction details
 	tx, pending, err := c.client.TransactionByHash(ctx, hash)
 	if err != nil {
-		slog.Error("Failed to get transaction", slog.Any("error", err), slog.String("hash", txHash))
+		if !IsTransactionNotFound(err) {
+			slog.Error("Failed to get transaction", slog.Any("error", err), slog.String("hash", txHash))
+		}
 		return nil, fmt.Errorf("failed to get transaction: %w", err)
 	}
 
@@ -32,35 +37,184 @@ func (c *Client) VerifyTransaction(ctx context.Context, txHash string) (*model.T
 	receipt, err := c.client.TransactionReceipt(ctx, hash)
 	if err != nil {
 		// Transaction might be pending, return basic info
-		return &model.TransactionDetails{
-			Hash:     tx.Hash().Hex(),
-			From:     getTransactionSender(tx),
-			To:       getTransactionRecipient(tx),
-			Value:    tx.Value().String(),
-			Gas:      tx.Gas(),
-			GasPrice: tx.GasPrice().String(),
-		}, nil
+		return PendingTransactionDetails(tx), nil
 	}
 
 	// Convert block number to int64 for our model
 	var blockNumber *int64
+	var blockHash *string
+	var transactionIndex *uint
 	if receipt.BlockNumber != nil {
 		bn := receipt.BlockNumber.Int64()
 		blockNumber = &bn
+
+		bh := receipt.BlockHash.Hex()
+		blockHash = &bh
+
+		index := receipt.TransactionIndex
+		transactionIndex = &index
 	}
 
 	return &model.TransactionDetails{
-		Hash:        tx.Hash().Hex(),
-		From:        getTransactionSender(tx),
-		To:          getTransactionRecipient(tx),
-		Value:       tx.Value().String(),
-		Gas:         tx.Gas(),
-		GasPrice:    tx.GasPrice().String(),
-		BlockNumber: blockNumber,
-		Status:      receipt.Status,
+		Hash:             tx.Hash().Hex(),
+		From:             getTransactionSender(tx),
+		To:               getTransactionRecipient(tx),
+		Value:            model.NewAmount(tx.Value()),
+		Gas:              tx.Gas(),
+		GasPrice:         model.NewAmount(tx.GasPrice()),
+		BlockNumber:      blockNumber,
+		BlockHash:        blockHash,
+		TransactionIndex: transactionIndex,
+		Status:           receipt.Status,
+		Fees:             c.transactionFees(ctx, tx, receipt),
+		TokenTransfers:   decodeTransferLogs(receipt.Logs),
 	}, nil
 }
 
+// PendingTransactionDetails returns what is known of a transaction before it
+// is mined: no block, status or fees, and token transfers decoded from a
+// direct transfer() call
+func PendingTransactionDetails(tx *types.Transaction) *model.TransactionDetails {
+	sender := getTransactionSender(tx)
+	return &model.TransactionDetails{
+		Hash:           tx.Hash().Hex(),
+		From:           sender,
+		To:             getTransactionRecipient(tx),
+		Value:          model.NewAmount(tx.Value()),
+		Gas:            tx.Gas(),
+		GasPrice:       model.NewAmount(tx.GasPrice()),
+		TokenTransfers: decodeTransferCall(tx, sender),
+	}
+}
+
+// DecodeRawTransaction decodes a hex encoded signed transaction, as passed to
+// eth_sendRawTransaction
+func DecodeRawTransaction(raw string) (*types.Transaction, error) {
+	data, err := hexutil.Decode(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw transaction hex: %w", err)
+	}
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("invalid raw transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// SendTransaction broadcasts a signed transaction. A transaction the node
+// already has, e.g. from an earlier attempt, counts as sent.
+func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	err := c.client.SendTransaction(ctx, tx)
+	if err != nil {
+		if strings.Contains(err.Error(), "already known") {
+			return nil
+		}
+		slog.Error("Failed to send transaction", slog.Any("error", err), slog.String("hash", tx.Hash().Hex()))
+		return err
+	}
+	return nil
+}
+
+// IsTransactionNotFound reports whether a VerifyTransaction error means the
+// node knows no transaction with the hash: it was never broadcast, or was
+// dropped from the mempool before being mined
+func IsTransactionNotFound(err error) bool {
+	return errors.Is(err, ethereum.NotFound)
+}
+
+// JSON-RPC error codes that say nothing about the transaction itself: the
+// node failed internally or is rate limiting the caller
+const (
+	rpcErrorInternal      = -32603
+	rpcErrorLimitExceeded = -32005
+)
+
+// IsTransactionRejected reports whether a SendTransaction error is the node
+// refusing the transaction, e.g. for a used nonce or an insufficient
+// balance. Any other error, such as a timeout or a failed connection, leaves
+// it unknown whether the node accepted the transaction.
+func IsTransactionRejected(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	code := rpcErr.ErrorCode()
+	return code != rpcErrorInternal && code != rpcErrorLimitExceeded
+}
+
+// transactionFees reads what a mined transaction paid for gas from its
+// receipt, split by the base fee of its block. The gas limit and offered
+// price of the transaction itself overstate the fee: unused gas is refunded,
+// and EIP-1559 transactions pay less than their fee cap.
+func (c *Client) transactionFees(ctx context.Context, tx *types.Transaction, receipt *types.Receipt) *model.GasFees {
+	// The split is left out rather than failing verification when the block
+	// header cannot be read
+	var baseFee *big.Int
+	header, err := c.client.HeaderByHash(ctx, receipt.BlockHash)
+	if err != nil {
+		slog.Warn("Failed to get block header for base fee", slog.Any("error", err), slog.String("hash", tx.Hash().Hex()))
+	} else {
+		baseFee = header.BaseFee
+	}
+
+	effectiveGasPrice := receipt.EffectiveGasPrice
+	if effectiveGasPrice == nil {
+		// Receipts from nodes predating the field; the price follows from
+		// the transaction and the base fee
+		effectiveGasPrice = tx.GasPrice()
+		if baseFee != nil {
+			if tip, err := tx.EffectiveGasTip(baseFee); err == nil {
+				effectiveGasPrice = new(big.Int).Add(baseFee, tip)
+			}
+		}
+	}
+
+	return model.NewGasFees(receipt.GasUsed, effectiveGasPrice, baseFee)
+}
+
+// GetNativeTransfersInBlock returns the transactions in a block that send ETH
+// to any of the given recipients. Receipts are not fetched, so Status is not
+// set; callers should verify matches with VerifyTransaction.
+func (c *Client) GetNativeTransfersInBlock(ctx context.Context, blockNumber uint64, recipients []string) ([]model.TransactionDetails, error) {
+	if len(recipients) == 0 {
+		return nil, nil
+	}
+
+	wanted := make(map[common.Address]bool, len(recipients))
+	for _, recipient := range recipients {
+		wanted[common.HexToAddress(recipient)] = true
+	}
+
+	block, err := c.GetBlock(ctx, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block %d: %w", blockNumber, err)
+	}
+
+	bn := block.Number().Int64()
+	bh := block.Hash().Hex()
+
+	var transfers []model.TransactionDetails
+	for _, tx := range block.Transactions() {
+		if tx.To() == nil || !wanted[*tx.To()] || tx.Value().Sign() == 0 {
+			continue
+		}
+
+		transfers = append(transfers, model.TransactionDetails{
+			Hash:        tx.Hash().Hex(),
+			From:        getTransactionSender(tx),
+			To:          tx.To().Hex(),
+			Value:       model.NewAmount(tx.Value()),
+			Gas:         tx.Gas(),
+			GasPrice:    model.NewAmount(tx.GasPrice()),
+			BlockNumber: &bn,
+			BlockHash:   &bh,
+		})
+	}
+
+	return transfers, nil
+}
+
 // ValidateTransactionForPayment validates if a transaction matches the payment request
 func (c *Client) ValidateTransactionForPayment(ctx context.Context, txDetails *model.TransactionDetails, req *model.CreatePaymentRequest, fromAddress string) error {
 	// Validate addresses
@@ -82,23 +236,19 @@ func (c *Client) ValidateTransactionForPayment(ctx context.Context, txDetails *m
 		return fmt.Errorf("transaction to address %s does not match requested address %s", txDetails.To, req.ToAddress)
 	}
 
-	// Validate amount (convert both to big.Int for comparison)
-	txValue, ok := new(big.Int).SetString(txDetails.Value, 10)
-	if !ok {
-		return fmt.Errorf("invalid transaction value: %s", txDetails.Value)
-	}
-
-	reqValue, ok := new(big.Int).SetString(req.Amount, 10)
-	if !ok {
-		return fmt.Errorf("invalid requested amount: %s", req.Amount)
+	// Validate amount
+	reqValue, err := model.ParseAmount(req.Amount)
+	if err != nil {
+		return fmt.Errorf("invalid requested amount: %w", err)
 	}
 
-	if txValue.Cmp(reqValue) != 0 {
-		return fmt.Errorf("transaction amount %s does not match requested amount %s", txDetails.Value, req.Amount)
+	if txDetails.Value.Cmp(reqValue) != 0 {
+		return fmt.Errorf("transaction amount %s does not match requested amount %s", txDetails.Value, reqValue)
 	}
 
-	// Check transaction status (1 = success, 0 = failed)
-	if txDetails.Status == 0 {
+	// Check transaction status (1 = success, 0 = failed). Transactions that have
+	// not been mined yet have no receipt and are accepted as pending.
+	if txDetails.BlockNumber != nil && txDetails.Status == 0 {
 		return fmt.Errorf("transaction failed on blockchain")
 	}
 
@@ -117,8 +267,47 @@ func (c *Client) GetTransactionConfirmations(ctx context.Context, txHash string)
 		return 0, err
 	}
 
-	confirmations := currentBlock - receipt.BlockNumber.Uint64() + 1
-	return confirmations, nil
+	return ConfirmationsAt(currentBlock, receipt.BlockNumber.Uint64()), nil
+}
+
+// ConfirmationsAt returns the confirmation depth of a block at the given
+// chain head, counting the block itself. A head below the block, as reported
+// by a lagging or load-balanced node, counts as no confirmations.
+func ConfirmationsAt(head, block uint64) uint64 {
+	if head < block {
+		return 0
+	}
+	return head - block + 1
+}
+
+// IsTransactionMined reports whether a transaction has been included in a
+// block. Transactions the node does not know about, e.g. ones dropped from the
+// mempool, are reported as not mined.
+func (c *Client) IsTransactionMined(ctx context.Context, txHash string) (bool, error) {
+	receipt, err := c.client.TransactionReceipt(ctx, common.HexToHash(txHash))
+	if err != nil {
+		if errors.Is(err, ethereum.NotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get transaction receipt: %w", err)
+	}
+
+	return receipt.BlockNumber != nil, nil
+}
+
+// IsTransactionPending reports whether a transaction is waiting in the node's
+// mempool. Mined transactions and ones the node does not know about are not
+// pending.
+func (c *Client) IsTransactionPending(ctx context.Context, txHash string) (bool, error) {
+	_, pending, err := c.client.TransactionByHash(ctx, common.HexToHash(txHash))
+	if err != nil {
+		if errors.Is(err, ethereum.NotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	return pending, nil
 }
 
 // EstimateGas estimates gas for a transaction
@@ -138,6 +327,37 @@ func (c *Client) EstimateGas(ctx context.Context, from, to common.Address, value
 	return gasEstimate, nil
 }
 
+// EstimateCallGas estimates gas for a transaction carrying call data, e.g.
+// an ERC-20 transfer
+func (c *Client) EstimateCallGas(ctx context.Context, from, to string, value *big.Int, data []byte) (uint64, error) {
+	contract := common.HexToAddress(to)
+	msg := ethereum.CallMsg{
+		From:  common.HexToAddress(from),
+		To:    &contract,
+		Value: value,
+		Data:  data,
+	}
+
+	gasEstimate, err := c.client.EstimateGas(ctx, msg)
+	if err != nil {
+		slog.Error("Failed to estimate gas", slog.Any("error", err))
+		return 0, err
+	}
+
+	return gasEstimate, nil
+}
+
+// GetPendingNonce returns the next nonce of an address, counting its
+// transactions waiting in the mempool
+func (c *Client) GetPendingNonce(ctx context.Context, address string) (uint64, error) {
+	nonce, err := c.client.PendingNonceAt(ctx, common.HexToAddress(address))
+	if err != nil {
+		slog.Error("Failed to get pending nonce", slog.Any("error", err), slog.String("address", address))
+		return 0, err
+	}
+	return nonce, nil
+}
+
 // GetGasPrice gets the current gas price
 func (c *Client) GetGasPrice(ctx context.Context) (*big.Int, error) {
 	gasPrice, err := c.client.SuggestGasPrice(ctx)
@@ -160,23 +380,14 @@ func EtherToWei(eth *big.Float) *big.Int {
 	return result
 }
 
-// ParseEtherAmount parses an ether amount string to Wei
+// ParseEtherAmount parses an ether amount string, e.g. "1.5", to Wei. Amounts
+// with more than 18 decimal places are rejected rather than rounded.
 func ParseEtherAmount(amount string) (*big.Int, error) {
-	// Try to parse as float first
-	ethAmount, err := strconv.ParseFloat(amount, 64)
+	parsed, err := model.ParseDecimalAmount(amount, model.Ether)
 	if err != nil {
-		// If that fails, try to parse as big.Int (assuming it's already in Wei)
-		wei, ok := new(big.Int).SetString(amount, 10)
-		if !ok {
-			return nil, fmt.Errorf("invalid amount format: %s", amount)
-		}
-		return wei, nil
+		return nil, fmt.Errorf("invalid amount format: %s", amount)
 	}
-
-	// Convert ether to wei
-	ethBig := big.NewFloat(ethAmount)
-	wei := EtherToWei(ethBig)
-	return wei, nil
+	return parsed.BigInt(), nil
 }
 
 // Helper functions
@@ -0,0 +1,111 @@
+package export
+
+import (
+	"backend/internal/model"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvHeader names the columns written by csvWriter
+var csvHeader = []string{
+	"id", "created_at", "confirmed_at", "status", "direction",
+	"from_address", "to_address", "amount", "amount_formatted", "currency", "token_address",
+	"transaction_hash", "block_number", "confirmations",
+	"gas_used", "gas_price", "base_fee_per_gas", "priority_fee_per_gas", "gas_fee", "gas_fee_eth",
+	"description", "invoice_id", "category_id", "tags",
+}
+
+// csvWriter writes one row per payment. Amounts are in the currency's smallest
+// unit, with amount_formatted and gas_fee_eth in whole units for spreadsheets.
+// Tags are joined with semicolons.
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	writer := &csvWriter{w: csv.NewWriter(w)}
+	if err := writer.w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (cw *csvWriter) WritePayment(payment *model.Payment) error {
+	var tokenAddress, blockNumber, gasUsed, gasPrice, baseFee, priorityFee, gasFee, gasFeeEth, description, invoiceID, categoryID string
+	if payment.TokenAddress != nil {
+		tokenAddress = *payment.TokenAddress
+	}
+	if payment.BlockNumber != nil {
+		blockNumber = strconv.FormatInt(*payment.BlockNumber, 10)
+	}
+	if payment.GasUsed != nil {
+		gasUsed = strconv.FormatInt(*payment.GasUsed, 10)
+	}
+	if payment.GasPrice != nil {
+		gasPrice = payment.GasPrice.String()
+	}
+	if payment.BaseFeePerGas != nil {
+		baseFee = payment.BaseFeePerGas.String()
+	}
+	if payment.PriorityFeePerGas != nil {
+		priorityFee = payment.PriorityFeePerGas.String()
+	}
+	if fee := payment.GasFee(); fee != nil {
+		gasFee = fee.String()
+		gasFeeEth = fee.Format(model.Ether)
+	}
+	if payment.Description != nil {
+		description = *payment.Description
+	}
+	if payment.InvoiceID != nil {
+		invoiceID = payment.InvoiceID.String()
+	}
+	if payment.CategoryID != nil {
+		categoryID = payment.CategoryID.String()
+	}
+
+	return cw.w.Write([]string{
+		payment.ID.String(),
+		payment.CreatedAt.UTC().Format(time.RFC3339),
+		formatTime(payment.ConfirmedAt),
+		string(payment.Status),
+		string(payment.Direction),
+		payment.FromAddress,
+		payment.ToAddress,
+		payment.Amount.String(),
+		payment.Amount.Format(model.Unit(payment.TokenDecimals)),
+		payment.Currency,
+		tokenAddress,
+		payment.TransactionHash,
+		blockNumber,
+		strconv.FormatInt(payment.Confirmations, 10),
+		gasUsed,
+		gasPrice,
+		baseFee,
+		priorityFee,
+		gasFee,
+		gasFeeEth,
+		escapeFormula(description),
+		invoiceID,
+		categoryID,
+		escapeFormula(strings.Join(payment.Tags, ";")),
+	})
+}
+
+// escapeFormula stops spreadsheets from evaluating free text as a formula.
+// Descriptions can come from other users, e.g. a split or intent description
+// copied into the payer's payment, so a leading formula character is quoted.
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
@@ -0,0 +1,95 @@
+// Package export writes payment history as downloadable files. Writers take
+// one payment at a time so exports can be streamed straight from the database
+// to the client.
+package export
+
+import (
+	"backend/internal/model"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Format is a payment export file format
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+	FormatOFX    Format = "ofx"
+)
+
+var ErrorUnsupportedFormat = errors.New("unsupported export format")
+
+// ParseFormat parses a format name, case-insensitively
+func ParseFormat(s string) (Format, error) {
+	format := Format(strings.ToLower(strings.TrimSpace(s)))
+	switch format {
+	case FormatCSV, FormatNDJSON, FormatOFX:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: %q (must be csv, ndjson or ofx)", ErrorUnsupportedFormat, s)
+	}
+}
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatOFX:
+		return "application/x-ofx"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// FileExtension returns the file name extension of the format, without the dot
+func (f Format) FileExtension() string {
+	return string(f)
+}
+
+// PaymentWriter writes payments to an export file one at a time
+type PaymentWriter interface {
+	WritePayment(payment *model.Payment) error
+
+	// Close writes any trailer and flushes buffered output. It does not close
+	// the underlying writer.
+	Close() error
+}
+
+// Statement describes the account and period an export covers. Only OFX
+// files record it.
+type Statement struct {
+	AccountID string
+	Currency  string
+	Start     time.Time
+	End       time.Time
+}
+
+// NewPaymentWriter returns a writer for the format. Output is buffered; nothing
+// reaches w until the buffer fills or the writer is closed.
+func NewPaymentWriter(format Format, w io.Writer, statement Statement) (PaymentWriter, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatNDJSON:
+		return newNDJSONWriter(w), nil
+	case FormatOFX:
+		return newOFXWriter(w, statement)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrorUnsupportedFormat, format)
+	}
+}
+
+// formatTime formats an optional timestamp in UTC, or returns "" when it is nil
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
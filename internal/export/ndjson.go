@@ -0,0 +1,39 @@
+package export
+
+import (
+	"backend/internal/model"
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// ndjsonRecord is the JSON object written for each payment
+type ndjsonRecord struct {
+	*model.Payment
+	AmountFormatted string        `json:"amount_formatted"`
+	GasFee          *model.Amount `json:"gas_fee,omitempty"` // In wei
+}
+
+// ndjsonWriter writes one JSON object per line (JSON Lines)
+type ndjsonWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	buf := bufio.NewWriter(w)
+	return &ndjsonWriter{buf: buf, enc: json.NewEncoder(buf)}
+}
+
+func (nw *ndjsonWriter) WritePayment(payment *model.Payment) error {
+	// Encode terminates each value with a newline
+	return nw.enc.Encode(ndjsonRecord{
+		Payment:         payment,
+		AmountFormatted: payment.Amount.Format(model.Unit(payment.TokenDecimals)),
+		GasFee:          payment.GasFee(),
+	})
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nw.buf.Flush()
+}
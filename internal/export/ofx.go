@@ -0,0 +1,125 @@
+package export
+
+import (
+	"backend/internal/model"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ofxTimeLayout is the OFX date-time format, always written in UTC
+const ofxTimeLayout = "20060102150405.000[0:GMT]"
+
+// ofxNativeCurrency is the currency gas fees are paid in
+const ofxNativeCurrency = "ETH"
+
+// ofxWriter writes an OFX 2.2 bank statement with one transaction per
+// payment: a debit for outgoing payments, a credit for incoming ones, plus a
+// fee transaction for the gas an outgoing payment's transaction burned. A
+// statement has a single currency: payments in other currencies are skipped,
+// as are gas fees unless the statement is in ETH.
+type ofxWriter struct {
+	buf       *bufio.Writer
+	statement Statement
+}
+
+func newOFXWriter(w io.Writer, statement Statement) (*ofxWriter, error) {
+	if statement.Currency == "" {
+		statement.Currency = ofxNativeCurrency
+	}
+
+	ow := &ofxWriter{buf: bufio.NewWriter(w), statement: statement}
+	now := time.Now().UTC().Format(ofxTimeLayout)
+
+	fmt.Fprint(ow.buf, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>`+"\n")
+	fmt.Fprint(ow.buf, `<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>`+"\n")
+	fmt.Fprint(ow.buf, "<OFX>\n")
+	fmt.Fprintf(ow.buf, "<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>"+
+		"<DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE></SONRS></SIGNONMSGSRSV1>\n", now)
+	fmt.Fprint(ow.buf, "<BANKMSGSRSV1><STMTTRNRS><TRNUID>0</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n")
+	fmt.Fprintf(ow.buf, "<STMTRS><CURDEF>%s</CURDEF>\n", escapeOFX(statement.Currency))
+	fmt.Fprintf(ow.buf, "<BANKACCTFROM><BANKID>ETHEREUM</BANKID><ACCTID>%s</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>\n",
+		escapeOFX(statement.AccountID))
+	_, err := fmt.Fprintf(ow.buf, "<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>\n",
+		statement.Start.UTC().Format(ofxTimeLayout), statement.End.UTC().Format(ofxTimeLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	return ow, nil
+}
+
+func (ow *ofxWriter) WritePayment(payment *model.Payment) error {
+	posted := payment.CreatedAt
+	if payment.ConfirmedAt != nil {
+		posted = *payment.ConfirmedAt
+	}
+
+	// Failed and cancelled payments moved no funds, though a reverted
+	// transaction still paid for its gas
+	movedFunds := payment.Status != model.PaymentStatusFailed && payment.Status != model.PaymentStatusCancelled
+	if movedFunds && strings.EqualFold(payment.Currency, ow.statement.Currency) {
+		memo := "Payment " + payment.TransactionHash
+		if payment.Description != nil && *payment.Description != "" {
+			memo = *payment.Description
+		}
+		amount := payment.Amount.Format(model.Unit(payment.TokenDecimals))
+		if payment.Direction == model.PaymentDirectionIncoming {
+			ow.writeTransaction("CREDIT", payment.ID.String(), posted, payment.CreatedAt, amount, payment.FromAddress, memo)
+		} else {
+			ow.writeTransaction("DEBIT", payment.ID.String(), posted, payment.CreatedAt, "-"+amount, payment.ToAddress, memo)
+		}
+	}
+
+	// The sender pays for gas
+	if payment.Direction == model.PaymentDirectionIncoming || !strings.EqualFold(ow.statement.Currency, ofxNativeCurrency) {
+		return ow.err()
+	}
+	if fee := payment.GasFee(); fee != nil && !fee.IsZero() {
+		ow.writeTransaction("FEE", payment.ID.String()+"-fee", posted, payment.CreatedAt,
+			"-"+fee.Format(model.Ether), payment.ToAddress, "Gas fee for "+payment.TransactionHash)
+	}
+
+	return ow.err()
+}
+
+func (ow *ofxWriter) writeTransaction(trnType, fitID string, posted, user time.Time, amount, name, memo string) {
+	// NAME is limited to 32 characters
+	if len(name) > 32 {
+		name = name[:32]
+	}
+
+	fmt.Fprintf(ow.buf, "<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><DTUSER>%s</DTUSER>"+
+		"<TRNAMT>%s</TRNAMT><FITID>%s</FITID><NAME>%s</NAME><MEMO>%s</MEMO></STMTTRN>\n",
+		trnType, posted.UTC().Format(ofxTimeLayout), user.UTC().Format(ofxTimeLayout),
+		amount, escapeOFX(fitID), escapeOFX(name), escapeOFX(memo))
+}
+
+func (ow *ofxWriter) Close() error {
+	fmt.Fprint(ow.buf, "</BANKTRANLIST>\n")
+	// Wallet balances are not tracked per statement, so the ledger balance
+	// OFX requires is reported as zero
+	fmt.Fprintf(ow.buf, "<LEDGERBAL><BALAMT>0</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>\n",
+		ow.statement.End.UTC().Format(ofxTimeLayout))
+	fmt.Fprint(ow.buf, "</STMTRS></STMTTRNRS></BANKMSGSRSV1>\n</OFX>\n")
+	if err := ow.err(); err != nil {
+		return err
+	}
+	return ow.buf.Flush()
+}
+
+// err returns the first write error, which bufio.Writer keeps returning
+func (ow *ofxWriter) err() error {
+	_, err := ow.buf.Write(nil)
+	return err
+}
+
+// escapeOFX escapes text for use as OFX element content
+func escapeOFX(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -90,3 +90,18 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 	}
 }
+
+// TokenFromQuery lets clients that cannot set request headers, such as the
+// browser's EventSource and WebSocket APIs, pass their access token in the
+// access_token query parameter. It must run before AuthMiddleware; an
+// Authorization header, when present, takes precedence.
+func TokenFromQuery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query("access_token"); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader carries the client's key for a request
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader is set on responses replayed from a previous
+	// request with the same key
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// Idempotency makes a route safe to retry. When a request carries an
+// Idempotency-Key header, its successful response is stored and returned
+// unchanged for later requests with the same key and body, without running
+// the handler again. Reusing a key for a different request is rejected with
+// 422, and a retry that arrives while the original is still running with
+// 409. Requests without the header are handled normally. Must run after
+// AuthMiddleware, since keys are scoped to the user.
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		userID := c.GetString(string(UserIDKey))
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := service.IdempotencyFingerprint(c.Request.Method, c.FullPath(), body)
+		stored, err := service.BeginIdempotentRequest(c.Request.Context(), userID, key, fingerprint)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrInvalidIdempotencyKey):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, service.ErrIdempotencyKeyReused):
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			case errors.Is(err, service.ErrIdempotencyKeyInProgress):
+				c.Header("Retry-After", "1")
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				slog.Error("Failed to claim idempotency key", slog.Any("error", err), slog.String("userID", userID))
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process idempotency key"})
+			}
+			c.Abort()
+			return
+		}
+
+		if stored != nil {
+			contentType := "application/json; charset=utf-8"
+			if stored.ContentType != nil {
+				contentType = *stored.ContentType
+			}
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(*stored.StatusCode, contentType, stored.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Store the outcome even if the client gave up waiting, since a
+		// client that times out is exactly the one that will retry
+		ctx := context.WithoutCancel(c.Request.Context())
+		status := recorder.Status()
+		err = service.CompleteIdempotentRequest(ctx, userID, key, status, recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+		if err != nil {
+			slog.Error("Failed to store idempotent response",
+				slog.Any("error", err),
+				slog.String("userID", userID),
+				slog.Int("status", status))
+		}
+	}
+}
+
+// responseRecorder keeps a copy of the response body as it is written
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
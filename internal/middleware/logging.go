@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"log/slog"
+	"regexp"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,7 +13,7 @@ func StructuredLogger() gin.HandlerFunc {
 		start := time.Now()
 		path := c.Request.URL.Path
 		if c.Request.URL.RawQuery != "" {
-			path = path + "?" + c.Request.URL.RawQuery
+			path = path + "?" + redactQuery(c.Request.URL.RawQuery)
 		}
 
 		c.Next()
@@ -36,3 +37,13 @@ func StructuredLogger() gin.HandlerFunc {
 		}
 	}
 }
+
+// accessTokenParam matches access_token query parameters, escaped or not
+var accessTokenParam = regexp.MustCompile(`(?i)(^|&)(access(?:_|%5f)token=)[^&]*`)
+
+// redactQuery hides access tokens passed in the query string, see
+// TokenFromQuery. The raw query is rewritten rather than parsed, so a
+// malformed query cannot leak the token.
+func redactQuery(rawQuery string) string {
+	return accessTokenParam.ReplaceAllString(rawQuery, "${1}${2}REDACTED")
+}
@@ -0,0 +1,211 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Unit is the number of decimal places between an amount's smallest unit
+// and the unit it is displayed in
+type Unit uint8
+
+const (
+	Wei   Unit = 0
+	Gwei  Unit = 9
+	Ether Unit = 18
+)
+
+// Amount is an exact, arbitrary-precision quantity of a currency in its
+// smallest unit (wei for ETH, the token's base unit for ERC-20 tokens). The
+// zero value is zero. Amounts are immutable; arithmetic returns new values.
+// They are encoded as decimal strings in JSON and as NUMERIC in SQL.
+type Amount struct {
+	value *big.Int
+}
+
+// NewAmount returns an Amount holding a copy of v
+func NewAmount(v *big.Int) Amount {
+	if v == nil {
+		return Amount{}
+	}
+	return Amount{value: new(big.Int).Set(v)}
+}
+
+// AmountFromInt64 returns an Amount of v smallest units
+func AmountFromInt64(v int64) Amount {
+	return Amount{value: big.NewInt(v)}
+}
+
+// ParseAmount parses a base-10 integer number of smallest units, e.g. "1500000000000000000"
+func ParseAmount(s string) (Amount, error) {
+	value, ok := new(big.Int).SetString(strings.TrimSpace(s), 10)
+	if !ok {
+		return Amount{}, fmt.Errorf("invalid amount %q: must be an integer in the currency's smallest unit", s)
+	}
+	return Amount{value: value}, nil
+}
+
+// ParseDecimalAmount parses a decimal quantity expressed in unit, e.g. "1.5"
+// ether, into smallest units. It fails rather than rounding if s has more
+// decimal places than the unit allows.
+func ParseDecimalAmount(s string, unit Unit) (Amount, error) {
+	rat, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return Amount{}, fmt.Errorf("invalid amount %q", s)
+	}
+
+	rat.Mul(rat, new(big.Rat).SetInt(unitScale(unit)))
+	if !rat.IsInt() {
+		return Amount{}, fmt.Errorf("amount %q has more than %d decimal places", s, unit)
+	}
+
+	return Amount{value: new(big.Int).Set(rat.Num())}, nil
+}
+
+// BigInt returns a copy of the amount as a big.Int
+func (a Amount) BigInt() *big.Int {
+	if a.value == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(a.value)
+}
+
+// String returns the amount in smallest units as a base-10 integer
+func (a Amount) String() string {
+	if a.value == nil {
+		return "0"
+	}
+	return a.value.String()
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of the amount
+func (a Amount) Sign() int {
+	if a.value == nil {
+		return 0
+	}
+	return a.value.Sign()
+}
+
+// IsZero reports whether the amount is zero
+func (a Amount) IsZero() bool {
+	return a.Sign() == 0
+}
+
+// Cmp compares two amounts and returns -1, 0 or +1
+func (a Amount) Cmp(b Amount) int {
+	return a.BigInt().Cmp(b.BigInt())
+}
+
+// Add returns a + b
+func (a Amount) Add(b Amount) Amount {
+	return Amount{value: new(big.Int).Add(a.BigInt(), b.BigInt())}
+}
+
+// Sub returns a - b
+func (a Amount) Sub(b Amount) Amount {
+	return Amount{value: new(big.Int).Sub(a.BigInt(), b.BigInt())}
+}
+
+// Format returns the exact amount expressed in unit, without trailing
+// zeros, e.g. 1500000000000000000 wei formats as "1.5" in Ether
+func (a Amount) Format(unit Unit) string {
+	value := a.BigInt()
+	if unit == 0 {
+		return value.String()
+	}
+
+	sign := ""
+	if value.Sign() < 0 {
+		sign = "-"
+		value.Neg(value)
+	}
+
+	whole, frac := new(big.Int).QuoRem(value, unitScale(unit), new(big.Int))
+	if frac.Sign() == 0 {
+		return sign + whole.String()
+	}
+
+	fracStr := fmt.Sprintf("%0*s", int(unit), frac.String())
+	return sign + whole.String() + "." + strings.TrimRight(fracStr, "0")
+}
+
+// FormatFixed returns the amount expressed in unit with exactly places
+// decimal places, truncating any further digits
+func (a Amount) FormatFixed(unit Unit, places int) string {
+	formatted := a.Format(unit)
+
+	whole, frac, _ := strings.Cut(formatted, ".")
+	if places <= 0 {
+		return whole
+	}
+	if len(frac) > places {
+		frac = frac[:places]
+	}
+	return whole + "." + frac + strings.Repeat("0", places-len(frac))
+}
+
+// MarshalJSON encodes the amount as a JSON string of smallest units, so that
+// clients never parse it into a lossy float
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON accepts the amount as a JSON string or integer of smallest units
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var s string
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	} else {
+		s = string(data)
+	}
+
+	parsed, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+
+	*a = parsed
+	return nil
+}
+
+// Value implements driver.Valuer, storing the amount in a NUMERIC column
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Scan implements sql.Scanner for NUMERIC columns. Values with a fractional
+// part are rejected rather than truncated.
+func (a *Amount) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case int64:
+		*a = AmountFromInt64(v)
+		return nil
+	case nil:
+		return fmt.Errorf("cannot scan NULL into Amount")
+	default:
+		return fmt.Errorf("cannot scan %T into Amount", src)
+	}
+
+	rat, ok := new(big.Rat).SetString(s)
+	if !ok || !rat.IsInt() {
+		return fmt.Errorf("cannot scan %q into Amount: not an integer", s)
+	}
+
+	*a = Amount{value: new(big.Int).Set(rat.Num())}
+	return nil
+}
+
+// unitScale returns 10^unit
+func unitScale(unit Unit) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(unit)), nil)
+}
@@ -0,0 +1,120 @@
+package model
+
+import "time"
+
+// AnalyticsInterval is the width of the time buckets in a payment series
+type AnalyticsInterval string
+
+const (
+	AnalyticsIntervalDay   AnalyticsInterval = "day"
+	AnalyticsIntervalWeek  AnalyticsInterval = "week"
+	AnalyticsIntervalMonth AnalyticsInterval = "month"
+)
+
+// IsValid checks if the analytics interval is supported
+func (i AnalyticsInterval) IsValid() bool {
+	switch i {
+	case AnalyticsIntervalDay, AnalyticsIntervalWeek, AnalyticsIntervalMonth:
+		return true
+	default:
+		return false
+	}
+}
+
+// AnalyticsQuery represents query parameters for payment analytics. The range
+// covers payments created at or after From and before To; it defaults to the
+// last 30 days.
+type AnalyticsQuery struct {
+	From              *time.Time        `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To                *time.Time        `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Interval          AnalyticsInterval `form:"interval,default=day"`
+	Currency          *string           `form:"currency"`
+	TopCounterparties int               `form:"top_counterparties,default=5"`
+}
+
+// PaymentTotal is the number and summed amount of a user's payments with one
+// status and direction in one currency. Currencies are told apart by token
+// address and decimals, never by symbol alone.
+type PaymentTotal struct {
+	Status        PaymentStatus    `json:"status"`
+	Direction     PaymentDirection `json:"direction"`
+	Currency      string           `json:"currency"`
+	TokenAddress  *string          `json:"token_address,omitempty"` // nil for native ETH
+	TokenDecimals int              `json:"token_decimals"`
+	Count         int64            `json:"count"`
+	Amount        Amount           `json:"amount"` // In the currency's smallest unit
+}
+
+// IsNative reports whether the total is of native ETH payments
+func (t PaymentTotal) IsNative() bool {
+	return t.TokenAddress == nil
+}
+
+// PaymentSeriesPoint is the activity in one currency and direction during
+// one time bucket. Buckets without payments are omitted.
+type PaymentSeriesPoint struct {
+	Period          time.Time        `json:"period"` // Start of the bucket
+	Direction       PaymentDirection `json:"direction"`
+	Currency        string           `json:"currency"`
+	TokenAddress    *string          `json:"token_address,omitempty"` // nil for native ETH
+	TokenDecimals   int              `json:"token_decimals"`
+	Count           int64            `json:"count"`
+	ConfirmedCount  int64            `json:"confirmed_count"`
+	ConfirmedAmount Amount           `json:"confirmed_amount"` // In the currency's smallest unit
+}
+
+// CounterpartyTotal is the confirmed volume exchanged with one address in one
+// currency and direction: the recipient of outgoing payments, or the sender
+// of incoming ones
+type CounterpartyTotal struct {
+	Address       string           `json:"address"`
+	Direction     PaymentDirection `json:"direction"`
+	Currency      string           `json:"currency"`
+	TokenAddress  *string          `json:"token_address,omitempty"` // nil for native ETH
+	TokenDecimals int              `json:"token_decimals"`
+	Count         int64            `json:"count"`
+	Amount        Amount           `json:"amount"` // In the currency's smallest unit
+}
+
+// ConfirmationTimeStats summarises how long confirmed payments took from
+// creation to confirmation
+type ConfirmationTimeStats struct {
+	ConfirmedPayments int64   `json:"confirmed_payments"`
+	AverageSeconds    float64 `json:"average_seconds"`
+}
+
+// GasSpent is the gas used by outgoing payments with known gas details, and
+// the fee paid for it in wei
+type GasSpent struct {
+	Payments int64  `json:"payments"`
+	GasUsed  int64  `json:"gas_used"`
+	Fee      Amount `json:"fee"`
+}
+
+// PaymentAnalytics is the aggregated view of a user's payments over a date range
+type PaymentAnalytics struct {
+	From              time.Time             `json:"from"`
+	To                time.Time             `json:"to"`
+	Interval          AnalyticsInterval     `json:"interval"`
+	Totals            []PaymentTotal        `json:"totals"`
+	Series            []PaymentSeriesPoint  `json:"series"`
+	TopCounterparties []CounterpartyTotal   `json:"top_counterparties"`
+	ConfirmationTime  ConfirmationTimeStats `json:"confirmation_time"`
+	Gas               GasSpent              `json:"gas"`
+}
+
+// PaymentStats is the all-time summary of a user's payments. TotalAmount and
+// TotalReceived are the sums of confirmed native ETH payments sent and
+// received, in wei. Fiat sums the fiat values of confirmed payments in the
+// user's display currency.
+type PaymentStats struct {
+	TotalPayments int64          `json:"total_payments"`
+	Confirmed     int64          `json:"confirmed"`
+	Confirming    int64          `json:"confirming"`
+	Pending       int64          `json:"pending"`
+	Failed        int64          `json:"failed"`
+	TotalAmount   Amount         `json:"total_amount"`
+	TotalReceived Amount         `json:"total_received"`
+	Totals        []PaymentTotal `json:"totals"`
+	Fiat          *FiatTotals    `json:"fiat,omitempty"`
+}
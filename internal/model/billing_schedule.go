@@ -0,0 +1,257 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BillingInterval is how often a billing schedule charges its payer
+type BillingInterval string
+
+const (
+	BillingIntervalWeekly  BillingInterval = "weekly"
+	BillingIntervalMonthly BillingInterval = "monthly"
+)
+
+// IsValid checks if the billing interval is valid
+func (i BillingInterval) IsValid() bool {
+	return i == BillingIntervalWeekly || i == BillingIntervalMonthly
+}
+
+// PeriodStart returns when the nth period (counting from zero) of a schedule
+// anchored at start begins. Monthly periods keep the anchor's day of month,
+// falling back to the last day of shorter months, so a schedule starting on
+// January 31 bills on February 28 (or 29) and March 31.
+func (i BillingInterval) PeriodStart(start time.Time, n int) time.Time {
+	if i == BillingIntervalWeekly {
+		return start.AddDate(0, 0, 7*n)
+	}
+
+	// Day 0 of the month after the target month is the target month's last day
+	firstOfMonth := time.Date(start.Year(), start.Month()+time.Month(n), 1,
+		start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	return firstOfMonth.AddDate(0, 0, min(start.Day(), lastDay)-1)
+}
+
+// BillingScheduleStatus represents whether a schedule still generates periods
+type BillingScheduleStatus string
+
+const (
+	BillingScheduleStatusActive    BillingScheduleStatus = "active"
+	BillingScheduleStatusEnded     BillingScheduleStatus = "ended" // Every period up to ends_at was generated
+	BillingScheduleStatusCancelled BillingScheduleStatus = "cancelled"
+)
+
+// IsValid checks if the billing schedule status is valid
+func (s BillingScheduleStatus) IsValid() bool {
+	switch s {
+	case BillingScheduleStatusActive, BillingScheduleStatusEnded, BillingScheduleStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// BillingSchedule is a merchant's recurring charge to a payer's wallet,
+// paid into one of the merchant's wallets. Each interval generates a
+// BillingPeriod that is settled by matching transfers from the payer. No
+// keys are held: the payer sends every payment themselves. Amounts are in
+// the currency's smallest unit, like payments.
+type BillingSchedule struct {
+	ID               uuid.UUID             `json:"id" db:"id"`
+	UserID           uuid.UUID             `json:"user_id" db:"user_id"`
+	PayerAddress     string                `json:"payer_address" db:"payer_address"`
+	PayeeAddress     string                `json:"payee_address" db:"payee_address"`
+	Amount           Amount                `json:"amount" db:"amount"`
+	Currency         string                `json:"currency" db:"currency"`
+	TokenAddress     *string               `json:"token_address,omitempty" db:"token_address"`
+	TokenDecimals    int                   `json:"token_decimals" db:"token_decimals"`
+	Interval         BillingInterval       `json:"interval" db:"billing_interval"`
+	GracePeriodDays  int                   `json:"grace_period_days" db:"grace_period_days"`
+	Description      *string               `json:"description,omitempty" db:"description"`
+	Status           BillingScheduleStatus `json:"status" db:"status"`
+	StartsAt         time.Time             `json:"starts_at" db:"starts_at"`
+	EndsAt           *time.Time            `json:"ends_at,omitempty" db:"ends_at"`
+	PeriodsGenerated int                   `json:"periods_generated" db:"periods_generated"`
+	NextPeriodAt     *time.Time            `json:"next_period_at,omitempty" db:"next_period_at"` // Nil once no more periods will be generated
+	CreatedAt        time.Time             `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time             `json:"updated_at" db:"updated_at"`
+}
+
+// NewPeriod returns the schedule's nth billing period, due GracePeriodDays
+// after it starts
+func (s *BillingSchedule) NewPeriod(n int, now time.Time) *BillingPeriod {
+	start := s.Interval.PeriodStart(s.StartsAt, n)
+	return &BillingPeriod{
+		ID:           uuid.New(),
+		ScheduleID:   s.ID,
+		PeriodNumber: n,
+		PeriodStart:  start,
+		PeriodEnd:    s.Interval.PeriodStart(s.StartsAt, n+1),
+		DueAt:        start.AddDate(0, 0, s.GracePeriodDays),
+		Amount:       s.Amount,
+		Status:       BillingPeriodStatusDue,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+// HasPeriod reports whether the schedule bills a nth period, i.e. whether
+// that period starts before the schedule ends
+func (s *BillingSchedule) HasPeriod(n int) bool {
+	return s.EndsAt == nil || s.Interval.PeriodStart(s.StartsAt, n).Before(*s.EndsAt)
+}
+
+// BillingPeriodStatus represents the settlement state of a billing period
+type BillingPeriodStatus string
+
+const (
+	BillingPeriodStatusDue     BillingPeriodStatus = "due"
+	BillingPeriodStatusPaid    BillingPeriodStatus = "paid"
+	BillingPeriodStatusOverdue BillingPeriodStatus = "overdue" // Not fully paid by its due date, still accepts payments
+)
+
+// IsValid checks if the billing period status is valid
+func (s BillingPeriodStatus) IsValid() bool {
+	switch s {
+	case BillingPeriodStatusDue, BillingPeriodStatusPaid, BillingPeriodStatusOverdue:
+		return true
+	default:
+		return false
+	}
+}
+
+// AcceptsPayments reports whether transfers can still be matched to the period
+func (s BillingPeriodStatus) AcceptsPayments() bool {
+	return s == BillingPeriodStatusDue || s == BillingPeriodStatusOverdue
+}
+
+// BillingPeriod is the payment request a billing schedule generates for one
+// interval
+type BillingPeriod struct {
+	ID           uuid.UUID           `json:"id" db:"id"`
+	ScheduleID   uuid.UUID           `json:"schedule_id" db:"schedule_id"`
+	PeriodNumber int                 `json:"period_number" db:"period_number"`
+	PeriodStart  time.Time           `json:"period_start" db:"period_start"`
+	PeriodEnd    time.Time           `json:"period_end" db:"period_end"`
+	DueAt        time.Time           `json:"due_at" db:"due_at"`
+	Amount       Amount              `json:"amount" db:"amount"`
+	PaidAmount   Amount              `json:"paid_amount" db:"paid_amount"`
+	Status       BillingPeriodStatus `json:"status" db:"status"`
+	PaidAt       *time.Time          `json:"paid_at,omitempty" db:"paid_at"`
+	CreatedAt    time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+// Settle returns the status of the period once paid has been received in
+// total, at time now
+func (p *BillingPeriod) Settle(paid Amount, now time.Time) BillingPeriodStatus {
+	switch {
+	case paid.Cmp(p.Amount) >= 0:
+		return BillingPeriodStatusPaid
+	case !now.Before(p.DueAt):
+		return BillingPeriodStatusOverdue
+	default:
+		return BillingPeriodStatusDue
+	}
+}
+
+// CreateBillingScheduleRequest represents the request to bill a payer on a
+// recurring basis. StartsAt defaults to now; without EndsAt the schedule runs
+// until cancelled.
+type CreateBillingScheduleRequest struct {
+	PayerAddress    string          `json:"payer_address" binding:"required,len=42"`
+	PayeeAddress    string          `json:"payee_address" binding:"required,len=42"`
+	Amount          string          `json:"amount" binding:"required"`
+	Currency        string          `json:"currency,omitempty"`
+	TokenAddress    string          `json:"token_address,omitempty" binding:"omitempty,len=42"`
+	Interval        BillingInterval `json:"interval" binding:"required"`
+	GracePeriodDays int             `json:"grace_period_days,omitempty" binding:"omitempty,min=0,max=27"`
+	Description     *string         `json:"description,omitempty" binding:"omitempty,max=500"`
+	StartsAt        *time.Time      `json:"starts_at,omitempty"`
+	EndsAt          *time.Time      `json:"ends_at,omitempty"`
+}
+
+// BillingPeriodResponse represents a billing period returned to the schedule owner
+type BillingPeriodResponse struct {
+	ID                  uuid.UUID           `json:"id"`
+	PeriodNumber        int                 `json:"period_number"`
+	PeriodStart         time.Time           `json:"period_start"`
+	PeriodEnd           time.Time           `json:"period_end"`
+	DueAt               time.Time           `json:"due_at"`
+	Amount              Amount              `json:"amount"`
+	AmountFormatted     string              `json:"amount_formatted"`
+	PaidAmount          Amount              `json:"paid_amount"`
+	PaidAmountFormatted string              `json:"paid_amount_formatted"`
+	Status              BillingPeriodStatus `json:"status"`
+	PaidAt              *time.Time          `json:"paid_at,omitempty"`
+	Payments            []PaymentResponse   `json:"payments,omitempty"`
+}
+
+// BillingScheduleResponse represents a billing schedule returned to its owner
+type BillingScheduleResponse struct {
+	ID              uuid.UUID               `json:"id"`
+	PayerAddress    string                  `json:"payer_address"`
+	PayeeAddress    string                  `json:"payee_address"`
+	Amount          Amount                  `json:"amount"`
+	AmountFormatted string                  `json:"amount_formatted"`
+	Currency        string                  `json:"currency"`
+	TokenAddress    *string                 `json:"token_address,omitempty"`
+	TokenDecimals   int                     `json:"token_decimals"`
+	Interval        BillingInterval         `json:"interval"`
+	GracePeriodDays int                     `json:"grace_period_days"`
+	Description     *string                 `json:"description,omitempty"`
+	Status          BillingScheduleStatus   `json:"status"`
+	StartsAt        time.Time               `json:"starts_at"`
+	EndsAt          *time.Time              `json:"ends_at,omitempty"`
+	NextPeriodAt    *time.Time              `json:"next_period_at,omitempty"`
+	CreatedAt       time.Time               `json:"created_at"`
+	Periods         []BillingPeriodResponse `json:"periods,omitempty"`
+}
+
+// BillingScheduleQuery represents query parameters for listing billing schedules
+type BillingScheduleQuery struct {
+	Status *BillingScheduleStatus `form:"status"`
+}
+
+// ToResponse converts a BillingSchedule model to BillingScheduleResponse
+func (s *BillingSchedule) ToResponse() BillingScheduleResponse {
+	return BillingScheduleResponse{
+		ID:              s.ID,
+		PayerAddress:    s.PayerAddress,
+		PayeeAddress:    s.PayeeAddress,
+		Amount:          s.Amount,
+		AmountFormatted: s.Amount.Format(Unit(s.TokenDecimals)),
+		Currency:        s.Currency,
+		TokenAddress:    s.TokenAddress,
+		TokenDecimals:   s.TokenDecimals,
+		Interval:        s.Interval,
+		GracePeriodDays: s.GracePeriodDays,
+		Description:     s.Description,
+		Status:          s.Status,
+		StartsAt:        s.StartsAt,
+		EndsAt:          s.EndsAt,
+		NextPeriodAt:    s.NextPeriodAt,
+		CreatedAt:       s.CreatedAt,
+	}
+}
+
+// ToResponse converts a period of the given schedule to BillingPeriodResponse
+func (p *BillingPeriod) ToResponse(schedule *BillingSchedule) BillingPeriodResponse {
+	unit := Unit(schedule.TokenDecimals)
+	return BillingPeriodResponse{
+		ID:                  p.ID,
+		PeriodNumber:        p.PeriodNumber,
+		PeriodStart:         p.PeriodStart,
+		PeriodEnd:           p.PeriodEnd,
+		DueAt:               p.DueAt,
+		Amount:              p.Amount,
+		AmountFormatted:     p.Amount.Format(unit),
+		PaidAmount:          p.PaidAmount,
+		PaidAmountFormatted: p.PaidAmount.Format(unit),
+		Status:              p.Status,
+		PaidAt:              p.PaidAt,
+	}
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ConfirmationThreshold requires a number of confirmations for payments whose
+// amount (in the smallest unit, e.g. wei) is at least MinAmount
+type ConfirmationThreshold struct {
+	MinAmount     Amount
+	Confirmations int64
+}
+
+// ConfirmationPolicy decides how many block confirmations a payment needs
+// before it is considered confirmed
+type ConfirmationPolicy struct {
+	Default    int64
+	Thresholds []ConfirmationThreshold // Sorted by MinAmount ascending
+}
+
+// Required returns the number of confirmations required for the given amount.
+// The highest threshold the amount reaches wins; amounts below every
+// threshold use the default.
+func (p ConfirmationPolicy) Required(amount Amount) int64 {
+	required := p.Default
+
+	for _, threshold := range p.Thresholds {
+		if amount.Cmp(threshold.MinAmount) >= 0 && threshold.Confirmations > required {
+			required = threshold.Confirmations
+		}
+	}
+
+	return required
+}
+
+// ParseConfirmationPolicy builds a policy from a default and a comma-separated
+// list of "min_amount:confirmations" pairs, e.g. "1000000000000000000:12"
+func ParseConfirmationPolicy(defaultConfirmations int64, thresholds string) (ConfirmationPolicy, error) {
+	if defaultConfirmations < 1 {
+		return ConfirmationPolicy{}, fmt.Errorf("default confirmations must be at least 1, got %d", defaultConfirmations)
+	}
+
+	policy := ConfirmationPolicy{Default: defaultConfirmations}
+
+	for _, pair := range strings.Split(thresholds, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		amountStr, confirmationsStr, found := strings.Cut(pair, ":")
+		if !found {
+			return ConfirmationPolicy{}, fmt.Errorf("invalid confirmation threshold %q, expected min_amount:confirmations", pair)
+		}
+
+		minAmount, err := ParseAmount(amountStr)
+		if err != nil || minAmount.Sign() < 0 {
+			return ConfirmationPolicy{}, fmt.Errorf("invalid threshold amount %q", amountStr)
+		}
+
+		confirmations, err := strconv.ParseInt(strings.TrimSpace(confirmationsStr), 10, 64)
+		if err != nil || confirmations < 1 {
+			return ConfirmationPolicy{}, fmt.Errorf("invalid threshold confirmations %q", confirmationsStr)
+		}
+
+		policy.Thresholds = append(policy.Thresholds, ConfirmationThreshold{
+			MinAmount:     minAmount,
+			Confirmations: confirmations,
+		})
+	}
+
+	sort.Slice(policy.Thresholds, func(i, j int) bool {
+		return policy.Thresholds[i].MinAmount.Cmp(policy.Thresholds[j].MinAmount) < 0
+	})
+
+	return policy, nil
+}
@@ -0,0 +1,131 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// lookalikeAffixLength is how many leading and trailing hex characters
+	// wallets typically display, and address poisoners copy
+	lookalikeAffixLength = 4
+
+	// maxLookalikeDifferences is the most characters two addresses may differ
+	// in to be mistaken for each other
+	maxLookalikeDifferences = 3
+)
+
+// Contact is a labeled address in a user's address book. The phone number
+// and username are the contact's details on this service, when known.
+type Contact struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	UserID      uuid.UUID `json:"user_id" db:"user_id"`
+	Name        string    `json:"name" db:"name"`
+	Address     string    `json:"address" db:"address"`
+	PhoneNumber *string   `json:"phone_number,omitempty" db:"phone_number"`
+	Username    *string   `json:"username,omitempty" db:"username"`
+	Notes       *string   `json:"notes,omitempty" db:"notes"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ContactRequest represents the details of a contact to save or update
+type ContactRequest struct {
+	Name        string  `json:"name" binding:"required,max=100"`
+	Address     string  `json:"address" binding:"required,len=42"`
+	PhoneNumber *string `json:"phone_number,omitempty" binding:"omitempty,len=10"`
+	Username    *string `json:"username,omitempty" binding:"omitempty,max=100"`
+	Notes       *string `json:"notes,omitempty" binding:"omitempty,max=1000"`
+}
+
+// ContactResponse represents a contact returned to its owner
+type ContactResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Address     string    `json:"address"`
+	PhoneNumber *string   `json:"phone_number,omitempty"`
+	Username    *string   `json:"username,omitempty"`
+	Notes       *string   `json:"notes,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ContactQuery represents query parameters for listing contacts
+type ContactQuery struct {
+	Search *string `form:"search" binding:"omitempty,max=100"` // Matches name, address, phone number or username
+}
+
+// AddressCheckResponse tells whether an address is safe to pay: whether it
+// belongs to a saved contact and which contacts it could be mistaken for
+type AddressCheckResponse struct {
+	Address    string            `json:"address"`
+	Contact    *ContactResponse  `json:"contact,omitempty"`    // Saved contact with exactly this address
+	Lookalikes []ContactResponse `json:"lookalikes,omitempty"` // Contacts whose address differs by only a few characters
+	Warning    *string           `json:"warning,omitempty"`
+}
+
+// ToResponse converts a Contact model to ContactResponse
+func (c *Contact) ToResponse() ContactResponse {
+	return ContactResponse{
+		ID:          c.ID,
+		Name:        c.Name,
+		Address:     c.Address,
+		PhoneNumber: c.PhoneNumber,
+		Username:    c.Username,
+		Notes:       c.Notes,
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+	}
+}
+
+// AddressesLookAlike reports whether two different addresses could be
+// mistaken for each other: they share the leading and trailing characters
+// wallets display, or differ in only a few characters. Address poisoning
+// attacks send dust from such lookalikes of a victim's counterparties, hoping
+// the victim copies the wrong address from their history.
+func AddressesLookAlike(a, b string) bool {
+	a, b = strings.ToLower(strings.TrimPrefix(a, "0x")), strings.ToLower(strings.TrimPrefix(b, "0x"))
+	if len(a) != 40 || len(b) != 40 || a == b {
+		return false
+	}
+
+	if a[:lookalikeAffixLength] == b[:lookalikeAffixLength] &&
+		a[len(a)-lookalikeAffixLength:] == b[len(b)-lookalikeAffixLength:] {
+		return true
+	}
+
+	differences := 0
+	for i := range len(a) {
+		if a[i] != b[i] {
+			differences++
+		}
+	}
+	return differences <= maxLookalikeDifferences
+}
+
+// CheckAddress compares an address with the user's contacts
+func CheckAddress(address string, contacts []*Contact) AddressCheckResponse {
+	check := AddressCheckResponse{Address: address}
+	for _, contact := range contacts {
+		if strings.EqualFold(contact.Address, address) {
+			response := contact.ToResponse()
+			check.Contact = &response
+		} else if AddressesLookAlike(contact.Address, address) {
+			check.Lookalikes = append(check.Lookalikes, contact.ToResponse())
+		}
+	}
+
+	// Resembling a contact is only suspicious when the address is not a
+	// contact itself
+	if check.Contact == nil && len(check.Lookalikes) > 0 {
+		lookalike := check.Lookalikes[0]
+		warning := fmt.Sprintf("address %s closely resembles saved contact %s (%s) but is different; make sure it was not copied from a poisoned transaction",
+			address, lookalike.Name, lookalike.Address)
+		check.Warning = &warning
+	}
+
+	return check
+}
@@ -0,0 +1,316 @@
+package model
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SplitMethod is how a group expense is divided among its participants
+type SplitMethod string
+
+const (
+	SplitMethodEqual  SplitMethod = "equal"
+	SplitMethodCustom SplitMethod = "custom" // Each participant's amount is given explicitly
+)
+
+// IsValid checks if the split method is valid
+func (m SplitMethod) IsValid() bool {
+	return m == SplitMethodEqual || m == SplitMethodCustom
+}
+
+// SplitEqually divides total into parts shares that differ by at most one
+// smallest unit. The remainder goes to the first shares, so the division is
+// deterministic and the shares always add up to total.
+func SplitEqually(total Amount, parts int) []Amount {
+	if parts <= 0 {
+		return nil
+	}
+
+	quotient, remainder := new(big.Int).QuoRem(total.BigInt(), big.NewInt(int64(parts)), new(big.Int))
+	extra := int(remainder.Int64())
+
+	shares := make([]Amount, parts)
+	for i := range shares {
+		shares[i] = NewAmount(quotient)
+		if i < extra {
+			shares[i] = shares[i].Add(AmountFromInt64(1))
+		}
+	}
+	return shares
+}
+
+// ExpenseSplitStatus tells whether every share of a split has been settled.
+// It is derived from the shares rather than stored.
+type ExpenseSplitStatus string
+
+const (
+	ExpenseSplitStatusOpen    ExpenseSplitStatus = "open" // Some shares are not settled yet
+	ExpenseSplitStatusSettled ExpenseSplitStatus = "settled"
+)
+
+// IsValid checks if the expense split status is valid
+func (s ExpenseSplitStatus) IsValid() bool {
+	return s == ExpenseSplitStatusOpen || s == ExpenseSplitStatusSettled
+}
+
+// SplitShareStatus represents the settlement state of a participant's share
+type SplitShareStatus string
+
+const (
+	SplitShareStatusOutstanding SplitShareStatus = "outstanding"
+	SplitShareStatusPending     SplitShareStatus = "pending" // Paid by a transaction that is not confirmed yet
+	SplitShareStatusSettled     SplitShareStatus = "settled"
+)
+
+// IsValid checks if the split share status is valid
+func (s SplitShareStatus) IsValid() bool {
+	switch s {
+	case SplitShareStatusOutstanding, SplitShareStatusPending, SplitShareStatusSettled:
+		return true
+	default:
+		return false
+	}
+}
+
+// SplitShareStatusFor returns the status of a share paid by a payment in the
+// given status. A failed or cancelled payment leaves the share outstanding,
+// so it can be paid again.
+func SplitShareStatusFor(status PaymentStatus) SplitShareStatus {
+	switch status {
+	case PaymentStatusConfirmed:
+		return SplitShareStatusSettled
+	case PaymentStatusFailed, PaymentStatusCancelled:
+		return SplitShareStatusOutstanding
+	default:
+		return SplitShareStatusPending
+	}
+}
+
+// ExpenseSplit is a group expense its organizer divides among other users.
+// Each participant settles their share by paying the organizer's payee
+// wallet. Amounts are in the currency's smallest unit, like payments.
+type ExpenseSplit struct {
+	ID            uuid.UUID   `json:"id" db:"id"`
+	UserID        uuid.UUID   `json:"user_id" db:"user_id"`
+	PayeeAddress  string      `json:"payee_address" db:"payee_address"`
+	TotalAmount   Amount      `json:"total_amount" db:"total_amount"`
+	Currency      string      `json:"currency" db:"currency"`
+	TokenAddress  *string     `json:"token_address,omitempty" db:"token_address"`
+	TokenDecimals int         `json:"token_decimals" db:"token_decimals"`
+	Method        SplitMethod `json:"method" db:"split_method"`
+	Description   *string     `json:"description,omitempty" db:"description"`
+	CreatedAt     time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at" db:"updated_at"`
+
+	// Organizer details, joined from users so participants can see who they owe
+	OrganizerUsername string `json:"organizer_username" db:"-"`
+	OrganizerPhone    string `json:"organizer_phone" db:"-"`
+}
+
+// SplitShare is the part of an expense split one participant owes
+type SplitShare struct {
+	ID              uuid.UUID        `json:"id" db:"id"`
+	SplitID         uuid.UUID        `json:"split_id" db:"split_id"`
+	ParticipantID   uuid.UUID        `json:"participant_id" db:"participant_id"`
+	Amount          Amount           `json:"amount" db:"amount"`
+	Status          SplitShareStatus `json:"status" db:"status"`
+	PaymentID       *uuid.UUID       `json:"payment_id,omitempty" db:"payment_id"`
+	TransactionHash *string          `json:"transaction_hash,omitempty" db:"transaction_hash"`
+	SettledAt       *time.Time       `json:"settled_at,omitempty" db:"settled_at"`
+	ReminderCount   int              `json:"reminder_count" db:"reminder_count"`
+	LastRemindedAt  *time.Time       `json:"last_reminded_at,omitempty" db:"last_reminded_at"`
+	CreatedAt       time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at" db:"updated_at"`
+
+	// Participant details, joined from users
+	ParticipantUsername string `json:"participant_username" db:"-"`
+	ParticipantPhone    string `json:"participant_phone" db:"-"`
+}
+
+// CanRemind reports whether the participant can be reminded of the share at
+// time now, given the minimum time between reminders
+func (s *SplitShare) CanRemind(now time.Time, cooldown time.Duration) bool {
+	if s.Status != SplitShareStatusOutstanding {
+		return false
+	}
+	return s.LastRemindedAt == nil || !now.Before(s.LastRemindedAt.Add(cooldown))
+}
+
+// SplitParticipantRequest identifies a participant by username or phone
+// number. Amount is required for custom splits and must be omitted for
+// equal ones.
+type SplitParticipantRequest struct {
+	Username    string `json:"username,omitempty"`
+	PhoneNumber string `json:"phone_number,omitempty" binding:"omitempty,len=10"`
+	Amount      string `json:"amount,omitempty"`
+}
+
+// CreateExpenseSplitRequest represents the request to split an expense.
+// Amount is the total to divide for equal splits. For custom splits it
+// defaults to the sum of the participants' amounts; any excess is the
+// organizer's own part. IncludeOrganizer counts the organizer in an equal
+// split, so participants only owe their part of the total. PayeeAddress defaults to the organizer's
+// preferred wallet.
+type CreateExpenseSplitRequest struct {
+	Amount           string                    `json:"amount,omitempty"`
+	Currency         string                    `json:"currency,omitempty"`
+	TokenAddress     string                    `json:"token_address,omitempty" binding:"omitempty,len=42"`
+	PayeeAddress     string                    `json:"payee_address,omitempty" binding:"omitempty,len=42"`
+	Method           SplitMethod               `json:"method" binding:"required"`
+	IncludeOrganizer bool                      `json:"include_organizer,omitempty"`
+	Description      *string                   `json:"description,omitempty" binding:"omitempty,max=500"`
+	Participants     []SplitParticipantRequest `json:"participants" binding:"required,min=1,max=50,dive"`
+}
+
+// SplitParty identifies the other user of an expense split
+type SplitParty struct {
+	Username    string `json:"username"`
+	PhoneNumber string `json:"phone_number"`
+}
+
+// SplitShareResponse represents a participant's share returned to the organizer
+type SplitShareResponse struct {
+	ID              uuid.UUID        `json:"id"`
+	Participant     SplitParty       `json:"participant"`
+	Amount          Amount           `json:"amount"`
+	AmountFormatted string           `json:"amount_formatted"`
+	Status          SplitShareStatus `json:"status"`
+	PaymentID       *uuid.UUID       `json:"payment_id,omitempty"`
+	TransactionHash *string          `json:"transaction_hash,omitempty"`
+	SettledAt       *time.Time       `json:"settled_at,omitempty"`
+	ReminderCount   int              `json:"reminder_count"`
+	LastRemindedAt  *time.Time       `json:"last_reminded_at,omitempty"`
+}
+
+// ExpenseSplitResponse represents an expense split returned to its organizer
+type ExpenseSplitResponse struct {
+	ID                         uuid.UUID            `json:"id"`
+	PayeeAddress               string               `json:"payee_address"`
+	TotalAmount                Amount               `json:"total_amount"`
+	TotalAmountFormatted       string               `json:"total_amount_formatted"`
+	OutstandingAmount          Amount               `json:"outstanding_amount"` // Owed by participants whose shares are not settled
+	OutstandingAmountFormatted string               `json:"outstanding_amount_formatted"`
+	Currency                   string               `json:"currency"`
+	TokenAddress               *string              `json:"token_address,omitempty"`
+	TokenDecimals              int                  `json:"token_decimals"`
+	Method                     SplitMethod          `json:"method"`
+	Description                *string              `json:"description,omitempty"`
+	Status                     ExpenseSplitStatus   `json:"status"`
+	CreatedAt                  time.Time            `json:"created_at"`
+	Shares                     []SplitShareResponse `json:"shares"`
+}
+
+// OwedShareResponse represents a share returned to the participant who owes
+// it, with what they need to pay it
+type OwedShareResponse struct {
+	ID              uuid.UUID        `json:"id"`
+	SplitID         uuid.UUID        `json:"split_id"`
+	Organizer       SplitParty       `json:"organizer"`
+	PayeeAddress    string           `json:"payee_address"`
+	Amount          Amount           `json:"amount"`
+	AmountFormatted string           `json:"amount_formatted"`
+	Currency        string           `json:"currency"`
+	TokenAddress    *string          `json:"token_address,omitempty"`
+	TokenDecimals   int              `json:"token_decimals"`
+	Description     *string          `json:"description,omitempty"`
+	Status          SplitShareStatus `json:"status"`
+	PaymentID       *uuid.UUID       `json:"payment_id,omitempty"`
+	TransactionHash *string          `json:"transaction_hash,omitempty"`
+	SettledAt       *time.Time       `json:"settled_at,omitempty"`
+	ReminderCount   int              `json:"reminder_count"`
+	LastRemindedAt  *time.Time       `json:"last_reminded_at,omitempty"`
+	CreatedAt       time.Time        `json:"created_at"`
+}
+
+// ExpenseSplitQuery represents query parameters for listing expense splits
+type ExpenseSplitQuery struct {
+	Status *ExpenseSplitStatus `form:"status"`
+}
+
+// OwedShareQuery represents query parameters for listing the shares a user owes
+type OwedShareQuery struct {
+	Status *SplitShareStatus `form:"status"`
+}
+
+// ToResponse converts an ExpenseSplit and its shares to ExpenseSplitResponse
+func (s *ExpenseSplit) ToResponse(shares []*SplitShare) ExpenseSplitResponse {
+	unit := Unit(s.TokenDecimals)
+	outstanding := Amount{}
+	responses := make([]SplitShareResponse, 0, len(shares))
+	for _, share := range shares {
+		if share.Status != SplitShareStatusSettled {
+			outstanding = outstanding.Add(share.Amount)
+		}
+		responses = append(responses, share.ToResponse(s))
+	}
+
+	status := ExpenseSplitStatusSettled
+	if outstanding.Sign() > 0 {
+		status = ExpenseSplitStatusOpen
+	}
+
+	return ExpenseSplitResponse{
+		ID:                         s.ID,
+		PayeeAddress:               s.PayeeAddress,
+		TotalAmount:                s.TotalAmount,
+		TotalAmountFormatted:       s.TotalAmount.Format(unit),
+		OutstandingAmount:          outstanding,
+		OutstandingAmountFormatted: outstanding.Format(unit),
+		Currency:                   s.Currency,
+		TokenAddress:               s.TokenAddress,
+		TokenDecimals:              s.TokenDecimals,
+		Method:                     s.Method,
+		Description:                s.Description,
+		Status:                     status,
+		CreatedAt:                  s.CreatedAt,
+		Shares:                     responses,
+	}
+}
+
+// ToResponse converts a share of the given split to SplitShareResponse
+func (s *SplitShare) ToResponse(split *ExpenseSplit) SplitShareResponse {
+	return SplitShareResponse{
+		ID: s.ID,
+		Participant: SplitParty{
+			Username:    s.ParticipantUsername,
+			PhoneNumber: s.ParticipantPhone,
+		},
+		Amount:          s.Amount,
+		AmountFormatted: s.Amount.Format(Unit(split.TokenDecimals)),
+		Status:          s.Status,
+		PaymentID:       s.PaymentID,
+		TransactionHash: s.TransactionHash,
+		SettledAt:       s.SettledAt,
+		ReminderCount:   s.ReminderCount,
+		LastRemindedAt:  s.LastRemindedAt,
+	}
+}
+
+// ToOwedResponse converts a share of the given split to the participant's
+// OwedShareResponse
+func (s *SplitShare) ToOwedResponse(split *ExpenseSplit) OwedShareResponse {
+	return OwedShareResponse{
+		ID:      s.ID,
+		SplitID: split.ID,
+		Organizer: SplitParty{
+			Username:    split.OrganizerUsername,
+			PhoneNumber: split.OrganizerPhone,
+		},
+		PayeeAddress:    split.PayeeAddress,
+		Amount:          s.Amount,
+		AmountFormatted: s.Amount.Format(Unit(split.TokenDecimals)),
+		Currency:        split.Currency,
+		TokenAddress:    split.TokenAddress,
+		TokenDecimals:   split.TokenDecimals,
+		Description:     split.Description,
+		Status:          s.Status,
+		PaymentID:       s.PaymentID,
+		TransactionHash: s.TransactionHash,
+		SettledAt:       s.SettledAt,
+		ReminderCount:   s.ReminderCount,
+		LastRemindedAt:  s.LastRemindedAt,
+		CreatedAt:       s.CreatedAt,
+	}
+}
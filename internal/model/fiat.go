@@ -0,0 +1,231 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// FiatCurrency is an ISO 4217 code of a currency payments can be valued in
+type FiatCurrency string
+
+// DefaultDisplayCurrency is the fiat currency of users who have not chosen one
+const DefaultDisplayCurrency FiatCurrency = "USD"
+
+// fiatCurrencyUnits lists the supported fiat currencies with the number of
+// decimal places of their minor unit
+var fiatCurrencyUnits = map[FiatCurrency]Unit{
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"INR": 2,
+	"JPY": 0,
+}
+
+// ParseFiatCurrency parses a supported fiat currency code in any casing
+func ParseFiatCurrency(s string) (FiatCurrency, error) {
+	currency := FiatCurrency(strings.ToUpper(strings.TrimSpace(s)))
+	if !currency.IsValid() {
+		return "", fmt.Errorf("unsupported fiat currency %q", s)
+	}
+	return currency, nil
+}
+
+// IsValid checks if the fiat currency is supported
+func (c FiatCurrency) IsValid() bool {
+	_, ok := fiatCurrencyUnits[c]
+	return ok
+}
+
+// Unit returns the number of decimal places of the currency's minor unit,
+// e.g. 2 for cents
+func (c FiatCurrency) Unit() Unit {
+	return fiatCurrencyUnits[c]
+}
+
+// exchangeRatePlaces is the precision exchange rates are kept at, enough for
+// tokens priced at a tiny fraction of a cent
+const exchangeRatePlaces = 18
+
+// ExchangeRate is the exact, positive price of one whole unit of a currency
+// (one ether, one token) in a fiat currency. The zero value is not a valid
+// rate. Rates are encoded as decimal strings in JSON and as NUMERIC in SQL.
+type ExchangeRate struct {
+	value *big.Rat
+}
+
+// ParseExchangeRate parses a positive decimal rate, e.g. "3012.45". Digits
+// beyond 18 decimal places are rounded.
+func ParseExchangeRate(s string) (ExchangeRate, error) {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || value.Sign() <= 0 || strings.ContainsAny(s, "/eE") {
+		return ExchangeRate{}, fmt.Errorf("invalid exchange rate %q", s)
+	}
+
+	rate, _ := new(big.Rat).SetString(value.FloatString(exchangeRatePlaces))
+	if rate.Sign() <= 0 {
+		return ExchangeRate{}, fmt.Errorf("exchange rate %q is too small", s)
+	}
+	return ExchangeRate{value: rate}, nil
+}
+
+// IsZero reports whether the rate is unset
+func (r ExchangeRate) IsZero() bool {
+	return r.value == nil
+}
+
+// String returns the rate as a decimal without trailing zeros
+func (r ExchangeRate) String() string {
+	if r.value == nil {
+		return "0"
+	}
+
+	s := r.value.FloatString(exchangeRatePlaces)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// Convert values amount, given in its currency's smallest unit with unit
+// decimal places, in the minor unit of fiat. The result is rounded half away
+// from zero to a whole minor unit.
+func (r ExchangeRate) Convert(amount Amount, unit Unit, fiat FiatCurrency) Amount {
+	if r.value == nil {
+		return Amount{}
+	}
+
+	value := new(big.Rat).SetInt(amount.BigInt())
+	value.Mul(value, r.value)
+	value.Mul(value, new(big.Rat).SetInt(unitScale(fiat.Unit())))
+	value.Quo(value, new(big.Rat).SetInt(unitScale(unit)))
+
+	// Round half away from zero: (2|num| + den) / 2den keeps the sign apart
+	num := new(big.Int).Abs(value.Num())
+	den := value.Denom()
+	num.Mul(num, big.NewInt(2)).Add(num, den)
+	num.Quo(num, new(big.Int).Mul(den, big.NewInt(2)))
+	if value.Sign() < 0 {
+		num.Neg(num)
+	}
+
+	return NewAmount(num)
+}
+
+// MarshalJSON encodes the rate as a JSON decimal string
+func (r ExchangeRate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON accepts the rate as a JSON decimal string or number
+func (r *ExchangeRate) UnmarshalJSON(data []byte) error {
+	var s string
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	} else {
+		s = string(data)
+	}
+
+	parsed, err := ParseExchangeRate(s)
+	if err != nil {
+		return err
+	}
+
+	*r = parsed
+	return nil
+}
+
+// Value implements driver.Valuer, storing the rate in a NUMERIC column
+func (r ExchangeRate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// Scan implements sql.Scanner for NUMERIC columns
+func (r *ExchangeRate) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into ExchangeRate", src)
+	}
+
+	parsed, err := ParseExchangeRate(s)
+	if err != nil {
+		return err
+	}
+
+	*r = parsed
+	return nil
+}
+
+// FiatValuation is the value of a payment in a fiat currency, at the rate
+// quoted by a price source at QuotedAt. Payments are valued when they are
+// recorded and valued again when they confirm.
+type FiatValuation struct {
+	Currency FiatCurrency `json:"currency"`
+	Rate     ExchangeRate `json:"rate"`  // Price of one whole unit of the payment's currency
+	Value    Amount       `json:"value"` // In the fiat currency's minor unit, e.g. cents
+	Source   string       `json:"source"`
+	QuotedAt time.Time    `json:"quoted_at"`
+}
+
+// FiatValuationResponse represents a payment's fiat valuation returned to clients
+type FiatValuationResponse struct {
+	Currency       FiatCurrency `json:"currency"`
+	Rate           ExchangeRate `json:"rate"`            // Price of one whole unit of the payment's currency
+	Value          Amount       `json:"value"`           // In the fiat currency's minor unit, e.g. cents
+	ValueFormatted string       `json:"value_formatted"` // In whole units, e.g. "12.34"
+	Source         string       `json:"source"`
+	QuotedAt       time.Time    `json:"quoted_at"`
+}
+
+// NewFiatValuation values a payment at a quoted rate
+func NewFiatValuation(payment *Payment, fiat FiatCurrency, rate ExchangeRate, source string, quotedAt time.Time) *FiatValuation {
+	return &FiatValuation{
+		Currency: fiat,
+		Rate:     rate,
+		Value:    rate.Convert(payment.Amount, Unit(payment.TokenDecimals), fiat),
+		Source:   source,
+		QuotedAt: quotedAt,
+	}
+}
+
+// ToResponse converts a FiatValuation to FiatValuationResponse
+func (v *FiatValuation) ToResponse() *FiatValuationResponse {
+	if v == nil {
+		return nil
+	}
+	return &FiatValuationResponse{
+		Currency:       v.Currency,
+		Rate:           v.Rate,
+		Value:          v.Value,
+		ValueFormatted: v.Value.FormatFixed(v.Currency.Unit(), int(v.Currency.Unit())),
+		Source:         v.Source,
+		QuotedAt:       v.QuotedAt,
+	}
+}
+
+// FiatTotals sums the fiat values of a user's confirmed payments in their
+// display currency. Payments that were not valued, or were valued in another
+// currency, are only counted.
+type FiatTotals struct {
+	Currency          FiatCurrency `json:"currency"`
+	Sent              Amount       `json:"sent"`               // In the currency's minor unit
+	SentFormatted     string       `json:"sent_formatted"`     // In whole units
+	Received          Amount       `json:"received"`           // In the currency's minor unit
+	ReceivedFormatted string       `json:"received_formatted"` // In whole units
+	ValuedPayments    int64        `json:"valued_payments"`
+	UnvaluedPayments  int64        `json:"unvalued_payments"`
+}
+
+// UpdateDisplayCurrencyRequest represents the input for choosing the fiat
+// currency payments are valued in
+type UpdateDisplayCurrencyRequest struct {
+	Currency string `json:"currency" binding:"required,len=3"`
+}
@@ -0,0 +1,211 @@
+package model
+
+import (
+	"math/big"
+	"time"
+)
+
+// GasFees is what a mined transaction paid for gas, from its receipt. Prices
+// are per gas in wei. Since EIP-1559 the effective price is the block's base
+// fee, which is burned, plus a priority fee paid to the block producer;
+// BaseFee and PriorityFee are nil on chains without a base fee.
+type GasFees struct {
+	GasUsed           uint64  `json:"gas_used"`
+	EffectiveGasPrice Amount  `json:"effective_gas_price"`
+	BaseFee           *Amount `json:"base_fee,omitempty"`
+	PriorityFee       *Amount `json:"priority_fee,omitempty"`
+}
+
+// NewGasFees splits the effective gas price of a transaction into the base
+// fee of its block and the priority fee above it. baseFee is nil for blocks
+// without one.
+func NewGasFees(gasUsed uint64, effectiveGasPrice, baseFee *big.Int) *GasFees {
+	fees := &GasFees{
+		GasUsed:           gasUsed,
+		EffectiveGasPrice: NewAmount(effectiveGasPrice),
+	}
+	if baseFee == nil {
+		return fees
+	}
+
+	base := NewAmount(baseFee)
+	priority := fees.EffectiveGasPrice.Sub(base)
+	if priority.Sign() < 0 {
+		// Only possible with a node reporting inconsistent values
+		priority = Amount{}
+	}
+	fees.BaseFee = &base
+	fees.PriorityFee = &priority
+	return fees
+}
+
+// Total returns the fee paid for the transaction in wei
+func (f *GasFees) Total() Amount {
+	return NewAmount(new(big.Int).Mul(new(big.Int).SetUint64(f.GasUsed), f.EffectiveGasPrice.BigInt()))
+}
+
+// DefaultFeeReportRange is used when a fee report is requested without from
+const DefaultFeeReportRange = 365 * 24 * time.Hour
+
+// FeeReportQuery represents query parameters for the fee report. The range
+// covers payments created at or after From and before To; it defaults to the
+// last year.
+type FeeReportQuery struct {
+	From *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To   *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// MonthlyFees sums the fees a user paid for the outgoing payments created in
+// one month. Fees are in wei; BaseFee is the burned part of TotalFee and
+// PriorityFee the part paid to block producers, over the payments whose
+// split is known.
+type MonthlyFees struct {
+	Month       time.Time `json:"month"` // Start of the month
+	Payments    int64     `json:"payments"`
+	GasUsed     int64     `json:"gas_used"`
+	TotalFee    Amount    `json:"total_fee"`
+	BaseFee     Amount    `json:"base_fee"`
+	PriorityFee Amount    `json:"priority_fee"`
+	AverageFee  Amount    `json:"average_fee"` // Per payment, rounded down
+}
+
+// FeeReport is what a user paid in transaction fees over a date range. Only
+// outgoing payments with known gas details are counted: the senders of
+// incoming payments paid for those.
+type FeeReport struct {
+	From                time.Time     `json:"from"`
+	To                  time.Time     `json:"to"`
+	Months              []MonthlyFees `json:"months"`
+	Payments            int64         `json:"payments"`
+	GasUsed             int64         `json:"gas_used"`
+	TotalFee            Amount        `json:"total_fee"`             // In wei
+	TotalFeeFormatted   string        `json:"total_fee_formatted"`   // In ETH
+	AverageFee          Amount        `json:"average_fee"`           // Per payment in wei, rounded down
+	AverageFeeFormatted string        `json:"average_fee_formatted"` // In ETH
+}
+
+// NewFeeReport totals the monthly fees over a range and averages the fee per
+// payment, for each month and overall
+func NewFeeReport(from, to time.Time, months []MonthlyFees) *FeeReport {
+	report := &FeeReport{From: from, To: to, Months: months}
+	for i := range report.Months {
+		month := &report.Months[i]
+		month.AverageFee = averageFee(month.TotalFee, month.Payments)
+
+		report.Payments += month.Payments
+		report.GasUsed += month.GasUsed
+		report.TotalFee = report.TotalFee.Add(month.TotalFee)
+	}
+
+	report.AverageFee = averageFee(report.TotalFee, report.Payments)
+	report.TotalFeeFormatted = report.TotalFee.Format(Ether)
+	report.AverageFeeFormatted = report.AverageFee.Format(Ether)
+	return report
+}
+
+// averageFee divides a total fee over a number of payments, rounding down
+func averageFee(total Amount, payments int64) Amount {
+	if payments == 0 {
+		return Amount{}
+	}
+	return NewAmount(new(big.Int).Quo(total.BigInt(), big.NewInt(payments)))
+}
+
+// FeeSpeed selects how quickly a transaction offering suggested fees is
+// expected to be mined
+type FeeSpeed string
+
+const (
+	FeeSpeedSlow     FeeSpeed = "slow"
+	FeeSpeedStandard FeeSpeed = "standard"
+	FeeSpeedFast     FeeSpeed = "fast"
+)
+
+// IsValid checks if the fee speed is supported
+func (s FeeSpeed) IsValid() bool {
+	switch s {
+	case FeeSpeedSlow, FeeSpeedStandard, FeeSpeedFast:
+		return true
+	default:
+		return false
+	}
+}
+
+// FeeSuggestion is the EIP-1559 fee parameters to offer for one speed, per gas
+// in wei. The costs are set once the transaction's gas limit is known:
+// EstimatedCost at the next block's base fee, MaxCost if the base fee rises
+// to MaxFeePerGas, which the sender's balance must cover.
+type FeeSuggestion struct {
+	MaxFeePerGas         Amount  `json:"max_fee_per_gas"`
+	MaxPriorityFeePerGas Amount  `json:"max_priority_fee_per_gas"`
+	EstimatedCost        *Amount `json:"estimated_cost,omitempty"`
+	MaxCost              *Amount `json:"max_cost,omitempty"`
+}
+
+// FeeSuggestions are the fee parameters to offer for each speed, derived
+// from the priority fees paid in recent blocks. BaseFee is the base fee of
+// the next block, per gas in wei.
+type FeeSuggestions struct {
+	BlockNumber uint64        `json:"block_number"` // Latest block the suggestions are based on
+	BaseFee     Amount        `json:"base_fee"`
+	Slow        FeeSuggestion `json:"slow"`
+	Standard    FeeSuggestion `json:"standard"`
+	Fast        FeeSuggestion `json:"fast"`
+}
+
+// Suggestion returns the fee parameters for a speed, the standard ones for
+// an unknown speed
+func (s *FeeSuggestions) Suggestion(speed FeeSpeed) FeeSuggestion {
+	switch speed {
+	case FeeSpeedSlow:
+		return s.Slow
+	case FeeSpeedFast:
+		return s.Fast
+	default:
+		return s.Standard
+	}
+}
+
+// SetGasLimit fills in the costs of every suggestion for a transaction
+// using up to gasLimit gas
+func (s *FeeSuggestions) SetGasLimit(gasLimit uint64) {
+	gas := new(big.Int).SetUint64(gasLimit)
+	for _, suggestion := range []*FeeSuggestion{&s.Slow, &s.Standard, &s.Fast} {
+		// The priority fee paid is capped by what the max fee leaves over the base fee
+		price := s.BaseFee.Add(suggestion.MaxPriorityFeePerGas)
+		if price.Cmp(suggestion.MaxFeePerGas) > 0 {
+			price = suggestion.MaxFeePerGas
+		}
+
+		estimated := NewAmount(new(big.Int).Mul(gas, price.BigInt()))
+		maximum := NewAmount(new(big.Int).Mul(gas, suggestion.MaxFeePerGas.BigInt()))
+		suggestion.EstimatedCost = &estimated
+		suggestion.MaxCost = &maximum
+	}
+}
+
+// FeeEstimateQuery represents query parameters for fee suggestions. Given a
+// recipient, the gas of sending it Amount wei from the user's wallet From
+// (their first wallet by default) is estimated, and the wallet's balance is
+// checked against the cost at Speed.
+type FeeEstimateQuery struct {
+	From   *string  `form:"from" binding:"omitempty,len=42"`
+	To     *string  `form:"to" binding:"omitempty,len=42"`
+	Amount *string  `form:"amount"`
+	Speed  FeeSpeed `form:"speed,default=standard"`
+}
+
+// FeeEstimate is the current fee suggestions and, for a transfer to a given
+// recipient, its gas limit and what the sending wallet needs to hold:
+// the amount plus the maximum fee at the chosen speed, in wei
+type FeeEstimate struct {
+	FeeSuggestions
+	From               *string   `json:"from,omitempty"`
+	To                 *string   `json:"to,omitempty"`
+	Amount             *Amount   `json:"amount,omitempty"`
+	Speed              *FeeSpeed `json:"speed,omitempty"`
+	GasLimit           *uint64   `json:"gas_limit,omitempty"`
+	EthNeeded          *Amount   `json:"eth_needed,omitempty"`
+	EthNeededFormatted *string   `json:"eth_needed_formatted,omitempty"` // In ETH
+	SufficientBalance  *bool     `json:"sufficient_balance,omitempty"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyKey is a client-chosen key under which the response to a
+// request is kept, so that retries of the request return the same response
+// instead of repeating it
+type IdempotencyKey struct {
+	UserID       uuid.UUID `db:"user_id"`
+	Key          string    `db:"idempotency_key"`
+	RequestHash  string    `db:"request_hash"`
+	StatusCode   *int      `db:"status_code"` // Nil while the request is being processed
+	ContentType  *string   `db:"content_type"`
+	ResponseBody []byte    `db:"response_body"`
+	CreatedAt    time.Time `db:"created_at"`
+	ExpiresAt    time.Time `db:"expires_at"`
+}
+
+// Completed reports whether the response to the request has been stored
+func (k *IdempotencyKey) Completed() bool {
+	return k.StatusCode != nil
+}
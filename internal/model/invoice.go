@@ -0,0 +1,149 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InvoiceStatus represents the settlement state of an invoice
+type InvoiceStatus string
+
+const (
+	InvoiceStatusOpen      InvoiceStatus = "open"
+	InvoiceStatusPaid      InvoiceStatus = "paid"
+	InvoiceStatusUnderpaid InvoiceStatus = "underpaid" // Received less than the amount due, still accepts payments
+	InvoiceStatusOverpaid  InvoiceStatus = "overpaid"
+	InvoiceStatusExpired   InvoiceStatus = "expired"
+	InvoiceStatusCancelled InvoiceStatus = "cancelled"
+)
+
+// Invoice is a merchant's request to be paid into one of their wallets.
+// Amounts are in the currency's smallest unit, like payments.
+type Invoice struct {
+	ID               uuid.UUID     `json:"id" db:"id"`
+	UserID           uuid.UUID     `json:"user_id" db:"user_id"`
+	RecipientAddress string        `json:"recipient_address" db:"recipient_address"`
+	Amount           Amount        `json:"amount" db:"amount"`
+	PaidAmount       Amount        `json:"paid_amount" db:"paid_amount"`
+	Currency         string        `json:"currency" db:"currency"`
+	TokenAddress     *string       `json:"token_address,omitempty" db:"token_address"`
+	TokenDecimals    int           `json:"token_decimals" db:"token_decimals"`
+	Memo             *string       `json:"memo,omitempty" db:"memo"`
+	Status           InvoiceStatus `json:"status" db:"status"`
+	ExpiresAt        time.Time     `json:"expires_at" db:"expires_at"`
+	PaidAt           *time.Time    `json:"paid_at,omitempty" db:"paid_at"`
+	CreatedAt        time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// CreateInvoiceRequest represents the request to create a new invoice
+type CreateInvoiceRequest struct {
+	RecipientAddress string  `json:"recipient_address" binding:"required,len=42"`
+	Amount           string  `json:"amount" binding:"required"`
+	Currency         string  `json:"currency,omitempty"`
+	TokenAddress     string  `json:"token_address,omitempty" binding:"omitempty,len=42"`
+	Memo             *string `json:"memo,omitempty" binding:"omitempty,max=500"`
+	ExpiresInMinutes int     `json:"expires_in_minutes,omitempty" binding:"omitempty,min=1,max=525600"`
+}
+
+// InvoiceResponse represents an invoice returned to its owner
+type InvoiceResponse struct {
+	ID               uuid.UUID         `json:"id"`
+	RecipientAddress string            `json:"recipient_address"`
+	Amount           Amount            `json:"amount"`
+	PaidAmount       Amount            `json:"paid_amount"`
+	Currency         string            `json:"currency"`
+	TokenAddress     *string           `json:"token_address,omitempty"`
+	TokenDecimals    int               `json:"token_decimals"`
+	Memo             *string           `json:"memo,omitempty"`
+	Status           InvoiceStatus     `json:"status"`
+	ExpiresAt        time.Time         `json:"expires_at"`
+	PaidAt           *time.Time        `json:"paid_at,omitempty"`
+	CreatedAt        time.Time         `json:"created_at"`
+	Payments         []PaymentResponse `json:"payments,omitempty"`
+}
+
+// SharedInvoiceResponse is the public view of an invoice given to payers
+type SharedInvoiceResponse struct {
+	ID               uuid.UUID     `json:"id"`
+	RecipientAddress string        `json:"recipient_address"`
+	Amount           Amount        `json:"amount"`
+	PaidAmount       Amount        `json:"paid_amount"`
+	Currency         string        `json:"currency"`
+	TokenAddress     *string       `json:"token_address,omitempty"`
+	TokenDecimals    int           `json:"token_decimals"`
+	Memo             *string       `json:"memo,omitempty"`
+	Status           InvoiceStatus `json:"status"`
+	ExpiresAt        time.Time     `json:"expires_at"`
+}
+
+// InvoiceQuery represents query parameters for listing invoices
+type InvoiceQuery struct {
+	Status *InvoiceStatus `form:"status"`
+}
+
+// ToResponse converts an Invoice model to InvoiceResponse
+func (i *Invoice) ToResponse() InvoiceResponse {
+	return InvoiceResponse{
+		ID:               i.ID,
+		RecipientAddress: i.RecipientAddress,
+		Amount:           i.Amount,
+		PaidAmount:       i.PaidAmount,
+		Currency:         i.Currency,
+		TokenAddress:     i.TokenAddress,
+		TokenDecimals:    i.TokenDecimals,
+		Memo:             i.Memo,
+		Status:           i.Status,
+		ExpiresAt:        i.ExpiresAt,
+		PaidAt:           i.PaidAt,
+		CreatedAt:        i.CreatedAt,
+	}
+}
+
+// ToSharedResponse converts an Invoice model to the view shared with payers
+func (i *Invoice) ToSharedResponse() SharedInvoiceResponse {
+	return SharedInvoiceResponse{
+		ID:               i.ID,
+		RecipientAddress: i.RecipientAddress,
+		Amount:           i.Amount,
+		PaidAmount:       i.PaidAmount,
+		Currency:         i.Currency,
+		TokenAddress:     i.TokenAddress,
+		TokenDecimals:    i.TokenDecimals,
+		Memo:             i.Memo,
+		Status:           i.Status,
+		ExpiresAt:        i.ExpiresAt,
+	}
+}
+
+// IsValid checks if the invoice status is valid
+func (s InvoiceStatus) IsValid() bool {
+	switch s {
+	case InvoiceStatusOpen, InvoiceStatusPaid, InvoiceStatusUnderpaid, InvoiceStatusOverpaid,
+		InvoiceStatusExpired, InvoiceStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// AcceptsPayments reports whether incoming transfers can still be matched to the invoice
+func (s InvoiceStatus) AcceptsPayments() bool {
+	return s == InvoiceStatusOpen || s == InvoiceStatusUnderpaid
+}
+
+// SettlementStatus returns the status of an invoice for the amount due after
+// paid has been received
+func SettlementStatus(amount, paid Amount) InvoiceStatus {
+	switch {
+	case paid.Sign() <= 0:
+		return InvoiceStatusOpen
+	case paid.Cmp(amount) < 0:
+		return InvoiceStatusUnderpaid
+	case paid.Cmp(amount) == 0:
+		return InvoiceStatusPaid
+	default:
+		return InvoiceStatusOverpaid
+	}
+}
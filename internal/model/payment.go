@@ -1,6 +1,8 @@
 package model
 
 import (
+	"math/big"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,107 +12,407 @@ import (
 type PaymentStatus string
 
 const (
-	PaymentStatusPending   PaymentStatus = "pending"
-	PaymentStatusConfirmed PaymentStatus = "confirmed"
-	PaymentStatusFailed    PaymentStatus = "failed"
-	PaymentStatusCancelled PaymentStatus = "cancelled"
+	PaymentStatusPending    PaymentStatus = "pending"
+	PaymentStatusConfirming PaymentStatus = "confirming" // Mined, waiting for the required number of confirmations
+	PaymentStatusConfirmed  PaymentStatus = "confirmed"
+	PaymentStatusFailed     PaymentStatus = "failed"
+	PaymentStatusCancelled  PaymentStatus = "cancelled"
 )
 
+// PaymentDirection tells whether a payment was sent from or received by one of
+// the user's wallets
+type PaymentDirection string
+
+const (
+	PaymentDirectionOutgoing PaymentDirection = "outgoing"
+	PaymentDirectionIncoming PaymentDirection = "incoming" // Found on chain by the incoming payment indexer or invoice matcher
+)
+
+// IsValid checks if the payment direction is valid
+func (d PaymentDirection) IsValid() bool {
+	return d == PaymentDirectionOutgoing || d == PaymentDirectionIncoming
+}
+
 // Payment represents a blockchain payment transaction
 type Payment struct {
-	ID              uuid.UUID     `json:"id" db:"id"`
-	UserID          uuid.UUID     `json:"user_id" db:"user_id"`
-	FromAddress     string        `json:"from_address" db:"from_address"`
-	ToAddress       string        `json:"to_address" db:"to_address"`
-	Amount          string        `json:"amount" db:"amount"` // Using string to preserve precision
-	Currency        string        `json:"currency" db:"currency"`
-	TransactionHash string        `json:"transaction_hash" db:"transaction_hash"`
-	BlockNumber     *int64        `json:"block_number,omitempty" db:"block_number"`
-	GasUsed         *int64        `json:"gas_used,omitempty" db:"gas_used"`
-	GasPrice        *string       `json:"gas_price,omitempty" db:"gas_price"`
-	Status          PaymentStatus `json:"status" db:"status"`
-	Description     *string       `json:"description,omitempty" db:"description"`
-	CreatedAt       time.Time     `json:"created_at" db:"created_at"`
-	UpdatedAt       time.Time     `json:"updated_at" db:"updated_at"`
-	ConfirmedAt     *time.Time    `json:"confirmed_at,omitempty" db:"confirmed_at"`
-}
-
-// CreatePaymentRequest represents the request to create a new payment
+	ID                    uuid.UUID        `json:"id" db:"id"`
+	UserID                uuid.UUID        `json:"user_id" db:"user_id"`
+	FromAddress           string           `json:"from_address" db:"from_address"`
+	ToAddress             string           `json:"to_address" db:"to_address"`
+	Amount                Amount           `json:"amount" db:"amount"` // In the currency's smallest unit
+	Currency              string           `json:"currency" db:"currency"`
+	TokenAddress          *string          `json:"token_address,omitempty" db:"token_address"` // ERC-20 contract, nil for native ETH
+	TokenDecimals         int              `json:"token_decimals" db:"token_decimals"`
+	TransactionHash       string           `json:"transaction_hash" db:"transaction_hash"`
+	BlockNumber           *int64           `json:"block_number,omitempty" db:"block_number"`
+	BlockHash             *string          `json:"block_hash,omitempty" db:"block_hash"`
+	GasUsed               *int64           `json:"gas_used,omitempty" db:"gas_used"`
+	GasPrice              *Amount          `json:"gas_price,omitempty" db:"gas_price"`                       // Effective price paid per gas, in wei
+	BaseFeePerGas         *Amount          `json:"base_fee_per_gas,omitempty" db:"base_fee_per_gas"`         // Burned part of GasPrice
+	PriorityFeePerGas     *Amount          `json:"priority_fee_per_gas,omitempty" db:"priority_fee_per_gas"` // Part of GasPrice paid to the block producer
+	Status                PaymentStatus    `json:"status" db:"status"`
+	Direction             PaymentDirection `json:"direction" db:"direction"`
+	Description           *string          `json:"description,omitempty" db:"description"`
+	InvoiceID             *uuid.UUID       `json:"invoice_id,omitempty" db:"invoice_id"`
+	BillingPeriodID       *uuid.UUID       `json:"billing_period_id,omitempty" db:"billing_period_id"`
+	CategoryID            *uuid.UUID       `json:"category_id,omitempty" db:"category_id"`
+	Tags                  []string         `json:"tags" db:"tags"`
+	Fiat                  *FiatValuation   `json:"fiat,omitempty" db:"-"`                // Stored in the fiat_* columns
+	RefundedAmount        Amount           `json:"refunded_amount" db:"refunded_amount"` // Sum of recorded refunds, in the currency's smallest unit
+	Confirmations         int64            `json:"confirmations" db:"confirmations"`
+	RequiredConfirmations int64            `json:"required_confirmations" db:"required_confirmations"`
+	CreatedAt             time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time        `json:"updated_at" db:"updated_at"`
+	ConfirmedAt           *time.Time       `json:"confirmed_at,omitempty" db:"confirmed_at"`
+}
+
+// CreatePaymentRequest represents the request to create a new payment.
+// Amount is in the currency's smallest unit (wei for ETH). For ERC-20 payments
+// set TokenAddress to the token contract, or Currency to a supported token symbol.
 type CreatePaymentRequest struct {
-	ToAddress       string  `json:"to_address" binding:"required,len=42"` // Ethereum address length
-	Amount          string  `json:"amount" binding:"required"`
-	Currency        string  `json:"currency,omitempty"`
-	TransactionHash string  `json:"transaction_hash" binding:"required,len=66"` // Transaction hash length
-	Description     *string `json:"description,omitempty"`
+	ToAddress         string   `json:"to_address,omitempty" binding:"omitempty,len=42"` // Ethereum address length; may be omitted when paying a contact
+	Amount            string   `json:"amount" binding:"required"`
+	Currency          string   `json:"currency,omitempty"`
+	TokenAddress      string   `json:"token_address,omitempty" binding:"omitempty,len=42"`
+	TransactionHash   string   `json:"transaction_hash" binding:"required,len=66"` // Transaction hash length
+	Description       *string  `json:"description,omitempty"`
+	IntentID          *string  `json:"intent_id,omitempty" binding:"omitempty,uuid"`           // Pay-by-phone intent the transaction fulfils
+	SplitShareID      *string  `json:"split_share_id,omitempty" binding:"omitempty,uuid"`      // Expense split share the transaction settles
+	ContactID         *string  `json:"contact_id,omitempty" binding:"omitempty,uuid"`          // Saved contact the transaction pays
+	PreparedPaymentID *string  `json:"prepared_payment_id,omitempty" binding:"omitempty,uuid"` // Prepared payment whose transaction was broadcast
+	CategoryID        *string  `json:"category_id,omitempty" binding:"omitempty,uuid"`         // Category to file the payment under
+	Tags              []string `json:"tags,omitempty"`
+}
+
+// RelayPaymentRequest represents the request to broadcast a signed
+// transaction and record its payment. RawTransaction is the hex encoded
+// signed transaction; the other fields describe the payment it must make, as
+// in CreatePaymentRequest. Transactions paying an address that resembles a
+// saved contact are only relayed once the user acknowledges the warning.
+type RelayPaymentRequest struct {
+	RawTransaction     string   `json:"raw_transaction" binding:"required"`
+	ToAddress          string   `json:"to_address,omitempty" binding:"omitempty,len=42"`
+	Amount             string   `json:"amount" binding:"required"`
+	Currency           string   `json:"currency,omitempty"`
+	TokenAddress       string   `json:"token_address,omitempty" binding:"omitempty,len=42"`
+	Description        *string  `json:"description,omitempty"`
+	ContactID          *string  `json:"contact_id,omitempty" binding:"omitempty,uuid"`
+	PreparedPaymentID  *string  `json:"prepared_payment_id,omitempty" binding:"omitempty,uuid"` // Prepared payment the transaction was signed from
+	CategoryID         *string  `json:"category_id,omitempty" binding:"omitempty,uuid"`
+	Tags               []string `json:"tags,omitempty"`
+	AcknowledgeWarning bool     `json:"acknowledge_warning,omitempty"` // Relay even if the recipient resembles a saved contact
+}
+
+// PaymentRequest returns the request to record the relayed transaction's
+// payment once its hash is known
+func (r *RelayPaymentRequest) PaymentRequest(transactionHash string) *CreatePaymentRequest {
+	return &CreatePaymentRequest{
+		ToAddress:         r.ToAddress,
+		Amount:            r.Amount,
+		Currency:          r.Currency,
+		TokenAddress:      r.TokenAddress,
+		TransactionHash:   transactionHash,
+		Description:       r.Description,
+		ContactID:         r.ContactID,
+		PreparedPaymentID: r.PreparedPaymentID,
+		CategoryID:        r.CategoryID,
+		Tags:              r.Tags,
+	}
 }
 
 // PaymentResponse represents the response after creating/retrieving a payment
 type PaymentResponse struct {
-	ID              uuid.UUID     `json:"id"`
-	FromAddress     string        `json:"from_address"`
-	ToAddress       string        `json:"to_address"`
-	Amount          string        `json:"amount"`
-	Currency        string        `json:"currency"`
-	TransactionHash string        `json:"transaction_hash"`
-	BlockNumber     *int64        `json:"block_number,omitempty"`
-	Status          PaymentStatus `json:"status"`
-	Description     *string       `json:"description,omitempty"`
-	CreatedAt       time.Time     `json:"created_at"`
-	ConfirmedAt     *time.Time    `json:"confirmed_at,omitempty"`
-}
-
-// PaymentListResponse represents a paginated list of payments
+	ID                    uuid.UUID              `json:"id"`
+	FromAddress           string                 `json:"from_address"`
+	ToAddress             string                 `json:"to_address"`
+	Amount                Amount                 `json:"amount"`           // In the currency's smallest unit
+	AmountFormatted       string                 `json:"amount_formatted"` // Exact amount in whole units, e.g. "1.5" ETH
+	Currency              string                 `json:"currency"`
+	TokenAddress          *string                `json:"token_address,omitempty"`
+	TokenDecimals         int                    `json:"token_decimals"`
+	TransactionHash       string                 `json:"transaction_hash"`
+	BlockNumber           *int64                 `json:"block_number,omitempty"`
+	BlockHash             *string                `json:"block_hash,omitempty"`
+	Status                PaymentStatus          `json:"status"`
+	Direction             PaymentDirection       `json:"direction"`
+	Confirmations         int64                  `json:"confirmations"`
+	RequiredConfirmations int64                  `json:"required_confirmations"`
+	Description           *string                `json:"description,omitempty"`
+	InvoiceID             *uuid.UUID             `json:"invoice_id,omitempty"`
+	BillingPeriodID       *uuid.UUID             `json:"billing_period_id,omitempty"`
+	CategoryID            *uuid.UUID             `json:"category_id,omitempty"`
+	Tags                  []string               `json:"tags"`
+	Fiat                  *FiatValuationResponse `json:"fiat,omitempty"`
+	GasFee                *Amount                `json:"gas_fee,omitempty"` // Fee paid for the transaction, in wei
+	RefundedAmount        Amount                 `json:"refunded_amount"`   // In the currency's smallest unit
+	RefundStatus          *RefundStatus          `json:"refund_status,omitempty"`
+	CreatedAt             time.Time              `json:"created_at"`
+	ConfirmedAt           *time.Time             `json:"confirmed_at,omitempty"`
+	FromContact           *string                `json:"from_contact,omitempty"` // Name of the user's contact with the from address
+	ToContact             *string                `json:"to_contact,omitempty"`   // Name of the user's contact with the to address
+}
+
+// PaymentListResponse represents a paginated list of payments. NextCursor is
+// set when more payments follow; pass it back as cursor to fetch them without
+// an offset scan.
 type PaymentListResponse struct {
 	Payments   []PaymentResponse `json:"payments"`
 	TotalCount int64             `json:"total_count"`
 	Page       int               `json:"page"`
 	PageSize   int               `json:"page_size"`
 	TotalPages int               `json:"total_pages"`
+	NextCursor *string           `json:"next_cursor,omitempty"`
 }
 
-// PaymentQuery represents query parameters for filtering payments
+// Payment list sort fields and directions
+const (
+	PaymentSortCreatedAt = "created_at"
+	PaymentSortAmount    = "amount"
+
+	SortOrderAsc  = "asc"
+	SortOrderDesc = "desc"
+)
+
+// PaymentQuery represents query parameters for filtering payments. Amounts
+// are in the currency's smallest unit; Counterparty matches either side of
+// the payment. Search is a full-text query over the description, tags,
+// category, contact names and addresses. When Cursor is set, Page is ignored.
 type PaymentQuery struct {
-	Status   *PaymentStatus `form:"status"`
-	Currency *string        `form:"currency"`
-	Page     int            `form:"page,default=1"`
-	PageSize int            `form:"page_size,default=20"`
+	Status        *PaymentStatus    `form:"status"`
+	Direction     *PaymentDirection `form:"direction"`
+	Currency      *string           `form:"currency"`
+	CreatedAfter  *time.Time        `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore *time.Time        `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	Counterparty  *string           `form:"counterparty" binding:"omitempty,len=42"`
+	MinAmount     *string           `form:"min_amount"`
+	MaxAmount     *string           `form:"max_amount"`
+	CategoryID    *string           `form:"category_id" binding:"omitempty,uuid"`
+	Tag           *string           `form:"tag"`
+	Search        *string           `form:"q" binding:"omitempty,max=200"`
+	SortBy        string            `form:"sort_by,default=created_at"`
+	SortOrder     string            `form:"sort_order,default=desc"`
+	Cursor        *string           `form:"cursor"`
+	Page          int               `form:"page,default=1"`
+	PageSize      int               `form:"page_size,default=20"`
 }
 
 // TransactionDetails represents detailed information about a blockchain transaction
 type TransactionDetails struct {
-	Hash        string `json:"hash"`
-	From        string `json:"from"`
-	To          string `json:"to"`
-	Value       string `json:"value"`
-	Gas         uint64 `json:"gas"`
-	GasPrice    string `json:"gas_price"`
-	BlockNumber *int64 `json:"block_number"`
-	Status      uint64 `json:"status"` // 1 for success, 0 for failure
+	Hash        string  `json:"hash"`
+	From        string  `json:"from"`
+	To          string  `json:"to"`
+	Value       Amount  `json:"value"`
+	Gas         uint64  `json:"gas"`       // Gas limit
+	GasPrice    Amount  `json:"gas_price"` // Offered price per gas; the fee cap of EIP-1559 transactions
+	BlockNumber *int64  `json:"block_number"`
+	BlockHash   *string `json:"block_hash"`
+	Status      uint64  `json:"status"` // 1 for success, 0 for failure
+
+	// TransactionIndex is the position of the transaction in its block, nil
+	// until it is mined
+	TransactionIndex *uint `json:"transaction_index,omitempty"`
+
+	// Fees is what the transaction paid for gas, nil until it is mined
+	Fees *GasFees `json:"fees,omitempty"`
+
+	// TokenTransfers holds the ERC-20 transfers made by the transaction, decoded
+	// from receipt logs or, while pending, from a direct transfer() call
+	TokenTransfers []TokenTransfer `json:"token_transfers,omitempty"`
+}
+
+// MinedAfter reports whether the transaction was mined after another one: in
+// a later block, or later in the same block. Transactions not mined yet are
+// not ordered.
+func (d *TransactionDetails) MinedAfter(other *TransactionDetails) bool {
+	if d.BlockNumber == nil || other.BlockNumber == nil {
+		return false
+	}
+	if *d.BlockNumber != *other.BlockNumber {
+		return *d.BlockNumber > *other.BlockNumber
+	}
+	return d.TransactionIndex != nil && other.TransactionIndex != nil && *d.TransactionIndex > *other.TransactionIndex
+}
+
+// TokenTransfer is a single ERC-20 Transfer(address,address,uint256) event
+type TokenTransfer struct {
+	Token           string  `json:"token"`
+	From            string  `json:"from"`
+	To              string  `json:"to"`
+	Value           Amount  `json:"value"`                  // In the token's smallest unit
+	LogIndex        *uint   `json:"log_index,omitempty"`    // Nil when decoded from calldata
+	BlockNumber     *uint64 `json:"block_number,omitempty"` // Nil when decoded from calldata
+	TransactionHash string  `json:"transaction_hash,omitempty"`
+}
+
+// Token describes an ERC-20 token contract
+type Token struct {
+	Symbol   string `json:"symbol"`
+	Address  string `json:"address"`
+	Decimals uint8  `json:"decimals"`
+}
+
+// PaymentReorgEvent records that the block a payment was mined in was
+// orphaned by a chain reorganization
+type PaymentReorgEvent struct {
+	ID                 uuid.UUID     `json:"id" db:"id"`
+	PaymentID          uuid.UUID     `json:"payment_id" db:"payment_id"`
+	BlockNumber        int64         `json:"block_number" db:"block_number"`
+	OrphanedBlockHash  string        `json:"orphaned_block_hash" db:"orphaned_block_hash"`
+	CanonicalBlockHash string        `json:"canonical_block_hash" db:"canonical_block_hash"`
+	PreviousStatus     PaymentStatus `json:"previous_status" db:"previous_status"`
+	DetectedAt         time.Time     `json:"detected_at" db:"detected_at"`
+}
+
+// DetectPaymentReorg returns the reorg event of a mined payment whose block
+// is no longer the canonical block at its height, or nil if it still is.
+// found is whether the node has a block at that height at all; a chain that
+// is momentarily shorter is not evidence of a reorg, so the payment is left
+// alone until the height is filled again.
+func DetectPaymentReorg(payment *Payment, canonicalHash string, found bool, now time.Time) *PaymentReorgEvent {
+	if !found || payment.BlockNumber == nil || payment.BlockHash == nil {
+		return nil
+	}
+
+	if strings.EqualFold(canonicalHash, *payment.BlockHash) {
+		return nil
+	}
+
+	return &PaymentReorgEvent{
+		ID:                 uuid.New(),
+		PaymentID:          payment.ID,
+		BlockNumber:        *payment.BlockNumber,
+		OrphanedBlockHash:  *payment.BlockHash,
+		CanonicalBlockHash: canonicalHash,
+		PreviousStatus:     payment.Status,
+		DetectedAt:         now,
+	}
+}
+
+// SettlementChange reports how a payment moving from previous to next
+// affects what it settles, such as an invoice or a billing period: 1 when
+// it confirms and is credited, -1 when a confirmed payment is rolled back
+// and its credit has to be taken off again, 0 otherwise
+func SettlementChange(previous, next PaymentStatus) int {
+	wasCredited := previous == PaymentStatusConfirmed
+	credited := next == PaymentStatusConfirmed
+
+	switch {
+	case credited && !wasCredited:
+		return 1
+	case wasCredited && !credited:
+		return -1
+	default:
+		return 0
+	}
 }
 
 // ToResponse converts a Payment model to PaymentResponse
 func (p *Payment) ToResponse() PaymentResponse {
 	return PaymentResponse{
-		ID:              p.ID,
-		FromAddress:     p.FromAddress,
-		ToAddress:       p.ToAddress,
-		Amount:          p.Amount,
-		Currency:        p.Currency,
-		TransactionHash: p.TransactionHash,
-		BlockNumber:     p.BlockNumber,
-		Status:          p.Status,
-		Description:     p.Description,
-		CreatedAt:       p.CreatedAt,
-		ConfirmedAt:     p.ConfirmedAt,
+		ID:                    p.ID,
+		FromAddress:           p.FromAddress,
+		ToAddress:             p.ToAddress,
+		Amount:                p.Amount,
+		AmountFormatted:       p.Amount.Format(Unit(p.TokenDecimals)),
+		Currency:              p.Currency,
+		TokenAddress:          p.TokenAddress,
+		TokenDecimals:         p.TokenDecimals,
+		TransactionHash:       p.TransactionHash,
+		BlockNumber:           p.BlockNumber,
+		BlockHash:             p.BlockHash,
+		Status:                p.Status,
+		Direction:             p.Direction,
+		Confirmations:         p.Confirmations,
+		RequiredConfirmations: p.RequiredConfirmations,
+		Description:           p.Description,
+		InvoiceID:             p.InvoiceID,
+		BillingPeriodID:       p.BillingPeriodID,
+		CategoryID:            p.CategoryID,
+		Tags:                  p.Tags,
+		Fiat:                  p.Fiat.ToResponse(),
+		GasFee:                p.GasFee(),
+		RefundedAmount:        p.RefundedAmount,
+		RefundStatus:          p.RefundStatus(),
+		CreatedAt:             p.CreatedAt,
+		ConfirmedAt:           p.ConfirmedAt,
+	}
+}
+
+// GasFee returns the fee paid for the payment's transaction in wei, or nil
+// if its gas details are not known yet
+func (p *Payment) GasFee() *Amount {
+	if p.GasUsed == nil || p.GasPrice == nil {
+		return nil
 	}
+	fee := NewAmount(new(big.Int).Mul(big.NewInt(*p.GasUsed), p.GasPrice.BigInt()))
+	return &fee
+}
+
+// GasFees returns the gas details recorded for the payment's transaction, or
+// nil if they are not known yet
+func (p *Payment) GasFees() *GasFees {
+	if p.GasUsed == nil || p.GasPrice == nil {
+		return nil
+	}
+	return &GasFees{
+		GasUsed:           uint64(*p.GasUsed),
+		EffectiveGasPrice: *p.GasPrice,
+		BaseFee:           p.BaseFeePerGas,
+		PriorityFee:       p.PriorityFeePerGas,
+	}
+}
+
+// SetGasFees records the gas details of the payment's mined transaction. A
+// nil fees, for a transaction not mined yet, leaves them unset.
+func (p *Payment) SetGasFees(fees *GasFees) {
+	if fees == nil {
+		return
+	}
+
+	gasUsed := int64(fees.GasUsed)
+	gasPrice := fees.EffectiveGasPrice
+	p.GasUsed = &gasUsed
+	p.GasPrice = &gasPrice
+	p.BaseFeePerGas = fees.BaseFee
+	p.PriorityFeePerGas = fees.PriorityFee
 }
 
 // IsValidStatus checks if the payment status is valid
 func (ps PaymentStatus) IsValid() bool {
 	switch ps {
-	case PaymentStatusPending, PaymentStatusConfirmed, PaymentStatusFailed, PaymentStatusCancelled:
+	case PaymentStatusPending, PaymentStatusConfirming, PaymentStatusConfirmed, PaymentStatusFailed, PaymentStatusCancelled:
 		return true
 	default:
 		return false
 	}
 }
+
+// paymentTransitions lists the statuses a payment in each status may move to.
+// Pending and confirming payments may be rewritten with their own status when
+// their block details change; confirmed, failed and cancelled are final.
+// Chain reorganizations, which demote confirming and confirmed payments back
+// to pending, are the one exception and are handled separately.
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentStatusPending: {
+		PaymentStatusPending, PaymentStatusConfirming, PaymentStatusConfirmed,
+		PaymentStatusFailed, PaymentStatusCancelled,
+	},
+	PaymentStatusConfirming: {
+		PaymentStatusConfirming, PaymentStatusConfirmed, PaymentStatusFailed,
+	},
+}
+
+// CanTransitionTo checks if a payment may move from ps to next
+func (ps PaymentStatus) CanTransitionTo(next PaymentStatus) bool {
+	for _, allowed := range paymentTransitions[ps] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFinal checks if no further status changes are allowed
+func (ps PaymentStatus) IsFinal() bool {
+	return len(paymentTransitions[ps]) == 0
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxPaymentTags is the most tags a payment can carry
+	MaxPaymentTags = 20
+
+	// MaxPaymentTagLength is the longest a tag can be, in characters
+	MaxPaymentTagLength = 50
+)
+
+// PaymentCategory is a user-defined category payments can be filed under
+type PaymentCategory struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// PaymentCategoryRequest represents the details of a category to create or rename
+type PaymentCategoryRequest struct {
+	Name string `json:"name" binding:"required,max=50"`
+}
+
+// PaymentCategoryResponse represents a category returned to its owner
+type PaymentCategoryResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UpdatePaymentAnnotationsRequest replaces the category and tags of a payment.
+// A missing category leaves the payment uncategorized and missing tags clear
+// them.
+type UpdatePaymentAnnotationsRequest struct {
+	CategoryID *string  `json:"category_id,omitempty" binding:"omitempty,uuid"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+// ToResponse converts a PaymentCategory model to PaymentCategoryResponse
+func (c *PaymentCategory) ToResponse() PaymentCategoryResponse {
+	return PaymentCategoryResponse{
+		ID:        c.ID,
+		Name:      c.Name,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+// NormalizeTag trims and lowercases a tag, so tags differing only in casing
+// or surrounding whitespace are the same tag
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// NormalizeTags normalizes tags with NormalizeTag and drops duplicates,
+// keeping the first occurrence of each. Blank, overlong or too many tags are
+// rejected.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" {
+			return nil, fmt.Errorf("tags must not be blank")
+		}
+		if len([]rune(tag)) > MaxPaymentTagLength {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", tag, MaxPaymentTagLength)
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > MaxPaymentTags {
+		return nil, fmt.Errorf("a payment can have at most %d tags", MaxPaymentTags)
+	}
+
+	return normalized, nil
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentEventType names a change pushed to a user's payment stream
+type PaymentEventType string
+
+const (
+	PaymentEventCreated       PaymentEventType = "payment.created"        // Recorded by the user or found on chain
+	PaymentEventStatusChanged PaymentEventType = "payment.status_changed" // Moved to a different status
+)
+
+// PaymentEvent is a change to one of a user's payments, published to the
+// payment stream once the change is committed
+type PaymentEvent struct {
+	Type           PaymentEventType `json:"type"`
+	UserID         uuid.UUID        `json:"-"`
+	PreviousStatus *PaymentStatus   `json:"previous_status,omitempty"` // Set for status changes
+	Payment        PaymentResponse  `json:"payment"`
+	OccurredAt     time.Time        `json:"occurred_at"`
+}
+
+// NewPaymentCreatedEvent builds the event announcing a new payment
+func NewPaymentCreatedEvent(payment *Payment) PaymentEvent {
+	return PaymentEvent{
+		Type:       PaymentEventCreated,
+		UserID:     payment.UserID,
+		Payment:    payment.ToResponse(),
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+// NewPaymentStatusChangedEvent builds the event announcing that a payment
+// moved from previous to its current status
+func NewPaymentStatusChangedEvent(previous PaymentStatus, payment *Payment) PaymentEvent {
+	return PaymentEvent{
+		Type:           PaymentEventStatusChanged,
+		UserID:         payment.UserID,
+		PreviousStatus: &previous,
+		Payment:        payment.ToResponse(),
+		OccurredAt:     time.Now().UTC(),
+	}
+}
@@ -0,0 +1,135 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentIntentStatus represents the state of a pay-by-phone payment intent
+type PaymentIntentStatus string
+
+const (
+	PaymentIntentStatusPending    PaymentIntentStatus = "pending"
+	PaymentIntentStatusProcessing PaymentIntentStatus = "processing" // Paid by a transaction that is not confirmed yet
+	PaymentIntentStatusCompleted  PaymentIntentStatus = "completed"  // Paid by a confirmed transaction
+	PaymentIntentStatusExpired    PaymentIntentStatus = "expired"
+)
+
+// PaymentIntentStatusFor returns the status of an intent paid by a payment in
+// the given status. A failed or cancelled payment leaves the intent pending,
+// so it can be paid again until it expires.
+func PaymentIntentStatusFor(status PaymentStatus) PaymentIntentStatus {
+	switch status {
+	case PaymentStatusConfirmed:
+		return PaymentIntentStatusCompleted
+	case PaymentStatusFailed, PaymentStatusCancelled:
+		return PaymentIntentStatusPending
+	default:
+		return PaymentIntentStatusProcessing
+	}
+}
+
+// PaymentIntent is a payer's intention to pay the owner of a phone number.
+// The phone number is resolved to the recipient's preferred wallet when the
+// intent is created. Amounts are in the currency's smallest unit.
+type PaymentIntent struct {
+	ID               uuid.UUID           `json:"id" db:"id"`
+	PayerID          uuid.UUID           `json:"payer_id" db:"payer_id"`
+	RecipientID      uuid.UUID           `json:"recipient_id" db:"recipient_id"`
+	RecipientPhone   string              `json:"recipient_phone" db:"recipient_phone"`
+	RecipientAddress string              `json:"recipient_address" db:"recipient_address"`
+	Amount           Amount              `json:"amount" db:"amount"`
+	Currency         string              `json:"currency" db:"currency"`
+	TokenAddress     *string             `json:"token_address,omitempty" db:"token_address"`
+	TokenDecimals    int                 `json:"token_decimals" db:"token_decimals"`
+	Description      *string             `json:"description,omitempty" db:"description"`
+	Status           PaymentIntentStatus `json:"status" db:"status"`
+	PaymentID        *uuid.UUID          `json:"payment_id,omitempty" db:"payment_id"`
+	TransactionHash  *string             `json:"transaction_hash,omitempty" db:"transaction_hash"`
+	ExpiresAt        time.Time           `json:"expires_at" db:"expires_at"`
+	CreatedAt        time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at" db:"updated_at"`
+
+	// Payer details, joined from users so the recipient can see who paid them
+	PayerUsername string `json:"payer_username" db:"-"`
+	PayerPhone    string `json:"payer_phone" db:"-"`
+}
+
+// CreatePaymentIntentRequest represents the request to pay a phone number
+type CreatePaymentIntentRequest struct {
+	PhoneNumber      string  `json:"phone_number" binding:"required,len=10"`
+	Amount           string  `json:"amount" binding:"required"`
+	Currency         string  `json:"currency,omitempty"`
+	TokenAddress     string  `json:"token_address,omitempty" binding:"omitempty,len=42"`
+	Description      *string `json:"description,omitempty" binding:"omitempty,max=500"`
+	ExpiresInMinutes int     `json:"expires_in_minutes,omitempty" binding:"omitempty,min=1,max=10080"`
+}
+
+// TransactionRequest is the unsigned transaction the payer's wallet signs to
+// fulfil a payment intent. Value is in wei; Data is hex encoded.
+type TransactionRequest struct {
+	ChainID string `json:"chain_id"`
+	To      string `json:"to"`
+	Value   Amount `json:"value"`
+	Data    string `json:"data,omitempty"`
+}
+
+// PaymentIntentParty identifies the other user of a payment intent
+type PaymentIntentParty struct {
+	Username    string `json:"username"`
+	PhoneNumber string `json:"phone_number"`
+}
+
+// PaymentIntentResponse represents a payment intent returned to its payer or recipient
+type PaymentIntentResponse struct {
+	ID               uuid.UUID           `json:"id"`
+	RecipientPhone   string              `json:"recipient_phone"`
+	RecipientAddress string              `json:"recipient_address"`
+	Amount           Amount              `json:"amount"`
+	Currency         string              `json:"currency"`
+	TokenAddress     *string             `json:"token_address,omitempty"`
+	TokenDecimals    int                 `json:"token_decimals"`
+	Description      *string             `json:"description,omitempty"`
+	Status           PaymentIntentStatus `json:"status"`
+	Payer            PaymentIntentParty  `json:"payer"`
+	PaymentID        *uuid.UUID          `json:"payment_id,omitempty"`
+	TransactionHash  *string             `json:"transaction_hash,omitempty"`
+	ExpiresAt        time.Time           `json:"expires_at"`
+	CreatedAt        time.Time           `json:"created_at"`
+	Transaction      *TransactionRequest `json:"transaction,omitempty"`
+}
+
+// ToResponse converts a PaymentIntent model to PaymentIntentResponse. Pending
+// intents past their expiry are reported as expired.
+func (p *PaymentIntent) ToResponse() PaymentIntentResponse {
+	status := p.Status
+	if status == PaymentIntentStatusPending && time.Now().After(p.ExpiresAt) {
+		status = PaymentIntentStatusExpired
+	}
+
+	return PaymentIntentResponse{
+		ID:               p.ID,
+		RecipientPhone:   p.RecipientPhone,
+		RecipientAddress: p.RecipientAddress,
+		Amount:           p.Amount,
+		Currency:         p.Currency,
+		TokenAddress:     p.TokenAddress,
+		TokenDecimals:    p.TokenDecimals,
+		Description:      p.Description,
+		Status:           status,
+		Payer: PaymentIntentParty{
+			Username:    p.PayerUsername,
+			PhoneNumber: p.PayerPhone,
+		},
+		PaymentID:       p.PaymentID,
+		TransactionHash: p.TransactionHash,
+		ExpiresAt:       p.ExpiresAt,
+		CreatedAt:       p.CreatedAt,
+	}
+}
+
+// IsPayable reports whether a payment can still be bound to the intent
+func (p *PaymentIntent) IsPayable() bool {
+	return p.Status == PaymentIntentStatusPending && time.Now().Before(p.ExpiresAt)
+}
@@ -0,0 +1,146 @@
+package model
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PreparedPaymentStatus represents the state of a prepared payment
+type PreparedPaymentStatus string
+
+const (
+	PreparedPaymentStatusPending    PreparedPaymentStatus = "pending"
+	PreparedPaymentStatusProcessing PreparedPaymentStatus = "processing" // Bound to a payment that is not confirmed yet
+	PreparedPaymentStatusCompleted  PreparedPaymentStatus = "completed"  // Bound to a confirmed payment
+	PreparedPaymentStatusExpired    PreparedPaymentStatus = "expired"
+)
+
+// PreparedPaymentStatusFor returns the status of a preparation bound to a
+// payment in the given status. A failed or cancelled payment releases the
+// preparation, so another payment can be bound to it.
+func PreparedPaymentStatusFor(status PaymentStatus) PreparedPaymentStatus {
+	switch status {
+	case PaymentStatusConfirmed:
+		return PreparedPaymentStatusCompleted
+	case PaymentStatusFailed, PaymentStatusCancelled:
+		return PreparedPaymentStatusPending
+	default:
+		return PreparedPaymentStatusProcessing
+	}
+}
+
+// PreparedPayment is an unsigned EIP-1559 transaction prepared for one of a
+// user's wallets to sign, reserving its nonce until it expires. The payment
+// recorded once the signed transaction is broadcast is bound to it. Amounts
+// are in the currency's smallest unit, fees per gas in wei.
+type PreparedPayment struct {
+	ID                   uuid.UUID             `json:"id" db:"id"`
+	UserID               uuid.UUID             `json:"user_id" db:"user_id"`
+	FromAddress          string                `json:"from_address" db:"from_address"`
+	ToAddress            string                `json:"to_address" db:"to_address"`
+	Amount               Amount                `json:"amount" db:"amount"`
+	Currency             string                `json:"currency" db:"currency"`
+	TokenAddress         *string               `json:"token_address,omitempty" db:"token_address"`
+	TokenDecimals        int                   `json:"token_decimals" db:"token_decimals"`
+	Description          *string               `json:"description,omitempty" db:"description"`
+	ChainID              int64                 `json:"chain_id" db:"chain_id"`
+	Nonce                uint64                `json:"nonce" db:"nonce"`
+	GasLimit             uint64                `json:"gas_limit" db:"gas_limit"`
+	MaxFeePerGas         Amount                `json:"max_fee_per_gas" db:"max_fee_per_gas"`
+	MaxPriorityFeePerGas Amount                `json:"max_priority_fee_per_gas" db:"max_priority_fee_per_gas"`
+	Status               PreparedPaymentStatus `json:"status" db:"status"`
+	PaymentID            *uuid.UUID            `json:"payment_id,omitempty" db:"payment_id"`
+	TransactionHash      *string               `json:"transaction_hash,omitempty" db:"transaction_hash"`
+	ExpiresAt            time.Time             `json:"expires_at" db:"expires_at"`
+	CreatedAt            time.Time             `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time             `json:"updated_at" db:"updated_at"`
+}
+
+// PreparePaymentRequest represents the request to prepare a payment for
+// client-side signing. Amount is in the currency's smallest unit; for ERC-20
+// payments set TokenAddress to the token contract, or Currency to a supported
+// token symbol.
+type PreparePaymentRequest struct {
+	FromAddress  string   `json:"from_address,omitempty" binding:"omitempty,len=42"` // One of the user's wallets; their preferred wallet by default
+	ToAddress    string   `json:"to_address" binding:"required,len=42"`
+	Amount       string   `json:"amount" binding:"required"`
+	Currency     string   `json:"currency,omitempty"`
+	TokenAddress string   `json:"token_address,omitempty" binding:"omitempty,len=42"`
+	Description  *string  `json:"description,omitempty" binding:"omitempty,max=500"`
+	Speed        FeeSpeed `json:"speed,omitempty"` // Fee suggestion to offer: slow, standard (default) or fast
+}
+
+// UnsignedTransaction is a ready-to-sign EIP-1559 (type 2) transaction.
+// Quantities are decimal strings in wei; Data is hex encoded.
+type UnsignedTransaction struct {
+	Type                 uint8  `json:"type"`
+	ChainID              string `json:"chain_id"`
+	Nonce                uint64 `json:"nonce"`
+	From                 string `json:"from"`
+	To                   string `json:"to"`
+	Value                Amount `json:"value"`
+	Data                 string `json:"data,omitempty"`
+	Gas                  uint64 `json:"gas"`
+	MaxFeePerGas         Amount `json:"max_fee_per_gas"`
+	MaxPriorityFeePerGas Amount `json:"max_priority_fee_per_gas"`
+}
+
+// PreparedPaymentResponse represents a prepared payment returned to its user.
+// MaxFee is the most the transaction can pay for gas, in wei.
+type PreparedPaymentResponse struct {
+	ID              uuid.UUID             `json:"id"`
+	FromAddress     string                `json:"from_address"`
+	ToAddress       string                `json:"to_address"`
+	Amount          Amount                `json:"amount"`
+	Currency        string                `json:"currency"`
+	TokenAddress    *string               `json:"token_address,omitempty"`
+	TokenDecimals   int                   `json:"token_decimals"`
+	Description     *string               `json:"description,omitempty"`
+	Status          PreparedPaymentStatus `json:"status"`
+	MaxFee          Amount                `json:"max_fee"`
+	PaymentID       *uuid.UUID            `json:"payment_id,omitempty"`
+	TransactionHash *string               `json:"transaction_hash,omitempty"`
+	ExpiresAt       time.Time             `json:"expires_at"`
+	CreatedAt       time.Time             `json:"created_at"`
+	Transaction     *UnsignedTransaction  `json:"transaction,omitempty"`
+}
+
+// ToResponse converts a PreparedPayment model to PreparedPaymentResponse.
+// Pending preparations past their expiry are reported as expired.
+func (p *PreparedPayment) ToResponse() PreparedPaymentResponse {
+	status := p.Status
+	if status == PreparedPaymentStatusPending && time.Now().After(p.ExpiresAt) {
+		status = PreparedPaymentStatusExpired
+	}
+
+	return PreparedPaymentResponse{
+		ID:              p.ID,
+		FromAddress:     p.FromAddress,
+		ToAddress:       p.ToAddress,
+		Amount:          p.Amount,
+		Currency:        p.Currency,
+		TokenAddress:    p.TokenAddress,
+		TokenDecimals:   p.TokenDecimals,
+		Description:     p.Description,
+		Status:          status,
+		MaxFee:          p.MaxFee(),
+		PaymentID:       p.PaymentID,
+		TransactionHash: p.TransactionHash,
+		ExpiresAt:       p.ExpiresAt,
+		CreatedAt:       p.CreatedAt,
+	}
+}
+
+// MaxFee returns the most the prepared transaction can pay for gas, in wei
+func (p *PreparedPayment) MaxFee() Amount {
+	return NewAmount(new(big.Int).Mul(new(big.Int).SetUint64(p.GasLimit), p.MaxFeePerGas.BigInt()))
+}
+
+// IsBindable reports whether a payment can still be bound to the
+// preparation. Expiry only releases the reserved nonce: a transaction
+// broadcast late is still bound.
+func (p *PreparedPayment) IsBindable() bool {
+	return p.Status == PreparedPaymentStatusPending
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefundStatus tells how much of a payment has been returned to its sender
+type RefundStatus string
+
+const (
+	RefundStatusPartiallyRefunded RefundStatus = "partially_refunded"
+	RefundStatusRefunded          RefundStatus = "refunded"
+)
+
+// RefundStatus returns whether the payment was partially or fully refunded,
+// or nil if nothing was refunded
+func (p *Payment) RefundStatus() *RefundStatus {
+	if p.RefundedAmount.Sign() <= 0 {
+		return nil
+	}
+
+	status := RefundStatusPartiallyRefunded
+	if p.RefundedAmount.Cmp(p.Amount) >= 0 {
+		status = RefundStatusRefunded
+	}
+	return &status
+}
+
+// Refund is a transaction returning funds from a payment's recipient to its
+// sender. Amounts are in the payment currency's smallest unit.
+type Refund struct {
+	ID              uuid.UUID `json:"id" db:"id"`
+	PaymentID       uuid.UUID `json:"payment_id" db:"payment_id"`
+	UserID          uuid.UUID `json:"user_id" db:"user_id"`
+	FromAddress     string    `json:"from_address" db:"from_address"` // The payment's recipient
+	ToAddress       string    `json:"to_address" db:"to_address"`     // The payment's sender
+	Amount          Amount    `json:"amount" db:"amount"`
+	TransactionHash string    `json:"transaction_hash" db:"transaction_hash"`
+	BlockNumber     *int64    `json:"block_number,omitempty" db:"block_number"`
+	Reason          *string   `json:"reason,omitempty" db:"reason"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// CreateRefundRequest represents the request to record a refund of a payment.
+// The amount is read from the transaction.
+type CreateRefundRequest struct {
+	TransactionHash string  `json:"transaction_hash" binding:"required,len=66"`
+	Reason          *string `json:"reason,omitempty" binding:"omitempty,max=500"`
+}
+
+// RefundResponse represents a recorded refund
+type RefundResponse struct {
+	ID              uuid.UUID `json:"id"`
+	PaymentID       uuid.UUID `json:"payment_id"`
+	FromAddress     string    `json:"from_address"`
+	ToAddress       string    `json:"to_address"`
+	Amount          Amount    `json:"amount"`
+	AmountFormatted string    `json:"amount_formatted"`
+	Currency        string    `json:"currency"`
+	TransactionHash string    `json:"transaction_hash"`
+	BlockNumber     *int64    `json:"block_number,omitempty"`
+	Reason          *string   `json:"reason,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// ToResponse converts a refund of the given payment to its response
+func (r *Refund) ToResponse(payment *Payment) RefundResponse {
+	return RefundResponse{
+		ID:              r.ID,
+		PaymentID:       r.PaymentID,
+		FromAddress:     r.FromAddress,
+		ToAddress:       r.ToAddress,
+		Amount:          r.Amount,
+		AmountFormatted: r.Amount.Format(Unit(payment.TokenDecimals)),
+		Currency:        payment.Currency,
+		TransactionHash: r.TransactionHash,
+		BlockNumber:     r.BlockNumber,
+		Reason:          r.Reason,
+		CreatedAt:       r.CreatedAt,
+	}
+}
+
+// PaymentRefundsResponse lists the refunds recorded against a payment
+type PaymentRefundsResponse struct {
+	Payment PaymentResponse  `json:"payment"`
+	Refunds []RefundResponse `json:"refunds"`
+}
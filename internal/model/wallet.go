@@ -12,3 +12,9 @@ type ConnectWalletRequest struct {
 	Message   string `json:"message"`
 	Signature string `json:"signature"`
 }
+
+// SetPreferredWalletRequest represents the request to choose which wallet
+// receives pay-by-phone payments
+type SetPreferredWalletRequest struct {
+	Address string `json:"address" binding:"required,len=42"`
+}
@@ -0,0 +1,218 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookEventType names a payment lifecycle event pushed to webhook endpoints
+type WebhookEventType string
+
+const (
+	WebhookEventPaymentCreated   WebhookEventType = "payment.created"
+	WebhookEventPaymentConfirmed WebhookEventType = "payment.confirmed"
+	WebhookEventPaymentFailed    WebhookEventType = "payment.failed"
+	WebhookEventPaymentCancelled WebhookEventType = "payment.cancelled"
+	WebhookEventPaymentReorged   WebhookEventType = "payment.reorged" // The payment's block was orphaned; it is pending again
+)
+
+// IsValid checks if the webhook event type is supported
+func (t WebhookEventType) IsValid() bool {
+	switch t {
+	case WebhookEventPaymentCreated, WebhookEventPaymentConfirmed, WebhookEventPaymentFailed, WebhookEventPaymentCancelled,
+		WebhookEventPaymentReorged:
+		return true
+	default:
+		return false
+	}
+}
+
+// statusWebhookEvents maps the payment statuses that are announced to
+// webhooks to their event
+var statusWebhookEvents = map[PaymentStatus]WebhookEventType{
+	PaymentStatusConfirmed: WebhookEventPaymentConfirmed,
+	PaymentStatusFailed:    WebhookEventPaymentFailed,
+	PaymentStatusCancelled: WebhookEventPaymentCancelled,
+}
+
+// PaymentWebhookEvents returns the events a payment status change fires.
+// A nil previous status means the payment was just created, which fires
+// payment.created followed by the event for its initial status, if any.
+// A mined payment moving back to pending, which only a reorg does, fires
+// payment.reorged. Other changes that do not reach a new announced status
+// fire nothing.
+func PaymentWebhookEvents(previous *PaymentStatus, current PaymentStatus) []WebhookEventType {
+	var events []WebhookEventType
+	if previous == nil {
+		events = append(events, WebhookEventPaymentCreated)
+	} else if *previous == current {
+		return nil
+	} else if current == PaymentStatusPending {
+		if *previous == PaymentStatusConfirming || *previous == PaymentStatusConfirmed {
+			return []WebhookEventType{WebhookEventPaymentReorged}
+		}
+		return nil
+	}
+
+	if event, ok := statusWebhookEvents[current]; ok {
+		events = append(events, event)
+	}
+
+	return events
+}
+
+// WebhookEndpoint is a URL a user registered to receive payment events.
+// Payloads sent to it are signed with Secret.
+type WebhookEndpoint struct {
+	ID          uuid.UUID          `json:"id" db:"id"`
+	UserID      uuid.UUID          `json:"user_id" db:"user_id"`
+	URL         string             `json:"url" db:"url"`
+	Secret      string             `json:"-" db:"secret"`
+	EventTypes  []WebhookEventType `json:"event_types" db:"event_types"` // Empty subscribes to every event
+	Description *string            `json:"description,omitempty" db:"description"`
+	CreatedAt   time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// Subscribes checks if the endpoint receives events of the given type
+func (e *WebhookEndpoint) Subscribes(eventType WebhookEventType) bool {
+	if len(e.EventTypes) == 0 {
+		return true
+	}
+	for _, subscribed := range e.EventTypes {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// JoinWebhookEventTypes encodes event types for the comma-separated
+// event_types column
+func JoinWebhookEventTypes(eventTypes []WebhookEventType) string {
+	values := make([]string, len(eventTypes))
+	for i, eventType := range eventTypes {
+		values[i] = string(eventType)
+	}
+	return strings.Join(values, ",")
+}
+
+// SplitWebhookEventTypes decodes the comma-separated event_types column
+func SplitWebhookEventTypes(column string) []WebhookEventType {
+	eventTypes := []WebhookEventType{}
+	for _, value := range strings.Split(column, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			eventTypes = append(eventTypes, WebhookEventType(value))
+		}
+	}
+	return eventTypes
+}
+
+// CreateWebhookEndpointRequest represents the request to register a webhook
+// endpoint. Leave EventTypes empty to receive every event.
+type CreateWebhookEndpointRequest struct {
+	URL         string             `json:"url" binding:"required,url,max=2048"`
+	EventTypes  []WebhookEventType `json:"event_types,omitempty"`
+	Description *string            `json:"description,omitempty" binding:"omitempty,max=500"`
+}
+
+// WebhookEndpointResponse represents a webhook endpoint returned to its owner.
+// The signing secret is only included when the endpoint is created.
+type WebhookEndpointResponse struct {
+	ID          uuid.UUID          `json:"id"`
+	URL         string             `json:"url"`
+	EventTypes  []WebhookEventType `json:"event_types"`
+	Description *string            `json:"description,omitempty"`
+	Secret      string             `json:"secret,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+// ToResponse converts an endpoint to its response without the secret
+func (e *WebhookEndpoint) ToResponse() WebhookEndpointResponse {
+	return WebhookEndpointResponse{
+		ID:          e.ID,
+		URL:         e.URL,
+		EventTypes:  e.EventTypes,
+		Description: e.Description,
+		CreatedAt:   e.CreatedAt,
+	}
+}
+
+// WebhookEvent is the signed JSON body posted to webhook endpoints
+type WebhookEvent struct {
+	ID        uuid.UUID        `json:"id"`
+	Type      WebhookEventType `json:"type"`
+	CreatedAt time.Time        `json:"created_at"`
+	Data      PaymentResponse  `json:"data"`
+}
+
+// NewPaymentWebhookEvent builds an event carrying the payment's current state
+func NewPaymentWebhookEvent(eventType WebhookEventType, payment *Payment) WebhookEvent {
+	return WebhookEvent{
+		ID:        uuid.New(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      payment.ToResponse(),
+	}
+}
+
+// WebhookDeliveryStatus represents the state of an event's delivery to one endpoint
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending" // Waiting for its next attempt
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed" // Gave up after the maximum number of attempts
+)
+
+// WebhookDelivery is an event queued for one endpoint, with the outcome of
+// its latest attempt
+type WebhookDelivery struct {
+	ID             uuid.UUID             `json:"id"`
+	EventID        uuid.UUID             `json:"event_id"`
+	EndpointID     uuid.UUID             `json:"endpoint_id"`
+	EventType      WebhookEventType      `json:"event_type"`
+	PaymentID      *uuid.UUID            `json:"payment_id,omitempty"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time            `json:"last_attempt_at,omitempty"`
+	LastStatusCode *int                  `json:"last_status_code,omitempty"`
+	LastError      *string               `json:"last_error,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	AttemptLog     []WebhookAttempt      `json:"attempt_log,omitempty"`
+}
+
+// PendingWebhook is a delivery claimed by the dispatcher, with everything
+// needed to post it
+type PendingWebhook struct {
+	DeliveryID uuid.UUID
+	EventID    uuid.UUID
+	EventType  WebhookEventType
+	URL        string
+	Secret     string
+	Payload    []byte
+	Attempts   int // Attempts made before this one
+}
+
+// WebhookAttempt is the log entry of one delivery attempt. StatusCode is nil
+// when no response was received.
+type WebhookAttempt struct {
+	AttemptedAt time.Time `json:"attempted_at"`
+	StatusCode  *int      `json:"status_code,omitempty"`
+	Error       *string   `json:"error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+}
+
+// Succeeded reports whether the endpoint acknowledged the event with a 2xx response
+func (a *WebhookAttempt) Succeeded() bool {
+	return a.StatusCode != nil && *a.StatusCode >= 200 && *a.StatusCode < 300
+}
+
+// WebhookDeliveryQuery represents query parameters for listing an endpoint's deliveries
+type WebhookDeliveryQuery struct {
+	Status *WebhookDeliveryStatus `form:"status"`
+	Limit  int                    `form:"limit,default=50" binding:"min=1,max=200"`
+}
@@ -0,0 +1,62 @@
+package pricing
+
+import (
+	"backend/internal/model"
+	"context"
+	"sync"
+	"time"
+)
+
+// cacheKey identifies a cached quote
+type cacheKey struct {
+	currency string
+	fiat     model.FiatCurrency
+}
+
+// CachedSource reuses the quotes of another source for a while, so valuing a
+// burst of payments does not query a rate-limited price API for each one.
+// Failed quotes are not cached.
+type CachedSource struct {
+	source Source
+	ttl    time.Duration
+
+	mu     sync.Mutex
+	quotes map[cacheKey]Quote
+}
+
+// NewCachedSource returns a source answering from source, reusing each quote
+// until it is older than ttl
+func NewCachedSource(source Source, ttl time.Duration) *CachedSource {
+	return &CachedSource{
+		source: source,
+		ttl:    ttl,
+		quotes: make(map[cacheKey]Quote),
+	}
+}
+
+// Quote returns a cached quote of currency in fiat if a fresh one is known,
+// and otherwise asks the underlying source. Cached quotes keep the time they
+// were originally quoted at.
+func (c *CachedSource) Quote(ctx context.Context, currency string, fiat model.FiatCurrency) (Quote, error) {
+	key := cacheKey{currency: normalizeSymbol(currency), fiat: fiat}
+
+	c.mu.Lock()
+	quote, ok := c.quotes[key]
+	c.mu.Unlock()
+	if ok && time.Since(quote.QuotedAt) < c.ttl {
+		return quote, nil
+	}
+
+	// The lock is not held while querying, so a slow source does not block
+	// quotes of other currencies
+	quote, err := c.source.Quote(ctx, currency, fiat)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	c.mu.Lock()
+	c.quotes[key] = quote
+	c.mu.Unlock()
+
+	return quote, nil
+}
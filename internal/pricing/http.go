@@ -0,0 +1,108 @@
+package pricing
+
+import (
+	"backend/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxPriceResponseSize bounds the price API responses read
+const maxPriceResponseSize = 1 << 20
+
+// HTTPSource quotes prices from a CoinGecko-compatible simple price API:
+//
+//	GET {baseURL}/simple/price?ids=ethereum&vs_currencies=usd
+//	{"ethereum": {"usd": 3012.45}}
+//
+// The API identifies coins by its own IDs, so every supported currency
+// symbol must be mapped to one. Every call queries the API; wrap the source
+// in a CachedSource to reuse quotes.
+type HTTPSource struct {
+	baseURL string
+	coinIDs map[string]string
+	client  *http.Client
+}
+
+// NewHTTPSource returns a source querying the API at baseURL, with coinIDs
+// mapping currency symbols to the API's coin IDs, e.g. "ETH" to "ethereum"
+func NewHTTPSource(baseURL string, coinIDs map[string]string, client *http.Client) *HTTPSource {
+	ids := make(map[string]string, len(coinIDs))
+	for symbol, id := range coinIDs {
+		ids[normalizeSymbol(symbol)] = id
+	}
+
+	return &HTTPSource{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		coinIDs: ids,
+		client:  client,
+	}
+}
+
+// Quote fetches the current rate of currency in fiat from the API
+func (s *HTTPSource) Quote(ctx context.Context, currency string, fiat model.FiatCurrency) (Quote, error) {
+	symbol := normalizeSymbol(currency)
+	coinID, ok := s.coinIDs[symbol]
+	if !ok {
+		return Quote{}, fmt.Errorf("%w: no price API coin ID for %s", ErrRateUnavailable, symbol)
+	}
+
+	vsCurrency := strings.ToLower(string(fiat))
+	query := url.Values{
+		"ids":           {coinID},
+		"vs_currencies": {vsCurrency},
+		"precision":     {"full"},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/simple/price?"+query.Encode(), nil)
+	if err != nil {
+		return Quote{}, fmt.Errorf("failed to build price request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Quote{}, fmt.Errorf("price request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Quote{}, fmt.Errorf("price API returned status %d", resp.StatusCode)
+	}
+
+	// Prices are decoded as numbers rather than floats to keep every digit
+	var prices map[string]map[string]json.Number
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, resp.Body, maxPriceResponseSize))
+	decoder.UseNumber()
+	if err := decoder.Decode(&prices); err != nil {
+		return Quote{}, fmt.Errorf("invalid price API response: %w", err)
+	}
+
+	price, ok := prices[coinID][vsCurrency]
+	if !ok {
+		return Quote{}, fmt.Errorf("%w: price API has no %s rate for %s", ErrRateUnavailable, fiat, symbol)
+	}
+
+	// The API may answer in exponent notation for very small prices
+	value, ok := new(big.Rat).SetString(price.String())
+	if !ok {
+		return Quote{}, fmt.Errorf("invalid price %q for %s", price, symbol)
+	}
+	rate, err := model.ParseExchangeRate(value.FloatString(18))
+	if err != nil {
+		return Quote{}, fmt.Errorf("invalid price %q for %s: %w", price, symbol, err)
+	}
+
+	return Quote{
+		Currency: symbol,
+		Fiat:     fiat,
+		Rate:     rate,
+		Source:   "http",
+		QuotedAt: time.Now(),
+	}, nil
+}
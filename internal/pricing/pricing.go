@@ -0,0 +1,39 @@
+// Package pricing quotes the fiat prices of the currencies payments are made
+// in. Prices come from a Source: a static table for offline use, or an HTTP
+// price API behind a cache.
+package pricing
+
+import (
+	"backend/internal/model"
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrRateUnavailable is returned when a source has no price for a currency
+// in a fiat currency
+var ErrRateUnavailable = errors.New("exchange rate unavailable")
+
+// Quote is the price of one whole unit of a currency in a fiat currency, as
+// quoted by a source at a point in time
+type Quote struct {
+	Currency string
+	Fiat     model.FiatCurrency
+	Rate     model.ExchangeRate
+	Source   string
+	QuotedAt time.Time
+}
+
+// Source quotes the current price of a currency, identified by its symbol
+// such as "ETH" or "USDC", in a fiat currency. Implementations must be safe
+// for concurrent use.
+type Source interface {
+	Quote(ctx context.Context, currency string, fiat model.FiatCurrency) (Quote, error)
+}
+
+// normalizeSymbol upper-cases a currency symbol, so sources match symbols
+// in any casing
+func normalizeSymbol(currency string) string {
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
@@ -0,0 +1,94 @@
+package pricing
+
+import (
+	"backend/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// StaticSource quotes prices from a fixed table, for offline use and tests.
+// Its quotes are timestamped with the time the table was loaded.
+type StaticSource struct {
+	name     string
+	rates    map[string]map[model.FiatCurrency]model.ExchangeRate
+	loadedAt time.Time
+}
+
+// NewStaticSource returns a source quoting the given rates, keyed by currency
+// symbol and fiat currency
+func NewStaticSource(name string, rates map[string]map[model.FiatCurrency]model.ExchangeRate) *StaticSource {
+	table := make(map[string]map[model.FiatCurrency]model.ExchangeRate, len(rates))
+	for currency, fiatRates := range rates {
+		symbol := normalizeSymbol(currency)
+		if table[symbol] == nil {
+			table[symbol] = make(map[model.FiatCurrency]model.ExchangeRate, len(fiatRates))
+		}
+		for fiat, rate := range fiatRates {
+			table[symbol][fiat] = rate
+		}
+	}
+
+	return &StaticSource{name: name, rates: table, loadedAt: time.Now()}
+}
+
+// ParseStaticRates reads a rate table from JSON mapping currency symbols to
+// their price in each fiat currency, e.g.
+//
+//	{"ETH": {"USD": "3012.45", "INR": "251000"}, "USDC": {"USD": "1"}}
+func ParseStaticRates(r io.Reader) (map[string]map[model.FiatCurrency]model.ExchangeRate, error) {
+	var raw map[string]map[string]model.ExchangeRate
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid rate table: %w", err)
+	}
+
+	rates := make(map[string]map[model.FiatCurrency]model.ExchangeRate, len(raw))
+	for currency, fiatRates := range raw {
+		rates[currency] = make(map[model.FiatCurrency]model.ExchangeRate, len(fiatRates))
+		for code, rate := range fiatRates {
+			fiat, err := model.ParseFiatCurrency(code)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rate table entry %s/%s: %w", currency, code, err)
+			}
+			rates[currency][fiat] = rate
+		}
+	}
+
+	return rates, nil
+}
+
+// LoadStaticSource reads a rate table in the ParseStaticRates format from a file
+func LoadStaticSource(path string) (*StaticSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rate table: %w", err)
+	}
+	defer file.Close()
+
+	rates, err := ParseStaticRates(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStaticSource("file", rates), nil
+}
+
+// Quote returns the rate of currency in fiat from the table
+func (s *StaticSource) Quote(_ context.Context, currency string, fiat model.FiatCurrency) (Quote, error) {
+	symbol := normalizeSymbol(currency)
+	rate, ok := s.rates[symbol][fiat]
+	if !ok {
+		return Quote{}, fmt.Errorf("%w: no %s rate for %s in the %s table", ErrRateUnavailable, fiat, symbol, s.name)
+	}
+
+	return Quote{
+		Currency: symbol,
+		Fiat:     fiat,
+		Rate:     rate,
+		Source:   s.name,
+		QuotedAt: s.loadedAt,
+	}, nil
+}
@@ -0,0 +1,122 @@
+// Package pubsub fans payment events out to the streams of the users they
+// belong to. The hub lives inside the server process, so events published by
+// the background workers reach every stream opened on the same instance.
+package pubsub
+
+import (
+	"backend/internal/model"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// DefaultBufferSize is how many events a subscription holds before it is
+// considered too slow and closed
+const DefaultBufferSize = 64
+
+// Hub delivers published events to the subscriptions of the event's user.
+// Publishing never blocks: a subscriber whose buffer is full is dropped, and
+// its Events channel closed, so that it can reconnect and reload its state.
+type Hub struct {
+	mu          sync.Mutex
+	bufferSize  int
+	subscribers map[uuid.UUID]map[*Subscription]struct{}
+}
+
+// Subscription receives the events of one user until it is closed
+type Subscription struct {
+	hub    *Hub
+	userID uuid.UUID
+	events chan model.PaymentEvent
+	closed bool
+}
+
+// NewHub creates an empty hub. A non-positive buffer size falls back to
+// DefaultBufferSize.
+func NewHub(bufferSize int) *Hub {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+
+	return &Hub{
+		bufferSize:  bufferSize,
+		subscribers: make(map[uuid.UUID]map[*Subscription]struct{}),
+	}
+}
+
+var defaultHub = NewHub(DefaultBufferSize)
+
+// Default returns the hub shared by the server's handlers and workers
+func Default() *Hub {
+	return defaultHub
+}
+
+// Publish delivers an event to the subscriptions of its user
+func (h *Hub) Publish(event model.PaymentEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers[event.UserID] {
+		select {
+		case sub.events <- event:
+		default:
+			h.remove(sub)
+		}
+	}
+}
+
+// Subscribe starts receiving the events of a user. The subscription must be
+// closed when the caller stops reading.
+func (h *Hub) Subscribe(userID uuid.UUID) *Subscription {
+	sub := &Subscription{
+		hub:    h,
+		userID: userID,
+		events: make(chan model.PaymentEvent, h.bufferSize),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[userID][sub] = struct{}{}
+
+	return sub
+}
+
+// Subscribers returns how many subscriptions a user has open
+func (h *Hub) Subscribers(userID uuid.UUID) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subscribers[userID])
+}
+
+// remove drops a subscription and closes its channel. The caller holds h.mu.
+func (h *Hub) remove(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	close(sub.events)
+
+	delete(h.subscribers[sub.userID], sub)
+	if len(h.subscribers[sub.userID]) == 0 {
+		delete(h.subscribers, sub.userID)
+	}
+}
+
+// Events returns the channel events are delivered on. It is closed when the
+// subscription is closed or dropped for falling behind.
+func (s *Subscription) Events() <-chan model.PaymentEvent {
+	return s.events
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.remove(s)
+}
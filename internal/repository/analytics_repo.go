@@ -0,0 +1,295 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrorInvalidAnalyticsQuery = errors.New("invalid payment analytics query")
+
+// analyticsIntervals maps each series interval to its date_trunc field
+var analyticsIntervals = map[model.AnalyticsInterval]string{
+	model.AnalyticsIntervalDay:   "day",
+	model.AnalyticsIntervalWeek:  "week",
+	model.AnalyticsIntervalMonth: "month",
+}
+
+// PaymentAnalyticsRange selects the payments analytics are computed over. A
+// nil bound leaves that side of the range open. Native restricts it to native
+// ETH payments, which are told apart by having no token address.
+type PaymentAnalyticsRange struct {
+	UserID   uuid.UUID
+	From     *time.Time
+	To       *time.Time
+	Currency *string
+	Native   bool
+}
+
+// conditions builds the WHERE conditions and their arguments for the range
+func (r PaymentAnalyticsRange) conditions() ([]string, []any) {
+	conditions := []string{"user_id = $1"}
+	args := []any{r.UserID}
+
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if r.From != nil {
+		add("created_at >= $%d", r.From.UTC().Format(cursorTimeLayout))
+	}
+	if r.To != nil {
+		add("created_at < $%d", r.To.UTC().Format(cursorTimeLayout))
+	}
+	if r.Currency != nil {
+		add("UPPER(currency) = UPPER($%d)", *r.Currency)
+	}
+	if r.Native {
+		conditions = append(conditions, "token_address IS NULL")
+	}
+
+	return conditions, args
+}
+
+// GetPaymentTotals counts and sums a user's payments per status, direction
+// and currency. A currency is a token address and its decimals; the symbol
+// is only reported, since any contract may claim any symbol.
+func GetPaymentTotals(ctx context.Context, r PaymentAnalyticsRange) ([]model.PaymentTotal, error) {
+	db := database.New("")
+
+	conditions, args := r.conditions()
+	query := `
+		SELECT status, direction, MIN(currency), token_address, token_decimals, COUNT(*), COALESCE(SUM(amount), 0)
+		FROM payments
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		GROUP BY status, direction, token_address, token_decimals
+		ORDER BY status, direction, token_address NULLS FIRST, token_decimals`
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	totals := []model.PaymentTotal{}
+	for rows.Next() {
+		var total model.PaymentTotal
+		if err := rows.Scan(&total.Status, &total.Direction, &total.Currency, &total.TokenAddress, &total.TokenDecimals, &total.Count, &total.Amount); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		totals = append(totals, total)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return totals, nil
+}
+
+// GetFiatTotals sums the fiat values of a user's confirmed payments valued
+// in currency, per direction. Confirmed payments without such a valuation
+// are counted as unvalued.
+func GetFiatTotals(ctx context.Context, userID uuid.UUID, currency model.FiatCurrency) (*model.FiatTotals, error) {
+	db := database.New("")
+
+	query := `
+		SELECT COALESCE(SUM(fiat_value) FILTER (WHERE valued AND direction = 'outgoing'), 0),
+			   COALESCE(SUM(fiat_value) FILTER (WHERE valued AND direction = 'incoming'), 0),
+			   COUNT(*) FILTER (WHERE valued),
+			   COUNT(*) FILTER (WHERE NOT valued)
+		FROM (
+			SELECT direction, fiat_value, fiat_value IS NOT NULL AND fiat_currency = $2 AS valued
+			FROM payments
+			WHERE user_id = $1 AND status = 'confirmed'
+		) confirmed`
+
+	totals := &model.FiatTotals{Currency: currency}
+	err := db.QueryRowContext(ctx, query, userID, string(currency)).Scan(
+		&totals.Sent,
+		&totals.Received,
+		&totals.ValuedPayments,
+		&totals.UnvaluedPayments,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	totals.SentFormatted = totals.Sent.FormatFixed(currency.Unit(), int(currency.Unit()))
+	totals.ReceivedFormatted = totals.Received.FormatFixed(currency.Unit(), int(currency.Unit()))
+	return totals, nil
+}
+
+// GetPaymentSeries buckets a user's payments by creation time, direction and
+// currency, told apart by token address and decimals
+func GetPaymentSeries(ctx context.Context, r PaymentAnalyticsRange, interval model.AnalyticsInterval) ([]model.PaymentSeriesPoint, error) {
+	field, ok := analyticsIntervals[interval]
+	if !ok {
+		return nil, ErrorInvalidAnalyticsQuery
+	}
+
+	db := database.New("")
+
+	conditions, args := r.conditions()
+	query := `
+		SELECT DATE_TRUNC('` + field + `', created_at) AS period, direction, MIN(currency),
+			   token_address, token_decimals, COUNT(*),
+			   COUNT(*) FILTER (WHERE status = 'confirmed'),
+			   COALESCE(SUM(amount) FILTER (WHERE status = 'confirmed'), 0)
+		FROM payments
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		GROUP BY period, direction, token_address, token_decimals
+		ORDER BY period, direction, token_address NULLS FIRST, token_decimals`
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	series := []model.PaymentSeriesPoint{}
+	for rows.Next() {
+		var point model.PaymentSeriesPoint
+		err := rows.Scan(&point.Period, &point.Direction, &point.Currency, &point.TokenAddress, &point.TokenDecimals, &point.Count, &point.ConfirmedCount, &point.ConfirmedAmount)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		series = append(series, point)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return series, nil
+}
+
+// GetTopCounterparties returns the addresses a user exchanged the most
+// confirmed payments with, busiest first
+func GetTopCounterparties(ctx context.Context, r PaymentAnalyticsRange, limit int) ([]model.CounterpartyTotal, error) {
+	db := database.New("")
+
+	conditions, args := r.conditions()
+	conditions = append(conditions, "status = 'confirmed'")
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT CASE WHEN direction = 'incoming' THEN from_address ELSE to_address END AS counterparty,
+			   direction, MIN(currency), token_address, token_decimals, COUNT(*), SUM(amount)
+		FROM payments
+		WHERE %s
+		GROUP BY counterparty, direction, token_address, token_decimals
+		ORDER BY COUNT(*) DESC, SUM(amount) DESC, counterparty
+		LIMIT $%d`, strings.Join(conditions, " AND "), len(args))
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	counterparties := []model.CounterpartyTotal{}
+	for rows.Next() {
+		var counterparty model.CounterpartyTotal
+		err := rows.Scan(&counterparty.Address, &counterparty.Direction, &counterparty.Currency, &counterparty.TokenAddress, &counterparty.TokenDecimals, &counterparty.Count, &counterparty.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		counterparties = append(counterparties, counterparty)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return counterparties, nil
+}
+
+// GetConfirmationTimeStats averages the time confirmed payments took from
+// creation to confirmation
+func GetConfirmationTimeStats(ctx context.Context, r PaymentAnalyticsRange) (*model.ConfirmationTimeStats, error) {
+	db := database.New("")
+
+	conditions, args := r.conditions()
+	query := `
+		SELECT COUNT(*), COALESCE(AVG(EXTRACT(EPOCH FROM confirmed_at - created_at)), 0)::float8
+		FROM payments
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		  AND status = 'confirmed' AND confirmed_at IS NOT NULL`
+
+	stats := &model.ConfirmationTimeStats{}
+	err := db.QueryRowContext(ctx, query, args...).Scan(&stats.ConfirmedPayments, &stats.AverageSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return stats, nil
+}
+
+// GetGasSpent sums the gas used by a user's outgoing payments and the fee
+// paid for it. Gas of incoming payments was paid by their senders.
+func GetGasSpent(ctx context.Context, r PaymentAnalyticsRange) (*model.GasSpent, error) {
+	db := database.New("")
+
+	conditions, args := r.conditions()
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(gas_used), 0)::bigint, COALESCE(SUM(gas_fee), 0)
+		FROM payments
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		  AND direction = 'outgoing' AND gas_fee IS NOT NULL`
+
+	gas := &model.GasSpent{}
+	err := db.QueryRowContext(ctx, query, args...).Scan(&gas.Payments, &gas.GasUsed, &gas.Fee)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return gas, nil
+}
+
+// GetMonthlyFees sums the fees paid for a user's outgoing payments per month
+// of creation. The base and priority fee sums cover the payments whose split
+// is known.
+func GetMonthlyFees(ctx context.Context, r PaymentAnalyticsRange) ([]model.MonthlyFees, error) {
+	db := database.New("")
+
+	conditions, args := r.conditions()
+	query := `
+		SELECT DATE_TRUNC('month', created_at) AS month, COUNT(*), COALESCE(SUM(gas_used), 0)::bigint,
+			   COALESCE(SUM(gas_fee), 0),
+			   COALESCE(SUM(gas_used * base_fee_per_gas), 0),
+			   COALESCE(SUM(gas_used * priority_fee_per_gas), 0)
+		FROM payments
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		  AND direction = 'outgoing' AND gas_fee IS NOT NULL
+		GROUP BY month
+		ORDER BY month`
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	months := []model.MonthlyFees{}
+	for rows.Next() {
+		var month model.MonthlyFees
+		err := rows.Scan(&month.Month, &month.Payments, &month.GasUsed, &month.TotalFee, &month.BaseFee, &month.PriorityFee)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		months = append(months, month)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return months, nil
+}
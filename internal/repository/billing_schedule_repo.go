@@ -0,0 +1,562 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/model"
+	"backend/internal/pubsub"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// billingScheduleColumns lists the billing_schedules columns in the order
+// scanBillingSchedule reads them
+const billingScheduleColumns = `id, user_id, payer_address, payee_address, amount, currency,
+			   token_address, token_decimals, billing_interval, grace_period_days, description,
+			   status, starts_at, ends_at, periods_generated, next_period_at, created_at, updated_at`
+
+// billingPeriodColumns lists the billing_periods columns in the order
+// scanBillingPeriod reads them
+const billingPeriodColumns = `id, schedule_id, period_number, period_start, period_end, due_at,
+			   amount, paid_amount, status, paid_at, created_at, updated_at`
+
+var (
+	ErrorBillingScheduleNotFound      = errors.New("billing schedule not found")
+	ErrorBillingScheduleNotCancelable = errors.New("only active billing schedules can be cancelled")
+	ErrorBillingScheduleChanged       = errors.New("billing schedule changed concurrently")
+	ErrorBillingPeriodNotFound        = errors.New("billing period not found")
+)
+
+// scanBillingSchedule reads a schedule selected with billingScheduleColumns
+func scanBillingSchedule(row rowScanner) (*model.BillingSchedule, error) {
+	schedule := &model.BillingSchedule{}
+	err := row.Scan(
+		&schedule.ID,
+		&schedule.UserID,
+		&schedule.PayerAddress,
+		&schedule.PayeeAddress,
+		&schedule.Amount,
+		&schedule.Currency,
+		&schedule.TokenAddress,
+		&schedule.TokenDecimals,
+		&schedule.Interval,
+		&schedule.GracePeriodDays,
+		&schedule.Description,
+		&schedule.Status,
+		&schedule.StartsAt,
+		&schedule.EndsAt,
+		&schedule.PeriodsGenerated,
+		&schedule.NextPeriodAt,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func scanBillingSchedules(rows *sql.Rows) ([]*model.BillingSchedule, error) {
+	var schedules []*model.BillingSchedule
+	for rows.Next() {
+		schedule, err := scanBillingSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return schedules, nil
+}
+
+// scanBillingPeriod reads a period selected with billingPeriodColumns
+func scanBillingPeriod(row rowScanner) (*model.BillingPeriod, error) {
+	period := &model.BillingPeriod{}
+	err := row.Scan(
+		&period.ID,
+		&period.ScheduleID,
+		&period.PeriodNumber,
+		&period.PeriodStart,
+		&period.PeriodEnd,
+		&period.DueAt,
+		&period.Amount,
+		&period.PaidAmount,
+		&period.Status,
+		&period.PaidAt,
+		&period.CreatedAt,
+		&period.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return period, nil
+}
+
+func scanBillingPeriods(rows *sql.Rows) ([]*model.BillingPeriod, error) {
+	var periods []*model.BillingPeriod
+	for rows.Next() {
+		period, err := scanBillingPeriod(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		periods = append(periods, period)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return periods, nil
+}
+
+// CreateBillingSchedule creates a new billing schedule record in the database
+func CreateBillingSchedule(ctx context.Context, schedule *model.BillingSchedule) error {
+	db := database.New("")
+
+	query := `
+		INSERT INTO billing_schedules (
+			id, user_id, payer_address, payee_address, amount, currency,
+			token_address, token_decimals, billing_interval, grace_period_days, description,
+			status, starts_at, ends_at, periods_generated, next_period_at, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18
+		)`
+
+	_, err := db.ExecContext(ctx, query,
+		schedule.ID,
+		schedule.UserID,
+		schedule.PayerAddress,
+		schedule.PayeeAddress,
+		schedule.Amount,
+		schedule.Currency,
+		schedule.TokenAddress,
+		schedule.TokenDecimals,
+		schedule.Interval,
+		schedule.GracePeriodDays,
+		schedule.Description,
+		schedule.Status,
+		schedule.StartsAt,
+		schedule.EndsAt,
+		schedule.PeriodsGenerated,
+		schedule.NextPeriodAt,
+		schedule.CreatedAt,
+		schedule.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return nil
+}
+
+// GetBillingScheduleByID retrieves a billing schedule by its ID
+func GetBillingScheduleByID(ctx context.Context, scheduleID uuid.UUID) (*model.BillingSchedule, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + billingScheduleColumns + `
+		FROM billing_schedules
+		WHERE id = $1`
+
+	schedule, err := scanBillingSchedule(db.QueryRowContext(ctx, query, scheduleID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrorBillingScheduleNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return schedule, nil
+}
+
+// GetBillingSchedulesByUserID retrieves a user's billing schedules, newest
+// first, optionally filtered by status
+func GetBillingSchedulesByUserID(ctx context.Context, userID uuid.UUID, status *model.BillingScheduleStatus) ([]*model.BillingSchedule, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + billingScheduleColumns + `
+		FROM billing_schedules
+		WHERE user_id = $1 AND ($2::text IS NULL OR status = $2)
+		ORDER BY created_at DESC`
+
+	rows, err := db.QueryContext(ctx, query, userID, status)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	return scanBillingSchedules(rows)
+}
+
+// GetBillingSchedulesByIDs retrieves the billing schedules with the given IDs
+func GetBillingSchedulesByIDs(ctx context.Context, scheduleIDs []uuid.UUID) ([]*model.BillingSchedule, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + billingScheduleColumns + `
+		FROM billing_schedules
+		WHERE id = ANY($1)`
+
+	rows, err := db.QueryContext(ctx, query, scheduleIDs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	return scanBillingSchedules(rows)
+}
+
+// GetBillingSchedulesDueForPeriods retrieves the active schedules whose next
+// period has started
+func GetBillingSchedulesDueForPeriods(ctx context.Context) ([]*model.BillingSchedule, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + billingScheduleColumns + `
+		FROM billing_schedules
+		WHERE status = $1 AND next_period_at <= NOW()
+		ORDER BY next_period_at ASC`
+
+	rows, err := db.QueryContext(ctx, query, model.BillingScheduleStatusActive)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	return scanBillingSchedules(rows)
+}
+
+// CancelBillingSchedule cancels an active billing schedule owned by the user.
+// Periods already generated stay payable.
+func CancelBillingSchedule(ctx context.Context, userID uuid.UUID, scheduleID uuid.UUID) error {
+	db := database.New("")
+
+	query := `
+		UPDATE billing_schedules
+		SET status = $1, next_period_at = NULL, updated_at = NOW()
+		WHERE id = $2 AND user_id = $3 AND status = $4`
+
+	result, err := db.ExecContext(ctx, query, model.BillingScheduleStatusCancelled, scheduleID, userID, model.BillingScheduleStatusActive)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrorBillingScheduleNotCancelable
+	}
+
+	return nil
+}
+
+// CreateBillingPeriods stores newly started periods of a schedule and
+// advances the schedule past them in one transaction. schedule holds the
+// advanced state; previouslyGenerated is the period count it was read with,
+// so a schedule advanced or cancelled concurrently fails with
+// ErrorBillingScheduleChanged and nothing is stored.
+func CreateBillingPeriods(ctx context.Context, schedule *model.BillingSchedule, previouslyGenerated int, periods []*model.BillingPeriod) error {
+	db := database.New("")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer tx.Rollback()
+
+	updateQuery := `
+		UPDATE billing_schedules
+		SET periods_generated = $1, next_period_at = $2, status = $3, updated_at = NOW()
+		WHERE id = $4 AND periods_generated = $5 AND status = $6`
+
+	result, err := tx.ExecContext(ctx, updateQuery,
+		schedule.PeriodsGenerated,
+		schedule.NextPeriodAt,
+		schedule.Status,
+		schedule.ID,
+		previouslyGenerated,
+		model.BillingScheduleStatusActive,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	if rowsAffected == 0 {
+		return ErrorBillingScheduleChanged
+	}
+
+	insertQuery := `
+		INSERT INTO billing_periods (
+			id, schedule_id, period_number, period_start, period_end, due_at,
+			amount, paid_amount, status, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+		)`
+
+	for _, period := range periods {
+		_, err := tx.ExecContext(ctx, insertQuery,
+			period.ID,
+			period.ScheduleID,
+			period.PeriodNumber,
+			period.PeriodStart,
+			period.PeriodEnd,
+			period.DueAt,
+			period.Amount,
+			period.PaidAmount,
+			period.Status,
+			period.CreatedAt,
+			period.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return nil
+}
+
+// GetBillingPeriodsByScheduleID retrieves a schedule's periods, oldest first
+func GetBillingPeriodsByScheduleID(ctx context.Context, scheduleID uuid.UUID) ([]*model.BillingPeriod, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + billingPeriodColumns + `
+		FROM billing_periods
+		WHERE schedule_id = $1
+		ORDER BY period_number ASC`
+
+	rows, err := db.QueryContext(ctx, query, scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	return scanBillingPeriods(rows)
+}
+
+// GetBillingPeriodByID retrieves a billing period by its ID
+func GetBillingPeriodByID(ctx context.Context, periodID uuid.UUID) (*model.BillingPeriod, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + billingPeriodColumns + `
+		FROM billing_periods
+		WHERE id = $1`
+
+	period, err := scanBillingPeriod(db.QueryRowContext(ctx, query, periodID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrorBillingPeriodNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return period, nil
+}
+
+// GetPayableBillingPeriods retrieves all periods that still accept payments,
+// oldest first
+func GetPayableBillingPeriods(ctx context.Context) ([]*model.BillingPeriod, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + billingPeriodColumns + `
+		FROM billing_periods
+		WHERE status = ANY($1)
+		ORDER BY period_start ASC, period_number ASC`
+
+	statuses := []string{string(model.BillingPeriodStatusDue), string(model.BillingPeriodStatusOverdue)}
+	rows, err := db.QueryContext(ctx, query, statuses)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	return scanBillingPeriods(rows)
+}
+
+// MarkOverdueBillingPeriods marks due periods past their due date as overdue
+// and returns how many changed
+func MarkOverdueBillingPeriods(ctx context.Context) (int64, error) {
+	db := database.New("")
+
+	query := `
+		UPDATE billing_periods
+		SET status = $1, updated_at = NOW()
+		WHERE status = $2 AND due_at <= NOW()`
+
+	result, err := db.ExecContext(ctx, query, model.BillingPeriodStatusOverdue, model.BillingPeriodStatusDue)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return rowsAffected, nil
+}
+
+// GetPaymentsByBillingScheduleID retrieves the payments matched to any period
+// of a schedule, oldest first
+func GetPaymentsByBillingScheduleID(ctx context.Context, scheduleID uuid.UUID) ([]*model.Payment, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE billing_period_id IN (SELECT id FROM billing_periods WHERE schedule_id = $1)
+		ORDER BY created_at ASC`
+
+	rows, err := db.QueryContext(ctx, query, scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	return scanPayments(rows)
+}
+
+// RecordBillingPeriodPayment stores a payment matched to a billing period,
+// crediting the period in the same transaction if the payment is already
+// confirmed. Like RecordInvoicePayment, a transfer the incoming payment
+// indexer already recorded is attached to the period instead, and
+// ErrorDuplicateTransaction is returned if the owner's payment for the
+// transaction is already matched.
+func RecordBillingPeriodPayment(ctx context.Context, payment *model.Payment) error {
+	db := database.New("")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer tx.Rollback()
+
+	// xmax is zero for a freshly inserted row and set for one claimed by the update
+	var inserted bool
+	stored := *payment
+	err = tx.QueryRowContext(ctx, insertPaymentQuery+`
+		ON CONFLICT (transaction_hash, user_id, direction) DO UPDATE
+		SET billing_period_id = EXCLUDED.billing_period_id, description = EXCLUDED.description, updated_at = NOW()
+		WHERE payments.billing_period_id IS NULL AND payments.invoice_id IS NULL
+		RETURNING (xmax = 0), id, status, amount`, paymentInsertArgs(payment)...).Scan(&inserted, &stored.ID, &stored.Status, &stored.Amount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrorDuplicateTransaction
+		}
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	// A claimed payment already announced itself when it was indexed
+	if inserted {
+		if err := enqueuePaymentEvents(ctx, tx, payment, model.PaymentWebhookEvents(nil, payment.Status)...); err != nil {
+			return err
+		}
+	}
+
+	// The stored payment was never credited, whatever its status
+	if err := syncBillingPeriodCredit(ctx, tx, model.PaymentStatusPending, &stored); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if inserted {
+		pubsub.Default().Publish(model.NewPaymentCreatedEvent(payment))
+	}
+	return nil
+}
+
+// syncBillingPeriodCredit keeps the paid amount of the billing period a
+// payment is matched to, if any, in line with the payment's status as part of
+// tx, like syncInvoiceCredit. A payment that confirms once its period is
+// already paid is detached from it.
+func syncBillingPeriodCredit(ctx context.Context, tx *sql.Tx, previous model.PaymentStatus, payment *model.Payment) error {
+	if payment.BillingPeriodID == nil {
+		return nil
+	}
+
+	switch model.SettlementChange(previous, payment.Status) {
+	case 1:
+		accepting := []string{string(model.BillingPeriodStatusDue), string(model.BillingPeriodStatusOverdue)}
+		applied, err := adjustBillingPeriodPaidAmount(ctx, tx, *payment.BillingPeriodID, payment.Amount, accepting)
+		if err != nil || applied {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE payments SET billing_period_id = NULL, updated_at = NOW() WHERE id = $1", payment.ID)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		payment.BillingPeriodID = nil
+	case -1:
+		allStatuses := []string{string(model.BillingPeriodStatusDue), string(model.BillingPeriodStatusOverdue), string(model.BillingPeriodStatusPaid)}
+		if _, err := adjustBillingPeriodPaidAmount(ctx, tx, *payment.BillingPeriodID, model.Amount{}.Sub(payment.Amount), allStatuses); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// adjustBillingPeriodPaidAmount adds delta to the paid amount of a billing
+// period in one of statuses and settles it accordingly. It reports whether
+// the period was in one of statuses.
+func adjustBillingPeriodPaidAmount(ctx context.Context, tx *sql.Tx, periodID uuid.UUID, delta model.Amount, statuses []string) (bool, error) {
+	query := `
+		SELECT ` + billingPeriodColumns + `
+		FROM billing_periods
+		WHERE id = $1 AND status = ANY($2)
+		FOR UPDATE`
+
+	period, err := scanBillingPeriod(tx.QueryRowContext(ctx, query, periodID, statuses))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	status := period.Settle(period.PaidAmount.Add(delta), time.Now())
+
+	updateQuery := `
+		UPDATE billing_periods
+		SET paid_amount = paid_amount + $1, status = $2,
+			paid_at = CASE WHEN $2 = $3 THEN COALESCE(paid_at, NOW()) END, updated_at = NOW()
+		WHERE id = $4 AND status = ANY($5)`
+
+	result, err := tx.ExecContext(ctx, updateQuery, delta, status, model.BillingPeriodStatusPaid, periodID, statuses)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return rowsAffected > 0, nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// GetChainScanCursor returns the last block processed by a named block
+// scanner. found is false if the scanner has never run.
+func GetChainScanCursor(ctx context.Context, name string) (blockNumber int64, found bool, err error) {
+	db := database.New("")
+
+	query := "SELECT block_number FROM chain_scan_cursors WHERE name = $1"
+	err = db.QueryRowContext(ctx, query, name).Scan(&blockNumber)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return blockNumber, true, nil
+}
+
+// SetChainScanCursor records the last block processed by a named block scanner
+func SetChainScanCursor(ctx context.Context, name string, blockNumber int64) error {
+	db := database.New("")
+
+	query := `
+		INSERT INTO chain_scan_cursors (name, block_number, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET block_number = EXCLUDED.block_number, updated_at = EXCLUDED.updated_at`
+
+	_, err := db.ExecContext(ctx, query, name, blockNumber, time.Now())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return nil
+}
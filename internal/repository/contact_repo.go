@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// contactColumns lists the contacts columns in the order scanContact reads them
+const contactColumns = `id, user_id, name, address, phone_number, username, notes, created_at, updated_at`
+
+// contactAddressIndex keeps a user from saving the same address twice
+const contactAddressIndex = "idx_contacts_user_address"
+
+var (
+	ErrorContactNotFound      = errors.New("contact not found")
+	ErrorContactAddressExists = errors.New("a contact with this address already exists")
+)
+
+// scanContact reads a contact selected with contactColumns
+func scanContact(row rowScanner) (*model.Contact, error) {
+	contact := &model.Contact{}
+	err := row.Scan(
+		&contact.ID,
+		&contact.UserID,
+		&contact.Name,
+		&contact.Address,
+		&contact.PhoneNumber,
+		&contact.Username,
+		&contact.Notes,
+		&contact.CreatedAt,
+		&contact.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return contact, nil
+}
+
+// contactWriteError maps a failed insert or update of a contact
+func contactWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == contactAddressIndex {
+		return ErrorContactAddressExists
+	}
+	return fmt.Errorf("%w: %v", ErrorDatabase, err)
+}
+
+// CreateContact creates a new contact record in the database
+func CreateContact(ctx context.Context, contact *model.Contact) error {
+	db := database.New("")
+
+	query := `
+		INSERT INTO contacts (
+			id, user_id, name, address, phone_number, username, notes, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		)`
+
+	_, err := db.ExecContext(ctx, query,
+		contact.ID,
+		contact.UserID,
+		contact.Name,
+		contact.Address,
+		contact.PhoneNumber,
+		contact.Username,
+		contact.Notes,
+		contact.CreatedAt,
+		contact.UpdatedAt,
+	)
+	if err != nil {
+		return contactWriteError(err)
+	}
+
+	return nil
+}
+
+// GetContact retrieves one of a user's contacts
+func GetContact(ctx context.Context, userID uuid.UUID, contactID uuid.UUID) (*model.Contact, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + contactColumns + `
+		FROM contacts
+		WHERE id = $1 AND user_id = $2`
+
+	contact, err := scanContact(db.QueryRowContext(ctx, query, contactID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrorContactNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return contact, nil
+}
+
+// GetContactsByUserID retrieves a user's contacts ordered by name. A search
+// term keeps the contacts whose name, address, phone number or username
+// contains it.
+func GetContactsByUserID(ctx context.Context, userID uuid.UUID, search *string) ([]*model.Contact, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + contactColumns + `
+		FROM contacts
+		WHERE user_id = $1 AND ($2::text IS NULL
+			OR name ILIKE '%' || $2 || '%'
+			OR address ILIKE '%' || $2 || '%'
+			OR phone_number ILIKE '%' || $2 || '%'
+			OR username ILIKE '%' || $2 || '%')
+		ORDER BY LOWER(name) ASC, created_at ASC`
+
+	rows, err := db.QueryContext(ctx, query, userID, search)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	var contacts []*model.Contact
+	for rows.Next() {
+		contact, err := scanContact(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		contacts = append(contacts, contact)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return contacts, nil
+}
+
+// UpdateContact replaces the details of one of a user's contacts
+func UpdateContact(ctx context.Context, contact *model.Contact) error {
+	db := database.New("")
+
+	query := `
+		UPDATE contacts
+		SET name = $1, address = $2, phone_number = $3, username = $4, notes = $5, updated_at = $6
+		WHERE id = $7 AND user_id = $8
+		RETURNING created_at`
+
+	err := db.QueryRowContext(ctx, query,
+		contact.Name,
+		contact.Address,
+		contact.PhoneNumber,
+		contact.Username,
+		contact.Notes,
+		contact.UpdatedAt,
+		contact.ID,
+		contact.UserID,
+	).Scan(&contact.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrorContactNotFound
+		}
+		return contactWriteError(err)
+	}
+
+	return nil
+}
+
+// DeleteContact removes one of a user's contacts
+func DeleteContact(ctx context.Context, userID uuid.UUID, contactID uuid.UUID) error {
+	db := database.New("")
+
+	query := "DELETE FROM contacts WHERE id = $1 AND user_id = $2"
+	result, err := db.ExecContext(ctx, query, contactID, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrorContactNotFound
+	}
+
+	return nil
+}
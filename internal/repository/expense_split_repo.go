@@ -0,0 +1,438 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/model"
+	"backend/internal/pubsub"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// expenseSplitColumns lists the expense_splits columns, joined with the
+// organizer's user row, in the order scanExpenseSplit reads them
+const expenseSplitColumns = `e.id, e.user_id, e.payee_address, e.total_amount, e.currency,
+			   e.token_address, e.token_decimals, e.split_method, e.description,
+			   e.created_at, e.updated_at, organizer.username, organizer.phone_number`
+
+// splitShareColumns lists the split_shares columns, joined with the
+// participant's user row, in the order scanSplitShare reads them
+const splitShareColumns = `s.id, s.split_id, s.participant_id, s.amount, s.status, s.payment_id,
+			   s.transaction_hash, s.settled_at, s.reminder_count, s.last_reminded_at,
+			   s.created_at, s.updated_at, participant.username, participant.phone_number`
+
+var (
+	ErrorExpenseSplitNotFound = errors.New("expense split not found")
+	ErrorSplitShareNotFound   = errors.New("split share not found")
+	ErrorSplitShareNotPayable = errors.New("split share is already paid")
+	ErrorNoOutstandingShares  = errors.New("expense split has no outstanding shares")
+	ErrorDuplicateParticipant = errors.New("a participant can only have one share of an expense split")
+)
+
+// splitShareParticipantConstraint is the unique constraint allowing one share
+// per participant and split
+const splitShareParticipantConstraint = "split_shares_split_id_participant_id_key"
+
+// scanExpenseSplit reads a split selected with expenseSplitColumns
+func scanExpenseSplit(row rowScanner) (*model.ExpenseSplit, error) {
+	split := &model.ExpenseSplit{}
+	err := row.Scan(
+		&split.ID,
+		&split.UserID,
+		&split.PayeeAddress,
+		&split.TotalAmount,
+		&split.Currency,
+		&split.TokenAddress,
+		&split.TokenDecimals,
+		&split.Method,
+		&split.Description,
+		&split.CreatedAt,
+		&split.UpdatedAt,
+		&split.OrganizerUsername,
+		&split.OrganizerPhone,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return split, nil
+}
+
+func scanExpenseSplits(rows *sql.Rows) ([]*model.ExpenseSplit, error) {
+	var splits []*model.ExpenseSplit
+	for rows.Next() {
+		split, err := scanExpenseSplit(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		splits = append(splits, split)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return splits, nil
+}
+
+// scanSplitShare reads a share selected with splitShareColumns
+func scanSplitShare(row rowScanner) (*model.SplitShare, error) {
+	share := &model.SplitShare{}
+	err := row.Scan(
+		&share.ID,
+		&share.SplitID,
+		&share.ParticipantID,
+		&share.Amount,
+		&share.Status,
+		&share.PaymentID,
+		&share.TransactionHash,
+		&share.SettledAt,
+		&share.ReminderCount,
+		&share.LastRemindedAt,
+		&share.CreatedAt,
+		&share.UpdatedAt,
+		&share.ParticipantUsername,
+		&share.ParticipantPhone,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+func scanSplitShares(rows *sql.Rows) ([]*model.SplitShare, error) {
+	var shares []*model.SplitShare
+	for rows.Next() {
+		share, err := scanSplitShare(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		shares = append(shares, share)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return shares, nil
+}
+
+// CreateExpenseSplit creates an expense split and its shares in one transaction
+func CreateExpenseSplit(ctx context.Context, split *model.ExpenseSplit, shares []*model.SplitShare) error {
+	db := database.New("")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer tx.Rollback()
+
+	splitQuery := `
+		INSERT INTO expense_splits (
+			id, user_id, payee_address, total_amount, currency, token_address,
+			token_decimals, split_method, description, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+		)`
+
+	_, err = tx.ExecContext(ctx, splitQuery,
+		split.ID,
+		split.UserID,
+		split.PayeeAddress,
+		split.TotalAmount,
+		split.Currency,
+		split.TokenAddress,
+		split.TokenDecimals,
+		split.Method,
+		split.Description,
+		split.CreatedAt,
+		split.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	shareQuery := `
+		INSERT INTO split_shares (
+			id, split_id, participant_id, amount, status, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		)`
+
+	for _, share := range shares {
+		_, err = tx.ExecContext(ctx, shareQuery,
+			share.ID,
+			share.SplitID,
+			share.ParticipantID,
+			share.Amount,
+			share.Status,
+			share.CreatedAt,
+			share.UpdatedAt,
+		)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == splitShareParticipantConstraint {
+				return ErrorDuplicateParticipant
+			}
+			return fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return nil
+}
+
+// GetExpenseSplitByID retrieves an expense split by its ID
+func GetExpenseSplitByID(ctx context.Context, splitID uuid.UUID) (*model.ExpenseSplit, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + expenseSplitColumns + `
+		FROM expense_splits e
+		JOIN users organizer ON organizer.id = e.user_id
+		WHERE e.id = $1`
+
+	split, err := scanExpenseSplit(db.QueryRowContext(ctx, query, splitID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrorExpenseSplitNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return split, nil
+}
+
+// GetExpenseSplitsByIDs retrieves the expense splits with the given IDs
+func GetExpenseSplitsByIDs(ctx context.Context, splitIDs []uuid.UUID) ([]*model.ExpenseSplit, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + expenseSplitColumns + `
+		FROM expense_splits e
+		JOIN users organizer ON organizer.id = e.user_id
+		WHERE e.id = ANY($1)`
+
+	rows, err := db.QueryContext(ctx, query, splitIDs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	return scanExpenseSplits(rows)
+}
+
+// GetExpenseSplitsByUserID retrieves the expense splits a user organized,
+// newest first. An open status keeps splits with a share that is not settled
+// yet; a settled status keeps the others.
+func GetExpenseSplitsByUserID(ctx context.Context, userID uuid.UUID, status *model.ExpenseSplitStatus) ([]*model.ExpenseSplit, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + expenseSplitColumns + `
+		FROM expense_splits e
+		JOIN users organizer ON organizer.id = e.user_id
+		WHERE e.user_id = $1
+		  AND ($2::text IS NULL OR ($2 = $3) = EXISTS (
+			SELECT 1 FROM split_shares s WHERE s.split_id = e.id AND s.status <> $4
+		  ))
+		ORDER BY e.created_at DESC`
+
+	rows, err := db.QueryContext(ctx, query, userID, status, model.ExpenseSplitStatusOpen, model.SplitShareStatusSettled)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	return scanExpenseSplits(rows)
+}
+
+// GetSplitSharesBySplitIDs retrieves the shares of the given expense splits,
+// grouped by split and ordered by participant username
+func GetSplitSharesBySplitIDs(ctx context.Context, splitIDs []uuid.UUID) (map[uuid.UUID][]*model.SplitShare, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + splitShareColumns + `
+		FROM split_shares s
+		JOIN users participant ON participant.id = s.participant_id
+		WHERE s.split_id = ANY($1)
+		ORDER BY participant.username ASC`
+
+	rows, err := db.QueryContext(ctx, query, splitIDs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	shares, err := scanSplitShares(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	bySplit := make(map[uuid.UUID][]*model.SplitShare, len(splitIDs))
+	for _, share := range shares {
+		bySplit[share.SplitID] = append(bySplit[share.SplitID], share)
+	}
+
+	return bySplit, nil
+}
+
+// GetSplitShareByID retrieves a split share by its ID
+func GetSplitShareByID(ctx context.Context, shareID uuid.UUID) (*model.SplitShare, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + splitShareColumns + `
+		FROM split_shares s
+		JOIN users participant ON participant.id = s.participant_id
+		WHERE s.id = $1`
+
+	share, err := scanSplitShare(db.QueryRowContext(ctx, query, shareID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrorSplitShareNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return share, nil
+}
+
+// GetSplitSharesByParticipantID retrieves the shares a user owes, newest
+// first, optionally filtered by status
+func GetSplitSharesByParticipantID(ctx context.Context, participantID uuid.UUID, status *model.SplitShareStatus) ([]*model.SplitShare, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + splitShareColumns + `
+		FROM split_shares s
+		JOIN users participant ON participant.id = s.participant_id
+		WHERE s.participant_id = $1 AND ($2::text IS NULL OR s.status = $2)
+		ORDER BY s.created_at DESC`
+
+	rows, err := db.QueryContext(ctx, query, participantID, status)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	return scanSplitShares(rows)
+}
+
+// CreatePaymentForSplitShare stores a payment and binds it to an outstanding
+// share owed by the payment's owner in one transaction. The share becomes
+// pending, or settled if the payment is already confirmed.
+// ErrorSplitShareNotPayable is returned, and nothing is stored, if the share
+// was already paid.
+func CreatePaymentForSplitShare(ctx context.Context, payment *model.Payment, shareID uuid.UUID) error {
+	db := database.New("")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer tx.Rollback()
+
+	if err := insertPayment(ctx, tx, payment); err != nil {
+		return err
+	}
+
+	var settledAt *time.Time
+	status := model.SplitShareStatusFor(payment.Status)
+	if status == model.SplitShareStatusSettled {
+		settledAt = payment.ConfirmedAt
+	}
+
+	query := `
+		UPDATE split_shares
+		SET status = $1, payment_id = $2, transaction_hash = $3, settled_at = $4, updated_at = NOW()
+		WHERE id = $5 AND participant_id = $6 AND status = $7`
+
+	result, err := tx.ExecContext(ctx, query,
+		status,
+		payment.ID,
+		payment.TransactionHash,
+		settledAt,
+		shareID,
+		payment.UserID,
+		model.SplitShareStatusOutstanding,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrorSplitShareNotPayable
+	}
+
+	if err := enqueuePaymentEvents(ctx, tx, payment, model.PaymentWebhookEvents(nil, payment.Status)...); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	pubsub.Default().Publish(model.NewPaymentCreatedEvent(payment))
+	return nil
+}
+
+// syncSplitShare brings the share paid by a payment, if any, in line with
+// the payment's status as part of tx. A share whose payment failed or was
+// cancelled is released, so the participant can pay it again.
+func syncSplitShare(ctx context.Context, tx *sql.Tx, payment *model.Payment) error {
+	status := model.SplitShareStatusFor(payment.Status)
+
+	query := `
+		UPDATE split_shares
+		SET status = $1,
+			payment_id = CASE WHEN $1 = $2 THEN NULL ELSE payment_id END,
+			transaction_hash = CASE WHEN $1 = $2 THEN NULL ELSE transaction_hash END,
+			settled_at = CASE WHEN $1 = $3 THEN COALESCE(settled_at, NOW()) END,
+			updated_at = NOW()
+		WHERE payment_id = $4 AND status <> $1`
+
+	_, err := tx.ExecContext(ctx, query, status, model.SplitShareStatusOutstanding, model.SplitShareStatusSettled, payment.ID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return nil
+}
+
+// RecordSplitReminders marks the outstanding shares of a split whose
+// participants were not reminded within cooldown as reminded now, and
+// returns them
+func RecordSplitReminders(ctx context.Context, splitID uuid.UUID, cooldown time.Duration) ([]*model.SplitShare, error) {
+	db := database.New("")
+
+	query := `
+		UPDATE split_shares s
+		SET reminder_count = s.reminder_count + 1, last_reminded_at = NOW(), updated_at = NOW()
+		FROM users participant
+		WHERE participant.id = s.participant_id
+		  AND s.split_id = $1 AND s.status = $2
+		  AND (s.last_reminded_at IS NULL OR s.last_reminded_at <= NOW() - make_interval(secs => $3))
+		RETURNING ` + splitShareColumns
+
+	rows, err := db.QueryContext(ctx, query, splitID, model.SplitShareStatusOutstanding, cooldown.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	return scanSplitShares(rows)
+}
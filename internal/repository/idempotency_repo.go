@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrorIdempotencyKeyNotFound = errors.New("idempotency key not found")
+
+// ClaimIdempotencyKey reserves a key for a request about to be processed. A
+// key can be claimed when it is new, when its retention window has passed or
+// when a previous claim was never completed within lockTimeout, e.g. because
+// the server stopped mid-request. It returns nil if the key was claimed and
+// otherwise the key as currently stored.
+func ClaimIdempotencyKey(ctx context.Context, userID uuid.UUID, key, requestHash string, ttl, lockTimeout time.Duration) (*model.IdempotencyKey, error) {
+	db := database.New("")
+
+	claimQuery := `
+		INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, NOW(), NOW() + $4 * INTERVAL '1 second')
+		ON CONFLICT (user_id, idempotency_key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+			status_code = NULL,
+			content_type = NULL,
+			response_body = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()
+			OR (idempotency_keys.status_code IS NULL
+				AND idempotency_keys.created_at <= NOW() - $5 * INTERVAL '1 second')`
+
+	result, err := db.ExecContext(ctx, claimQuery, userID, key, requestHash, ttl.Seconds(), lockTimeout.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	if claimed > 0 {
+		return nil, nil
+	}
+
+	existing := &model.IdempotencyKey{}
+	err = db.QueryRowContext(ctx, `
+		SELECT user_id, idempotency_key, request_hash, status_code, content_type,
+			   response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2`, userID, key).Scan(
+		&existing.UserID,
+		&existing.Key,
+		&existing.RequestHash,
+		&existing.StatusCode,
+		&existing.ContentType,
+		&existing.ResponseBody,
+		&existing.CreatedAt,
+		&existing.ExpiresAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// Released between the claim and the read
+			return nil, ErrorIdempotencyKeyNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return existing, nil
+}
+
+// CompleteIdempotencyKey stores the response to a claimed request
+func CompleteIdempotencyKey(ctx context.Context, userID uuid.UUID, key string, statusCode int, contentType string, body []byte) error {
+	db := database.New("")
+
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $1, content_type = $2, response_body = $3
+		WHERE user_id = $4 AND idempotency_key = $5 AND status_code IS NULL`
+
+	result, err := db.ExecContext(ctx, query, statusCode, contentType, body, userID, key)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	if rowsAffected == 0 {
+		return ErrorIdempotencyKeyNotFound
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey drops a claim whose request did not complete, so the
+// request can be retried under the same key
+func ReleaseIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) error {
+	db := database.New("")
+
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2 AND status_code IS NULL`
+
+	if _, err := db.ExecContext(ctx, query, userID, key); err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys removes keys past their retention window and
+// returns how many were removed
+func DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	db := database.New("")
+
+	result, err := db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return deleted, nil
+}
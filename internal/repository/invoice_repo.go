@@ -0,0 +1,354 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/model"
+	"backend/internal/pubsub"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// invoiceColumns lists the invoices table columns in the order scanInvoice reads them
+const invoiceColumns = `id, user_id, recipient_address, amount, paid_amount, currency,
+			   token_address, token_decimals, memo, status, expires_at, paid_at,
+			   created_at, updated_at`
+
+var (
+	ErrorInvoiceNotFound      = errors.New("invoice not found")
+	ErrorInvoiceNotCancelable = errors.New("only open invoices can be cancelled")
+)
+
+// scanInvoice reads an invoice selected with invoiceColumns
+func scanInvoice(row rowScanner) (*model.Invoice, error) {
+	invoice := &model.Invoice{}
+	err := row.Scan(
+		&invoice.ID,
+		&invoice.UserID,
+		&invoice.RecipientAddress,
+		&invoice.Amount,
+		&invoice.PaidAmount,
+		&invoice.Currency,
+		&invoice.TokenAddress,
+		&invoice.TokenDecimals,
+		&invoice.Memo,
+		&invoice.Status,
+		&invoice.ExpiresAt,
+		&invoice.PaidAt,
+		&invoice.CreatedAt,
+		&invoice.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// CreateInvoice creates a new invoice record in the database
+func CreateInvoice(ctx context.Context, invoice *model.Invoice) error {
+	db := database.New("")
+
+	query := `
+		INSERT INTO invoices (
+			id, user_id, recipient_address, amount, paid_amount, currency,
+			token_address, token_decimals, memo, status, expires_at,
+			created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+		)`
+
+	_, err := db.ExecContext(ctx, query,
+		invoice.ID,
+		invoice.UserID,
+		invoice.RecipientAddress,
+		invoice.Amount,
+		invoice.PaidAmount,
+		invoice.Currency,
+		invoice.TokenAddress,
+		invoice.TokenDecimals,
+		invoice.Memo,
+		invoice.Status,
+		invoice.ExpiresAt,
+		invoice.CreatedAt,
+		invoice.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return nil
+}
+
+// GetInvoiceByID retrieves an invoice by its ID
+func GetInvoiceByID(ctx context.Context, invoiceID uuid.UUID) (*model.Invoice, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + invoiceColumns + `
+		FROM invoices
+		WHERE id = $1`
+
+	invoice, err := scanInvoice(db.QueryRowContext(ctx, query, invoiceID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrorInvoiceNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return invoice, nil
+}
+
+// GetInvoicesByUserID retrieves a user's invoices, newest first, optionally filtered by status
+func GetInvoicesByUserID(ctx context.Context, userID uuid.UUID, status *model.InvoiceStatus) ([]*model.Invoice, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + invoiceColumns + `
+		FROM invoices
+		WHERE user_id = $1 AND ($2::text IS NULL OR status = $2)
+		ORDER BY created_at DESC`
+
+	rows, err := db.QueryContext(ctx, query, userID, status)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	return scanInvoices(rows)
+}
+
+// GetPayableInvoices retrieves all unexpired invoices that still accept payments, oldest first
+func GetPayableInvoices(ctx context.Context) ([]*model.Invoice, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + invoiceColumns + `
+		FROM invoices
+		WHERE status = ANY($1) AND expires_at > NOW()
+		ORDER BY created_at ASC`
+
+	statuses := []string{string(model.InvoiceStatusOpen), string(model.InvoiceStatusUnderpaid)}
+	rows, err := db.QueryContext(ctx, query, statuses)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	return scanInvoices(rows)
+}
+
+func scanInvoices(rows *sql.Rows) ([]*model.Invoice, error) {
+	var invoices []*model.Invoice
+	for rows.Next() {
+		invoice, err := scanInvoice(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		invoices = append(invoices, invoice)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return invoices, nil
+}
+
+// GetPaymentsByInvoiceID retrieves the payments matched to an invoice, oldest first
+func GetPaymentsByInvoiceID(ctx context.Context, invoiceID uuid.UUID) ([]*model.Payment, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE invoice_id = $1
+		ORDER BY created_at ASC`
+
+	rows, err := db.QueryContext(ctx, query, invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	return scanPayments(rows)
+}
+
+// CancelInvoice cancels an open invoice owned by the user
+func CancelInvoice(ctx context.Context, userID uuid.UUID, invoiceID uuid.UUID) error {
+	db := database.New("")
+
+	query := `
+		UPDATE invoices
+		SET status = $1, updated_at = NOW()
+		WHERE id = $2 AND user_id = $3 AND status = $4`
+
+	result, err := db.ExecContext(ctx, query, model.InvoiceStatusCancelled, invoiceID, userID, model.InvoiceStatusOpen)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrorInvoiceNotCancelable
+	}
+
+	return nil
+}
+
+// ExpireInvoices marks open invoices past their expiry as expired and returns
+// how many changed. Invoices with a matched payment that has yet to confirm
+// stay open until it does or fails.
+func ExpireInvoices(ctx context.Context) (int64, error) {
+	db := database.New("")
+
+	query := `
+		UPDATE invoices
+		SET status = $1, updated_at = NOW()
+		WHERE status = $2 AND expires_at <= NOW()
+		  AND NOT EXISTS (
+			SELECT 1 FROM payments
+			WHERE payments.invoice_id = invoices.id AND payments.status IN ('pending', 'confirming')
+		  )`
+
+	result, err := db.ExecContext(ctx, query, model.InvoiceStatusExpired, model.InvoiceStatusOpen)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return rowsAffected, nil
+}
+
+// RecordInvoicePayment stores a payment matched to an invoice, crediting the
+// invoice in the same transaction if the payment is already confirmed. If the
+// incoming payment indexer already recorded the transfer, that payment is
+// attached to the invoice instead. It returns ErrorDuplicateTransaction if
+// the invoice owner's payment for the transaction already belongs to an
+// invoice, which makes re-scanning the same blocks harmless.
+func RecordInvoicePayment(ctx context.Context, payment *model.Payment) error {
+	db := database.New("")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer tx.Rollback()
+
+	// xmax is zero for a freshly inserted row and set for one claimed by the update
+	var inserted bool
+	stored := *payment
+	err = tx.QueryRowContext(ctx, insertPaymentQuery+`
+		ON CONFLICT (transaction_hash, user_id, direction) DO UPDATE
+		SET invoice_id = EXCLUDED.invoice_id, description = EXCLUDED.description, updated_at = NOW()
+		WHERE payments.invoice_id IS NULL AND payments.billing_period_id IS NULL
+		RETURNING (xmax = 0), id, status, amount`, paymentInsertArgs(payment)...).Scan(&inserted, &stored.ID, &stored.Status, &stored.Amount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrorDuplicateTransaction
+		}
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	// A claimed payment already announced itself when it was indexed
+	if inserted {
+		if err := enqueuePaymentEvents(ctx, tx, payment, model.PaymentWebhookEvents(nil, payment.Status)...); err != nil {
+			return err
+		}
+	}
+
+	// The stored payment was never credited, whatever its status
+	if err := syncInvoiceCredit(ctx, tx, model.PaymentStatusPending, &stored); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if inserted {
+		pubsub.Default().Publish(model.NewPaymentCreatedEvent(payment))
+	}
+	return nil
+}
+
+// syncInvoiceCredit keeps the paid amount of the invoice a payment is matched
+// to, if any, in line with the payment's status as part of tx. The amount is
+// added when the payment confirms and taken off again when it leaves
+// confirmed, e.g. when a reorganization rolls it back. A payment that
+// confirms once its invoice no longer accepts payments is detached from it,
+// so a confirmed payment with an invoice is always one that was credited.
+func syncInvoiceCredit(ctx context.Context, tx *sql.Tx, previous model.PaymentStatus, payment *model.Payment) error {
+	if payment.InvoiceID == nil {
+		return nil
+	}
+
+	switch model.SettlementChange(previous, payment.Status) {
+	case 1:
+		accepting := []string{string(model.InvoiceStatusOpen), string(model.InvoiceStatusUnderpaid)}
+		applied, err := adjustInvoicePaidAmount(ctx, tx, *payment.InvoiceID, payment.Amount, accepting)
+		if err != nil || applied {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE payments SET invoice_id = NULL, updated_at = NOW() WHERE id = $1", payment.ID)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		payment.InvoiceID = nil
+	case -1:
+		creditedStatuses := []string{string(model.InvoiceStatusUnderpaid), string(model.InvoiceStatusPaid), string(model.InvoiceStatusOverpaid)}
+		if _, err := adjustInvoicePaidAmount(ctx, tx, *payment.InvoiceID, model.Amount{}.Sub(payment.Amount), creditedStatuses); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// adjustInvoicePaidAmount adds delta to the paid amount of an invoice in one
+// of statuses and settles it accordingly. It reports whether the invoice was
+// in one of statuses.
+func adjustInvoicePaidAmount(ctx context.Context, tx *sql.Tx, invoiceID uuid.UUID, delta model.Amount, statuses []string) (bool, error) {
+	var amount, paid model.Amount
+	err := tx.QueryRowContext(ctx, "SELECT amount, paid_amount FROM invoices WHERE id = $1 AND status = ANY($2) FOR UPDATE",
+		invoiceID, statuses).Scan(&amount, &paid)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	status := model.SettlementStatus(amount, paid.Add(delta))
+	settled := status == model.InvoiceStatusPaid || status == model.InvoiceStatusOverpaid
+
+	query := `
+		UPDATE invoices
+		SET paid_amount = paid_amount + $1, status = $2,
+			paid_at = CASE WHEN $3 THEN COALESCE(paid_at, NOW()) END, updated_at = NOW()
+		WHERE id = $4 AND status = ANY($5)`
+
+	result, err := tx.ExecContext(ctx, query, delta, status, settled, invoiceID, statuses)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return rowsAffected > 0, nil
+}
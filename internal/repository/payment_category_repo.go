@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// paymentCategoryColumns lists the payment_categories columns in the order scanPaymentCategory reads them
+const paymentCategoryColumns = `id, user_id, name, created_at, updated_at`
+
+// paymentCategoryNameIndex keeps a user from creating two categories with the same name
+const paymentCategoryNameIndex = "idx_payment_categories_user_name"
+
+var (
+	ErrorPaymentCategoryNotFound = errors.New("payment category not found")
+	ErrorPaymentCategoryExists   = errors.New("a category with this name already exists")
+)
+
+// scanPaymentCategory reads a category selected with paymentCategoryColumns
+func scanPaymentCategory(row rowScanner) (*model.PaymentCategory, error) {
+	category := &model.PaymentCategory{}
+	err := row.Scan(
+		&category.ID,
+		&category.UserID,
+		&category.Name,
+		&category.CreatedAt,
+		&category.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// paymentCategoryWriteError maps a failed insert or update of a category
+func paymentCategoryWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == paymentCategoryNameIndex {
+		return ErrorPaymentCategoryExists
+	}
+	return fmt.Errorf("%w: %v", ErrorDatabase, err)
+}
+
+// CreatePaymentCategory creates a new payment category record in the database
+func CreatePaymentCategory(ctx context.Context, category *model.PaymentCategory) error {
+	db := database.New("")
+
+	query := `
+		INSERT INTO payment_categories (id, user_id, name, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := db.ExecContext(ctx, query,
+		category.ID,
+		category.UserID,
+		category.Name,
+		category.CreatedAt,
+		category.UpdatedAt,
+	)
+	if err != nil {
+		return paymentCategoryWriteError(err)
+	}
+
+	return nil
+}
+
+// GetPaymentCategory retrieves one of a user's payment categories
+func GetPaymentCategory(ctx context.Context, userID uuid.UUID, categoryID uuid.UUID) (*model.PaymentCategory, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + paymentCategoryColumns + `
+		FROM payment_categories
+		WHERE id = $1 AND user_id = $2`
+
+	category, err := scanPaymentCategory(db.QueryRowContext(ctx, query, categoryID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrorPaymentCategoryNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return category, nil
+}
+
+// GetPaymentCategoriesByUserID retrieves a user's payment categories ordered by name
+func GetPaymentCategoriesByUserID(ctx context.Context, userID uuid.UUID) ([]*model.PaymentCategory, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + paymentCategoryColumns + `
+		FROM payment_categories
+		WHERE user_id = $1
+		ORDER BY LOWER(name) ASC`
+
+	rows, err := db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	var categories []*model.PaymentCategory
+	for rows.Next() {
+		category, err := scanPaymentCategory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		categories = append(categories, category)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return categories, nil
+}
+
+// UpdatePaymentCategory renames one of a user's payment categories
+func UpdatePaymentCategory(ctx context.Context, category *model.PaymentCategory) error {
+	db := database.New("")
+
+	query := `
+		UPDATE payment_categories
+		SET name = $1, updated_at = $2
+		WHERE id = $3 AND user_id = $4
+		RETURNING created_at`
+
+	err := db.QueryRowContext(ctx, query,
+		category.Name,
+		category.UpdatedAt,
+		category.ID,
+		category.UserID,
+	).Scan(&category.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrorPaymentCategoryNotFound
+		}
+		return paymentCategoryWriteError(err)
+	}
+
+	return nil
+}
+
+// DeletePaymentCategory removes one of a user's payment categories. Its
+// payments are left uncategorized.
+func DeletePaymentCategory(ctx context.Context, userID uuid.UUID, categoryID uuid.UUID) error {
+	db := database.New("")
+
+	query := "DELETE FROM payment_categories WHERE id = $1 AND user_id = $2"
+	result, err := db.ExecContext(ctx, query, categoryID, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrorPaymentCategoryNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/model"
+	"backend/internal/pubsub"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// paymentIntentColumns lists the payment_intents columns, joined with the
+// payer's user row, in the order scanPaymentIntent reads them
+const paymentIntentColumns = `i.id, i.payer_id, i.recipient_id, i.recipient_phone, i.recipient_address,
+			   i.amount, i.currency, i.token_address, i.token_decimals, i.description,
+			   i.status, i.payment_id, i.transaction_hash, i.expires_at, i.created_at,
+			   i.updated_at, payer.username, payer.phone_number`
+
+var (
+	ErrorPaymentIntentNotFound   = errors.New("payment intent not found")
+	ErrorPaymentIntentNotPayable = errors.New("payment intent is no longer payable")
+)
+
+// scanPaymentIntent reads a payment intent selected with paymentIntentColumns
+func scanPaymentIntent(row rowScanner) (*model.PaymentIntent, error) {
+	intent := &model.PaymentIntent{}
+	err := row.Scan(
+		&intent.ID,
+		&intent.PayerID,
+		&intent.RecipientID,
+		&intent.RecipientPhone,
+		&intent.RecipientAddress,
+		&intent.Amount,
+		&intent.Currency,
+		&intent.TokenAddress,
+		&intent.TokenDecimals,
+		&intent.Description,
+		&intent.Status,
+		&intent.PaymentID,
+		&intent.TransactionHash,
+		&intent.ExpiresAt,
+		&intent.CreatedAt,
+		&intent.UpdatedAt,
+		&intent.PayerUsername,
+		&intent.PayerPhone,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return intent, nil
+}
+
+// CreatePaymentIntent creates a new payment intent record in the database
+func CreatePaymentIntent(ctx context.Context, intent *model.PaymentIntent) error {
+	db := database.New("")
+
+	query := `
+		INSERT INTO payment_intents (
+			id, payer_id, recipient_id, recipient_phone, recipient_address,
+			amount, currency, token_address, token_decimals, description,
+			status, expires_at, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+		)`
+
+	_, err := db.ExecContext(ctx, query,
+		intent.ID,
+		intent.PayerID,
+		intent.RecipientID,
+		intent.RecipientPhone,
+		intent.RecipientAddress,
+		intent.Amount,
+		intent.Currency,
+		intent.TokenAddress,
+		intent.TokenDecimals,
+		intent.Description,
+		intent.Status,
+		intent.ExpiresAt,
+		intent.CreatedAt,
+		intent.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return nil
+}
+
+// GetPaymentIntentByID retrieves a payment intent by its ID
+func GetPaymentIntentByID(ctx context.Context, intentID uuid.UUID) (*model.PaymentIntent, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + paymentIntentColumns + `
+		FROM payment_intents i
+		JOIN users payer ON payer.id = i.payer_id
+		WHERE i.id = $1`
+
+	intent, err := scanPaymentIntent(db.QueryRowContext(ctx, query, intentID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrorPaymentIntentNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return intent, nil
+}
+
+// GetPaymentIntentsByUserID retrieves the payment intents a user sent or
+// received, newest first
+func GetPaymentIntentsByUserID(ctx context.Context, userID uuid.UUID) ([]*model.PaymentIntent, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + paymentIntentColumns + `
+		FROM payment_intents i
+		JOIN users payer ON payer.id = i.payer_id
+		WHERE i.payer_id = $1 OR i.recipient_id = $1
+		ORDER BY i.created_at DESC`
+
+	rows, err := db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	var intents []*model.PaymentIntent
+	for rows.Next() {
+		intent, err := scanPaymentIntent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		intents = append(intents, intent)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return intents, nil
+}
+
+// CreatePaymentForIntent stores a payment and binds it to a pending payment
+// intent in one transaction. The intent is processing until the payment
+// confirms. ErrorPaymentIntentNotPayable is returned, and nothing is stored,
+// if the intent has expired or was already paid.
+func CreatePaymentForIntent(ctx context.Context, payment *model.Payment, intentID uuid.UUID) error {
+	db := database.New("")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer tx.Rollback()
+
+	if err := insertPayment(ctx, tx, payment); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE payment_intents
+		SET status = $1, payment_id = $2, transaction_hash = $3, updated_at = NOW()
+		WHERE id = $4 AND payer_id = $5 AND status = $6 AND expires_at > NOW()`
+
+	result, err := tx.ExecContext(ctx, query,
+		model.PaymentIntentStatusFor(payment.Status),
+		payment.ID,
+		payment.TransactionHash,
+		intentID,
+		payment.UserID,
+		model.PaymentIntentStatusPending,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrorPaymentIntentNotPayable
+	}
+
+	if err := enqueuePaymentEvents(ctx, tx, payment, model.PaymentWebhookEvents(nil, payment.Status)...); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	pubsub.Default().Publish(model.NewPaymentCreatedEvent(payment))
+	return nil
+}
+
+// syncPaymentIntent brings the intent paid by a payment, if any, in line
+// with the payment's status as part of tx. An intent whose payment failed or
+// was cancelled is released, so the payer can pay it again.
+func syncPaymentIntent(ctx context.Context, tx *sql.Tx, payment *model.Payment) error {
+	status := model.PaymentIntentStatusFor(payment.Status)
+
+	query := `
+		UPDATE payment_intents
+		SET status = $1,
+			payment_id = CASE WHEN $1 = $2 THEN NULL ELSE payment_id END,
+			transaction_hash = CASE WHEN $1 = $2 THEN NULL ELSE transaction_hash END,
+			updated_at = NOW()
+		WHERE payment_id = $3 AND status <> $1`
+
+	_, err := tx.ExecContext(ctx, query, status, model.PaymentIntentStatusPending, payment.ID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return nil
+}
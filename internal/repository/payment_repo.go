@@ -3,99 +3,308 @@ package repository
 import (
 	"backend/internal/database"
 	"backend/internal/model"
+	"backend/internal/pubsub"
 	"context"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// paymentColumns lists the payments table columns in the order scanPayment reads them
+const paymentColumns = `id, user_id, from_address, to_address, amount, currency,
+			   token_address, token_decimals,
+			   transaction_hash, block_number, block_hash, gas_used, gas_price, base_fee_per_gas, priority_fee_per_gas,
+			   status, direction, description, invoice_id, billing_period_id, refunded_amount, confirmations, required_confirmations,
+			   created_at, updated_at, confirmed_at, category_id, array_to_json(tags),
+			   fiat_currency, fiat_rate, fiat_value, fiat_source, fiat_quoted_at`
+
+// paymentTransactionConstraint keeps a user from recording the same
+// transaction twice in the same direction
+const paymentTransactionConstraint = "payments_transaction_hash_user_id_direction_key"
+
 var (
-	ErrorPaymentNotFound      = errors.New("payment not found")
-	ErrorDuplicateTransaction = errors.New("transaction hash already exists")
-	ErrorInvalidPaymentStatus = errors.New("invalid payment status")
+	ErrorPaymentNotFound         = errors.New("payment not found")
+	ErrorDuplicateTransaction    = errors.New("transaction hash already exists")
+	ErrorInvalidPaymentQuery     = errors.New("invalid payment list query")
+	ErrorInvalidPaymentStatus    = errors.New("invalid payment status")
+	ErrorInvalidStatusTransition = errors.New("illegal payment status transition")
 )
 
-// CreatePayment creates a new payment record in the database
-func CreatePayment(ctx context.Context, payment *model.Payment) error {
-	db := database.New("")
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
 
-	query := `
+// scanPayment reads a payment selected with paymentColumns
+func scanPayment(row rowScanner) (*model.Payment, error) {
+	payment := &model.Payment{}
+	var tags []byte
+	var fiatCurrency, fiatSource *string
+	var fiatRate *model.ExchangeRate
+	var fiatValue *model.Amount
+	var fiatQuotedAt *time.Time
+	err := row.Scan(
+		&payment.ID,
+		&payment.UserID,
+		&payment.FromAddress,
+		&payment.ToAddress,
+		&payment.Amount,
+		&payment.Currency,
+		&payment.TokenAddress,
+		&payment.TokenDecimals,
+		&payment.TransactionHash,
+		&payment.BlockNumber,
+		&payment.BlockHash,
+		&payment.GasUsed,
+		&payment.GasPrice,
+		&payment.BaseFeePerGas,
+		&payment.PriorityFeePerGas,
+		&payment.Status,
+		&payment.Direction,
+		&payment.Description,
+		&payment.InvoiceID,
+		&payment.BillingPeriodID,
+		&payment.RefundedAmount,
+		&payment.Confirmations,
+		&payment.RequiredConfirmations,
+		&payment.CreatedAt,
+		&payment.UpdatedAt,
+		&payment.ConfirmedAt,
+		&payment.CategoryID,
+		&tags,
+		&fiatCurrency,
+		&fiatRate,
+		&fiatValue,
+		&fiatSource,
+		&fiatQuotedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(tags, &payment.Tags); err != nil {
+		return nil, fmt.Errorf("invalid payment tags: %w", err)
+	}
+	if fiatCurrency != nil && fiatRate != nil && fiatValue != nil && fiatSource != nil && fiatQuotedAt != nil {
+		payment.Fiat = &model.FiatValuation{
+			Currency: model.FiatCurrency(*fiatCurrency),
+			Rate:     *fiatRate,
+			Value:    *fiatValue,
+			Source:   *fiatSource,
+			QuotedAt: *fiatQuotedAt,
+		}
+	}
+	return payment, nil
+}
+
+// insertPaymentQuery inserts a payment using the arguments from paymentInsertArgs
+const insertPaymentQuery = `
 		INSERT INTO payments (
 			id, user_id, from_address, to_address, amount, currency,
-			transaction_hash, block_number, gas_used, gas_price,
-			status, description, created_at, updated_at
+			token_address, token_decimals,
+			transaction_hash, block_number, block_hash, gas_used, gas_price, base_fee_per_gas, priority_fee_per_gas,
+			status, direction, description, invoice_id, billing_period_id, refunded_amount, confirmations, required_confirmations,
+			created_at, updated_at, confirmed_at, category_id, tags,
+			fiat_currency, fiat_rate, fiat_value, fiat_source, fiat_quoted_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26,
+			$27, $28, $29, $30, $31, $32, $33
 		)`
 
-	_, err := db.ExecContext(ctx, query,
+// paymentInsertArgs returns the arguments for insertPaymentQuery
+func paymentInsertArgs(payment *model.Payment) []any {
+	return append([]any{
 		payment.ID,
 		payment.UserID,
 		payment.FromAddress,
 		payment.ToAddress,
 		payment.Amount,
 		payment.Currency,
+		payment.TokenAddress,
+		payment.TokenDecimals,
 		payment.TransactionHash,
 		payment.BlockNumber,
+		payment.BlockHash,
 		payment.GasUsed,
 		payment.GasPrice,
+		payment.BaseFeePerGas,
+		payment.PriorityFeePerGas,
 		payment.Status,
+		payment.Direction,
 		payment.Description,
+		payment.InvoiceID,
+		payment.BillingPeriodID,
+		payment.RefundedAmount,
+		payment.Confirmations,
+		payment.RequiredConfirmations,
 		payment.CreatedAt,
 		payment.UpdatedAt,
-	)
+		payment.ConfirmedAt,
+		payment.CategoryID,
+		paymentTags(payment),
+	}, fiatValuationArgs(payment.Fiat)...)
+}
 
-	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			switch pgErr.Code {
-			case "23505": // unique constraint violation
-				if pgErr.ConstraintName == "payments_transaction_hash_key" {
-					return ErrorDuplicateTransaction
-				}
-			}
+// fiatValuationArgs returns the fiat_currency, fiat_rate, fiat_value,
+// fiat_source and fiat_quoted_at values of a valuation, all NULL without one
+func fiatValuationArgs(valuation *model.FiatValuation) []any {
+	if valuation == nil {
+		return []any{nil, nil, nil, nil, nil}
+	}
+	return []any{
+		string(valuation.Currency),
+		valuation.Rate,
+		valuation.Value,
+		valuation.Source,
+		valuation.QuotedAt,
+	}
+}
+
+// gasFeeArgs returns the gas_used, gas_price, base_fee_per_gas and
+// priority_fee_per_gas values of a transaction's gas details, all NULL
+// without them
+func gasFeeArgs(fees *model.GasFees) []any {
+	if fees == nil {
+		return []any{nil, nil, nil, nil}
+	}
+	return []any{
+		int64(fees.GasUsed),
+		fees.EffectiveGasPrice,
+		fees.BaseFee,
+		fees.PriorityFee,
+	}
+}
+
+// paymentTags returns the tags of a payment to store, never nil as the
+// column does not accept NULL
+func paymentTags(payment *model.Payment) []string {
+	if payment.Tags == nil {
+		return []string{}
+	}
+	return payment.Tags
+}
+
+// scanPayments reads every payment from rows selected with paymentColumns
+func scanPayments(rows *sql.Rows) ([]*model.Payment, error) {
+	var payments []*model.Payment
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
 		}
+		payments = append(payments, payment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return payments, nil
+}
+
+// insertPayment inserts a payment inside tx. A payment for a transaction the
+// user already recorded in the same direction fails with
+// ErrorDuplicateTransaction; the conflict is resolved by the insert itself,
+// so concurrent submissions of one transaction cannot both succeed and the
+// losing transaction is left usable.
+func insertPayment(ctx context.Context, tx *sql.Tx, payment *model.Payment) error {
+	result, err := tx.ExecContext(ctx, insertPaymentQuery+`
+		ON CONFLICT ON CONSTRAINT `+paymentTransactionConstraint+` DO NOTHING`, paymentInsertArgs(payment)...)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	if inserted == 0 {
+		return ErrorDuplicateTransaction
+	}
+
+	return nil
+}
+
+// CreatePayment creates a new payment record in the database and queues its
+// webhook events in the same transaction
+func CreatePayment(ctx context.Context, payment *model.Payment) error {
+	db := database.New("")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer tx.Rollback()
+
+	if err := insertPayment(ctx, tx, payment); err != nil {
+		return err
+	}
+
+	if err := enqueuePaymentEvents(ctx, tx, payment, model.PaymentWebhookEvents(nil, payment.Status)...); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("%w: %v", ErrorDatabase, err)
 	}
 
+	pubsub.Default().Publish(model.NewPaymentCreatedEvent(payment))
 	return nil
 }
 
+// CreateIncomingPayment records a payment found on chain unless the user
+// already has an incoming payment for the transaction. It reports whether the
+// payment was inserted; webhook events are only queued for new payments.
+func CreateIncomingPayment(ctx context.Context, payment *model.Payment) (bool, error) {
+	db := database.New("")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, insertPaymentQuery+`
+		ON CONFLICT (transaction_hash, user_id, direction) DO NOTHING`, paymentInsertArgs(payment)...)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	if inserted == 0 {
+		return false, nil
+	}
+
+	if err := enqueuePaymentEvents(ctx, tx, payment, model.PaymentWebhookEvents(nil, payment.Status)...); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	pubsub.Default().Publish(model.NewPaymentCreatedEvent(payment))
+	return true, nil
+}
+
 // GetPaymentByID retrieves a payment by its ID
 func GetPaymentByID(ctx context.Context, paymentID uuid.UUID) (*model.Payment, error) {
 	db := database.New("")
 
 	query := `
-		SELECT id, user_id, from_address, to_address, amount, currency,
-			   transaction_hash, block_number, gas_used, gas_price,
-			   status, description, created_at, updated_at, confirmed_at
+		SELECT ` + paymentColumns + `
 		FROM payments
 		WHERE id = $1`
 
-	payment := &model.Payment{}
-	err := db.QueryRowContext(ctx, query, paymentID).Scan(
-		&payment.ID,
-		&payment.UserID,
-		&payment.FromAddress,
-		&payment.ToAddress,
-		&payment.Amount,
-		&payment.Currency,
-		&payment.TransactionHash,
-		&payment.BlockNumber,
-		&payment.GasUsed,
-		&payment.GasPrice,
-		&payment.Status,
-		&payment.Description,
-		&payment.CreatedAt,
-		&payment.UpdatedAt,
-		&payment.ConfirmedAt,
-	)
-
+	payment, err := scanPayment(db.QueryRowContext(ctx, query, paymentID))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrorPaymentNotFound
@@ -106,36 +315,18 @@ func GetPaymentByID(ctx context.Context, paymentID uuid.UUID) (*model.Payment, e
 	return payment, nil
 }
 
-// GetPaymentByTransactionHash retrieves a payment by its transaction hash
-func GetPaymentByTransactionHash(ctx context.Context, txHash string) (*model.Payment, error) {
+// GetPaymentByTransactionHash retrieves a user's payment in one direction by
+// its transaction hash. A transfer between two of the user's wallets has both
+// an outgoing and an incoming payment.
+func GetPaymentByTransactionHash(ctx context.Context, userID uuid.UUID, txHash string, direction model.PaymentDirection) (*model.Payment, error) {
 	db := database.New("")
 
 	query := `
-		SELECT id, user_id, from_address, to_address, amount, currency,
-			   transaction_hash, block_number, gas_used, gas_price,
-			   status, description, created_at, updated_at, confirmed_at
+		SELECT ` + paymentColumns + `
 		FROM payments
-		WHERE transaction_hash = $1`
-
-	payment := &model.Payment{}
-	err := db.QueryRowContext(ctx, query, txHash).Scan(
-		&payment.ID,
-		&payment.UserID,
-		&payment.FromAddress,
-		&payment.ToAddress,
-		&payment.Amount,
-		&payment.Currency,
-		&payment.TransactionHash,
-		&payment.BlockNumber,
-		&payment.GasUsed,
-		&payment.GasPrice,
-		&payment.Status,
-		&payment.Description,
-		&payment.CreatedAt,
-		&payment.UpdatedAt,
-		&payment.ConfirmedAt,
-	)
+		WHERE transaction_hash = $1 AND user_id = $2 AND direction = $3`
 
+	payment, err := scanPayment(db.QueryRowContext(ctx, query, txHash, userID, direction))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrorPaymentNotFound
@@ -146,71 +337,294 @@ func GetPaymentByTransactionHash(ctx context.Context, txHash string) (*model.Pay
 	return payment, nil
 }
 
-// GetPaymentsByUserID retrieves payments for a specific user with pagination and filtering
+// GetPaymentsByUserID retrieves one page of a user's payments, filtered and
+// sorted in SQL. Pages are fetched by keyset when query.Cursor is set and by
+// offset otherwise; either way NextCursor points at the following page.
 func GetPaymentsByUserID(ctx context.Context, userID uuid.UUID, query *model.PaymentQuery) (*model.PaymentListResponse, error) {
 	db := database.New("")
 
-	const selectQuery = `
-		SELECT id, user_id, from_address, to_address, amount, currency,
-			   transaction_hash, block_number, gas_used, gas_price,
-			   status, description, created_at, updated_at, confirmed_at
-		FROM payments 
-		WHERE user_id = $1
-		ORDER BY created_at DESC`
+	sortColumn, ok := paymentSortColumns[query.SortBy]
+	if !ok {
+		return nil, ErrorInvalidPaymentQuery
+	}
+
+	direction, comparison := "DESC", "<"
+	if query.SortOrder == model.SortOrderAsc {
+		direction, comparison = "ASC", ">"
+	}
+
+	conditions, args := paymentFilterConditions(userID, query)
+	where := strings.Join(conditions, " AND ")
+
+	var totalCount int64
+	countQuery := "SELECT COUNT(*) FROM payments WHERE " + where
+	if err := db.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	pageConditions := conditions
+	offset := (query.Page - 1) * query.PageSize
+	if query.Cursor != nil {
+		value, id, err := decodePaymentCursor(*query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, value, id)
+		pageConditions = append(pageConditions, fmt.Sprintf("(%s, id) %s ($%d::%s, $%d)",
+			sortColumn.name, comparison, len(args)-1, sortColumn.cast, len(args)))
+		offset = 0
+	}
+
+	// Fetch one extra row to find out whether another page follows
+	args = append(args, query.PageSize+1, offset)
+	selectQuery := fmt.Sprintf(`
+		SELECT %s
+		FROM payments
+		WHERE %s
+		ORDER BY %s %s, id %s
+		LIMIT $%d OFFSET $%d`,
+		paymentColumns, strings.Join(pageConditions, " AND "),
+		sortColumn.name, direction, direction, len(args)-1, len(args))
 
-	rows, err := db.QueryContext(ctx, selectQuery, userID)
+	rows, err := db.QueryContext(ctx, selectQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
 	}
 	defer rows.Close()
 
-	var payments []model.PaymentResponse
-	for rows.Next() {
-		payment := model.Payment{}
-		err := rows.Scan(
-			&payment.ID,
-			&payment.UserID,
-			&payment.FromAddress,
-			&payment.ToAddress,
-			&payment.Amount,
-			&payment.Currency,
-			&payment.TransactionHash,
-			&payment.BlockNumber,
-			&payment.GasUsed,
-			&payment.GasPrice,
-			&payment.Status,
-			&payment.Description,
-			&payment.CreatedAt,
-			&payment.UpdatedAt,
-			&payment.ConfirmedAt,
-		)
+	page, err := scanPayments(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var nextCursor *string
+	if len(page) > query.PageSize {
+		page = page[:query.PageSize]
+		cursor := encodePaymentCursor(query.SortBy, page[len(page)-1])
+		nextCursor = &cursor
+	}
+
+	payments := make([]model.PaymentResponse, 0, len(page))
+	for _, payment := range page {
+		payments = append(payments, payment.ToResponse())
+	}
+
+	response := &model.PaymentListResponse{
+		Payments:   payments,
+		TotalCount: totalCount,
+		Page:       query.Page,
+		PageSize:   query.PageSize,
+		TotalPages: int((totalCount + int64(query.PageSize) - 1) / int64(query.PageSize)),
+		NextCursor: nextCursor,
+	}
+
+	// Keyset pages have no page number
+	if query.Cursor != nil {
+		response.Page = 0
+	}
+
+	return response, nil
+}
+
+// StreamPaymentsByUserID calls fn for every payment of a user matching the
+// query's filters, in its sort order. Rows are read one at a time, so the
+// full history is never held in memory; iteration stops at the first error
+// returned by fn. Pagination fields of the query are ignored.
+func StreamPaymentsByUserID(ctx context.Context, userID uuid.UUID, query *model.PaymentQuery, fn func(*model.Payment) error) error {
+	db := database.New("")
+
+	sortColumn, ok := paymentSortColumns[query.SortBy]
+	if !ok {
+		return ErrorInvalidPaymentQuery
+	}
+
+	direction := "DESC"
+	if query.SortOrder == model.SortOrderAsc {
+		direction = "ASC"
+	}
+
+	conditions, args := paymentFilterConditions(userID, query)
+	selectQuery := fmt.Sprintf(`
+		SELECT %s
+		FROM payments
+		WHERE %s
+		ORDER BY %s %s, id %s`,
+		paymentColumns, strings.Join(conditions, " AND "), sortColumn.name, direction, direction)
+
+	rows, err := db.QueryContext(ctx, selectQuery, args...)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
 
+	for rows.Next() {
+		payment, err := scanPayment(rows)
 		if err != nil {
-			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+			return fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		if err := fn(payment); err != nil {
+			return err
 		}
+	}
 
-		amountInt, _ := strconv.ParseUint(payment.Amount, 10, 64)
-		payment.Amount = strconv.FormatFloat(float64(amountInt)/1e18, 'f', -1, 64)
-		payments = append(payments, payment.ToResponse())
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return nil
+}
+
+// paymentSortColumn is a column payment lists can be sorted and paged by,
+// with the SQL type its cursor value is cast to
+type paymentSortColumn struct {
+	name string
+	cast string
+}
+
+var paymentSortColumns = map[string]paymentSortColumn{
+	model.PaymentSortCreatedAt: {name: "created_at", cast: "timestamp"},
+	model.PaymentSortAmount:    {name: "amount", cast: "numeric"},
+}
+
+// paymentFilterConditions builds the WHERE conditions and their arguments for
+// a user's payment list
+func paymentFilterConditions(userID uuid.UUID, query *model.PaymentQuery) ([]string, []any) {
+	conditions := []string{"user_id = $1"}
+	args := []any{userID}
+
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if query.Status != nil {
+		add("status = $%d", string(*query.Status))
+	}
+	if query.Direction != nil {
+		add("direction = $%d", string(*query.Direction))
+	}
+	if query.Currency != nil {
+		add("UPPER(currency) = UPPER($%d)", *query.Currency)
 	}
+	if query.CreatedAfter != nil {
+		add("created_at >= $%d", query.CreatedAfter.UTC().Format(cursorTimeLayout))
+	}
+	if query.CreatedBefore != nil {
+		add("created_at < $%d", query.CreatedBefore.UTC().Format(cursorTimeLayout))
+	}
+	if query.Counterparty != nil {
+		args = append(args, *query.Counterparty)
+		conditions = append(conditions, fmt.Sprintf("(LOWER(from_address) = LOWER($%d) OR LOWER(to_address) = LOWER($%d))", len(args), len(args)))
+	}
+	if query.MinAmount != nil {
+		add("amount >= $%d::numeric", *query.MinAmount)
+	}
+	if query.MaxAmount != nil {
+		add("amount <= $%d::numeric", *query.MaxAmount)
+	}
+	if query.CategoryID != nil {
+		add("category_id = $%d::uuid", *query.CategoryID)
+	}
+	if query.Tag != nil {
+		add("$%d = ANY(tags)", model.NormalizeTag(*query.Tag))
+	}
+	if query.Search != nil {
+		args = append(args, *query.Search)
+		conditions = append(conditions, fmt.Sprintf(paymentSearchCondition, len(args), len(args), len(args)))
+	}
+
+	return conditions, args
+}
+
+// paymentSearchCondition matches payments against a full-text query over
+// their description, tags, category, the names of the user's contacts on
+// either side and the addresses themselves. The document is built at query
+// time since it spans the contacts and categories tables. Addresses are also
+// matched by substring, as the text search parser does not split them and
+// users commonly search by the first or last few characters.
+const paymentSearchCondition = `(to_tsvector('simple',
+			coalesce(payments.description, '') || ' ' ||
+			array_to_string(payments.tags, ' ') || ' ' ||
+			coalesce((SELECT pc.name FROM payment_categories pc WHERE pc.id = payments.category_id), '') || ' ' ||
+			coalesce((SELECT string_agg(c.name, ' ') FROM contacts c
+				WHERE c.user_id = payments.user_id
+				  AND LOWER(c.address) IN (LOWER(payments.from_address), LOWER(payments.to_address))), '') || ' ' ||
+			payments.from_address || ' ' || payments.to_address
+		) @@ websearch_to_tsquery('simple', $%d)
+		OR payments.from_address ILIKE '%%' || $%d || '%%'
+		OR payments.to_address ILIKE '%%' || $%d || '%%')`
+
+// UpdatePaymentAnnotations replaces the category and tags of one of a
+// user's payments and returns the updated payment
+func UpdatePaymentAnnotations(ctx context.Context, userID uuid.UUID, paymentID uuid.UUID, categoryID *uuid.UUID, tags []string) (*model.Payment, error) {
+	db := database.New("")
+
+	if tags == nil {
+		tags = []string{}
+	}
+
+	query := `
+		UPDATE payments
+		SET category_id = $1, tags = $2, updated_at = NOW()
+		WHERE id = $3 AND user_id = $4
+		RETURNING ` + paymentColumns
 
-	if err = rows.Err(); err != nil {
+	payment, err := scanPayment(db.QueryRowContext(ctx, query, categoryID, tags, paymentID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrorPaymentNotFound
+		}
 		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
 	}
 
-	totalCount := int64(len(payments))
+	return payment, nil
+}
+
+// cursorTimeLayout formats timestamps the way Postgres parses TIMESTAMP
+// values, at the microsecond precision it stores
+const cursorTimeLayout = "2006-01-02 15:04:05.999999"
+
+// encodePaymentCursor returns an opaque cursor pointing just after payment
+// in a list sorted by sortBy
+func encodePaymentCursor(sortBy string, payment *model.Payment) string {
+	value := payment.CreatedAt.Format(cursorTimeLayout)
+	if sortBy == model.PaymentSortAmount {
+		value = payment.Amount.String()
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(value + "|" + payment.ID.String()))
+}
+
+// decodePaymentCursor splits a cursor into the sort value and payment ID it
+// points after
+func decodePaymentCursor(cursor string) (string, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", uuid.Nil, ErrorInvalidPaymentQuery
+	}
 
-	return &model.PaymentListResponse{
-		Payments:   payments,
-		TotalCount: totalCount,
-		Page:       1,
-		PageSize:   int(totalCount),
-		TotalPages: 1,
-	}, nil
+	value, idPart, found := strings.Cut(string(raw), "|")
+	if !found || value == "" {
+		return "", uuid.Nil, ErrorInvalidPaymentQuery
+	}
+
+	id, err := uuid.Parse(idPart)
+	if err != nil {
+		return "", uuid.Nil, ErrorInvalidPaymentQuery
+	}
+
+	return value, id, nil
 }
 
-// UpdatePaymentStatus updates the status of a payment
-func UpdatePaymentStatus(ctx context.Context, paymentID uuid.UUID, status model.PaymentStatus, blockNumber *int64, gasUsed *int64, gasPrice *string) error {
+// UpdatePaymentStatus updates the status of a payment. The update only
+// applies if the payment's current status may move to the new one and has not
+// changed concurrently; otherwise ErrorInvalidStatusTransition is returned.
+// When the payment reaches a new confirmed, failed or cancelled status, the
+// matching webhook event is queued in the same transaction, which also
+// settles or releases the split share the payment pays and credits the
+// invoice or billing period it pays once it confirms. Every status change is published to the
+// user's payment stream once committed.
+func UpdatePaymentStatus(ctx context.Context, paymentID uuid.UUID, status model.PaymentStatus, blockNumber *int64, blockHash *string, fees *model.GasFees) error {
 	if !status.IsValid() {
 		return ErrorInvalidPaymentStatus
 	}
@@ -223,12 +637,119 @@ func UpdatePaymentStatus(ctx context.Context, paymentID uuid.UUID, status model.
 		confirmedAt = &now
 	}
 
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer tx.Rollback()
+
+	var previous model.PaymentStatus
+	err = tx.QueryRowContext(ctx, "SELECT status FROM payments WHERE id = $1", paymentID).Scan(&previous)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrorPaymentNotFound
+		}
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if !previous.CanTransitionTo(status) {
+		return fmt.Errorf("%w: %s to %s", ErrorInvalidStatusTransition, previous, status)
+	}
+
+	// Only update the payment if it is still in the status the transition was
+	// checked against
 	query := `
 		UPDATE payments
-		SET status = $1, block_number = $2, gas_used = $3, gas_price = $4, confirmed_at = $5, updated_at = NOW()
+		SET status = $1, block_number = $2, block_hash = $3, confirmed_at = $4,
+			gas_used = $5, gas_price = $6, base_fee_per_gas = $7, priority_fee_per_gas = $8, updated_at = NOW()
+		WHERE id = $9 AND status = $10
+		RETURNING ` + paymentColumns
+
+	args := append([]any{status, blockNumber, blockHash, confirmedAt}, gasFeeArgs(fees)...)
+	payment, err := scanPayment(tx.QueryRowContext(ctx, query, append(args, paymentID, previous)...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: status changed concurrently from %s", ErrorInvalidStatusTransition, previous)
+		}
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	// Read before the events are queued, so they carry the refunded amount
+	if err := syncRefundedAmount(ctx, tx, payment); err != nil {
+		return err
+	}
+
+	if err := enqueuePaymentEvents(ctx, tx, payment, model.PaymentWebhookEvents(&previous, status)...); err != nil {
+		return err
+	}
+
+	if err := syncSplitShare(ctx, tx, payment); err != nil {
+		return err
+	}
+
+	if err := syncPaymentIntent(ctx, tx, payment); err != nil {
+		return err
+	}
+
+	if err := syncPreparedPayment(ctx, tx, payment); err != nil {
+		return err
+	}
+
+	if err := syncInvoiceCredit(ctx, tx, previous, payment); err != nil {
+		return err
+	}
+
+	if err := syncBillingPeriodCredit(ctx, tx, previous, payment); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if previous != status {
+		pubsub.Default().Publish(model.NewPaymentStatusChangedEvent(previous, payment))
+	}
+	return nil
+}
+
+// UpdatePaymentFiatValuation replaces the fiat valuation of a payment
+func UpdatePaymentFiatValuation(ctx context.Context, paymentID uuid.UUID, valuation *model.FiatValuation) error {
+	db := database.New("")
+
+	query := `
+		UPDATE payments
+		SET fiat_currency = $1, fiat_rate = $2, fiat_value = $3, fiat_source = $4, fiat_quoted_at = $5, updated_at = NOW()
 		WHERE id = $6`
 
-	result, err := db.ExecContext(ctx, query, status, blockNumber, gasUsed, gasPrice, confirmedAt, paymentID)
+	args := append(fiatValuationArgs(valuation), paymentID)
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrorPaymentNotFound
+	}
+
+	return nil
+}
+
+// UpdatePaymentConfirmations records the current and required confirmation depth of a payment
+func UpdatePaymentConfirmations(ctx context.Context, paymentID uuid.UUID, confirmations int64, requiredConfirmations int64) error {
+	db := database.New("")
+
+	query := `
+		UPDATE payments
+		SET confirmations = $1, required_confirmations = $2, updated_at = NOW()
+		WHERE id = $3`
+
+	result, err := db.ExecContext(ctx, query, confirmations, requiredConfirmations, paymentID)
 	if err != nil {
 		return fmt.Errorf("%w: %v", ErrorDatabase, err)
 	}
@@ -269,53 +790,102 @@ func DeletePayment(ctx context.Context, paymentID uuid.UUID) error {
 
 // GetPendingPayments retrieves all pending payments (for background processing)
 func GetPendingPayments(ctx context.Context) ([]*model.Payment, error) {
+	return GetPaymentsByStatus(ctx, model.PaymentStatusPending)
+}
+
+// GetPaymentsByStatus retrieves all payments in any of the given statuses, oldest first
+func GetPaymentsByStatus(ctx context.Context, statuses ...model.PaymentStatus) ([]*model.Payment, error) {
 	db := database.New("")
 
+	values := make([]string, len(statuses))
+	for i, status := range statuses {
+		values[i] = string(status)
+	}
+
 	query := `
-		SELECT id, user_id, from_address, to_address, amount, currency,
-			   transaction_hash, block_number, gas_used, gas_price,
-			   status, description, created_at, updated_at, confirmed_at
+		SELECT ` + paymentColumns + `
 		FROM payments
-		WHERE status = $1
+		WHERE status = ANY($1)
 		ORDER BY created_at ASC`
 
-	rows, err := db.QueryContext(ctx, query, model.PaymentStatusPending)
+	rows, err := db.QueryContext(ctx, query, values)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
 	}
 	defer rows.Close()
 
-	var payments []*model.Payment
-	for rows.Next() {
-		payment := &model.Payment{}
-		err := rows.Scan(
-			&payment.ID,
-			&payment.UserID,
-			&payment.FromAddress,
-			&payment.ToAddress,
-			&payment.Amount,
-			&payment.Currency,
-			&payment.TransactionHash,
-			&payment.BlockNumber,
-			&payment.GasUsed,
-			&payment.GasPrice,
-			&payment.Status,
-			&payment.Description,
-			&payment.CreatedAt,
-			&payment.UpdatedAt,
-			&payment.ConfirmedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
-		}
-		payments = append(payments, payment)
+	return scanPayments(rows)
+}
+
+// GetUnsettledPayments returns up to limit pending or confirming payments
+// with an ID above after, ordered by ID
+func GetUnsettledPayments(ctx context.Context, after uuid.UUID, limit int) ([]*model.Payment, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE status = ANY($1) AND id > $2
+		ORDER BY id
+		LIMIT $3`
+
+	unsettled := []string{string(model.PaymentStatusPending), string(model.PaymentStatusConfirming)}
+	rows, err := db.QueryContext(ctx, query, unsettled, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
 	}
+	defer rows.Close()
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("row iteration error: %w", err)
+	return scanPayments(rows)
+}
+
+// GetPaymentsMissingGasFees returns up to limit mined outgoing payments with
+// an ID above after whose gas fees have not been read from their receipt,
+// ordered by ID
+func GetPaymentsMissingGasFees(ctx context.Context, after uuid.UUID, limit int) ([]*model.Payment, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE gas_used IS NULL AND block_number IS NOT NULL
+		  AND direction = $1 AND status = ANY($2) AND id > $3
+		ORDER BY id
+		LIMIT $4`
+
+	settled := []string{string(model.PaymentStatusConfirmed), string(model.PaymentStatusFailed)}
+	rows, err := db.QueryContext(ctx, query, model.PaymentDirectionOutgoing, settled, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
 	}
+	defer rows.Close()
 
-	return payments, nil
+	return scanPayments(rows)
+}
+
+// UpdatePaymentGasFees records the gas fees read from a payment's receipt
+// without changing its status
+func UpdatePaymentGasFees(ctx context.Context, paymentID uuid.UUID, fees *model.GasFees) error {
+	db := database.New("")
+
+	query := `
+		UPDATE payments
+		SET gas_used = $1, gas_price = $2, base_fee_per_gas = $3, priority_fee_per_gas = $4, updated_at = NOW()
+		WHERE id = $5`
+
+	result, err := db.ExecContext(ctx, query, append(gasFeeArgs(fees), paymentID)...)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	if rowsAffected == 0 {
+		return ErrorPaymentNotFound
+	}
+	return nil
 }
 
 // GetUserWalletAddresses retrieves all wallet addresses for a user
@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/model"
+	"backend/internal/pubsub"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// preparedPaymentColumns lists the prepared_payments columns in the order
+// scanPreparedPayment reads them
+const preparedPaymentColumns = `id, user_id, from_address, to_address, amount, currency,
+			   token_address, token_decimals, description,
+			   chain_id, nonce, gas_limit, max_fee_per_gas, max_priority_fee_per_gas,
+			   status, payment_id, transaction_hash, expires_at, created_at, updated_at`
+
+var (
+	ErrorPreparedPaymentNotFound    = errors.New("prepared payment not found")
+	ErrorPreparedPaymentNotBindable = errors.New("prepared payment already has a payment")
+)
+
+// scanPreparedPayment reads a prepared payment selected with preparedPaymentColumns
+func scanPreparedPayment(row rowScanner) (*model.PreparedPayment, error) {
+	prepared := &model.PreparedPayment{}
+	err := row.Scan(
+		&prepared.ID,
+		&prepared.UserID,
+		&prepared.FromAddress,
+		&prepared.ToAddress,
+		&prepared.Amount,
+		&prepared.Currency,
+		&prepared.TokenAddress,
+		&prepared.TokenDecimals,
+		&prepared.Description,
+		&prepared.ChainID,
+		&prepared.Nonce,
+		&prepared.GasLimit,
+		&prepared.MaxFeePerGas,
+		&prepared.MaxPriorityFeePerGas,
+		&prepared.Status,
+		&prepared.PaymentID,
+		&prepared.TransactionHash,
+		&prepared.ExpiresAt,
+		&prepared.CreatedAt,
+		&prepared.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return prepared, nil
+}
+
+// CreatePreparedPayment stores a prepared payment, reserving its nonce for
+// the sending wallet. prepared.Nonce should be the wallet's pending nonce on
+// the node; it is moved past the nonces of the wallet's other unexpired
+// preparations, so transactions prepared back to back do not replace each
+// other.
+func CreatePreparedPayment(ctx context.Context, prepared *model.PreparedPayment) error {
+	db := database.New("")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer tx.Rollback()
+
+	// Serialize reservations per wallet until the transaction ends
+	_, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext(LOWER($1)))", prepared.FromAddress)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	var reserved sql.NullInt64
+	err = tx.QueryRowContext(ctx, `
+		SELECT MAX(nonce) FROM prepared_payments
+		WHERE LOWER(from_address) = LOWER($1) AND status = $2 AND expires_at > NOW() AND nonce >= $3`,
+		prepared.FromAddress, model.PreparedPaymentStatusPending, int64(prepared.Nonce)).Scan(&reserved)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	if reserved.Valid {
+		prepared.Nonce = uint64(reserved.Int64) + 1
+	}
+
+	query := `
+		INSERT INTO prepared_payments (
+			id, user_id, from_address, to_address, amount, currency,
+			token_address, token_decimals, description,
+			chain_id, nonce, gas_limit, max_fee_per_gas, max_priority_fee_per_gas,
+			status, expires_at, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18
+		)`
+
+	_, err = tx.ExecContext(ctx, query,
+		prepared.ID,
+		prepared.UserID,
+		prepared.FromAddress,
+		prepared.ToAddress,
+		prepared.Amount,
+		prepared.Currency,
+		prepared.TokenAddress,
+		prepared.TokenDecimals,
+		prepared.Description,
+		prepared.ChainID,
+		int64(prepared.Nonce),
+		int64(prepared.GasLimit),
+		prepared.MaxFeePerGas,
+		prepared.MaxPriorityFeePerGas,
+		prepared.Status,
+		prepared.ExpiresAt,
+		prepared.CreatedAt,
+		prepared.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return nil
+}
+
+// GetPreparedPaymentByID retrieves a user's prepared payment by its ID
+func GetPreparedPaymentByID(ctx context.Context, userID, preparedID uuid.UUID) (*model.PreparedPayment, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + preparedPaymentColumns + `
+		FROM prepared_payments
+		WHERE id = $1 AND user_id = $2`
+
+	prepared, err := scanPreparedPayment(db.QueryRowContext(ctx, query, preparedID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrorPreparedPaymentNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return prepared, nil
+}
+
+// CreatePaymentForPreparation stores a payment and binds it to a pending
+// prepared payment in one transaction. The preparation is processing until
+// the payment confirms. ErrorPreparedPaymentNotBindable is returned, and
+// nothing is stored, if another payment was bound first.
+func CreatePaymentForPreparation(ctx context.Context, payment *model.Payment, preparedID uuid.UUID) error {
+	db := database.New("")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer tx.Rollback()
+
+	if err := insertPayment(ctx, tx, payment); err != nil {
+		return err
+	}
+
+	if err := bindPreparedPayment(ctx, tx, payment, preparedID); err != nil {
+		return err
+	}
+
+	if err := enqueuePaymentEvents(ctx, tx, payment, model.PaymentWebhookEvents(nil, payment.Status)...); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	pubsub.Default().Publish(model.NewPaymentCreatedEvent(payment))
+	return nil
+}
+
+// bindPreparedPayment binds a pending prepared payment to a payment stored
+// in the same transaction
+func bindPreparedPayment(ctx context.Context, tx *sql.Tx, payment *model.Payment, preparedID uuid.UUID) error {
+	query := `
+		UPDATE prepared_payments
+		SET status = $1, payment_id = $2, transaction_hash = $3, updated_at = NOW()
+		WHERE id = $4 AND user_id = $5 AND status = $6`
+
+	result, err := tx.ExecContext(ctx, query,
+		model.PreparedPaymentStatusFor(payment.Status),
+		payment.ID,
+		payment.TransactionHash,
+		preparedID,
+		payment.UserID,
+		model.PreparedPaymentStatusPending,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrorPreparedPaymentNotBindable
+	}
+	return nil
+}
+
+// syncPreparedPayment brings the preparation bound to a payment, if any, in
+// line with the payment's status as part of tx. A preparation whose payment
+// failed or was cancelled is released, so another payment can be bound to it.
+func syncPreparedPayment(ctx context.Context, tx *sql.Tx, payment *model.Payment) error {
+	status := model.PreparedPaymentStatusFor(payment.Status)
+
+	query := `
+		UPDATE prepared_payments
+		SET status = $1,
+			payment_id = CASE WHEN $1 = $2 THEN NULL ELSE payment_id END,
+			transaction_hash = CASE WHEN $1 = $2 THEN NULL ELSE transaction_hash END,
+			updated_at = NOW()
+		WHERE payment_id = $3 AND status <> $1`
+
+	_, err := tx.ExecContext(ctx, query, status, model.PreparedPaymentStatusPending, payment.ID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// refundColumns lists the refunds table columns in the order scanRefund reads them
+const refundColumns = `id, payment_id, user_id, from_address, to_address, amount,
+			   transaction_hash, block_number, reason, created_at`
+
+// refundTransactionConstraint keeps a user from counting the same refund
+// transaction twice
+const refundTransactionConstraint = "refunds_transaction_hash_user_id_key"
+
+var (
+	ErrorDuplicateRefund      = errors.New("refund transaction already recorded")
+	ErrorRefundExceedsPayment = errors.New("refunds would exceed the payment amount")
+	ErrorPaymentNotRefundable = errors.New("only confirmed payments can be refunded")
+)
+
+// scanRefund reads a refund selected with refundColumns
+func scanRefund(row rowScanner) (*model.Refund, error) {
+	refund := &model.Refund{}
+	err := row.Scan(
+		&refund.ID,
+		&refund.PaymentID,
+		&refund.UserID,
+		&refund.FromAddress,
+		&refund.ToAddress,
+		&refund.Amount,
+		&refund.TransactionHash,
+		&refund.BlockNumber,
+		&refund.Reason,
+		&refund.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// CreateRefund records a refund against one of the user's confirmed payments
+// and adds it to the payment's refunded amount in one transaction. The
+// payment row is locked while the running total is checked, so concurrent
+// refunds can never add up to more than the payment amount. It returns the
+// updated payment.
+func CreateRefund(ctx context.Context, refund *model.Refund) (*model.Payment, error) {
+	db := database.New("")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer tx.Rollback()
+
+	var status model.PaymentStatus
+	var amount, refunded model.Amount
+	err = tx.QueryRowContext(ctx, `
+		SELECT status, amount, refunded_amount
+		FROM payments
+		WHERE id = $1 AND user_id = $2
+		FOR UPDATE`, refund.PaymentID, refund.UserID).Scan(&status, &amount, &refunded)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrorPaymentNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if status != model.PaymentStatusConfirmed {
+		return nil, ErrorPaymentNotRefundable
+	}
+	if refunded.Add(refund.Amount).Cmp(amount) > 0 {
+		return nil, fmt.Errorf("%w: %s of %s already refunded", ErrorRefundExceedsPayment, refunded, amount)
+	}
+
+	insertQuery := `
+		INSERT INTO refunds (
+			id, payment_id, user_id, from_address, to_address, amount,
+			transaction_hash, block_number, reason, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+		)`
+
+	_, err = tx.ExecContext(ctx, insertQuery,
+		refund.ID,
+		refund.PaymentID,
+		refund.UserID,
+		refund.FromAddress,
+		refund.ToAddress,
+		refund.Amount,
+		refund.TransactionHash,
+		refund.BlockNumber,
+		refund.Reason,
+		refund.CreatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == refundTransactionConstraint {
+			return nil, ErrorDuplicateRefund
+		}
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	updateQuery := `
+		UPDATE payments
+		SET refunded_amount = refunded_amount + $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING ` + paymentColumns
+
+	payment, err := scanPayment(tx.QueryRowContext(ctx, updateQuery, refund.Amount, refund.PaymentID))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return payment, nil
+}
+
+// GetRefundsByPaymentID retrieves the refunds recorded against a payment, oldest first
+func GetRefundsByPaymentID(ctx context.Context, paymentID uuid.UUID) ([]*model.Refund, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + refundColumns + `
+		FROM refunds
+		WHERE payment_id = $1
+		ORDER BY created_at ASC`
+
+	rows, err := db.QueryContext(ctx, query, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	var refunds []*model.Refund
+	for rows.Next() {
+		refund, err := scanRefund(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		refunds = append(refunds, refund)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return refunds, nil
+}
+
+// syncRefundedAmount brings a payment's refunded amount in line with its
+// status as part of tx. Refunds only count against a confirmed payment: the
+// amount is zero while a reorg has rolled the payment back, and recomputed
+// from its refunds once it confirms again.
+func syncRefundedAmount(ctx context.Context, tx *sql.Tx, payment *model.Payment) error {
+	query := `
+		UPDATE payments
+		SET refunded_amount = CASE WHEN status = $2
+			THEN COALESCE((SELECT SUM(amount) FROM refunds WHERE payment_id = $1), 0)
+			ELSE 0 END
+		WHERE id = $1
+		RETURNING refunded_amount`
+
+	err := tx.QueryRowContext(ctx, query, payment.ID, model.PaymentStatusConfirmed).Scan(&payment.RefundedAmount)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return nil
+}
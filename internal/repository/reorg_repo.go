@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/model"
+	"backend/internal/pubsub"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrorPaymentBlockChanged = errors.New("payment block hash changed concurrently")
+)
+
+// GetPaymentsMinedSince retrieves confirming and confirmed payments whose
+// recorded block is at or above the given block number
+func GetPaymentsMinedSince(ctx context.Context, fromBlock int64) ([]*model.Payment, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE status = ANY($1)
+		  AND block_hash IS NOT NULL
+		  AND block_number >= $2
+		ORDER BY block_number ASC`
+
+	statuses := []string{string(model.PaymentStatusConfirming), string(model.PaymentStatusConfirmed)}
+	rows, err := db.QueryContext(ctx, query, statuses, fromBlock)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	return scanPayments(rows)
+}
+
+// RollbackReorgedPayment records a reorg event and demotes the payment back to
+// pending, clearing its block and confirmation details, in one transaction.
+// This is the only way a confirmed payment can move back to pending, so the
+// same transaction also takes back what the confirmed payment settled: its
+// split share, payment intent or prepared payment, the credit of its invoice
+// or billing period, and its refunded amount. The refunds themselves are
+// kept and count again once the payment re-confirms.
+// The update only applies if the payment is still recorded in the orphaned
+// block in the event's previous status; otherwise ErrorPaymentBlockChanged is
+// returned.
+func RollbackReorgedPayment(ctx context.Context, event *model.PaymentReorgEvent) error {
+	db := database.New("")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer tx.Rollback()
+
+	if event.PreviousStatus != model.PaymentStatusConfirming && event.PreviousStatus != model.PaymentStatusConfirmed {
+		return ErrorPaymentBlockChanged
+	}
+
+	updateQuery := `
+		UPDATE payments
+		SET status = $1, block_number = NULL, block_hash = NULL,
+			confirmations = 0, confirmed_at = NULL, updated_at = NOW()
+		WHERE id = $2 AND block_hash = $3 AND status = $4
+		RETURNING ` + paymentColumns
+
+	payment, err := scanPayment(tx.QueryRowContext(ctx, updateQuery, model.PaymentStatusPending, event.PaymentID, event.OrphanedBlockHash, event.PreviousStatus))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrorPaymentBlockChanged
+		}
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	insertQuery := `
+		INSERT INTO payment_reorg_events (
+			id, payment_id, block_number, orphaned_block_hash,
+			canonical_block_hash, previous_status, detected_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err = tx.ExecContext(ctx, insertQuery,
+		event.ID,
+		event.PaymentID,
+		event.BlockNumber,
+		event.OrphanedBlockHash,
+		event.CanonicalBlockHash,
+		event.PreviousStatus,
+		event.DetectedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if err := syncRefundedAmount(ctx, tx, payment); err != nil {
+		return err
+	}
+
+	// Integrators told the payment confirmed learn it was reversed
+	if err := enqueuePaymentEvents(ctx, tx, payment, model.PaymentWebhookEvents(&event.PreviousStatus, payment.Status)...); err != nil {
+		return err
+	}
+
+	// A share settled by the payment is pending again until it re-confirms,
+	// and an intent or preparation it completed is processing again
+	if err := syncSplitShare(ctx, tx, payment); err != nil {
+		return err
+	}
+
+	if err := syncPaymentIntent(ctx, tx, payment); err != nil {
+		return err
+	}
+
+	if err := syncPreparedPayment(ctx, tx, payment); err != nil {
+		return err
+	}
+
+	// So are the invoice or billing period it paid
+	if err := syncInvoiceCredit(ctx, tx, event.PreviousStatus, payment); err != nil {
+		return err
+	}
+
+	if err := syncBillingPeriodCredit(ctx, tx, event.PreviousStatus, payment); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	pubsub.Default().Publish(model.NewPaymentStatusChangedEvent(event.PreviousStatus, payment))
+	return nil
+}
@@ -91,6 +91,45 @@ func FindUserByID(ctx context.Context, userID uuid.UUID) (*model.User, error) {
 
 	return user, nil
 }
+
+// FindUserByPhoneNumber finds the user registered with a phone number.
+func FindUserByPhoneNumber(ctx context.Context, phoneNumber string) (*model.User, error) {
+	user := &model.User{}
+
+	rawDB := database.New("")
+
+	query := "SELECT id, email, username, phone_number, password_hash FROM users WHERE phone_number = $1"
+	row := rawDB.QueryRowContext(ctx, query, phoneNumber)
+	err := row.Scan(&user.ID, &user.Email, &user.Username, &user.PhoneNumber, &user.HashedPassword)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrorUserNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return user, nil
+}
+
+// FindUserByUsername finds the user registered with a username.
+func FindUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	user := &model.User{}
+
+	rawDB := database.New("")
+
+	query := "SELECT id, email, username, phone_number, password_hash FROM users WHERE username = $1"
+	row := rawDB.QueryRowContext(ctx, query, username)
+	err := row.Scan(&user.ID, &user.Email, &user.Username, &user.PhoneNumber, &user.HashedPassword)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrorUserNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return user, nil
+}
+
 func UpdateUserPassword(ctx context.Context, userID uuid.UUID, newHashedPassword string) error {
 	db := database.New("")
 	if db == nil {
@@ -128,6 +167,40 @@ func UpdateUserEmail(ctx context.Context, userID uuid.UUID, newEmail string) err
 	}
 	return nil
 }
+
+// GetUserDisplayCurrency returns the fiat currency a user's payments are valued in
+func GetUserDisplayCurrency(ctx context.Context, userID uuid.UUID) (model.FiatCurrency, error) {
+	db := database.New("")
+
+	var currency model.FiatCurrency
+	err := db.QueryRowContext(ctx, "SELECT display_currency FROM users WHERE id = $1", userID).Scan(&currency)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrorUserNotFound
+		}
+		return "", fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return currency, nil
+}
+
+// UpdateUserDisplayCurrency sets the fiat currency a user's payments are valued in
+func UpdateUserDisplayCurrency(ctx context.Context, userID uuid.UUID, currency model.FiatCurrency) error {
+	db := database.New("")
+
+	query := `UPDATE users SET display_currency = $1, updated_at = NOW() WHERE id = $2`
+	result, err := db.ExecContext(ctx, query, string(currency), userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrorUserNotFound
+	}
+	return nil
+}
+
 func DeleteUser(ctx context.Context, userID uuid.UUID) error {
 	db := database.New("")
 	if db == nil {
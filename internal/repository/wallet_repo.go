@@ -9,9 +9,14 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
+var (
+	ErrorWalletAddressNotFound = errors.New("wallet address not found for phone number")
+)
+
 func GetWalletAddressesFromPhone(ctx context.Context, phone string) ([]model.WalletAddress, error) {
 	var addresses []model.WalletAddress
 
@@ -73,3 +78,101 @@ func InsertWalletAddressPhone(ctx context.Context, walletAddress, phoneNumber st
 
 	return nil
 }
+
+// GetPreferredWalletAddress returns the wallet that receives payments sent to
+// a phone number: the one marked preferred, otherwise the most recently
+// connected wallet.
+func GetPreferredWalletAddress(ctx context.Context, phoneNumber string) (string, error) {
+	db := database.New("")
+
+	query := `
+		SELECT address FROM wallet_address_phone
+		WHERE phone_number = $1
+		ORDER BY is_preferred DESC, created_at DESC, id DESC
+		LIMIT 1`
+
+	var address string
+	err := db.QueryRowContext(ctx, query, phoneNumber).Scan(&address)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrorWalletAddressNotFound
+		}
+		return "", fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return address, nil
+}
+
+// SetPreferredWalletAddress marks one of a phone number's wallets as preferred
+// and clears the flag on the others.
+func SetPreferredWalletAddress(ctx context.Context, phoneNumber, walletAddress string) error {
+	db := database.New("")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE wallet_address_phone SET is_preferred = FALSE, updated_at = NOW() WHERE phone_number = $1 AND is_preferred",
+		phoneNumber)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	result, err := tx.ExecContext(ctx,
+		"UPDATE wallet_address_phone SET is_preferred = TRUE, updated_at = NOW() WHERE phone_number = $1 AND LOWER(address) = LOWER($2)",
+		phoneNumber, walletAddress)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrorWalletAddressNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return nil
+}
+
+// GetWalletOwners maps every connected wallet address, lower-cased, to the ID
+// of the user whose phone number it is registered to
+func GetWalletOwners(ctx context.Context) (map[string]uuid.UUID, error) {
+	db := database.New("")
+
+	query := `
+		SELECT w.address, u.id
+		FROM wallet_address_phone w
+		JOIN users u ON u.phone_number = w.phone_number`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	owners := make(map[string]uuid.UUID)
+	for rows.Next() {
+		var address string
+		var userID uuid.UUID
+		if err := rows.Scan(&address, &userID); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		owners[strings.ToLower(address)] = userID
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return owners, nil
+}
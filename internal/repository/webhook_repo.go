@@ -0,0 +1,434 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/model"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// webhookEndpointColumns lists the webhook_endpoints columns in the order
+// scanWebhookEndpoint reads them
+const webhookEndpointColumns = `id, user_id, url, secret, event_types, description, created_at, updated_at`
+
+// webhookDeliveryColumns lists the webhook_deliveries columns, joined with
+// their event, in the order scanWebhookDelivery reads them
+const webhookDeliveryColumns = `d.id, d.event_id, d.endpoint_id, e.event_type, e.payment_id,
+			   d.status, d.attempts, d.next_attempt_at, d.last_attempt_at,
+			   d.last_status_code, d.last_error, d.created_at`
+
+var (
+	ErrorWebhookEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrorWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// enqueuePaymentEvents writes payment events to the webhook outbox as part of
+// tx, with one pending delivery per endpoint of the payment's owner that
+// subscribes to the event. Nothing is written when no endpoint subscribes.
+func enqueuePaymentEvents(ctx context.Context, tx *sql.Tx, payment *model.Payment, eventTypes ...model.WebhookEventType) error {
+	eventQuery := `
+		INSERT INTO webhook_events (id, user_id, event_type, payment_id, payload, created_at)
+		SELECT $1, $2, $3, $4, $5, $6
+		WHERE EXISTS (
+			SELECT 1 FROM webhook_endpoints
+			WHERE user_id = $2 AND (event_types = '' OR $3 = ANY(string_to_array(event_types, ',')))
+		)`
+
+	deliveryQuery := `
+		INSERT INTO webhook_deliveries (event_id, endpoint_id)
+		SELECT $1, id
+		FROM webhook_endpoints
+		WHERE user_id = $2 AND (event_types = '' OR $3 = ANY(string_to_array(event_types, ',')))`
+
+	for _, eventType := range eventTypes {
+		event := model.NewPaymentWebhookEvent(eventType, payment)
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode webhook event: %w", err)
+		}
+
+		result, err := tx.ExecContext(ctx, eventQuery, event.ID, payment.UserID, string(eventType), payment.ID, payload, event.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		if written, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("%w: %v", ErrorDatabase, err)
+		} else if written == 0 {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, deliveryQuery, event.ID, payment.UserID, string(eventType)); err != nil {
+			return fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+	}
+
+	return nil
+}
+
+// scanWebhookEndpoint reads an endpoint selected with webhookEndpointColumns
+func scanWebhookEndpoint(row rowScanner) (*model.WebhookEndpoint, error) {
+	endpoint := &model.WebhookEndpoint{}
+	var eventTypes string
+	err := row.Scan(
+		&endpoint.ID,
+		&endpoint.UserID,
+		&endpoint.URL,
+		&endpoint.Secret,
+		&eventTypes,
+		&endpoint.Description,
+		&endpoint.CreatedAt,
+		&endpoint.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	endpoint.EventTypes = model.SplitWebhookEventTypes(eventTypes)
+	return endpoint, nil
+}
+
+// CreateWebhookEndpoint creates a new webhook endpoint record in the database
+func CreateWebhookEndpoint(ctx context.Context, endpoint *model.WebhookEndpoint) error {
+	db := database.New("")
+
+	query := `
+		INSERT INTO webhook_endpoints (
+			id, user_id, url, secret, event_types, description, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		)`
+
+	_, err := db.ExecContext(ctx, query,
+		endpoint.ID,
+		endpoint.UserID,
+		endpoint.URL,
+		endpoint.Secret,
+		model.JoinWebhookEventTypes(endpoint.EventTypes),
+		endpoint.Description,
+		endpoint.CreatedAt,
+		endpoint.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return nil
+}
+
+// GetWebhookEndpoint retrieves a webhook endpoint owned by the user
+func GetWebhookEndpoint(ctx context.Context, userID uuid.UUID, endpointID uuid.UUID) (*model.WebhookEndpoint, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + webhookEndpointColumns + `
+		FROM webhook_endpoints
+		WHERE id = $1 AND user_id = $2`
+
+	endpoint, err := scanWebhookEndpoint(db.QueryRowContext(ctx, query, endpointID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrorWebhookEndpointNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return endpoint, nil
+}
+
+// GetWebhookEndpointsByUserID retrieves a user's webhook endpoints, oldest first
+func GetWebhookEndpointsByUserID(ctx context.Context, userID uuid.UUID) ([]*model.WebhookEndpoint, error) {
+	db := database.New("")
+
+	query := `
+		SELECT ` + webhookEndpointColumns + `
+		FROM webhook_endpoints
+		WHERE user_id = $1
+		ORDER BY created_at ASC`
+
+	rows, err := db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	var endpoints []*model.WebhookEndpoint
+	for rows.Next() {
+		endpoint, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return endpoints, nil
+}
+
+// DeleteWebhookEndpoint deletes a user's webhook endpoint together with its
+// pending deliveries and their logs
+func DeleteWebhookEndpoint(ctx context.Context, userID uuid.UUID, endpointID uuid.UUID) error {
+	db := database.New("")
+
+	query := "DELETE FROM webhook_endpoints WHERE id = $1 AND user_id = $2"
+	result, err := db.ExecContext(ctx, query, endpointID, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrorWebhookEndpointNotFound
+	}
+
+	return nil
+}
+
+// scanWebhookDelivery reads a delivery selected with webhookDeliveryColumns
+func scanWebhookDelivery(row rowScanner) (*model.WebhookDelivery, error) {
+	delivery := &model.WebhookDelivery{}
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.EventID,
+		&delivery.EndpointID,
+		&delivery.EventType,
+		&delivery.PaymentID,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastAttemptAt,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// GetWebhookDeliveries retrieves an endpoint's deliveries, newest first, each
+// with its attempt log
+func GetWebhookDeliveries(ctx context.Context, endpointID uuid.UUID, query *model.WebhookDeliveryQuery) ([]*model.WebhookDelivery, error) {
+	db := database.New("")
+
+	selectQuery := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries d
+		JOIN webhook_events e ON e.id = d.event_id
+		WHERE d.endpoint_id = $1 AND ($2::text IS NULL OR d.status = $2)
+		ORDER BY d.created_at DESC, d.id DESC
+		LIMIT $3`
+
+	rows, err := db.QueryContext(ctx, selectQuery, endpointID, query.Status, query.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	var deliveries []*model.WebhookDelivery
+	byID := make(map[uuid.UUID]*model.WebhookDelivery)
+	ids := []string{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		deliveries = append(deliveries, delivery)
+		byID[delivery.ID] = delivery
+		ids = append(ids, delivery.ID.String())
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	if len(deliveries) == 0 {
+		return deliveries, nil
+	}
+
+	attemptQuery := `
+		SELECT delivery_id, attempted_at, status_code, error, duration_ms
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = ANY($1::uuid[])
+		ORDER BY attempted_at ASC`
+
+	attemptRows, err := db.QueryContext(ctx, attemptQuery, ids)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer attemptRows.Close()
+
+	for attemptRows.Next() {
+		var deliveryID uuid.UUID
+		var attempt model.WebhookAttempt
+		err := attemptRows.Scan(&deliveryID, &attempt.AttemptedAt, &attempt.StatusCode, &attempt.Error, &attempt.DurationMs)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		if delivery, ok := byID[deliveryID]; ok {
+			delivery.AttemptLog = append(delivery.AttemptLog, attempt)
+		}
+	}
+
+	if err := attemptRows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// ClaimPendingWebhooks picks up to limit deliveries that are due and leases
+// them for the given duration by pushing their next attempt back, so that
+// concurrent dispatchers skip them and a dispatcher that dies mid-delivery
+// only delays them.
+func ClaimPendingWebhooks(ctx context.Context, limit int, lease time.Duration) ([]*model.PendingWebhook, error) {
+	db := database.New("")
+
+	query := `
+		WITH due AS (
+			SELECT id
+			FROM webhook_deliveries
+			WHERE status = $1 AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at ASC
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + $3 * INTERVAL '1 second', updated_at = NOW()
+		FROM due, webhook_events e, webhook_endpoints ep
+		WHERE d.id = due.id AND e.id = d.event_id AND ep.id = d.endpoint_id
+		RETURNING d.id, d.event_id, e.event_type, ep.url, ep.secret, e.payload::text, d.attempts`
+
+	rows, err := db.QueryContext(ctx, query, model.WebhookDeliveryPending, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer rows.Close()
+
+	var pending []*model.PendingWebhook
+	for rows.Next() {
+		webhook := &model.PendingWebhook{}
+		var payload string
+		err := rows.Scan(&webhook.DeliveryID, &webhook.EventID, &webhook.EventType, &webhook.URL, &webhook.Secret, &payload, &webhook.Attempts)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+		}
+		webhook.Payload = []byte(payload)
+		pending = append(pending, webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return pending, nil
+}
+
+// RecordWebhookAttempt logs a delivery attempt and moves the delivery to
+// status in one transaction. A pending delivery is retried at nextAttemptAt.
+func RecordWebhookAttempt(ctx context.Context, deliveryID uuid.UUID, attempt *model.WebhookAttempt, status model.WebhookDeliveryStatus, nextAttemptAt time.Time) error {
+	db := database.New("")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer tx.Rollback()
+
+	insertQuery := `
+		INSERT INTO webhook_delivery_attempts (delivery_id, attempted_at, status_code, error, duration_ms)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	_, err = tx.ExecContext(ctx, insertQuery, deliveryID, attempt.AttemptedAt, attempt.StatusCode, attempt.Error, attempt.DurationMs)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	updateQuery := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = attempts + 1, next_attempt_at = $2, last_attempt_at = $3,
+			last_status_code = $4, last_error = $5, updated_at = NOW()
+		WHERE id = $6`
+
+	result, err := tx.ExecContext(ctx, updateQuery, status, nextAttemptAt, attempt.AttemptedAt, attempt.StatusCode, attempt.Error, deliveryID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrorWebhookDeliveryNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return nil
+}
+
+// RedeliverWebhook queues one of the user's deliveries to be sent again right
+// away with a fresh set of attempts. Earlier attempts stay in its log.
+func RedeliverWebhook(ctx context.Context, userID uuid.UUID, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	db := database.New("")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+	defer tx.Rollback()
+
+	updateQuery := `
+		UPDATE webhook_deliveries d
+		SET status = $1, attempts = 0, next_attempt_at = NOW(), updated_at = NOW()
+		FROM webhook_endpoints ep
+		WHERE d.id = $2 AND ep.id = d.endpoint_id AND ep.user_id = $3`
+
+	result, err := tx.ExecContext(ctx, updateQuery, model.WebhookDeliveryPending, deliveryID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, ErrorWebhookDeliveryNotFound
+	}
+
+	// Reselect the delivery with its event columns
+	selectQuery := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries d
+		JOIN webhook_events e ON e.id = d.event_id
+		WHERE d.id = $1`
+
+	delivery, err := scanWebhookDelivery(tx.QueryRowContext(ctx, selectQuery, deliveryID))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorDatabase, err)
+	}
+
+	return delivery, nil
+}
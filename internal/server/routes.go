@@ -26,6 +26,18 @@ func (s *Server) RegisterRoutes() http.Handler {
 		auth.POST("/logout", handler.LogoutHandler)
 	}
 
+	// Invoices are shared with payers who may not have an account
+	r.GET("/invoices/:id/share", handler.GetSharedInvoiceHandler)
+
+	// Payment streams accept the access token in the query string, since
+	// browsers cannot set headers on EventSource and WebSocket requests
+	stream := r.Group("/payments")
+	stream.Use(middleware.TokenFromQuery(), middleware.AuthMiddleware())
+	{
+		stream.GET("/stream", handler.StreamPaymentsHandler)
+		stream.GET("/ws", handler.StreamPaymentsWebSocketHandler)
+	}
+
 	protected := r.Group("/")
 	protected.Use(middleware.AuthMiddleware())
 	wallet := protected.Group("/wallet")
@@ -34,22 +46,95 @@ func (s *Server) RegisterRoutes() http.Handler {
 		wallet.POST("/connect", handler.ConnectWalletHandler)
 		wallet.GET("/balance/:address", handler.GetWalletBalanceHandler)
 		wallet.GET("/balances", handler.GetUserWalletBalancesHandler)
+		wallet.PUT("/preferred", handler.SetPreferredWalletHandler)
+	}
+
+	chain := protected.Group("/chain")
+	{
+		chain.GET("/fees", handler.GetChainFeesHandler)
 	}
 
 	payments := protected.Group("/payments")
 	{
-		payments.POST("", handler.CreatePaymentHandler)
+		payments.POST("", middleware.Idempotency(), handler.CreatePaymentHandler)
 		payments.GET("", handler.GetUserPaymentsHandler)
 		payments.GET("/stats", handler.GetPaymentStatsHandler)
+		payments.GET("/analytics", handler.GetPaymentAnalyticsHandler)
+		payments.GET("/fees", handler.GetFeeReportHandler)
+		payments.GET("/export", handler.ExportPaymentsHandler)
+		payments.GET("/search", handler.SearchPaymentsHandler)
+		payments.POST("/prepare", middleware.Idempotency(), handler.PreparePaymentHandler)
+		payments.POST("/relay", middleware.Idempotency(), handler.RelayPaymentHandler)
+		payments.POST("/intents", handler.CreatePaymentIntentHandler)
+		payments.GET("/intents", handler.GetUserPaymentIntentsHandler)
+		payments.GET("/intents/:id", handler.GetPaymentIntentHandler)
 		payments.GET("/:id", handler.GetPaymentHandler)
 		payments.POST("/:id/refresh", handler.RefreshPaymentStatusHandler)
+		payments.POST("/:id/cancel", handler.CancelPaymentHandler)
+		payments.POST("/:id/refunds", handler.CreateRefundHandler)
+		payments.GET("/:id/refunds", handler.GetPaymentRefundsHandler)
+		payments.PUT("/:id/annotations", handler.UpdatePaymentAnnotationsHandler)
 		payments.GET("/tx/:hash", handler.GetPaymentByTransactionHashHandler)
 	}
 
+	invoices := protected.Group("/invoices")
+	{
+		invoices.POST("", handler.CreateInvoiceHandler)
+		invoices.GET("", handler.GetUserInvoicesHandler)
+		invoices.GET("/:id", handler.GetInvoiceHandler)
+		invoices.POST("/:id/cancel", handler.CancelInvoiceHandler)
+	}
+
+	schedules := protected.Group("/schedules")
+	{
+		schedules.POST("", handler.CreateBillingScheduleHandler)
+		schedules.GET("", handler.GetUserBillingSchedulesHandler)
+		schedules.GET("/:id", handler.GetBillingScheduleHandler)
+		schedules.POST("/:id/cancel", handler.CancelBillingScheduleHandler)
+	}
+
+	splits := protected.Group("/splits")
+	{
+		splits.POST("", handler.CreateExpenseSplitHandler)
+		splits.GET("", handler.GetUserExpenseSplitsHandler)
+		splits.GET("/owed", handler.GetOwedSplitSharesHandler)
+		splits.GET("/:id", handler.GetExpenseSplitHandler)
+		splits.POST("/:id/remind", handler.RemindSplitParticipantsHandler)
+	}
+
+	contacts := protected.Group("/contacts")
+	{
+		contacts.POST("", handler.CreateContactHandler)
+		contacts.GET("", handler.GetContactsHandler)
+		contacts.GET("/check", handler.CheckAddressHandler)
+		contacts.GET("/:id", handler.GetContactHandler)
+		contacts.PUT("/:id", handler.UpdateContactHandler)
+		contacts.DELETE("/:id", handler.DeleteContactHandler)
+	}
+
+	categories := protected.Group("/categories")
+	{
+		categories.POST("", handler.CreatePaymentCategoryHandler)
+		categories.GET("", handler.GetPaymentCategoriesHandler)
+		categories.PUT("/:id", handler.UpdatePaymentCategoryHandler)
+		categories.DELETE("/:id", handler.DeletePaymentCategoryHandler)
+	}
+
+	webhooks := protected.Group("/webhooks")
+	{
+		webhooks.POST("", handler.CreateWebhookEndpointHandler)
+		webhooks.GET("", handler.GetWebhookEndpointsHandler)
+		webhooks.GET("/:id", handler.GetWebhookEndpointHandler)
+		webhooks.DELETE("/:id", handler.DeleteWebhookEndpointHandler)
+		webhooks.GET("/:id/deliveries", handler.GetWebhookDeliveriesHandler)
+		webhooks.POST("/deliveries/:id/redeliver", handler.RedeliverWebhookHandler)
+	}
+
 	account := protected.Group("/account")
 	{
 		account.PATCH("/change-password", handler.ChangePasswordHandler)
 		account.PATCH("/update-email", handler.UpdateEmailHandler)
+		account.PATCH("/display-currency", handler.UpdateDisplayCurrencyHandler)
 		account.DELETE("/delete", handler.DeleteAccountHandler)
 	}
 
@@ -127,3 +127,20 @@ func DeleteAccountService(ctx context.Context, userID uuid.UUID, req model.Delet
 	slog.Info("Service: Account deleted successfully", slog.String("userID", userID.String()))
 	return nil
 }
+
+// UpdateDisplayCurrencyService sets the fiat currency the user's payments are
+// valued and totalled in. Payments already valued keep their valuation.
+func UpdateDisplayCurrencyService(ctx context.Context, userID uuid.UUID, req model.UpdateDisplayCurrencyRequest) (model.FiatCurrency, error) {
+	currency, err := model.ParseFiatCurrency(req.Currency)
+	if err != nil {
+		return "", err
+	}
+
+	if err := repository.UpdateUserDisplayCurrency(ctx, userID, currency); err != nil {
+		slog.Error("Service: UpdateDisplayCurrency - Failed to update display currency in DB", slog.String("userID", userID.String()), slog.Any("error", err))
+		return "", err
+	}
+
+	slog.Info("Service: Display currency updated successfully", slog.String("userID", userID.String()), slog.String("currency", string(currency)))
+	return currency, nil
+}
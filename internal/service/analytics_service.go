@@ -0,0 +1,146 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/internal/repository"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultAnalyticsRange is used when analytics are requested without from
+	DefaultAnalyticsRange = 30 * 24 * time.Hour
+
+	// maxTopCounterparties bounds the top_counterparties parameter
+	maxTopCounterparties = 50
+)
+
+// GetPaymentAnalytics aggregates a user's payments over a date range: totals
+// per status and currency, a time series, top counterparties, average
+// confirmation time and gas spent. All aggregation happens in the database.
+func GetPaymentAnalytics(ctx context.Context, userID string, query *model.AnalyticsQuery) (*model.PaymentAnalytics, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	if err := validateAnalyticsQuery(query, time.Now()); err != nil {
+		return nil, fmt.Errorf("%w: %v", repository.ErrorInvalidAnalyticsQuery, err)
+	}
+
+	r := repository.PaymentAnalyticsRange{
+		UserID:   userUUID,
+		From:     query.From,
+		To:       query.To,
+		Currency: query.Currency,
+	}
+	if query.Currency != nil && strings.EqualFold(*query.Currency, NativeCurrency) {
+		r.Currency = nil
+		r.Native = true
+	}
+
+	totals, err := repository.GetPaymentTotals(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	series, err := repository.GetPaymentSeries(ctx, r, query.Interval)
+	if err != nil {
+		return nil, err
+	}
+
+	counterparties, err := repository.GetTopCounterparties(ctx, r, query.TopCounterparties)
+	if err != nil {
+		return nil, err
+	}
+
+	confirmationTime, err := repository.GetConfirmationTimeStats(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	gas, err := repository.GetGasSpent(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.PaymentAnalytics{
+		From:              *query.From,
+		To:                *query.To,
+		Interval:          query.Interval,
+		Totals:            totals,
+		Series:            series,
+		TopCounterparties: counterparties,
+		ConfirmationTime:  *confirmationTime,
+		Gas:               *gas,
+	}, nil
+}
+
+// GetFeeReport sums what a user paid in transaction fees for their outgoing
+// payments over a date range, by month, with the average fee per payment
+func GetFeeReport(ctx context.Context, userID string, query *model.FeeReportQuery) (*model.FeeReport, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	now := time.Now()
+	if query.To == nil {
+		query.To = &now
+	}
+	if query.From == nil {
+		from := query.To.Add(-model.DefaultFeeReportRange)
+		query.From = &from
+	}
+	if !query.From.Before(*query.To) {
+		return nil, fmt.Errorf("%w: from must be before to", repository.ErrorInvalidAnalyticsQuery)
+	}
+
+	months, err := repository.GetMonthlyFees(ctx, repository.PaymentAnalyticsRange{
+		UserID: userUUID,
+		From:   query.From,
+		To:     query.To,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return model.NewFeeReport(*query.From, *query.To, months), nil
+}
+
+// validateAnalyticsQuery checks an analytics query and fills in the defaults
+// for its range, interval and counterparty limit
+func validateAnalyticsQuery(query *model.AnalyticsQuery, now time.Time) error {
+	if query.Interval == "" {
+		query.Interval = model.AnalyticsIntervalDay
+	}
+	if !query.Interval.IsValid() {
+		return fmt.Errorf("interval must be %s, %s or %s",
+			model.AnalyticsIntervalDay, model.AnalyticsIntervalWeek, model.AnalyticsIntervalMonth)
+	}
+
+	if query.To == nil {
+		query.To = &now
+	}
+	if query.From == nil {
+		from := query.To.Add(-DefaultAnalyticsRange)
+		query.From = &from
+	}
+	if !query.From.Before(*query.To) {
+		return fmt.Errorf("from must be before to")
+	}
+
+	if query.TopCounterparties < 0 || query.TopCounterparties > maxTopCounterparties {
+		return fmt.Errorf("top_counterparties must be between 0 and %d", maxTopCounterparties)
+	}
+
+	if query.Currency != nil && strings.TrimSpace(*query.Currency) == "" {
+		query.Currency = nil
+	}
+
+	return nil
+}
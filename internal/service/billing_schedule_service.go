@@ -0,0 +1,532 @@
+package service
+
+import (
+	"backend/internal/ethclient"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// billingMatcherCursor names the chain scan cursor of ProcessBillingSchedules
+	billingMatcherCursor = "billing_matcher"
+
+	// maxBillingScanBlocks bounds how many blocks a single sweep scans
+	maxBillingScanBlocks = 100
+
+	// maxPeriodsPerSweep bounds how many periods of one schedule a sweep
+	// generates, so a schedule that fell behind catches up over several sweeps
+	maxPeriodsPerSweep = 12
+
+	// billingStartTolerance is how far in the past a schedule may start,
+	// allowing for clock differences with the client
+	billingStartTolerance = 5 * time.Minute
+)
+
+// CreateBillingSchedule creates a recurring charge to a payer's wallet,
+// payable into one of the user's wallets. The first period starts at
+// req.StartsAt, or immediately if it is not set.
+func CreateBillingSchedule(ctx context.Context, userID string, req *model.CreateBillingScheduleRequest) (*model.BillingScheduleResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	if !req.Interval.IsValid() {
+		return nil, fmt.Errorf("invalid interval: must be %q or %q", model.BillingIntervalWeekly, model.BillingIntervalMonthly)
+	}
+	if req.Interval == model.BillingIntervalWeekly && req.GracePeriodDays >= 7 {
+		return nil, fmt.Errorf("grace period of a weekly schedule must be shorter than a week")
+	}
+
+	if !ethclient.ValidateAddress(req.PayerAddress) {
+		return nil, fmt.Errorf("invalid payer address")
+	}
+	if equalAddresses(req.PayerAddress, req.PayeeAddress) {
+		return nil, fmt.Errorf("payer and payee addresses must differ")
+	}
+
+	payee, err := connectedWalletAddress(ctx, userID, req.PayeeAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := model.ParseAmount(req.Amount)
+	if err != nil || amount.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid amount: must be a positive integer in the currency's smallest unit")
+	}
+
+	now := time.Now()
+	startsAt := now
+	if req.StartsAt != nil {
+		if req.StartsAt.Before(now.Add(-billingStartTolerance)) {
+			return nil, fmt.Errorf("starts_at must not be in the past")
+		}
+		startsAt = *req.StartsAt
+	}
+	if req.EndsAt != nil && !req.EndsAt.After(startsAt) {
+		return nil, fmt.Errorf("ends_at must be after starts_at")
+	}
+
+	ethClient, err := ethclient.NewClient()
+	if err != nil {
+		slog.Error("Failed to create Ethereum client", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to connect to blockchain: %w", err)
+	}
+	defer ethClient.Close()
+
+	token, err := resolveToken(ctx, ethClient, req.Currency, req.TokenAddress)
+	if err != nil {
+		slog.Error("Failed to resolve billing schedule currency", slog.Any("error", err))
+		return nil, err
+	}
+
+	schedule := &model.BillingSchedule{
+		ID:              uuid.New(),
+		UserID:          userUUID,
+		PayerAddress:    req.PayerAddress,
+		PayeeAddress:    payee,
+		Amount:          amount,
+		Currency:        NativeCurrency,
+		TokenDecimals:   18,
+		Interval:        req.Interval,
+		GracePeriodDays: req.GracePeriodDays,
+		Description:     req.Description,
+		Status:          model.BillingScheduleStatusActive,
+		StartsAt:        startsAt,
+		EndsAt:          req.EndsAt,
+		NextPeriodAt:    &startsAt,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	if token != nil {
+		schedule.Currency = token.Symbol
+		schedule.TokenAddress = &token.Address
+		schedule.TokenDecimals = int(token.Decimals)
+	}
+
+	if err := repository.CreateBillingSchedule(ctx, schedule); err != nil {
+		slog.Error("Failed to create billing schedule record", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to save billing schedule: %w", err)
+	}
+
+	slog.Info("Billing schedule created successfully",
+		slog.String("scheduleID", schedule.ID.String()),
+		slog.String("payer", schedule.PayerAddress),
+		slog.String("interval", string(schedule.Interval)),
+		slog.String("amount", schedule.Amount.String()),
+		slog.String("currency", schedule.Currency))
+
+	response := schedule.ToResponse()
+	return &response, nil
+}
+
+// GetBillingSchedule retrieves a billing schedule owned by the user, including
+// its periods and the payments matched to each
+func GetBillingSchedule(ctx context.Context, userID string, scheduleID string) (*model.BillingScheduleResponse, error) {
+	scheduleUUID, err := uuid.Parse(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid billing schedule ID format: %w", err)
+	}
+
+	schedule, err := repository.GetBillingScheduleByID(ctx, scheduleUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify user owns this schedule
+	if schedule.UserID.String() != userID {
+		return nil, repository.ErrorBillingScheduleNotFound
+	}
+
+	periods, err := repository.GetBillingPeriodsByScheduleID(ctx, schedule.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := repository.GetPaymentsByBillingScheduleID(ctx, schedule.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentsByPeriod := make(map[uuid.UUID][]model.PaymentResponse)
+	for _, payment := range payments {
+		paymentsByPeriod[*payment.BillingPeriodID] = append(paymentsByPeriod[*payment.BillingPeriodID], payment.ToResponse())
+	}
+
+	response := schedule.ToResponse()
+	for _, period := range periods {
+		periodResponse := period.ToResponse(schedule)
+		periodResponse.Payments = paymentsByPeriod[period.ID]
+		response.Periods = append(response.Periods, periodResponse)
+	}
+
+	return &response, nil
+}
+
+// GetUserBillingSchedules retrieves the user's billing schedules, optionally
+// filtered by status
+func GetUserBillingSchedules(ctx context.Context, userID string, query *model.BillingScheduleQuery) ([]model.BillingScheduleResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	if query.Status != nil && !query.Status.IsValid() {
+		return nil, fmt.Errorf("invalid status filter")
+	}
+
+	schedules, err := repository.GetBillingSchedulesByUserID(ctx, userUUID, query.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]model.BillingScheduleResponse, 0, len(schedules))
+	for _, schedule := range schedules {
+		responses = append(responses, schedule.ToResponse())
+	}
+
+	return responses, nil
+}
+
+// CancelBillingSchedule stops one of the user's active schedules from
+// generating further periods
+func CancelBillingSchedule(ctx context.Context, userID string, scheduleID string) (*model.BillingScheduleResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	scheduleUUID, err := uuid.Parse(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid billing schedule ID format: %w", err)
+	}
+
+	schedule, err := repository.GetBillingScheduleByID(ctx, scheduleUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if schedule.UserID != userUUID {
+		return nil, repository.ErrorBillingScheduleNotFound
+	}
+
+	if err := repository.CancelBillingSchedule(ctx, userUUID, scheduleUUID); err != nil {
+		return nil, err
+	}
+
+	return GetBillingSchedule(ctx, userID, scheduleID)
+}
+
+// ProcessBillingSchedules generates the periods that have started for every
+// active schedule, marks unpaid periods past their due date as overdue, then
+// scans the blocks mined since the last sweep for transfers from payers to
+// payees of payable periods. Each matching transfer is verified and recorded
+// as an incoming payment of the schedule owner, and its amount is added to
+// the period's paid amount once the payment confirms. It returns the number
+// of payments matched.
+func ProcessBillingSchedules(ctx context.Context) (int, error) {
+	generated, err := generateBillingPeriods(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if generated > 0 {
+		slog.Info("Billing periods generated", slog.Int("count", generated))
+	}
+
+	overdue, err := repository.MarkOverdueBillingPeriods(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark overdue billing periods: %w", err)
+	}
+	if overdue > 0 {
+		slog.Info("Billing periods overdue", slog.Int64("count", overdue))
+	}
+
+	return matchBillingPayments(ctx)
+}
+
+// generateBillingPeriods creates the periods of active schedules that have
+// started by now and returns how many were created
+func generateBillingPeriods(ctx context.Context, now time.Time) (int, error) {
+	schedules, err := repository.GetBillingSchedulesDueForPeriods(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get billing schedules: %w", err)
+	}
+
+	generated := 0
+	for _, schedule := range schedules {
+		if ctx.Err() != nil {
+			return generated, ctx.Err()
+		}
+
+		previouslyGenerated := schedule.PeriodsGenerated
+		periods := advanceBillingSchedule(schedule, now)
+
+		err := repository.CreateBillingPeriods(ctx, schedule, previouslyGenerated, periods)
+		if err != nil {
+			if errors.Is(err, repository.ErrorBillingScheduleChanged) {
+				continue
+			}
+			return generated, fmt.Errorf("failed to create billing periods: %w", err)
+		}
+
+		generated += len(periods)
+	}
+
+	return generated, nil
+}
+
+// advanceBillingSchedule returns the schedule's periods that have started by
+// now, up to maxPeriodsPerSweep, and moves the schedule past them. A
+// schedule with no periods left ends.
+func advanceBillingSchedule(schedule *model.BillingSchedule, now time.Time) []*model.BillingPeriod {
+	var periods []*model.BillingPeriod
+	n := schedule.PeriodsGenerated
+	for len(periods) < maxPeriodsPerSweep && schedule.HasPeriod(n) {
+		if schedule.Interval.PeriodStart(schedule.StartsAt, n).After(now) {
+			break
+		}
+		periods = append(periods, schedule.NewPeriod(n, now))
+		n++
+	}
+
+	schedule.PeriodsGenerated = n
+	if schedule.HasPeriod(n) {
+		next := schedule.Interval.PeriodStart(schedule.StartsAt, n)
+		schedule.NextPeriodAt = &next
+	} else {
+		schedule.NextPeriodAt = nil
+		schedule.Status = model.BillingScheduleStatusEnded
+	}
+
+	return periods
+}
+
+// matchBillingPayments scans the blocks mined since the last sweep for
+// transfers that settle payable billing periods and returns the number of
+// payments matched
+func matchBillingPayments(ctx context.Context) (int, error) {
+	ethClient, err := ethclient.NewClient()
+	if err != nil {
+		return 0, fmt.Errorf("failed to connect to blockchain: %w", err)
+	}
+	defer ethClient.Close()
+
+	head, err := ethClient.GetBlockNumber(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest block number: %w", err)
+	}
+
+	cursor, found, err := repository.GetChainScanCursor(ctx, billingMatcherCursor)
+	if err != nil {
+		return 0, err
+	}
+
+	// On the first run start from the chain head rather than genesis
+	if !found {
+		return 0, repository.SetChainScanCursor(ctx, billingMatcherCursor, int64(head))
+	}
+
+	fromBlock := uint64(cursor) + 1
+	if fromBlock > head {
+		return 0, nil
+	}
+	toBlock := min(head, fromBlock+maxBillingScanBlocks-1)
+
+	periods, err := repository.GetPayableBillingPeriods(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get payable billing periods: %w", err)
+	}
+
+	matched := 0
+	if len(periods) > 0 {
+		schedules, err := billingSchedulesOf(ctx, periods)
+		if err != nil {
+			return 0, err
+		}
+
+		var nativePayees, tokenPayees, tokens []string
+		for _, schedule := range schedules {
+			if schedule.TokenAddress == nil {
+				nativePayees = append(nativePayees, schedule.PayeeAddress)
+			} else {
+				tokenPayees = append(tokenPayees, schedule.PayeeAddress)
+				tokens = append(tokens, *schedule.TokenAddress)
+			}
+		}
+
+		transfers, err := findTransfers(ctx, ethClient, nativePayees, tokenPayees, tokens, fromBlock, toBlock)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, transfer := range transfers {
+			if ctx.Err() != nil {
+				return matched, ctx.Err()
+			}
+
+			ok, err := settleBillingTransfer(ctx, ethClient, periods, schedules, transfer)
+			if err != nil {
+				// Leave the cursor in place so the range is scanned again
+				return matched, err
+			}
+			if ok {
+				matched++
+			}
+		}
+	}
+
+	if err := repository.SetChainScanCursor(ctx, billingMatcherCursor, int64(toBlock)); err != nil {
+		return matched, err
+	}
+
+	return matched, nil
+}
+
+// billingSchedulesOf loads the schedules the periods belong to, by ID
+func billingSchedulesOf(ctx context.Context, periods []*model.BillingPeriod) (map[uuid.UUID]*model.BillingSchedule, error) {
+	seen := make(map[uuid.UUID]bool)
+	var ids []uuid.UUID
+	for _, period := range periods {
+		if !seen[period.ScheduleID] {
+			seen[period.ScheduleID] = true
+			ids = append(ids, period.ScheduleID)
+		}
+	}
+
+	schedules, err := repository.GetBillingSchedulesByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get billing schedules: %w", err)
+	}
+
+	byID := make(map[uuid.UUID]*model.BillingSchedule, len(schedules))
+	for _, schedule := range schedules {
+		byID[schedule.ID] = schedule
+	}
+
+	return byID, nil
+}
+
+// settleBillingTransfer records a transfer as a payment for the payable
+// period it settles. The period is only credited once the payment confirms.
+// It reports whether a new payment was recorded.
+func settleBillingTransfer(ctx context.Context, ethClient *ethclient.Client, periods []*model.BillingPeriod, schedules map[uuid.UUID]*model.BillingSchedule, transfer chainTransfer) (bool, error) {
+	period := selectBillingPeriodForTransfer(periods, schedules, transfer)
+	if period == nil {
+		return false, nil
+	}
+	schedule := schedules[period.ScheduleID]
+
+	txDetails, err := ethClient.VerifyTransaction(ctx, transfer.TransactionHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify transaction %s: %w", transfer.TransactionHash, err)
+	}
+
+	// Reverted transactions moved no funds
+	if txDetails.Status != 1 || txDetails.BlockNumber == nil {
+		return false, nil
+	}
+
+	now := time.Now()
+	description := fmt.Sprintf("Billing schedule %s, period %d", schedule.ID, period.PeriodNumber+1)
+	payment := &model.Payment{
+		ID:              uuid.New(),
+		UserID:          schedule.UserID,
+		FromAddress:     transfer.From,
+		ToAddress:       schedule.PayeeAddress,
+		Amount:          transfer.Value,
+		Currency:        schedule.Currency,
+		TokenAddress:    schedule.TokenAddress,
+		TokenDecimals:   schedule.TokenDecimals,
+		TransactionHash: txDetails.Hash,
+		BlockNumber:     txDetails.BlockNumber,
+		BlockHash:       txDetails.BlockHash,
+		Status:          model.PaymentStatusPending,
+		Direction:       model.PaymentDirectionIncoming,
+		Description:     &description,
+		BillingPeriodID: &period.ID,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	payment.RequiredConfirmations = requiredConfirmationsFor(payment)
+
+	confirmations, err := ethClient.GetTransactionConfirmations(ctx, txDetails.Hash)
+	if err != nil {
+		slog.Warn("Failed to get transaction confirmations", slog.Any("error", err), slog.String("txHash", txDetails.Hash))
+	}
+	payment.Confirmations = int64(confirmations)
+	payment.Status = statusForConfirmations(payment.Confirmations, payment.RequiredConfirmations)
+	if payment.Status == model.PaymentStatusConfirmed {
+		payment.ConfirmedAt = &now
+	}
+
+	payment.SetGasFees(txDetails.Fees)
+
+	valuePayment(ctx, payment)
+
+	err = repository.RecordBillingPeriodPayment(ctx, payment)
+	if err != nil {
+		if errors.Is(err, repository.ErrorDuplicateTransaction) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to record billing payment: %w", err)
+	}
+
+	// Pick up the credit of a payment that was already confirmed
+	if updated, err := repository.GetBillingPeriodByID(ctx, period.ID); err == nil {
+		*period = *updated
+	}
+
+	slog.Info("Billing payment matched",
+		slog.String("scheduleID", schedule.ID.String()),
+		slog.String("periodID", period.ID.String()),
+		slog.String("paymentID", payment.ID.String()),
+		slog.String("txHash", payment.TransactionHash),
+		slog.String("status", string(period.Status)))
+
+	return true, nil
+}
+
+// selectBillingPeriodForTransfer picks the payable period a transfer
+// settles, or nil if it matches none. The transfer must come from the
+// schedule's payer and go to its payee in the schedule's currency. Among
+// matching periods the one whose outstanding amount it pays exactly wins,
+// otherwise the oldest. periods must be ordered oldest first.
+func selectBillingPeriodForTransfer(periods []*model.BillingPeriod, schedules map[uuid.UUID]*model.BillingSchedule, transfer chainTransfer) *model.BillingPeriod {
+	var oldest *model.BillingPeriod
+	for _, period := range periods {
+		schedule := schedules[period.ScheduleID]
+		if schedule == nil || !period.Status.AcceptsPayments() {
+			continue
+		}
+
+		if !equalAddresses(schedule.PayerAddress, transfer.From) || !equalAddresses(schedule.PayeeAddress, transfer.To) {
+			continue
+		}
+
+		if (schedule.TokenAddress == nil) != (transfer.TokenAddress == nil) {
+			continue
+		}
+		if schedule.TokenAddress != nil && !strings.EqualFold(*schedule.TokenAddress, *transfer.TokenAddress) {
+			continue
+		}
+
+		if period.Amount.Sub(period.PaidAmount).Cmp(transfer.Value) == 0 {
+			return period
+		}
+
+		if oldest == nil {
+			oldest = period
+		}
+	}
+
+	return oldest
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"backend/internal/ethclient"
+	"backend/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+var ErrInvalidFeeEstimateQuery = errors.New("invalid fee estimate query")
+
+// GetFeeEstimate suggests EIP-1559 fees for each speed from recent blocks.
+// Given a recipient, it also estimates the gas of sending it the amount from
+// one of the user's wallets, what the wallet needs to hold to send it at the
+// requested speed, and whether it does.
+func GetFeeEstimate(ctx context.Context, userID string, query *model.FeeEstimateQuery) (*model.FeeEstimate, error) {
+	if query.Speed == "" {
+		query.Speed = model.FeeSpeedStandard
+	}
+	if !query.Speed.IsValid() {
+		return nil, fmt.Errorf("%w: speed must be %s, %s or %s", ErrInvalidFeeEstimateQuery,
+			model.FeeSpeedSlow, model.FeeSpeedStandard, model.FeeSpeedFast)
+	}
+	if query.To == nil && (query.From != nil || query.Amount != nil) {
+		return nil, fmt.Errorf("%w: to is required to estimate a transfer", ErrInvalidFeeEstimateQuery)
+	}
+	if query.To != nil && !ethclient.ValidateAddress(*query.To) {
+		return nil, fmt.Errorf("%w: invalid to address", ErrInvalidFeeEstimateQuery)
+	}
+
+	var value model.Amount
+	if query.Amount != nil {
+		amount, err := model.ParseAmount(*query.Amount)
+		if err != nil || amount.Sign() < 0 {
+			return nil, fmt.Errorf("%w: amount must be a non-negative integer in wei", ErrInvalidFeeEstimateQuery)
+		}
+		value = amount
+	}
+
+	ethClient, err := ethclient.NewClient()
+	if err != nil {
+		slog.Error("Failed to create Ethereum client", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to connect to blockchain: %w", err)
+	}
+	defer ethClient.Close()
+
+	suggestions, err := ethClient.SuggestFees(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest fees: %w", err)
+	}
+
+	estimate := &model.FeeEstimate{FeeSuggestions: *suggestions}
+	if query.To == nil {
+		return estimate, nil
+	}
+
+	requested := ""
+	if query.From != nil {
+		requested = *query.From
+	}
+	from, err := connectedWalletAddress(ctx, userID, requested)
+	if err != nil {
+		return nil, err
+	}
+
+	gasLimit, err := ethClient.EstimateTransferGas(ctx, from, *query.To, value.BigInt())
+	if err != nil {
+		return nil, err
+	}
+	estimate.SetGasLimit(gasLimit)
+
+	// The node only accepts the transaction if the wallet covers its value
+	// and maximum fee, even if less is paid in the end
+	needed := value.Add(*estimate.Suggestion(query.Speed).MaxCost)
+	sufficient, err := ethClient.HasSufficientBalance(ctx, from, needed.BigInt(), false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check balance: %w", err)
+	}
+
+	neededFormatted := needed.Format(model.Ether)
+	estimate.From = &from
+	estimate.To = query.To
+	estimate.Amount = &value
+	estimate.Speed = &query.Speed
+	estimate.GasLimit = &gasLimit
+	estimate.EthNeeded = &needed
+	estimate.EthNeededFormatted = &neededFormatted
+	estimate.SufficientBalance = &sufficient
+	return estimate, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"backend/internal/config"
+	"backend/internal/model"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// confirmationPolicy is loaded once from the environment:
+//
+//	PAYMENT_REQUIRED_CONFIRMATIONS  global default (falls back to config.DefaultRequiredConfirmations)
+//	PAYMENT_CONFIRMATION_THRESHOLDS per-amount overrides, e.g. "1000000000000000000:12,10000000000000000000:32"
+var confirmationPolicy = sync.OnceValue(func() model.ConfirmationPolicy {
+	defaultConfirmations := int64(config.DefaultRequiredConfirmations)
+	if value := os.Getenv("PAYMENT_REQUIRED_CONFIRMATIONS"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 1 {
+			slog.Warn("Invalid PAYMENT_REQUIRED_CONFIRMATIONS, using default",
+				slog.String("value", value),
+				slog.Int64("default", defaultConfirmations))
+		} else {
+			defaultConfirmations = parsed
+		}
+	}
+
+	policy, err := model.ParseConfirmationPolicy(defaultConfirmations, os.Getenv("PAYMENT_CONFIRMATION_THRESHOLDS"))
+	if err != nil {
+		slog.Warn("Invalid PAYMENT_CONFIRMATION_THRESHOLDS, ignoring thresholds", slog.Any("error", err))
+		return model.ConfirmationPolicy{Default: defaultConfirmations}
+	}
+
+	return policy
+})
+
+// DefaultUnknownTransactionTimeout is how long a pending payment's
+// transaction may stay unknown to the node before the payment fails when
+// PAYMENT_UNKNOWN_TRANSACTION_TIMEOUT is not set. It matches how long nodes
+// keep transactions they cannot execute yet in their mempool.
+const DefaultUnknownTransactionTimeout = 3 * time.Hour
+
+// unknownTransactionTimeout is loaded once from the
+// PAYMENT_UNKNOWN_TRANSACTION_TIMEOUT environment variable (e.g. "90m", "6h")
+var unknownTransactionTimeout = sync.OnceValue(func() time.Duration {
+	value := os.Getenv("PAYMENT_UNKNOWN_TRANSACTION_TIMEOUT")
+	if value == "" {
+		return DefaultUnknownTransactionTimeout
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		slog.Warn("Invalid PAYMENT_UNKNOWN_TRANSACTION_TIMEOUT, using default",
+			slog.String("value", value),
+			slog.Duration("default", DefaultUnknownTransactionTimeout))
+		return DefaultUnknownTransactionTimeout
+	}
+
+	return timeout
+})
+
+// RequiredConfirmations returns the number of confirmations a native ETH
+// payment of the given amount (in wei) needs before it is marked confirmed
+func RequiredConfirmations(amount model.Amount) int64 {
+	return confirmationPolicy().Required(amount)
+}
+
+// requiredConfirmationsFor applies the policy to a payment. Amount thresholds
+// are denominated in wei, so token payments use the default depth.
+func requiredConfirmationsFor(payment *model.Payment) int64 {
+	if payment.TokenAddress != nil {
+		return confirmationPolicy().Default
+	}
+	return RequiredConfirmations(payment.Amount)
+}
+
+// statusForConfirmations maps the confirmation depth of a successful
+// transaction to the payment status it should have
+func statusForConfirmations(confirmations, required int64) model.PaymentStatus {
+	if confirmations >= required {
+		return model.PaymentStatusConfirmed
+	}
+	return model.PaymentStatusConfirming
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"backend/internal/ethclient"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateContact saves an address to the user's address book
+func CreateContact(ctx context.Context, userID string, req *model.ContactRequest) (*model.ContactResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	now := time.Now()
+	contact := &model.Contact{
+		ID:        uuid.New(),
+		UserID:    userUUID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := applyContactRequest(contact, req); err != nil {
+		return nil, err
+	}
+
+	if err := repository.CreateContact(ctx, contact); err != nil {
+		return nil, err
+	}
+
+	response := contact.ToResponse()
+	return &response, nil
+}
+
+// GetContacts retrieves the user's contacts, optionally filtered by a search term
+func GetContacts(ctx context.Context, userID string, query *model.ContactQuery) ([]model.ContactResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	contacts, err := repository.GetContactsByUserID(ctx, userUUID, query.Search)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]model.ContactResponse, 0, len(contacts))
+	for _, contact := range contacts {
+		responses = append(responses, contact.ToResponse())
+	}
+
+	return responses, nil
+}
+
+// GetContact retrieves one of the user's contacts
+func GetContact(ctx context.Context, userID string, contactID string) (*model.ContactResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	contactUUID, err := uuid.Parse(contactID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid contact ID format: %w", err)
+	}
+
+	contact, err := repository.GetContact(ctx, userUUID, contactUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := contact.ToResponse()
+	return &response, nil
+}
+
+// UpdateContact replaces the details of one of the user's contacts
+func UpdateContact(ctx context.Context, userID string, contactID string, req *model.ContactRequest) (*model.ContactResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	contactUUID, err := uuid.Parse(contactID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid contact ID format: %w", err)
+	}
+
+	contact := &model.Contact{
+		ID:        contactUUID,
+		UserID:    userUUID,
+		UpdatedAt: time.Now(),
+	}
+	if err := applyContactRequest(contact, req); err != nil {
+		return nil, err
+	}
+
+	if err := repository.UpdateContact(ctx, contact); err != nil {
+		return nil, err
+	}
+
+	response := contact.ToResponse()
+	return &response, nil
+}
+
+// DeleteContact removes one of the user's contacts
+func DeleteContact(ctx context.Context, userID string, contactID string) error {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	contactUUID, err := uuid.Parse(contactID)
+	if err != nil {
+		return fmt.Errorf("invalid contact ID format: %w", err)
+	}
+
+	return repository.DeleteContact(ctx, userUUID, contactUUID)
+}
+
+// CheckAddress compares an address the user is about to pay with their
+// contacts, warning when it closely resembles a contact's address without
+// being it
+func CheckAddress(ctx context.Context, userID string, address string) (*model.AddressCheckResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	if !ethclient.ValidateAddress(address) {
+		return nil, fmt.Errorf("invalid address")
+	}
+
+	contacts, err := repository.GetContactsByUserID(ctx, userUUID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	check := model.CheckAddress(address, contacts)
+	if check.Warning != nil {
+		slog.Warn("Address resembles a saved contact",
+			slog.String("userID", userID),
+			slog.String("address", address),
+			slog.String("contact", check.Lookalikes[0].Address))
+	}
+
+	return &check, nil
+}
+
+// applyContactRequest validates a contact request and copies it into contact
+func applyContactRequest(contact *model.Contact, req *model.ContactRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("contact name must not be blank")
+	}
+
+	if !ethclient.ValidateAddress(req.Address) {
+		return fmt.Errorf("invalid contact address")
+	}
+
+	contact.Name = name
+	contact.Address = ethclient.NormalizeAddress(req.Address)
+	contact.PhoneNumber = req.PhoneNumber
+	contact.Username = req.Username
+	contact.Notes = req.Notes
+	return nil
+}
+
+// applyPaymentContact fills in the to address of a payment request from the
+// contact it refers to. An explicit to address must be the contact's.
+func applyPaymentContact(ctx context.Context, userID uuid.UUID, req *model.CreatePaymentRequest) error {
+	contactUUID, err := uuid.Parse(*req.ContactID)
+	if err != nil {
+		return fmt.Errorf("invalid contact ID format: %w", err)
+	}
+
+	contact, err := repository.GetContact(ctx, userID, contactUUID)
+	if err != nil {
+		return err
+	}
+
+	if req.ToAddress == "" {
+		req.ToAddress = contact.Address
+	} else if !equalAddresses(req.ToAddress, contact.Address) {
+		return fmt.Errorf("to address %s is not the address of contact %s", req.ToAddress, contact.Name)
+	}
+
+	return nil
+}
+
+// labelPaymentContacts sets the names of the user's contacts on the payments
+// they sent to or received from. Payments are returned unlabeled if the
+// contacts cannot be loaded.
+func labelPaymentContacts(ctx context.Context, userID string, payments ...*model.PaymentResponse) {
+	if len(payments) == 0 {
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return
+	}
+
+	contacts, err := repository.GetContactsByUserID(ctx, userUUID, nil)
+	if err != nil {
+		slog.Warn("Failed to load contacts to label payments", slog.Any("error", err), slog.String("userID", userID))
+		return
+	}
+	if len(contacts) == 0 {
+		return
+	}
+
+	names := make(map[string]*string, len(contacts))
+	for _, contact := range contacts {
+		names[strings.ToLower(contact.Address)] = &contact.Name
+	}
+
+	for _, payment := range payments {
+		payment.FromContact = names[strings.ToLower(payment.FromAddress)]
+		payment.ToContact = names[strings.ToLower(payment.ToAddress)]
+	}
+}
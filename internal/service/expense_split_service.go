@@ -0,0 +1,445 @@
+package service
+
+import (
+	"backend/internal/ethclient"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SplitReminderCooldown is the minimum time between two reminders of the
+// same share
+const SplitReminderCooldown = 24 * time.Hour
+
+// ErrSplitRecentlyReminded is returned when every outstanding participant of
+// a split was reminded within SplitReminderCooldown
+var ErrSplitRecentlyReminded = errors.New("participants were already reminded recently")
+
+// CreateExpenseSplit divides an expense among other users, identified by
+// username or phone number, who each owe their share to one of the
+// organizer's wallets
+func CreateExpenseSplit(ctx context.Context, userID string, req *model.CreateExpenseSplitRequest) (*model.ExpenseSplitResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	if !req.Method.IsValid() {
+		return nil, fmt.Errorf("invalid method: must be %q or %q", model.SplitMethodEqual, model.SplitMethodCustom)
+	}
+
+	participants := make([]*model.User, 0, len(req.Participants))
+	seen := make(map[uuid.UUID]bool, len(req.Participants))
+	for _, p := range req.Participants {
+		participant, err := resolveSplitParticipant(ctx, &p)
+		if err != nil {
+			return nil, err
+		}
+		if participant.ID == userUUID {
+			return nil, fmt.Errorf("cannot add yourself as a participant")
+		}
+		if seen[participant.ID] {
+			return nil, fmt.Errorf("%w: %s", repository.ErrorDuplicateParticipant, participant.Username)
+		}
+		seen[participant.ID] = true
+		participants = append(participants, participant)
+	}
+
+	total, amounts, err := splitAmounts(req)
+	if err != nil {
+		return nil, err
+	}
+
+	payee, err := connectedWalletAddress(ctx, userID, req.PayeeAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	ethClient, err := ethclient.NewClient()
+	if err != nil {
+		slog.Error("Failed to create Ethereum client", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to connect to blockchain: %w", err)
+	}
+	defer ethClient.Close()
+
+	token, err := resolveToken(ctx, ethClient, req.Currency, req.TokenAddress)
+	if err != nil {
+		slog.Error("Failed to resolve expense split currency", slog.Any("error", err))
+		return nil, err
+	}
+
+	organizer, err := repository.FindUserByID(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	split := &model.ExpenseSplit{
+		ID:                uuid.New(),
+		UserID:            userUUID,
+		PayeeAddress:      payee,
+		TotalAmount:       total,
+		Currency:          NativeCurrency,
+		TokenDecimals:     18,
+		Method:            req.Method,
+		Description:       req.Description,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		OrganizerUsername: organizer.Username,
+		OrganizerPhone:    organizer.PhoneNumber,
+	}
+
+	if token != nil {
+		split.Currency = token.Symbol
+		split.TokenAddress = &token.Address
+		split.TokenDecimals = int(token.Decimals)
+	}
+
+	shares := make([]*model.SplitShare, 0, len(participants))
+	for i, participant := range participants {
+		shares = append(shares, &model.SplitShare{
+			ID:                  uuid.New(),
+			SplitID:             split.ID,
+			ParticipantID:       participant.ID,
+			Amount:              amounts[i],
+			Status:              model.SplitShareStatusOutstanding,
+			CreatedAt:           now,
+			UpdatedAt:           now,
+			ParticipantUsername: participant.Username,
+			ParticipantPhone:    participant.PhoneNumber,
+		})
+	}
+
+	if err := repository.CreateExpenseSplit(ctx, split, shares); err != nil {
+		slog.Error("Failed to create expense split record", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to save expense split: %w", err)
+	}
+
+	slog.Info("Expense split created successfully",
+		slog.String("splitID", split.ID.String()),
+		slog.Int("participants", len(shares)),
+		slog.String("amount", split.TotalAmount.String()),
+		slog.String("currency", split.Currency))
+
+	response := split.ToResponse(shares)
+	return &response, nil
+}
+
+// GetExpenseSplit retrieves one of the user's expense splits with the
+// settlement state of every share
+func GetExpenseSplit(ctx context.Context, userID string, splitID string) (*model.ExpenseSplitResponse, error) {
+	split, err := loadOwnedExpenseSplit(ctx, userID, splitID)
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := repository.GetSplitSharesBySplitIDs(ctx, []uuid.UUID{split.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	response := split.ToResponse(shares[split.ID])
+	return &response, nil
+}
+
+// GetUserExpenseSplits retrieves the expense splits the user organized, each
+// with its shares
+func GetUserExpenseSplits(ctx context.Context, userID string, query *model.ExpenseSplitQuery) ([]model.ExpenseSplitResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	splits, err := repository.GetExpenseSplitsByUserID(ctx, userUUID, query.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	splitIDs := make([]uuid.UUID, 0, len(splits))
+	for _, split := range splits {
+		splitIDs = append(splitIDs, split.ID)
+	}
+
+	shares, err := repository.GetSplitSharesBySplitIDs(ctx, splitIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]model.ExpenseSplitResponse, 0, len(splits))
+	for _, split := range splits {
+		responses = append(responses, split.ToResponse(shares[split.ID]))
+	}
+
+	return responses, nil
+}
+
+// GetOwedSplitShares retrieves the shares of other users' expense splits that
+// the user owes, with where to pay each
+func GetOwedSplitShares(ctx context.Context, userID string, query *model.OwedShareQuery) ([]model.OwedShareResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	shares, err := repository.GetSplitSharesByParticipantID(ctx, userUUID, query.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	splitIDs := make([]uuid.UUID, 0, len(shares))
+	for _, share := range shares {
+		splitIDs = append(splitIDs, share.SplitID)
+	}
+
+	splits, err := repository.GetExpenseSplitsByIDs(ctx, splitIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	splitsByID := make(map[uuid.UUID]*model.ExpenseSplit, len(splits))
+	for _, split := range splits {
+		splitsByID[split.ID] = split
+	}
+
+	responses := make([]model.OwedShareResponse, 0, len(shares))
+	for _, share := range shares {
+		if split, ok := splitsByID[share.SplitID]; ok {
+			responses = append(responses, share.ToOwedResponse(split))
+		}
+	}
+
+	return responses, nil
+}
+
+// RemindSplitParticipants reminds the participants of an expense split whose
+// shares are still outstanding. Each share is reminded at most once per
+// SplitReminderCooldown; participants see their reminders on the shares they
+// owe. It returns the shares that were reminded.
+func RemindSplitParticipants(ctx context.Context, userID string, splitID string) ([]model.SplitShareResponse, error) {
+	split, err := loadOwnedExpenseSplit(ctx, userID, splitID)
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := repository.GetSplitSharesBySplitIDs(ctx, []uuid.UUID{split.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	outstanding, remindable := false, false
+	for _, share := range shares[split.ID] {
+		outstanding = outstanding || share.Status == model.SplitShareStatusOutstanding
+		remindable = remindable || share.CanRemind(now, SplitReminderCooldown)
+	}
+	if !outstanding {
+		return nil, repository.ErrorNoOutstandingShares
+	}
+	if !remindable {
+		return nil, ErrSplitRecentlyReminded
+	}
+
+	reminded, err := repository.RecordSplitReminders(ctx, split.ID, SplitReminderCooldown)
+	if err != nil {
+		return nil, err
+	}
+	if len(reminded) == 0 {
+		// Another request reminded everyone in the meantime
+		return nil, ErrSplitRecentlyReminded
+	}
+
+	responses := make([]model.SplitShareResponse, 0, len(reminded))
+	for _, share := range reminded {
+		slog.Info("Split participant reminded",
+			slog.String("splitID", split.ID.String()),
+			slog.String("shareID", share.ID.String()),
+			slog.Int("reminderCount", share.ReminderCount))
+		responses = append(responses, share.ToResponse(split))
+	}
+
+	return responses, nil
+}
+
+// loadOwnedExpenseSplit fetches an expense split organized by the user
+func loadOwnedExpenseSplit(ctx context.Context, userID string, splitID string) (*model.ExpenseSplit, error) {
+	splitUUID, err := uuid.Parse(splitID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expense split ID format: %w", err)
+	}
+
+	split, err := repository.GetExpenseSplitByID(ctx, splitUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if split.UserID.String() != userID {
+		return nil, repository.ErrorExpenseSplitNotFound
+	}
+
+	return split, nil
+}
+
+// resolveSplitParticipant finds the user a participant request refers to and
+// checks that they have a wallet to pay their share from
+func resolveSplitParticipant(ctx context.Context, p *model.SplitParticipantRequest) (*model.User, error) {
+	var (
+		participant *model.User
+		err         error
+	)
+
+	switch {
+	case p.Username != "" && p.PhoneNumber != "":
+		return nil, fmt.Errorf("identify participant %s by username or phone number, not both", p.Username)
+	case p.Username != "":
+		participant, err = repository.FindUserByUsername(ctx, p.Username)
+		if errors.Is(err, repository.ErrorUserNotFound) {
+			return nil, fmt.Errorf("no user is registered with username %s: %w", p.Username, err)
+		}
+	case p.PhoneNumber != "":
+		participant, err = repository.FindUserByPhoneNumber(ctx, p.PhoneNumber)
+		if errors.Is(err, repository.ErrorUserNotFound) {
+			return nil, fmt.Errorf("no user is registered with phone number %s: %w", p.PhoneNumber, err)
+		}
+	default:
+		return nil, fmt.Errorf("each participant needs a username or phone number")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	wallets, err := repository.GetWalletAddressesFromPhone(ctx, participant.PhoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(wallets) == 0 {
+		return nil, fmt.Errorf("participant %s has no connected wallet", participant.Username)
+	}
+
+	return participant, nil
+}
+
+// splitAmounts returns the total of a split request and each participant's
+// share, in request order. Equal splits divide the total among the
+// participants, and the organizer when included. Custom splits take each
+// participant's amount; a total above their sum is the organizer's own part.
+func splitAmounts(req *model.CreateExpenseSplitRequest) (model.Amount, []model.Amount, error) {
+	var total model.Amount
+	if req.Amount != "" {
+		amount, err := model.ParseAmount(req.Amount)
+		if err != nil || amount.Sign() <= 0 {
+			return model.Amount{}, nil, fmt.Errorf("invalid amount: must be a positive integer in the currency's smallest unit")
+		}
+		total = amount
+	}
+
+	if req.Method == model.SplitMethodEqual {
+		if req.Amount == "" {
+			return model.Amount{}, nil, fmt.Errorf("amount is required for an equal split")
+		}
+
+		parts := len(req.Participants)
+		if req.IncludeOrganizer {
+			parts++
+		}
+
+		amounts := model.SplitEqually(total, parts)
+		if amounts[parts-1].Sign() <= 0 {
+			return model.Amount{}, nil, fmt.Errorf("amount is too small to split %d ways", parts)
+		}
+
+		// With the organizer included, the last part is theirs and nobody owes it
+		return total, amounts[:len(req.Participants)], nil
+	}
+
+	if req.IncludeOrganizer {
+		return model.Amount{}, nil, fmt.Errorf("include_organizer only applies to equal splits")
+	}
+
+	sum := model.Amount{}
+	amounts := make([]model.Amount, 0, len(req.Participants))
+	for _, p := range req.Participants {
+		amount, err := model.ParseAmount(p.Amount)
+		if err != nil || amount.Sign() <= 0 {
+			return model.Amount{}, nil, fmt.Errorf("invalid amount for participant: each share of a custom split must be a positive integer in the currency's smallest unit")
+		}
+		sum = sum.Add(amount)
+		amounts = append(amounts, amount)
+	}
+
+	if req.Amount == "" {
+		return sum, amounts, nil
+	}
+	if total.Cmp(sum) < 0 {
+		return model.Amount{}, nil, fmt.Errorf("participants' shares add up to %s, more than the amount %s", sum, total)
+	}
+
+	return total, amounts, nil
+}
+
+// loadPayableShare fetches the split share a payment request refers to and
+// checks that the request pays it: owed by the user, to the organizer's payee
+// wallet, for the share's amount in the split's currency. Currency and token
+// address default to the split's when omitted.
+func loadPayableShare(ctx context.Context, userID uuid.UUID, req *model.CreatePaymentRequest) (*model.SplitShare, error) {
+	shareUUID, err := uuid.Parse(*req.SplitShareID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid split share ID format: %w", err)
+	}
+
+	share, err := repository.GetSplitShareByID(ctx, shareUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if share.ParticipantID != userID {
+		return nil, repository.ErrorSplitShareNotFound
+	}
+
+	if share.Status != model.SplitShareStatusOutstanding {
+		return nil, repository.ErrorSplitShareNotPayable
+	}
+
+	split, err := repository.GetExpenseSplitByID(ctx, share.SplitID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !equalAddresses(req.ToAddress, split.PayeeAddress) {
+		return nil, fmt.Errorf("to address %s does not match the expense split payee %s", req.ToAddress, split.PayeeAddress)
+	}
+
+	amount, err := model.ParseAmount(req.Amount)
+	if err != nil || amount.Cmp(share.Amount) != 0 {
+		return nil, fmt.Errorf("amount %s does not match the split share amount %s", req.Amount, share.Amount)
+	}
+
+	if req.Currency == "" {
+		req.Currency = split.Currency
+	}
+	if !strings.EqualFold(req.Currency, split.Currency) {
+		return nil, fmt.Errorf("currency %s does not match the expense split currency %s", req.Currency, split.Currency)
+	}
+
+	if split.TokenAddress == nil {
+		if req.TokenAddress != "" {
+			return nil, fmt.Errorf("token address does not match the expense split")
+		}
+	} else if req.TokenAddress == "" {
+		req.TokenAddress = *split.TokenAddress
+	} else if !strings.EqualFold(req.TokenAddress, *split.TokenAddress) {
+		return nil, fmt.Errorf("token address does not match the expense split")
+	}
+
+	if req.Description == nil {
+		req.Description = split.Description
+	}
+
+	return share, nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"backend/internal/export"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExportPayments streams the user's payments matching the query's filters to
+// w in the given format. The query is validated before anything is written;
+// output is buffered, so if an error is returned before the first buffer
+// fills, nothing has reached w. OFX statements default to ETH payments when
+// no currency filter is given.
+func ExportPayments(ctx context.Context, userID string, format export.Format, query *model.PaymentQuery, w io.Writer) error {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	if err := validatePaymentQuery(query); err != nil {
+		return fmt.Errorf("%w: %v", repository.ErrorInvalidPaymentQuery, err)
+	}
+
+	if format == export.FormatOFX && query.Currency == nil {
+		currency := NativeCurrency
+		query.Currency = &currency
+	}
+
+	statement := export.Statement{
+		AccountID: userID,
+		Start:     time.Unix(0, 0),
+		End:       time.Now(),
+	}
+	if query.Currency != nil {
+		statement.Currency = *query.Currency
+	}
+	if query.CreatedAfter != nil {
+		statement.Start = *query.CreatedAfter
+	}
+	if query.CreatedBefore != nil {
+		statement.End = *query.CreatedBefore
+	}
+
+	writer, err := export.NewPaymentWriter(format, w, statement)
+	if err != nil {
+		return err
+	}
+
+	err = repository.StreamPaymentsByUserID(ctx, userUUID, query, writer.WritePayment)
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/internal/repository"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultIdempotencyKeyTTL is how long responses are kept for replay when
+	// IDEMPOTENCY_KEY_TTL is not set
+	defaultIdempotencyKeyTTL = 24 * time.Hour
+
+	// idempotencyLockTimeout is how long a request may hold its key before a
+	// retry can take it over; it outlasts the server's write timeout
+	idempotencyLockTimeout = time.Minute
+
+	// MaxIdempotencyKeyLength is the longest Idempotency-Key accepted
+	MaxIdempotencyKeyLength = 255
+)
+
+var (
+	ErrInvalidIdempotencyKey    = fmt.Errorf("idempotency key must be 1 to %d characters", MaxIdempotencyKeyLength)
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still being processed")
+)
+
+// idempotencyKeyTTL returns how long responses are kept for replay
+func idempotencyKeyTTL() time.Duration {
+	value := os.Getenv("IDEMPOTENCY_KEY_TTL")
+	if value == "" {
+		return defaultIdempotencyKeyTTL
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		slog.Warn("Invalid IDEMPOTENCY_KEY_TTL, using default",
+			slog.String("value", value),
+			slog.Duration("default", defaultIdempotencyKeyTTL))
+		return defaultIdempotencyKeyTTL
+	}
+
+	return ttl
+}
+
+// IdempotencyFingerprint identifies a request by its method, path and body.
+// JSON bodies are compacted first, so retries that only differ in whitespace
+// match.
+func IdempotencyFingerprint(method, path string, body []byte) string {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, body); err == nil {
+		body = compact.Bytes()
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", method, path)
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// BeginIdempotentRequest claims an idempotency key for a request. It returns
+// nil when the caller should process the request, or the stored key whose
+// response should be replayed. A key already used for a different request
+// fails with ErrIdempotencyKeyReused, and one whose request is still being
+// processed with ErrIdempotencyKeyInProgress.
+func BeginIdempotentRequest(ctx context.Context, userID string, key string, fingerprint string) (*model.IdempotencyKey, error) {
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return nil, ErrInvalidIdempotencyKey
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	existing, err := repository.ClaimIdempotencyKey(ctx, userUUID, key, fingerprint, idempotencyKeyTTL(), idempotencyLockTimeout)
+	if errors.Is(err, repository.ErrorIdempotencyKeyNotFound) {
+		// The previous request failed and released the key just now
+		existing, err = repository.ClaimIdempotencyKey(ctx, userUUID, key, fingerprint, idempotencyKeyTTL(), idempotencyLockTimeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, nil
+	}
+
+	if existing.RequestHash != fingerprint {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if !existing.Completed() {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+
+	return existing, nil
+}
+
+// CompleteIdempotentRequest stores the response to a request that claimed
+// its key. Only successful responses are kept; the key of a failed request
+// is released so the client can retry it, since nothing was created.
+func CompleteIdempotentRequest(ctx context.Context, userID string, key string, statusCode int, contentType string, body []byte) error {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	if statusCode < 200 || statusCode >= 300 {
+		return repository.ReleaseIdempotencyKey(ctx, userUUID, key)
+	}
+
+	return repository.CompleteIdempotencyKey(ctx, userUUID, key, statusCode, contentType, body)
+}
+
+// PurgeExpiredIdempotencyKeys deletes keys past their retention window and
+// returns how many were deleted
+func PurgeExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	return repository.DeleteExpiredIdempotencyKeys(ctx)
+}
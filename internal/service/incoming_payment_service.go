@@ -0,0 +1,162 @@
+package service
+
+import (
+	"backend/internal/ethclient"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// incomingIndexerCursor names the chain scan cursor of IndexIncomingPayments
+	incomingIndexerCursor = "incoming_payment_indexer"
+
+	// maxIncomingScanBlocks bounds the number of blocks scanned per sweep
+	maxIncomingScanBlocks = 100
+)
+
+// IndexIncomingPayments scans the blocks mined since the last sweep for ETH
+// transfers into any connected wallet and records each as an incoming payment
+// of the wallet's owner. Confirmation tracking then proceeds as for payments
+// users register themselves. It returns the number of payments recorded.
+func IndexIncomingPayments(ctx context.Context) (int, error) {
+	ethClient, err := ethclient.NewClient()
+	if err != nil {
+		return 0, fmt.Errorf("failed to connect to blockchain: %w", err)
+	}
+	defer ethClient.Close()
+
+	head, err := ethClient.GetBlockNumber(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest block number: %w", err)
+	}
+
+	cursor, found, err := repository.GetChainScanCursor(ctx, incomingIndexerCursor)
+	if err != nil {
+		return 0, err
+	}
+
+	// On the first run start from the chain head rather than genesis
+	if !found {
+		return 0, repository.SetChainScanCursor(ctx, incomingIndexerCursor, int64(head))
+	}
+
+	fromBlock := uint64(cursor) + 1
+	if fromBlock > head {
+		return 0, nil
+	}
+	toBlock := min(head, fromBlock+maxIncomingScanBlocks-1)
+
+	owners, err := repository.GetWalletOwners(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get wallet owners: %w", err)
+	}
+
+	recipients := make([]string, 0, len(owners))
+	for address := range owners {
+		recipients = append(recipients, address)
+	}
+
+	indexed := 0
+	for block := fromBlock; block <= toBlock && len(recipients) > 0; block++ {
+		if ctx.Err() != nil {
+			return indexed, ctx.Err()
+		}
+
+		transfers, err := ethClient.GetNativeTransfersInBlock(ctx, block, recipients)
+		if err != nil {
+			// Leave the cursor in place so the range is scanned again
+			return indexed, err
+		}
+
+		for _, transfer := range transfers {
+			ok, err := recordIncomingTransfer(ctx, ethClient, owners[strings.ToLower(transfer.To)], transfer)
+			if err != nil {
+				return indexed, err
+			}
+			if ok {
+				indexed++
+			}
+		}
+	}
+
+	if err := repository.SetChainScanCursor(ctx, incomingIndexerCursor, int64(toBlock)); err != nil {
+		return indexed, err
+	}
+
+	return indexed, nil
+}
+
+// recordIncomingTransfer stores a successful ETH transfer as an incoming
+// payment of the receiving wallet's owner. It reports whether a new payment
+// was recorded; transfers already on record are skipped.
+func recordIncomingTransfer(ctx context.Context, ethClient *ethclient.Client, ownerID uuid.UUID, transfer model.TransactionDetails) (bool, error) {
+	if ownerID == uuid.Nil {
+		return false, nil
+	}
+
+	txDetails, err := ethClient.VerifyTransaction(ctx, transfer.Hash)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify transaction %s: %w", transfer.Hash, err)
+	}
+
+	// Reverted transactions moved no funds
+	if txDetails.Status != 1 || txDetails.BlockNumber == nil {
+		return false, nil
+	}
+
+	now := time.Now()
+	payment := &model.Payment{
+		ID:              uuid.New(),
+		UserID:          ownerID,
+		FromAddress:     txDetails.From,
+		ToAddress:       transfer.To,
+		Amount:          txDetails.Value,
+		Currency:        NativeCurrency,
+		TokenDecimals:   18,
+		TransactionHash: txDetails.Hash,
+		BlockNumber:     txDetails.BlockNumber,
+		BlockHash:       txDetails.BlockHash,
+		Status:          model.PaymentStatusPending,
+		Direction:       model.PaymentDirectionIncoming,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	payment.RequiredConfirmations = requiredConfirmationsFor(payment)
+
+	confirmations, err := ethClient.GetTransactionConfirmations(ctx, txDetails.Hash)
+	if err != nil {
+		slog.Warn("Failed to get transaction confirmations", slog.Any("error", err), slog.String("txHash", txDetails.Hash))
+	}
+	payment.Confirmations = int64(confirmations)
+	payment.Status = statusForConfirmations(payment.Confirmations, payment.RequiredConfirmations)
+	if payment.Status == model.PaymentStatusConfirmed {
+		payment.ConfirmedAt = &now
+	}
+
+	// Gas is recorded for reference; it was paid by the sender
+	payment.SetGasFees(txDetails.Fees)
+
+	valuePayment(ctx, payment)
+
+	inserted, err := repository.CreateIncomingPayment(ctx, payment)
+	if err != nil {
+		return false, fmt.Errorf("failed to record incoming payment: %w", err)
+	}
+
+	if inserted {
+		slog.Info("Incoming payment indexed",
+			slog.String("paymentID", payment.ID.String()),
+			slog.String("userID", ownerID.String()),
+			slog.String("txHash", payment.TransactionHash),
+			slog.String("amount", payment.Amount.String()))
+	}
+
+	return inserted, nil
+}
@@ -0,0 +1,500 @@
+package service
+
+import (
+	"backend/internal/ethclient"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultInvoiceExpiry is used when an invoice is created without expires_in_minutes
+const DefaultInvoiceExpiry = 24 * time.Hour
+
+// invoiceMatcherCursor names the chain scan cursor of MatchInvoicePayments
+const invoiceMatcherCursor = "invoice_matcher"
+
+// maxInvoiceScanBlocks bounds how many blocks a single matching sweep scans,
+// so a matcher that fell behind catches up over several sweeps
+const maxInvoiceScanBlocks = 100
+
+// CreateInvoice creates an invoice payable to one of the user's wallets
+func CreateInvoice(ctx context.Context, userID string, req *model.CreateInvoiceRequest) (*model.InvoiceResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	userWallets, err := repository.GetUserWalletAddresses(ctx, userID)
+	if err != nil {
+		slog.Error("Failed to get user wallet addresses", slog.Any("error", err), slog.String("userID", userID))
+		return nil, fmt.Errorf("failed to get user wallet addresses: %w", err)
+	}
+
+	var recipient string
+	for _, wallet := range userWallets {
+		if equalAddresses(wallet, req.RecipientAddress) {
+			recipient = wallet
+			break
+		}
+	}
+
+	if recipient == "" {
+		return nil, fmt.Errorf("recipient address is not one of your connected wallets")
+	}
+
+	amount, err := model.ParseAmount(req.Amount)
+	if err != nil || amount.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid amount: must be a positive integer in the currency's smallest unit")
+	}
+
+	ethClient, err := ethclient.NewClient()
+	if err != nil {
+		slog.Error("Failed to create Ethereum client", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to connect to blockchain: %w", err)
+	}
+	defer ethClient.Close()
+
+	token, err := resolveToken(ctx, ethClient, req.Currency, req.TokenAddress)
+	if err != nil {
+		slog.Error("Failed to resolve invoice currency", slog.Any("error", err))
+		return nil, err
+	}
+
+	expiry := DefaultInvoiceExpiry
+	if req.ExpiresInMinutes > 0 {
+		expiry = time.Duration(req.ExpiresInMinutes) * time.Minute
+	}
+
+	now := time.Now()
+	invoice := &model.Invoice{
+		ID:               uuid.New(),
+		UserID:           userUUID,
+		RecipientAddress: recipient,
+		Amount:           amount,
+		PaidAmount:       model.Amount{},
+		Currency:         NativeCurrency,
+		TokenDecimals:    18,
+		Memo:             req.Memo,
+		Status:           model.InvoiceStatusOpen,
+		ExpiresAt:        now.Add(expiry),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+
+	if token != nil {
+		invoice.Currency = token.Symbol
+		invoice.TokenAddress = &token.Address
+		invoice.TokenDecimals = int(token.Decimals)
+	}
+
+	err = repository.CreateInvoice(ctx, invoice)
+	if err != nil {
+		slog.Error("Failed to create invoice record", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to save invoice: %w", err)
+	}
+
+	slog.Info("Invoice created successfully",
+		slog.String("invoiceID", invoice.ID.String()),
+		slog.String("recipient", invoice.RecipientAddress),
+		slog.String("amount", invoice.Amount.String()),
+		slog.String("currency", invoice.Currency))
+
+	response := invoice.ToResponse()
+	return &response, nil
+}
+
+// GetInvoice retrieves an invoice owned by the user, including its matched payments
+func GetInvoice(ctx context.Context, userID string, invoiceID string) (*model.InvoiceResponse, error) {
+	invoiceUUID, err := uuid.Parse(invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid invoice ID format: %w", err)
+	}
+
+	invoice, err := repository.GetInvoiceByID(ctx, invoiceUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify user owns this invoice
+	if invoice.UserID.String() != userID {
+		return nil, repository.ErrorInvoiceNotFound
+	}
+
+	payments, err := repository.GetPaymentsByInvoiceID(ctx, invoice.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := invoice.ToResponse()
+	for _, payment := range payments {
+		response.Payments = append(response.Payments, payment.ToResponse())
+	}
+
+	return &response, nil
+}
+
+// GetUserInvoices retrieves the user's invoices, optionally filtered by status
+func GetUserInvoices(ctx context.Context, userID string, query *model.InvoiceQuery) ([]model.InvoiceResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	if query.Status != nil && !query.Status.IsValid() {
+		return nil, fmt.Errorf("invalid status filter")
+	}
+
+	invoices, err := repository.GetInvoicesByUserID(ctx, userUUID, query.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]model.InvoiceResponse, 0, len(invoices))
+	for _, invoice := range invoices {
+		responses = append(responses, invoice.ToResponse())
+	}
+
+	return responses, nil
+}
+
+// GetSharedInvoice retrieves the public view of an invoice that is shared with payers
+func GetSharedInvoice(ctx context.Context, invoiceID string) (*model.SharedInvoiceResponse, error) {
+	invoiceUUID, err := uuid.Parse(invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid invoice ID format: %w", err)
+	}
+
+	invoice, err := repository.GetInvoiceByID(ctx, invoiceUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := invoice.ToSharedResponse()
+	return &response, nil
+}
+
+// CancelInvoice cancels one of the user's open invoices
+func CancelInvoice(ctx context.Context, userID string, invoiceID string) (*model.InvoiceResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	invoiceUUID, err := uuid.Parse(invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid invoice ID format: %w", err)
+	}
+
+	invoice, err := repository.GetInvoiceByID(ctx, invoiceUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if invoice.UserID != userUUID {
+		return nil, repository.ErrorInvoiceNotFound
+	}
+
+	if err := repository.CancelInvoice(ctx, userUUID, invoiceUUID); err != nil {
+		return nil, err
+	}
+
+	return GetInvoice(ctx, userID, invoiceID)
+}
+
+// chainTransfer is an ETH or token transfer found on chain that may settle
+// an invoice or billing period
+type chainTransfer struct {
+	TransactionHash string
+	From            string
+	To              string
+	Value           model.Amount
+	TokenAddress    *string
+	BlockTime       time.Time // When the transfer's block was produced
+}
+
+// MatchInvoicePayments expires overdue invoices, then scans the blocks mined
+// since the last sweep for transfers into the recipient wallets of payable
+// invoices. Each matching transfer is recorded as a payment for the invoice
+// owner, and its amount is added to the invoice's paid amount once the
+// payment confirms. It returns the number of payments matched.
+func MatchInvoicePayments(ctx context.Context) (int, error) {
+	expired, err := repository.ExpireInvoices(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire invoices: %w", err)
+	}
+	if expired > 0 {
+		slog.Info("Invoices expired", slog.Int64("count", expired))
+	}
+
+	ethClient, err := ethclient.NewClient()
+	if err != nil {
+		return 0, fmt.Errorf("failed to connect to blockchain: %w", err)
+	}
+	defer ethClient.Close()
+
+	head, err := ethClient.GetBlockNumber(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest block number: %w", err)
+	}
+
+	cursor, found, err := repository.GetChainScanCursor(ctx, invoiceMatcherCursor)
+	if err != nil {
+		return 0, err
+	}
+
+	// On the first run start from the chain head rather than genesis
+	if !found {
+		return 0, repository.SetChainScanCursor(ctx, invoiceMatcherCursor, int64(head))
+	}
+
+	fromBlock := uint64(cursor) + 1
+	if fromBlock > head {
+		return 0, nil
+	}
+	toBlock := min(head, fromBlock+maxInvoiceScanBlocks-1)
+
+	invoices, err := repository.GetPayableInvoices(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get payable invoices: %w", err)
+	}
+
+	matched := 0
+	if len(invoices) > 0 {
+		transfers, err := findInvoiceTransfers(ctx, ethClient, invoices, fromBlock, toBlock)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, transfer := range transfers {
+			if ctx.Err() != nil {
+				return matched, ctx.Err()
+			}
+
+			ok, err := settleInvoiceTransfer(ctx, ethClient, invoices, transfer)
+			if err != nil {
+				// Leave the cursor in place so the range is scanned again
+				return matched, err
+			}
+			if ok {
+				matched++
+			}
+		}
+	}
+
+	if err := repository.SetChainScanCursor(ctx, invoiceMatcherCursor, int64(toBlock)); err != nil {
+		return matched, err
+	}
+
+	return matched, nil
+}
+
+// findInvoiceTransfers collects the ETH and token transfers between two
+// blocks (inclusive) that are sent to the recipient of any of the invoices
+func findInvoiceTransfers(ctx context.Context, ethClient *ethclient.Client, invoices []*model.Invoice, fromBlock, toBlock uint64) ([]chainTransfer, error) {
+	var nativeRecipients, tokenRecipients, tokens []string
+	for _, invoice := range invoices {
+		if invoice.TokenAddress == nil {
+			nativeRecipients = append(nativeRecipients, invoice.RecipientAddress)
+		} else {
+			tokenRecipients = append(tokenRecipients, invoice.RecipientAddress)
+			tokens = append(tokens, *invoice.TokenAddress)
+		}
+	}
+
+	return findTransfers(ctx, ethClient, nativeRecipients, tokenRecipients, tokens, fromBlock, toBlock)
+}
+
+// findTransfers collects the ETH transfers to nativeRecipients and the
+// transfers of the given tokens to tokenRecipients between two blocks
+// (inclusive)
+func findTransfers(ctx context.Context, ethClient *ethclient.Client, nativeRecipients, tokenRecipients, tokens []string, fromBlock, toBlock uint64) ([]chainTransfer, error) {
+	var transfers []chainTransfer
+
+	blockTimes := make(map[uint64]time.Time)
+	blockTime := func(block uint64) (time.Time, error) {
+		if t, ok := blockTimes[block]; ok {
+			return t, nil
+		}
+		t, err := ethClient.GetBlockTime(ctx, block)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to get time of block %d: %w", block, err)
+		}
+		blockTimes[block] = t
+		return t, nil
+	}
+
+	if len(nativeRecipients) > 0 {
+		for block := fromBlock; block <= toBlock; block++ {
+			txs, err := ethClient.GetNativeTransfersInBlock(ctx, block, nativeRecipients)
+			if err != nil {
+				return nil, err
+			}
+			if len(txs) == 0 {
+				continue
+			}
+
+			minedAt, err := blockTime(block)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, tx := range txs {
+				transfers = append(transfers, chainTransfer{
+					TransactionHash: tx.Hash,
+					From:            tx.From,
+					To:              tx.To,
+					Value:           tx.Value,
+					BlockTime:       minedAt,
+				})
+			}
+		}
+	}
+
+	tokenTransfers, err := ethClient.FilterTokenTransfers(ctx, fromBlock, toBlock, tokens, tokenRecipients)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter token transfers: %w", err)
+	}
+
+	for _, transfer := range tokenTransfers {
+		if transfer.BlockNumber == nil {
+			continue
+		}
+
+		minedAt, err := blockTime(*transfer.BlockNumber)
+		if err != nil {
+			return nil, err
+		}
+
+		tokenAddress := transfer.Token
+		transfers = append(transfers, chainTransfer{
+			TransactionHash: transfer.TransactionHash,
+			From:            transfer.From,
+			To:              transfer.To,
+			Value:           transfer.Value,
+			TokenAddress:    &tokenAddress,
+			BlockTime:       minedAt,
+		})
+	}
+
+	return transfers, nil
+}
+
+// settleInvoiceTransfer records a transfer as a payment for the best
+// matching payable invoice: the one whose outstanding amount it pays exactly,
+// otherwise the oldest. The invoice is only credited once the payment
+// confirms. It reports whether a new payment was recorded.
+func settleInvoiceTransfer(ctx context.Context, ethClient *ethclient.Client, invoices []*model.Invoice, transfer chainTransfer) (bool, error) {
+	invoice := selectInvoiceForTransfer(invoices, transfer)
+	if invoice == nil {
+		return false, nil
+	}
+
+	txDetails, err := ethClient.VerifyTransaction(ctx, transfer.TransactionHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify transaction %s: %w", transfer.TransactionHash, err)
+	}
+
+	// Reverted transactions moved no funds
+	if txDetails.Status != 1 || txDetails.BlockNumber == nil {
+		return false, nil
+	}
+
+	now := time.Now()
+	description := "Invoice " + invoice.ID.String()
+	payment := &model.Payment{
+		ID:              uuid.New(),
+		UserID:          invoice.UserID,
+		FromAddress:     txDetails.From,
+		ToAddress:       invoice.RecipientAddress,
+		Amount:          transfer.Value,
+		Currency:        invoice.Currency,
+		TokenAddress:    invoice.TokenAddress,
+		TokenDecimals:   invoice.TokenDecimals,
+		TransactionHash: txDetails.Hash,
+		BlockNumber:     txDetails.BlockNumber,
+		BlockHash:       txDetails.BlockHash,
+		Status:          model.PaymentStatusPending,
+		Direction:       model.PaymentDirectionIncoming,
+		Description:     &description,
+		InvoiceID:       &invoice.ID,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	payment.RequiredConfirmations = requiredConfirmationsFor(payment)
+
+	confirmations, err := ethClient.GetTransactionConfirmations(ctx, txDetails.Hash)
+	if err != nil {
+		slog.Warn("Failed to get transaction confirmations", slog.Any("error", err), slog.String("txHash", txDetails.Hash))
+	}
+	payment.Confirmations = int64(confirmations)
+	payment.Status = statusForConfirmations(payment.Confirmations, payment.RequiredConfirmations)
+	if payment.Status == model.PaymentStatusConfirmed {
+		payment.ConfirmedAt = &now
+	}
+
+	payment.SetGasFees(txDetails.Fees)
+
+	valuePayment(ctx, payment)
+
+	err = repository.RecordInvoicePayment(ctx, payment)
+	if err != nil {
+		if errors.Is(err, repository.ErrorDuplicateTransaction) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to record invoice payment: %w", err)
+	}
+
+	// Pick up the credit of a payment that was already confirmed
+	if updated, err := repository.GetInvoiceByID(ctx, invoice.ID); err == nil {
+		*invoice = *updated
+	}
+
+	slog.Info("Invoice payment matched",
+		slog.String("invoiceID", invoice.ID.String()),
+		slog.String("paymentID", payment.ID.String()),
+		slog.String("txHash", payment.TransactionHash),
+		slog.String("status", string(invoice.Status)))
+
+	return true, nil
+}
+
+// selectInvoiceForTransfer picks the payable invoice a transfer settles, or
+// nil if it matches none. Only transfers mined while the invoice was open,
+// between its creation and expiry, count. invoices must be ordered oldest
+// first.
+func selectInvoiceForTransfer(invoices []*model.Invoice, transfer chainTransfer) *model.Invoice {
+	var oldest *model.Invoice
+	for _, invoice := range invoices {
+		if !invoice.Status.AcceptsPayments() || !equalAddresses(invoice.RecipientAddress, transfer.To) {
+			continue
+		}
+
+		if transfer.BlockTime.Before(invoice.CreatedAt) || transfer.BlockTime.After(invoice.ExpiresAt) {
+			continue
+		}
+
+		if (invoice.TokenAddress == nil) != (transfer.TokenAddress == nil) {
+			continue
+		}
+		if invoice.TokenAddress != nil && !strings.EqualFold(*invoice.TokenAddress, *transfer.TokenAddress) {
+			continue
+		}
+
+		if invoice.Amount.Sub(invoice.PaidAmount).Cmp(transfer.Value) == 0 {
+			return invoice
+		}
+
+		if oldest == nil {
+			oldest = invoice
+		}
+	}
+
+	return oldest
+}
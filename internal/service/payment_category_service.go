@@ -0,0 +1,177 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/internal/repository"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreatePaymentCategory creates a category the user can file payments under
+func CreatePaymentCategory(ctx context.Context, userID string, req *model.PaymentCategoryRequest) (*model.PaymentCategoryResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("category name must not be blank")
+	}
+
+	now := time.Now()
+	category := &model.PaymentCategory{
+		ID:        uuid.New(),
+		UserID:    userUUID,
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := repository.CreatePaymentCategory(ctx, category); err != nil {
+		return nil, err
+	}
+
+	response := category.ToResponse()
+	return &response, nil
+}
+
+// GetPaymentCategories retrieves the user's payment categories
+func GetPaymentCategories(ctx context.Context, userID string) ([]model.PaymentCategoryResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	categories, err := repository.GetPaymentCategoriesByUserID(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]model.PaymentCategoryResponse, 0, len(categories))
+	for _, category := range categories {
+		responses = append(responses, category.ToResponse())
+	}
+
+	return responses, nil
+}
+
+// UpdatePaymentCategory renames one of the user's payment categories
+func UpdatePaymentCategory(ctx context.Context, userID string, categoryID string, req *model.PaymentCategoryRequest) (*model.PaymentCategoryResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	categoryUUID, err := uuid.Parse(categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid category ID format: %w", err)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("category name must not be blank")
+	}
+
+	category := &model.PaymentCategory{
+		ID:        categoryUUID,
+		UserID:    userUUID,
+		Name:      name,
+		UpdatedAt: time.Now(),
+	}
+
+	if err := repository.UpdatePaymentCategory(ctx, category); err != nil {
+		return nil, err
+	}
+
+	response := category.ToResponse()
+	return &response, nil
+}
+
+// DeletePaymentCategory removes one of the user's payment categories,
+// leaving its payments uncategorized
+func DeletePaymentCategory(ctx context.Context, userID string, categoryID string) error {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	categoryUUID, err := uuid.Parse(categoryID)
+	if err != nil {
+		return fmt.Errorf("invalid category ID format: %w", err)
+	}
+
+	return repository.DeletePaymentCategory(ctx, userUUID, categoryUUID)
+}
+
+// UpdatePaymentAnnotations replaces the category and tags of one of the
+// user's payments
+func UpdatePaymentAnnotations(ctx context.Context, userID string, paymentID string, req *model.UpdatePaymentAnnotationsRequest) (*model.PaymentResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	paymentUUID, err := uuid.Parse(paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payment ID format: %w", err)
+	}
+
+	categoryID, err := resolvePaymentCategory(ctx, userUUID, req.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := model.NormalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	payment, err := repository.UpdatePaymentAnnotations(ctx, userUUID, paymentUUID, categoryID, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Payment annotations updated",
+		slog.String("paymentID", payment.ID.String()),
+		slog.Int("tags", len(payment.Tags)))
+
+	response := payment.ToResponse()
+	labelPaymentContacts(ctx, userID, &response)
+	return &response, nil
+}
+
+// SearchPayments runs a full-text search over the user's payments, narrowed
+// by the other filters of the query
+func SearchPayments(ctx context.Context, userID string, query *model.PaymentQuery) (*model.PaymentListResponse, error) {
+	if query.Search == nil || strings.TrimSpace(*query.Search) == "" {
+		return nil, fmt.Errorf("%w: search query q is required", repository.ErrorInvalidPaymentQuery)
+	}
+
+	return GetUserPayments(ctx, userID, query)
+}
+
+// resolvePaymentCategory checks that a category a payment is filed under
+// belongs to the user
+func resolvePaymentCategory(ctx context.Context, userID uuid.UUID, categoryID *string) (*uuid.UUID, error) {
+	if categoryID == nil {
+		return nil, nil
+	}
+
+	categoryUUID, err := uuid.Parse(*categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid category ID format: %w", err)
+	}
+
+	category, err := repository.GetPaymentCategory(ctx, userID, categoryUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &category.ID, nil
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"backend/internal/ethclient"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/google/uuid"
+)
+
+// DefaultPaymentIntentExpiry is used when an intent is created without expires_in_minutes
+const DefaultPaymentIntentExpiry = 30 * time.Minute
+
+// CreatePaymentIntent resolves a phone number to its owner's preferred wallet
+// and records the payer's intention to pay it. The response includes the
+// unsigned transaction the payer's wallet needs to sign.
+func CreatePaymentIntent(ctx context.Context, userID string, req *model.CreatePaymentIntentRequest) (*model.PaymentIntentResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	amount, err := model.ParseAmount(req.Amount)
+	if err != nil || amount.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid amount: must be a positive integer in the currency's smallest unit")
+	}
+
+	payer, err := repository.FindUserByID(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient, err := repository.FindUserByPhoneNumber(ctx, req.PhoneNumber)
+	if err != nil {
+		if errors.Is(err, repository.ErrorUserNotFound) {
+			return nil, fmt.Errorf("no user is registered with phone number %s: %w", req.PhoneNumber, err)
+		}
+		return nil, err
+	}
+
+	if recipient.ID == userUUID {
+		return nil, fmt.Errorf("cannot create a payment intent to your own phone number")
+	}
+
+	recipientAddress, err := repository.GetPreferredWalletAddress(ctx, recipient.PhoneNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	ethClient, err := ethclient.NewClient()
+	if err != nil {
+		slog.Error("Failed to create Ethereum client", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to connect to blockchain: %w", err)
+	}
+	defer ethClient.Close()
+
+	token, err := resolveToken(ctx, ethClient, req.Currency, req.TokenAddress)
+	if err != nil {
+		slog.Error("Failed to resolve payment intent currency", slog.Any("error", err))
+		return nil, err
+	}
+
+	expiry := DefaultPaymentIntentExpiry
+	if req.ExpiresInMinutes > 0 {
+		expiry = time.Duration(req.ExpiresInMinutes) * time.Minute
+	}
+
+	now := time.Now()
+	intent := &model.PaymentIntent{
+		ID:               uuid.New(),
+		PayerID:          userUUID,
+		RecipientID:      recipient.ID,
+		RecipientPhone:   recipient.PhoneNumber,
+		RecipientAddress: recipientAddress,
+		Amount:           amount,
+		Currency:         NativeCurrency,
+		TokenDecimals:    18,
+		Description:      req.Description,
+		Status:           model.PaymentIntentStatusPending,
+		ExpiresAt:        now.Add(expiry),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		PayerUsername:    payer.Username,
+		PayerPhone:       payer.PhoneNumber,
+	}
+
+	if token != nil {
+		intent.Currency = token.Symbol
+		intent.TokenAddress = &token.Address
+		intent.TokenDecimals = int(token.Decimals)
+	}
+
+	transaction, err := buildIntentTransaction(ctx, ethClient, intent)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repository.CreatePaymentIntent(ctx, intent)
+	if err != nil {
+		slog.Error("Failed to create payment intent record", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to save payment intent: %w", err)
+	}
+
+	slog.Info("Payment intent created successfully",
+		slog.String("intentID", intent.ID.String()),
+		slog.String("recipient", intent.RecipientAddress),
+		slog.String("amount", intent.Amount.String()),
+		slog.String("currency", intent.Currency))
+
+	response := intent.ToResponse()
+	response.Transaction = transaction
+	return &response, nil
+}
+
+// GetPaymentIntent retrieves a payment intent sent or received by the user.
+// While the intent is still payable, its payer also gets the transaction to sign.
+func GetPaymentIntent(ctx context.Context, userID string, intentID string) (*model.PaymentIntentResponse, error) {
+	intentUUID, err := uuid.Parse(intentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payment intent ID format: %w", err)
+	}
+
+	intent, err := repository.GetPaymentIntentByID(ctx, intentUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	isPayer := intent.PayerID.String() == userID
+	if !isPayer && intent.RecipientID.String() != userID {
+		return nil, repository.ErrorPaymentIntentNotFound
+	}
+
+	response := intent.ToResponse()
+
+	if isPayer && intent.IsPayable() {
+		ethClient, err := ethclient.NewClient()
+		if err != nil {
+			slog.Error("Failed to create Ethereum client", slog.Any("error", err))
+			return nil, fmt.Errorf("failed to connect to blockchain: %w", err)
+		}
+		defer ethClient.Close()
+
+		response.Transaction, err = buildIntentTransaction(ctx, ethClient, intent)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &response, nil
+}
+
+// GetUserPaymentIntents retrieves the payment intents the user sent or received
+func GetUserPaymentIntents(ctx context.Context, userID string) ([]model.PaymentIntentResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	intents, err := repository.GetPaymentIntentsByUserID(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]model.PaymentIntentResponse, 0, len(intents))
+	for _, intent := range intents {
+		responses = append(responses, intent.ToResponse())
+	}
+
+	return responses, nil
+}
+
+// buildIntentTransaction returns the unsigned transaction that fulfils an
+// intent: a plain ETH transfer, or a transfer call on the token contract
+func buildIntentTransaction(ctx context.Context, ethClient *ethclient.Client, intent *model.PaymentIntent) (*model.TransactionRequest, error) {
+	chainID, err := ethClient.GetChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID: %w", err)
+	}
+
+	if intent.TokenAddress == nil {
+		return &model.TransactionRequest{
+			ChainID: chainID.String(),
+			To:      intent.RecipientAddress,
+			Value:   intent.Amount,
+		}, nil
+	}
+
+	data, err := ethclient.EncodeTokenTransfer(intent.RecipientAddress, intent.Amount.BigInt())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode token transfer: %w", err)
+	}
+
+	return &model.TransactionRequest{
+		ChainID: chainID.String(),
+		To:      *intent.TokenAddress,
+		Value:   model.Amount{},
+		Data:    hexutil.Encode(data),
+	}, nil
+}
+
+// loadPayableIntent fetches the intent a payment request refers to and checks
+// that the request pays it: same payer, recipient, amount and currency.
+// Currency and token address default to the intent's when omitted.
+func loadPayableIntent(ctx context.Context, userID uuid.UUID, req *model.CreatePaymentRequest) (*model.PaymentIntent, error) {
+	intentUUID, err := uuid.Parse(*req.IntentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payment intent ID format: %w", err)
+	}
+
+	intent, err := repository.GetPaymentIntentByID(ctx, intentUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if intent.PayerID != userID {
+		return nil, repository.ErrorPaymentIntentNotFound
+	}
+
+	if !intent.IsPayable() {
+		return nil, repository.ErrorPaymentIntentNotPayable
+	}
+
+	if !equalAddresses(req.ToAddress, intent.RecipientAddress) {
+		return nil, fmt.Errorf("to address %s does not match the payment intent recipient %s", req.ToAddress, intent.RecipientAddress)
+	}
+
+	amount, err := model.ParseAmount(req.Amount)
+	if err != nil || amount.Cmp(intent.Amount) != 0 {
+		return nil, fmt.Errorf("amount %s does not match the payment intent amount %s", req.Amount, intent.Amount)
+	}
+
+	if req.Currency == "" {
+		req.Currency = intent.Currency
+	}
+	if !strings.EqualFold(req.Currency, intent.Currency) {
+		return nil, fmt.Errorf("currency %s does not match the payment intent currency %s", req.Currency, intent.Currency)
+	}
+
+	if intent.TokenAddress == nil {
+		if req.TokenAddress != "" {
+			return nil, fmt.Errorf("token address does not match the payment intent")
+		}
+	} else if req.TokenAddress == "" {
+		req.TokenAddress = *intent.TokenAddress
+	} else if !strings.EqualFold(req.TokenAddress, *intent.TokenAddress) {
+		return nil, fmt.Errorf("token address does not match the payment intent")
+	}
+
+	if req.Description == nil {
+		req.Description = intent.Description
+	}
+
+	return intent, nil
+}
@@ -5,17 +5,45 @@ import (
 	"backend/internal/model"
 	"backend/internal/repository"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	// ErrPaymentAlreadyMined is returned when cancelling a payment whose
+	// transaction has been included in a block
+	ErrPaymentAlreadyMined = errors.New("payment transaction has already been mined")
+	// ErrPaymentTransactionPending is returned when cancelling a payment whose
+	// transaction is still in the mempool and may yet be mined
+	ErrPaymentTransactionPending = errors.New("payment transaction is still waiting to be mined")
+)
+
+var (
+	// ErrInvalidRawTransaction is returned when a transaction to relay cannot
+	// be decoded or its sender recovered
+	ErrInvalidRawTransaction = errors.New("invalid raw transaction")
+	// ErrChainIDMismatch is returned when a transaction to relay is signed for
+	// another chain
+	ErrChainIDMismatch = errors.New("chain ID mismatch")
+	// ErrLookalikeRecipient is returned when a transaction to relay pays an
+	// address resembling a saved contact and the warning was not acknowledged
+	ErrLookalikeRecipient = errors.New("recipient resembles a saved contact")
+	// ErrTransactionRejected is returned when the node refuses a transaction
+	// to relay
+	ErrTransactionRejected = errors.New("transaction rejected by the node")
+)
+
 // CreatePayment creates a new payment after verifying the transaction on blockchain
 func CreatePayment(ctx context.Context, userID string, req *model.CreatePaymentRequest) (*model.PaymentResponse, error) {
+	if req.ToAddress == "" && req.ContactID == nil {
+		return nil, fmt.Errorf("to_address or contact_id is required")
+	}
+
 	// Create Ethereum client
 	ethClient, err := ethclient.NewClient()
 	if err != nil {
@@ -35,11 +63,25 @@ func CreatePayment(ctx context.Context, userID string, req *model.CreatePaymentR
 		return nil, fmt.Errorf("no wallet addresses found for user")
 	}
 
+	// Parse user ID to UUID
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	// A payment to a saved contact is sent to the contact's address
+	if req.ContactID != nil {
+		if err := applyPaymentContact(ctx, userUUID, req); err != nil {
+			return nil, err
+		}
+	}
+
 	// Check if transaction already exists
-	existingPayment, err := repository.GetPaymentByTransactionHash(ctx, req.TransactionHash)
+	existingPayment, err := repository.GetPaymentByTransactionHash(ctx, userUUID, req.TransactionHash, model.PaymentDirectionOutgoing)
 	if err == nil {
 		slog.Warn("Transaction hash already exists", slog.String("txHash", req.TransactionHash))
 		response := existingPayment.ToResponse()
+		labelPaymentContacts(ctx, userID, &response)
 		return &response, nil
 	}
 	if err != repository.ErrorPaymentNotFound {
@@ -47,6 +89,53 @@ func CreatePayment(ctx context.Context, userID string, req *model.CreatePaymentR
 		return nil, fmt.Errorf("failed to check existing payment: %w", err)
 	}
 
+	// Annotations are checked before the transaction is looked up
+	categoryID, err := resolvePaymentCategory(ctx, userUUID, req.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := model.NormalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	bindings := 0
+	for _, id := range []*string{req.IntentID, req.SplitShareID, req.PreparedPaymentID} {
+		if id != nil {
+			bindings++
+		}
+	}
+	if bindings > 1 {
+		return nil, fmt.Errorf("a payment can only do one of fulfilling a payment intent, settling a split share or completing a prepared payment")
+	}
+
+	// A payment made for a pay-by-phone intent must match what was intended
+	var intent *model.PaymentIntent
+	if req.IntentID != nil {
+		intent, err = loadPayableIntent(ctx, userUUID, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Likewise, a payment settling a split share must pay what is owed
+	var share *model.SplitShare
+	if req.SplitShareID != nil {
+		share, err = loadPayableShare(ctx, userUUID, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// A payment completing a prepared payment must send what was prepared
+	var prepared *model.PreparedPayment
+	if req.PreparedPaymentID != nil {
+		prepared, err = loadBindablePreparation(ctx, userUUID, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Verify transaction on blockchain
 	txDetails, err := ethClient.VerifyTransaction(ctx, req.TransactionHash)
 	if err != nil {
@@ -67,23 +156,245 @@ func CreatePayment(ctx context.Context, userID string, req *model.CreatePaymentR
 		return nil, fmt.Errorf("transaction is not from any of your connected wallets")
 	}
 
-	// Validate transaction matches the request
-	err = ethClient.ValidateTransactionForPayment(ctx, txDetails, req, fromAddress)
+	if prepared != nil && !equalAddresses(fromAddress, prepared.FromAddress) {
+		return nil, fmt.Errorf("transaction is not from the prepared payment's wallet %s", prepared.FromAddress)
+	}
+
+	payment, err := newOutgoingPayment(ctx, ethClient, userUUID, req, txDetails, fromAddress)
 	if err != nil {
-		slog.Error("Transaction validation failed", slog.Any("error", err))
-		return nil, fmt.Errorf("transaction validation failed: %w", err)
+		return nil, err
 	}
+	payment.CategoryID = categoryID
+	payment.Tags = tags
 
-	// Parse user ID to UUID
+	valuePayment(ctx, payment)
+
+	// Save payment to database, binding it to its intent, split share or
+	// prepared payment if there is one
+	switch {
+	case intent != nil:
+		err = repository.CreatePaymentForIntent(ctx, payment, intent.ID)
+	case share != nil:
+		err = repository.CreatePaymentForSplitShare(ctx, payment, share.ID)
+	case prepared != nil:
+		err = repository.CreatePaymentForPreparation(ctx, payment, prepared.ID)
+	default:
+		err = repository.CreatePayment(ctx, payment)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrorPaymentIntentNotPayable) || errors.Is(err, repository.ErrorSplitShareNotPayable) ||
+			errors.Is(err, repository.ErrorPreparedPaymentNotBindable) {
+			return nil, err
+		}
+		if errors.Is(err, repository.ErrorDuplicateTransaction) {
+			// A concurrent submission of the same transaction won the insert;
+			// answer with its payment, as if this request had come second
+			existingPayment, err := repository.GetPaymentByTransactionHash(ctx, userUUID, payment.TransactionHash, model.PaymentDirectionOutgoing)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load existing payment: %w", err)
+			}
+			response := existingPayment.ToResponse()
+			labelPaymentContacts(ctx, userID, &response)
+			return &response, nil
+		}
+		slog.Error("Failed to create payment record", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to save payment: %w", err)
+	}
+
+	slog.Info("Payment created successfully",
+		slog.String("paymentID", payment.ID.String()),
+		slog.String("txHash", payment.TransactionHash),
+		slog.String("status", string(payment.Status)))
+
+	response := payment.ToResponse()
+	labelPaymentContacts(ctx, userID, &response)
+	return &response, nil
+}
+
+// RelayPayment decodes a signed transaction from one of the user's wallets,
+// checks that it is for this chain and pays what the request asks for,
+// records its pending payment and then broadcasts it, so a transaction cannot
+// be sent without being registered. The payment fails if the node rejects
+// the transaction; if the broadcast fails otherwise, it stays pending for the
+// reconciler to resolve. Relaying an already recorded transaction returns its
+// payment without broadcasting it again.
+func RelayPayment(ctx context.Context, userID string, relayReq *model.RelayPaymentRequest) (*model.PaymentResponse, error) {
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid user ID format: %w", err)
 	}
 
-	// Set default currency if not provided
-	currency := req.Currency
-	if currency == "" {
-		currency = "ETH"
+	signedTx, err := ethclient.DecodeRawTransaction(relayReq.RawTransaction)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRawTransaction, err)
+	}
+
+	txDetails := ethclient.PendingTransactionDetails(signedTx)
+	if txDetails.From == "" {
+		return nil, fmt.Errorf("%w: invalid signature", ErrInvalidRawTransaction)
+	}
+	if txDetails.To == "" {
+		return nil, fmt.Errorf("%w: contract creations cannot be relayed", ErrInvalidRawTransaction)
+	}
+
+	req := relayReq.PaymentRequest(txDetails.Hash)
+	if req.ToAddress == "" && req.ContactID == nil && req.PreparedPaymentID == nil {
+		return nil, fmt.Errorf("to_address, contact_id or prepared_payment_id is required")
+	}
+
+	userWallets, err := repository.GetUserWalletAddresses(ctx, userID)
+	if err != nil {
+		slog.Error("Failed to get user wallet addresses", slog.Any("error", err), slog.String("userID", userID))
+		return nil, fmt.Errorf("failed to get user wallet addresses: %w", err)
+	}
+
+	var fromAddress string
+	for _, wallet := range userWallets {
+		if equalAddresses(wallet, txDetails.From) {
+			fromAddress = wallet
+			break
+		}
+	}
+
+	if fromAddress == "" {
+		return nil, fmt.Errorf("%w: transaction is signed by %s", ErrWalletNotOwned, txDetails.From)
+	}
+
+	existingPayment, err := repository.GetPaymentByTransactionHash(ctx, userUUID, txDetails.Hash, model.PaymentDirectionOutgoing)
+	if err == nil {
+		slog.Warn("Relayed transaction already recorded", slog.String("txHash", txDetails.Hash))
+		response := existingPayment.ToResponse()
+		labelPaymentContacts(ctx, userID, &response)
+		return &response, nil
+	}
+	if err != repository.ErrorPaymentNotFound {
+		slog.Error("Failed to check existing payment", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to check existing payment: %w", err)
+	}
+
+	if req.ContactID != nil {
+		if err := applyPaymentContact(ctx, userUUID, req); err != nil {
+			return nil, err
+		}
+	}
+
+	categoryID, err := resolvePaymentCategory(ctx, userUUID, req.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := model.NormalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	// A transaction signed from a prepared payment must send what was prepared
+	var prepared *model.PreparedPayment
+	if req.PreparedPaymentID != nil {
+		prepared, err = loadBindablePreparation(ctx, userUUID, req)
+		if err != nil {
+			return nil, err
+		}
+		if !equalAddresses(fromAddress, prepared.FromAddress) {
+			return nil, fmt.Errorf("transaction is not from the prepared payment's wallet %s", prepared.FromAddress)
+		}
+	}
+
+	// Paying a lookalike of a saved contact suggests a poisoned address, and
+	// once broadcast the transaction cannot be taken back
+	check, err := CheckAddress(ctx, userID, req.ToAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check recipient: %w", err)
+	}
+	if check.Warning != nil && !relayReq.AcknowledgeWarning {
+		return nil, fmt.Errorf("%w: %s; set acknowledge_warning to relay it anyway", ErrLookalikeRecipient, *check.Warning)
+	}
+
+	ethClient, err := ethclient.NewClient()
+	if err != nil {
+		slog.Error("Failed to create Ethereum client", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to connect to blockchain: %w", err)
+	}
+	defer ethClient.Close()
+
+	chainID, err := ethClient.GetChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID: %w", err)
+	}
+	if signedTx.ChainId().Cmp(chainID) != 0 {
+		return nil, fmt.Errorf("%w: transaction is signed for chain %s, not %s", ErrChainIDMismatch, signedTx.ChainId(), chainID)
+	}
+
+	payment, err := newOutgoingPayment(ctx, ethClient, userUUID, req, txDetails, fromAddress)
+	if err != nil {
+		return nil, err
+	}
+	payment.CategoryID = categoryID
+	payment.Tags = tags
+
+	valuePayment(ctx, payment)
+
+	// The payment is stored before the transaction is broadcast, so a
+	// transaction the node accepts is never left unrecorded
+	if prepared != nil {
+		err = repository.CreatePaymentForPreparation(ctx, payment, prepared.ID)
+	} else {
+		err = repository.CreatePayment(ctx, payment)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrorPreparedPaymentNotBindable) {
+			return nil, err
+		}
+		if errors.Is(err, repository.ErrorDuplicateTransaction) {
+			// A concurrent relay of the same transaction recorded and
+			// broadcast it first
+			existingPayment, err := repository.GetPaymentByTransactionHash(ctx, userUUID, payment.TransactionHash, model.PaymentDirectionOutgoing)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load existing payment: %w", err)
+			}
+			response := existingPayment.ToResponse()
+			labelPaymentContacts(ctx, userID, &response)
+			return &response, nil
+		}
+		slog.Error("Failed to record relayed payment", slog.Any("error", err), slog.String("txHash", payment.TransactionHash))
+		return nil, fmt.Errorf("failed to save payment: %w", err)
+	}
+
+	if sendErr := ethClient.SendTransaction(ctx, signedTx); sendErr != nil {
+		if ethclient.IsTransactionRejected(sendErr) {
+			// The request may already be cancelled; the payment must not stay pending
+			err := repository.UpdatePaymentStatus(context.WithoutCancel(ctx), payment.ID, model.PaymentStatusFailed, nil, nil, nil)
+			if err != nil {
+				slog.Error("Failed to mark rejected payment as failed", slog.Any("error", err), slog.String("paymentID", payment.ID.String()))
+			}
+			return nil, fmt.Errorf("%w: %v", ErrTransactionRejected, sendErr)
+		}
+
+		// The node may have accepted the transaction anyway; the payment
+		// stays pending until the reconciler finds it or gives up on it
+		slog.Warn("Relayed transaction may not have been broadcast",
+			slog.Any("error", sendErr),
+			slog.String("paymentID", payment.ID.String()),
+			slog.String("txHash", payment.TransactionHash))
+	}
+
+	slog.Info("Payment relayed successfully",
+		slog.String("paymentID", payment.ID.String()),
+		slog.String("txHash", payment.TransactionHash))
+
+	response := payment.ToResponse()
+	labelPaymentContacts(ctx, userID, &response)
+	return &response, nil
+}
+
+// newOutgoingPayment builds the payment a user's transaction makes, sent from
+// fromAddress, after checking that it pays what the request asks for. Mined
+// transactions have their confirmation depth and gas details recorded.
+func newOutgoingPayment(ctx context.Context, ethClient *ethclient.Client, userUUID uuid.UUID, req *model.CreatePaymentRequest, txDetails *model.TransactionDetails, fromAddress string) (*model.Payment, error) {
+	// Determine whether this is a native ETH or an ERC-20 token payment
+	token, err := resolveToken(ctx, ethClient, req.Currency, req.TokenAddress)
+	if err != nil {
+		slog.Error("Failed to resolve payment currency", slog.Any("error", err))
+		return nil, err
 	}
 
 	// Create payment record
@@ -93,46 +404,63 @@ func CreatePayment(ctx context.Context, userID string, req *model.CreatePaymentR
 		FromAddress:     txDetails.From,
 		ToAddress:       txDetails.To,
 		Amount:          txDetails.Value,
-		Currency:        currency,
+		Currency:        NativeCurrency,
+		TokenDecimals:   18,
 		TransactionHash: txDetails.Hash,
 		BlockNumber:     txDetails.BlockNumber,
+		BlockHash:       txDetails.BlockHash,
 		Status:          model.PaymentStatusPending,
+		Direction:       model.PaymentDirectionOutgoing,
 		Description:     req.Description,
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
 	}
 
-	// If transaction is confirmed, update status and add confirmation details
-	if txDetails.Status == 1 && txDetails.BlockNumber != nil {
-		payment.Status = model.PaymentStatusConfirmed
-		now := time.Now()
-		payment.ConfirmedAt = &now
+	// Validate transaction matches the request
+	if token == nil {
+		err = ethClient.ValidateTransactionForPayment(ctx, txDetails, req, fromAddress)
+		if err != nil {
+			slog.Error("Transaction validation failed", slog.Any("error", err))
+			return nil, fmt.Errorf("transaction validation failed: %w", err)
+		}
+	} else {
+		transfer, err := ethClient.ValidateTokenTransferForPayment(ctx, txDetails, req, fromAddress, token)
+		if err != nil {
+			slog.Error("Token transfer validation failed", slog.Any("error", err))
+			return nil, fmt.Errorf("transaction validation failed: %w", err)
+		}
+
+		// The transaction itself is sent to the token contract; the payment is
+		// between the parties of the Transfer event
+		payment.ToAddress = transfer.To
+		payment.Amount = transfer.Value
+		payment.Currency = token.Symbol
+		payment.TokenAddress = &token.Address
+		payment.TokenDecimals = int(token.Decimals)
+	}
 
-		// Add gas information if available
-		if txDetails.Gas > 0 {
-			gasUsed := int64(txDetails.Gas)
-			payment.GasUsed = &gasUsed
+	payment.RequiredConfirmations = requiredConfirmationsFor(payment)
+
+	// If transaction is mined, record its confirmation depth and gas details.
+	// It only becomes confirmed once the required depth has been reached.
+	if txDetails.Status == 1 && txDetails.BlockNumber != nil {
+		confirmations, err := ethClient.GetTransactionConfirmations(ctx, txDetails.Hash)
+		if err != nil {
+			slog.Warn("Failed to get transaction confirmations", slog.Any("error", err), slog.String("txHash", txDetails.Hash))
 		}
 
-		if txDetails.GasPrice != "" {
-			payment.GasPrice = &txDetails.GasPrice
+		payment.Confirmations = int64(confirmations)
+		payment.Status = statusForConfirmations(payment.Confirmations, payment.RequiredConfirmations)
+		if payment.Status == model.PaymentStatusConfirmed {
+			now := time.Now()
+			payment.ConfirmedAt = &now
 		}
-	}
 
-	// Save payment to database
-	err = repository.CreatePayment(ctx, payment)
-	if err != nil {
-		slog.Error("Failed to create payment record", slog.Any("error", err))
-		return nil, fmt.Errorf("failed to save payment: %w", err)
+		// Add gas information from the receipt
+		payment.SetGasFees(txDetails.Fees)
 	}
 
-	slog.Info("Payment created successfully",
-		slog.String("paymentID", payment.ID.String()),
-		slog.String("txHash", payment.TransactionHash),
-		slog.String("status", string(payment.Status)))
-
-	response := payment.ToResponse()
-	return &response, nil
+	return payment, nil
 }
 
 // GetPayment retrieves a payment by ID for a specific user
@@ -153,6 +481,7 @@ func GetPayment(ctx context.Context, userID string, paymentID string) (*model.Pa
 	}
 
 	response := payment.ToResponse()
+	labelPaymentContacts(ctx, userID, &response)
 	return &response, nil
 }
 
@@ -171,11 +500,79 @@ func GetUserPayments(ctx context.Context, userID string, query *model.PaymentQue
 		query.PageSize = 20
 	}
 
+	if err := validatePaymentQuery(query); err != nil {
+		return nil, fmt.Errorf("%w: %v", repository.ErrorInvalidPaymentQuery, err)
+	}
+
+	payments, err := repository.GetPaymentsByUserID(ctx, userUUID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*model.PaymentResponse, len(payments.Payments))
+	for i := range payments.Payments {
+		responses[i] = &payments.Payments[i]
+	}
+	labelPaymentContacts(ctx, userID, responses...)
+
+	return payments, nil
+}
+
+// validatePaymentQuery checks the filters and sort options of a payment list query
+func validatePaymentQuery(query *model.PaymentQuery) error {
 	if query.Status != nil && !query.Status.IsValid() {
-		return nil, fmt.Errorf("invalid status filter")
+		return fmt.Errorf("invalid status filter")
 	}
+	if query.Direction != nil && !query.Direction.IsValid() {
+		return fmt.Errorf("direction must be %s or %s", model.PaymentDirectionIncoming, model.PaymentDirectionOutgoing)
+	}
+
+	if query.SortBy == "" {
+		query.SortBy = model.PaymentSortCreatedAt
+	}
+	if query.SortBy != model.PaymentSortCreatedAt && query.SortBy != model.PaymentSortAmount {
+		return fmt.Errorf("sort_by must be %s or %s", model.PaymentSortCreatedAt, model.PaymentSortAmount)
+	}
+
+	if query.SortOrder == "" {
+		query.SortOrder = model.SortOrderDesc
+	}
+	if query.SortOrder != model.SortOrderAsc && query.SortOrder != model.SortOrderDesc {
+		return fmt.Errorf("sort_order must be %s or %s", model.SortOrderAsc, model.SortOrderDesc)
+	}
+
+	if query.CreatedAfter != nil && query.CreatedBefore != nil && !query.CreatedAfter.Before(*query.CreatedBefore) {
+		return fmt.Errorf("created_after must be before created_before")
+	}
+
+	minAmount, err := parseAmountFilter("min_amount", query.MinAmount)
+	if err != nil {
+		return err
+	}
+	maxAmount, err := parseAmountFilter("max_amount", query.MaxAmount)
+	if err != nil {
+		return err
+	}
+
+	if minAmount != nil && maxAmount != nil && minAmount.Cmp(*maxAmount) > 0 {
+		return fmt.Errorf("min_amount must not exceed max_amount")
+	}
+
+	return nil
+}
 
-	return repository.GetPaymentsByUserID(ctx, userUUID, query)
+// parseAmountFilter parses an optional amount filter given in the currency's smallest unit
+func parseAmountFilter(name string, value *string) (*model.Amount, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	amount, err := model.ParseAmount(*value)
+	if err != nil || amount.Sign() < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative integer in the currency's smallest unit", name)
+	}
+
+	return &amount, nil
 }
 
 // UpdatePaymentStatus updates the status of a payment (internal use)
@@ -186,8 +583,8 @@ func UpdatePaymentStatus(ctx context.Context, paymentID uuid.UUID, status model.
 
 	// If updating to confirmed, try to get latest blockchain info
 	var blockNumber *int64
-	var gasUsed *int64
-	var gasPrice *string
+	var blockHash *string
+	var fees *model.GasFees
 
 	if status == model.PaymentStatusConfirmed {
 		payment, err := repository.GetPaymentByID(ctx, paymentID)
@@ -199,34 +596,54 @@ func UpdatePaymentStatus(ctx context.Context, paymentID uuid.UUID, status model.
 				txDetails, err := ethClient.VerifyTransaction(ctx, payment.TransactionHash)
 				if err == nil {
 					blockNumber = txDetails.BlockNumber
-					if txDetails.Gas > 0 {
-						gas := int64(txDetails.Gas)
-						gasUsed = &gas
-					}
-					if txDetails.GasPrice != "" {
-						gasPrice = &txDetails.GasPrice
-					}
+					blockHash = txDetails.BlockHash
+					fees = txDetails.Fees
 				}
 			}
 		}
 	}
 
-	return repository.UpdatePaymentStatus(ctx, paymentID, status, blockNumber, gasUsed, gasPrice)
+	if err := repository.UpdatePaymentStatus(ctx, paymentID, status, blockNumber, blockHash, fees); err != nil {
+		return err
+	}
+
+	if status == model.PaymentStatusConfirmed {
+		revaluePayment(ctx, paymentID)
+	}
+	return nil
 }
 
-// GetPaymentByTransactionHash retrieves a payment by transaction hash
-func GetPaymentByTransactionHash(ctx context.Context, userID string, txHash string) (*model.PaymentResponse, error) {
-	payment, err := repository.GetPaymentByTransactionHash(ctx, txHash)
+// GetPaymentByTransactionHash retrieves a payment by transaction hash. Without
+// a direction the outgoing payment is preferred, for transfers between the
+// user's own wallets that are recorded in both directions.
+func GetPaymentByTransactionHash(ctx context.Context, userID string, txHash string, direction *model.PaymentDirection) (*model.PaymentResponse, error) {
+	userUUID, err := uuid.Parse(userID)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
 	}
 
-	// Verify user owns this payment
-	if payment.UserID.String() != userID {
-		return nil, fmt.Errorf("payment not found")
+	directions := []model.PaymentDirection{model.PaymentDirectionOutgoing, model.PaymentDirectionIncoming}
+	if direction != nil {
+		if !direction.IsValid() {
+			return nil, fmt.Errorf("%w: direction must be %s or %s", repository.ErrorInvalidPaymentQuery,
+				model.PaymentDirectionIncoming, model.PaymentDirectionOutgoing)
+		}
+		directions = []model.PaymentDirection{*direction}
+	}
+
+	var payment *model.Payment
+	for _, d := range directions {
+		payment, err = repository.GetPaymentByTransactionHash(ctx, userUUID, txHash, d)
+		if err != repository.ErrorPaymentNotFound {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	response := payment.ToResponse()
+	labelPaymentContacts(ctx, userID, &response)
 	return &response, nil
 }
 
@@ -247,8 +664,8 @@ func RefreshPaymentStatus(ctx context.Context, userID string, paymentID string)
 		return nil, fmt.Errorf("payment not found")
 	}
 
-	// Only refresh pending payments
-	if payment.Status != model.PaymentStatusPending {
+	// Only refresh payments that are still waiting on the blockchain
+	if payment.Status.IsFinal() {
 		response := payment.ToResponse()
 		return &response, nil
 	}
@@ -261,91 +678,369 @@ func RefreshPaymentStatus(ctx context.Context, userID string, paymentID string)
 	}
 	defer ethClient.Close()
 
-	txDetails, err := ethClient.VerifyTransaction(ctx, payment.TransactionHash)
+	payment, err = reconcilePayment(ctx, ethClient, payment)
 	if err != nil {
 		slog.Error("Failed to verify transaction for refresh", slog.Any("error", err))
-		// Return current payment if we can't verify
-		response := payment.ToResponse()
-		return &response, nil
 	}
 
-	// Update status if transaction is now confirmed
-	if txDetails.Status == 1 && txDetails.BlockNumber != nil {
-		var gasUsed *int64
-		var gasPrice *string
+	response := payment.ToResponse()
+	return &response, nil
+}
+
+// CancelPayment cancels one of the user's pending payments whose transaction
+// was never mined, e.g. because it was dropped or replaced. A cancelled
+// payment is final and is no longer tracked, even if the transaction is mined
+// later. Payments in any other status are rejected with
+// repository.ErrorInvalidStatusTransition.
+func CancelPayment(ctx context.Context, userID string, paymentID string) (*model.PaymentResponse, error) {
+	paymentUUID, err := uuid.Parse(paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payment ID format: %w", err)
+	}
+
+	payment, err := repository.GetPaymentByID(ctx, paymentUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify user owns this payment
+	if payment.UserID.String() != userID {
+		return nil, repository.ErrorPaymentNotFound
+	}
+
+	if !payment.Status.CanTransitionTo(model.PaymentStatusCancelled) {
+		return nil, fmt.Errorf("%w: %s payments cannot be cancelled", repository.ErrorInvalidStatusTransition, payment.Status)
+	}
+
+	ethClient, err := ethclient.NewClient()
+	if err != nil {
+		slog.Error("Failed to create Ethereum client for cancellation", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to connect to blockchain: %w", err)
+	}
+	defer ethClient.Close()
+
+	mined, err := ethClient.IsTransactionMined(ctx, payment.TransactionHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check transaction: %w", err)
+	}
+	if mined {
+		return nil, ErrPaymentAlreadyMined
+	}
+
+	// Cancelled is final, so a transaction that can still be mined must not
+	// be cancelled: the transfer would stay recorded as cancelled
+	pending, err := ethClient.IsTransactionPending(ctx, payment.TransactionHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check transaction: %w", err)
+	}
+	if pending {
+		return nil, ErrPaymentTransactionPending
+	}
+
+	// The transition is checked again atomically, in case the reconciler
+	// moved the payment on in the meantime
+	err = repository.UpdatePaymentStatus(ctx, payment.ID, model.PaymentStatusCancelled,
+		payment.BlockNumber, payment.BlockHash, payment.GasFees())
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Payment cancelled",
+		slog.String("paymentID", payment.ID.String()),
+		slog.String("txHash", payment.TransactionHash))
+
+	cancelled, err := repository.GetPaymentByID(ctx, payment.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refetch cancelled payment: %w", err)
+	}
+
+	response := cancelled.ToResponse()
+	return &response, nil
+}
+
+// reconcileBatch is how many payments ReconcilePendingPayments verifies in
+// one call
+const reconcileBatch = 100
+
+// ReconcilePendingPayments re-verifies pending or confirming payments against
+// the blockchain, one batch of payments with IDs above after at a time,
+// tracks their confirmation depth and moves them to confirmed or failed once
+// settled. A pending payment whose transaction the node still does not know
+// after the unknown transaction timeout fails. It returns the ID to continue
+// from, uuid.Nil once every payment has been visited, and the number of
+// payments whose status changed.
+func ReconcilePendingPayments(ctx context.Context, after uuid.UUID) (uuid.UUID, int, error) {
+	payments, err := repository.GetUnsettledPayments(ctx, after, reconcileBatch)
+	if err != nil {
+		return after, 0, fmt.Errorf("failed to get pending payments: %w", err)
+	}
+
+	if len(payments) == 0 {
+		return uuid.Nil, 0, nil
+	}
+
+	ethClient, err := ethclient.NewClient()
+	if err != nil {
+		return after, 0, fmt.Errorf("failed to connect to blockchain: %w", err)
+	}
+	defer ethClient.Close()
 
-		if txDetails.Gas > 0 {
-			gas := int64(txDetails.Gas)
-			gasUsed = &gas
+	updated := 0
+	for _, payment := range payments {
+		// Stop early if we are shutting down
+		if ctx.Err() != nil {
+			return after, updated, ctx.Err()
 		}
-		if txDetails.GasPrice != "" {
-			gasPrice = &txDetails.GasPrice
+		after = payment.ID
+
+		reconciled, err := reconcilePayment(ctx, ethClient, payment)
+		if err != nil {
+			slog.Warn("Failed to reconcile pending payment",
+				slog.Any("error", err),
+				slog.String("paymentID", payment.ID.String()),
+				slog.String("txHash", payment.TransactionHash))
+			continue
+		}
+
+		if reconciled.Status != payment.Status {
+			updated++
 		}
+	}
+
+	if len(payments) < reconcileBatch {
+		after = uuid.Nil
+	}
+	return after, updated, nil
+}
+
+// gasFeeBackfillBatch is how many payments BackfillGasFees reads receipts for
+// in one call
+const gasFeeBackfillBatch = 50
+
+// BackfillGasFees reads the gas fees of settled outgoing payments recorded
+// without them, from their receipts, one batch of payments with IDs above
+// after at a time. It returns the ID to continue from, uuid.Nil once every
+// payment has been visited, and how many payments were updated. Payments
+// whose receipt cannot be read are left for the next pass.
+func BackfillGasFees(ctx context.Context, after uuid.UUID) (uuid.UUID, int, error) {
+	payments, err := repository.GetPaymentsMissingGasFees(ctx, after, gasFeeBackfillBatch)
+	if err != nil {
+		return after, 0, fmt.Errorf("failed to get payments missing gas fees: %w", err)
+	}
 
-		err = repository.UpdatePaymentStatus(ctx, paymentUUID, model.PaymentStatusConfirmed,
-			txDetails.BlockNumber, gasUsed, gasPrice)
+	if len(payments) == 0 {
+		return uuid.Nil, 0, nil
+	}
+
+	ethClient, err := ethclient.NewClient()
+	if err != nil {
+		return after, 0, fmt.Errorf("failed to connect to blockchain: %w", err)
+	}
+	defer ethClient.Close()
+
+	updated := 0
+	for _, payment := range payments {
+		if ctx.Err() != nil {
+			return after, updated, ctx.Err()
+		}
+		after = payment.ID
+
+		txDetails, err := ethClient.VerifyTransaction(ctx, payment.TransactionHash)
+		if err != nil || txDetails.Fees == nil {
+			slog.Debug("Gas fees not available yet",
+				slog.Any("error", err),
+				slog.String("paymentID", payment.ID.String()),
+				slog.String("txHash", payment.TransactionHash))
+			continue
+		}
+
+		if err := repository.UpdatePaymentGasFees(ctx, payment.ID, txDetails.Fees); err != nil {
+			slog.Warn("Failed to backfill payment gas fees",
+				slog.Any("error", err),
+				slog.String("paymentID", payment.ID.String()))
+			continue
+		}
+		updated++
+	}
+
+	if len(payments) < gasFeeBackfillBatch {
+		after = uuid.Nil
+	}
+	return after, updated, nil
+}
+
+// reconcilePayment checks the transaction of a pending or confirming payment
+// on the blockchain and persists its confirmation depth and new status. The
+// returned payment reflects the stored state; on error the original payment
+// is returned.
+func reconcilePayment(ctx context.Context, ethClient *ethclient.Client, payment *model.Payment) (*model.Payment, error) {
+	txDetails, err := ethClient.VerifyTransaction(ctx, payment.TransactionHash)
+	if ethclient.IsTransactionNotFound(err) {
+		return failUnknownTransaction(ctx, payment)
+	}
+	if err != nil {
+		return payment, fmt.Errorf("failed to verify transaction: %w", err)
+	}
+
+	// Transaction has not been mined yet, nothing to do
+	if txDetails.BlockNumber == nil {
+		return payment, nil
+	}
+
+	// Token payments must also still contain their Transfer event once mined;
+	// a transaction that did not emit it did not pay the recipient
+	paid := txDetails.Status == 1 && (payment.TokenAddress == nil || hasMatchingTokenTransfer(txDetails, payment))
+
+	var confirmations, required int64
+	if paid {
+		depth, err := ethClient.GetTransactionConfirmations(ctx, payment.TransactionHash)
 		if err != nil {
-			slog.Error("Failed to update payment status", slog.Any("error", err))
-		} else {
-			// Refetch updated payment
-			payment, err = repository.GetPaymentByID(ctx, paymentUUID)
-			if err != nil {
-				slog.Error("Failed to refetch updated payment", slog.Any("error", err))
-			}
+			return payment, fmt.Errorf("failed to get transaction confirmations: %w", err)
+		}
+		confirmations = int64(depth)
+
+		required = payment.RequiredConfirmations
+		if required < 1 {
+			required = requiredConfirmationsFor(payment)
+		}
+
+		err = repository.UpdatePaymentConfirmations(ctx, payment.ID, confirmations, required)
+		if err != nil {
+			return payment, fmt.Errorf("failed to update payment confirmations: %w", err)
 		}
 	}
 
-	response := payment.ToResponse()
-	return &response, nil
+	status, changed := ReconcilePaymentStatus(payment, paid, confirmations, required, *txDetails.BlockHash)
+	if changed {
+		err = repository.UpdatePaymentStatus(ctx, payment.ID, status, txDetails.BlockNumber, txDetails.BlockHash, txDetails.Fees)
+		if err != nil {
+			return payment, fmt.Errorf("failed to update payment status: %w", err)
+		}
+
+		slog.Info("Payment status reconciled",
+			slog.String("paymentID", payment.ID.String()),
+			slog.String("txHash", payment.TransactionHash),
+			slog.String("status", string(status)))
+
+		if status == model.PaymentStatusConfirmed && payment.Status != model.PaymentStatusConfirmed {
+			revaluePayment(ctx, payment.ID)
+		}
+	}
+
+	// Refetch updated payment
+	updated, err := repository.GetPaymentByID(ctx, payment.ID)
+	if err != nil {
+		return payment, fmt.Errorf("failed to refetch updated payment: %w", err)
+	}
+
+	return updated, nil
 }
 
-// GetPaymentStats returns payment statistics for a user
-func GetPaymentStats(ctx context.Context, userID string) (map[string]interface{}, error) {
-	userUUID, err := uuid.Parse(userID)
+// failUnknownTransaction fails a pending payment whose transaction the node
+// does not know once the unknown transaction timeout has passed: it was never
+// broadcast or was dropped from the mempool. Until then the transaction may
+// still show up, and the payment is left alone.
+func failUnknownTransaction(ctx context.Context, payment *model.Payment) (*model.Payment, error) {
+	if !TransactionAbandoned(payment, time.Now(), unknownTransactionTimeout()) {
+		slog.Debug("Payment transaction not found yet",
+			slog.String("paymentID", payment.ID.String()),
+			slog.String("txHash", payment.TransactionHash))
+		return payment, nil
+	}
+
+	err := repository.UpdatePaymentStatus(ctx, payment.ID, model.PaymentStatusFailed, nil, nil, nil)
 	if err != nil {
-		return nil, fmt.Errorf("invalid user ID format: %w", err)
+		return payment, fmt.Errorf("failed to update payment status: %w", err)
 	}
 
-	// Get all payments for stats calculation
-	query := &model.PaymentQuery{
-		Page:     1,
-		PageSize: 1000, // Get a large number for stats
+	slog.Info("Payment failed after its transaction was not found",
+		slog.String("paymentID", payment.ID.String()),
+		slog.String("txHash", payment.TransactionHash))
+
+	updated, err := repository.GetPaymentByID(ctx, payment.ID)
+	if err != nil {
+		return payment, fmt.Errorf("failed to refetch updated payment: %w", err)
 	}
+	return updated, nil
+}
 
-	payments, err := repository.GetPaymentsByUserID(ctx, userUUID, query)
+// TransactionAbandoned reports whether a payment whose transaction the node
+// does not know should be given up on: it is still pending and was last
+// updated, when it was recorded or rolled back by a reorg, at least timeout
+// ago. A confirming payment's transaction was mined, so its disappearance is
+// left to reorg detection.
+func TransactionAbandoned(payment *model.Payment, now time.Time, timeout time.Duration) bool {
+	return payment.Status == model.PaymentStatusPending && now.Sub(payment.UpdatedAt) >= timeout
+}
+
+// ReconcilePaymentStatus returns the status a payment should have once its
+// transaction is mined into the given block, and whether the payment must be
+// updated. A transaction that did not pay the recipient fails the payment; a
+// paying one keeps it confirming until it is required confirmations deep. A
+// payment whose transaction moved to another block is updated even if its
+// status stays the same.
+func ReconcilePaymentStatus(payment *model.Payment, paid bool, confirmations, required int64, blockHash string) (model.PaymentStatus, bool) {
+	status := model.PaymentStatusFailed
+	if paid {
+		status = statusForConfirmations(confirmations, required)
+	}
+
+	changed := status != payment.Status || payment.BlockHash == nil || *payment.BlockHash != blockHash
+	return status, changed
+}
+
+// hasMatchingTokenTransfer reports whether a mined transaction emitted the
+// Transfer event recorded on a token payment
+func hasMatchingTokenTransfer(txDetails *model.TransactionDetails, payment *model.Payment) bool {
+	transfer := ethclient.MatchTokenTransfer(txDetails.TokenTransfers, *payment.TokenAddress, payment.FromAddress, payment.ToAddress, payment.Amount)
+	return transfer != nil
+}
+
+// GetPaymentStats returns all-time payment statistics for a user, aggregated
+// in the database
+func GetPaymentStats(ctx context.Context, userID string) (*model.PaymentStats, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	totals, err := repository.GetPaymentTotals(ctx, repository.PaymentAnalyticsRange{UserID: userUUID})
 	if err != nil {
 		return nil, err
 	}
 
-	stats := map[string]interface{}{
-		"total_payments": payments.TotalCount,
-		"confirmed":      0,
-		"pending":        0,
-		"failed":         0,
-		"total_amount":   "0",
+	stats := &model.PaymentStats{Totals: totals}
+
+	// Fiat totals are best effort; the wei totals do not depend on them
+	fiat, err := repository.GetUserDisplayCurrency(ctx, userUUID)
+	if err == nil {
+		stats.Fiat, err = repository.GetFiatTotals(ctx, userUUID, fiat)
 	}
+	if err != nil {
+		slog.Warn("Failed to get fiat payment totals", slog.Any("error", err), slog.String("userID", userID))
+	}
+
+	for _, total := range totals {
+		stats.TotalPayments += total.Count
 
-	// Calculate stats
-	totalAmountWei := int64(0)
-	for _, payment := range payments.Payments {
-		switch payment.Status {
+		switch total.Status {
 		case model.PaymentStatusConfirmed:
-			stats["confirmed"] = stats["confirmed"].(int) + 1
+			stats.Confirmed += total.Count
+			// Token amounts are in other units and cannot be summed with wei
+			if total.IsNative() && total.Direction == model.PaymentDirectionIncoming {
+				stats.TotalReceived = stats.TotalReceived.Add(total.Amount)
+			} else if total.IsNative() {
+				stats.TotalAmount = stats.TotalAmount.Add(total.Amount)
+			}
+		case model.PaymentStatusConfirming:
+			stats.Confirming += total.Count
 		case model.PaymentStatusPending:
-			stats["pending"] = stats["pending"].(int) + 1
+			stats.Pending += total.Count
 		case model.PaymentStatusFailed:
-			stats["failed"] = stats["failed"].(int) + 1
-		}
-
-		// Add to total amount (assuming ETH/Wei)
-		if payment.Status == model.PaymentStatusConfirmed {
-			if amount, err := strconv.ParseInt(payment.Amount, 10, 64); err == nil {
-				totalAmountWei += amount
-			}
+			stats.Failed += total.Count
 		}
 	}
 
-	stats["total_amount"] = strconv.FormatInt(totalAmountWei, 10)
 	return stats, nil
 }
 
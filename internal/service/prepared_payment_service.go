@@ -0,0 +1,261 @@
+package service
+
+import (
+	"backend/internal/ethclient"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/google/uuid"
+)
+
+// PreparedPaymentExpiry is how long a prepared payment reserves its nonce
+const PreparedPaymentExpiry = 15 * time.Minute
+
+// ErrInsufficientBalance is returned when a wallet cannot cover a payment and
+// its fees
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+// PreparePayment builds a ready-to-sign EIP-1559 transaction sending the
+// requested amount from one of the user's wallets, after checking the wallet
+// can cover it and the maximum fee. The nonce, chain ID, gas limit and fees
+// come from the node. The preparation is stored so the payment recorded for
+// the broadcast transaction, with its prepared_payment_id, binds to it.
+func PreparePayment(ctx context.Context, userID string, req *model.PreparePaymentRequest) (*model.PreparedPaymentResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	amount, err := model.ParseAmount(req.Amount)
+	if err != nil || amount.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid amount: must be a positive integer in the currency's smallest unit")
+	}
+
+	if !ethclient.ValidateAddress(req.ToAddress) {
+		return nil, fmt.Errorf("invalid to address: %s", req.ToAddress)
+	}
+
+	if req.Speed == "" {
+		req.Speed = model.FeeSpeedStandard
+	}
+	if !req.Speed.IsValid() {
+		return nil, fmt.Errorf("speed must be %s, %s or %s", model.FeeSpeedSlow, model.FeeSpeedStandard, model.FeeSpeedFast)
+	}
+
+	from, err := connectedWalletAddress(ctx, userID, req.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	ethClient, err := ethclient.NewClient()
+	if err != nil {
+		slog.Error("Failed to create Ethereum client", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to connect to blockchain: %w", err)
+	}
+	defer ethClient.Close()
+
+	token, err := resolveToken(ctx, ethClient, req.Currency, req.TokenAddress)
+	if err != nil {
+		slog.Error("Failed to resolve prepared payment currency", slog.Any("error", err))
+		return nil, err
+	}
+
+	now := time.Now()
+	prepared := &model.PreparedPayment{
+		ID:            uuid.New(),
+		UserID:        userUUID,
+		FromAddress:   from,
+		ToAddress:     req.ToAddress,
+		Amount:        amount,
+		Currency:      NativeCurrency,
+		TokenDecimals: 18,
+		Description:   req.Description,
+		Status:        model.PreparedPaymentStatusPending,
+		ExpiresAt:     now.Add(PreparedPaymentExpiry),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if token != nil {
+		prepared.Currency = token.Symbol
+		prepared.TokenAddress = &token.Address
+		prepared.TokenDecimals = int(token.Decimals)
+
+		// Gas is estimated against the token balance, so check it first
+		balance, err := ethClient.GetTokenBalance(ctx, token.Address, from)
+		if err != nil {
+			return nil, err
+		}
+		if balance.Cmp(amount.BigInt()) < 0 {
+			return nil, fmt.Errorf("%w: %s holds %s %s, %s needed", ErrInsufficientBalance,
+				from, model.NewAmount(balance).Format(model.Unit(token.Decimals)), token.Symbol,
+				amount.Format(model.Unit(token.Decimals)))
+		}
+	}
+
+	to, value, data, err := preparedCall(prepared)
+	if err != nil {
+		return nil, err
+	}
+
+	if token == nil {
+		prepared.GasLimit, err = ethClient.EstimateTransferGas(ctx, from, to, value)
+	} else {
+		prepared.GasLimit, err = ethClient.EstimateCallGas(ctx, from, to, value, data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate gas: %w", err)
+	}
+
+	suggestions, err := ethClient.SuggestFees(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest fees: %w", err)
+	}
+	fees := suggestions.Suggestion(req.Speed)
+	prepared.MaxFeePerGas = fees.MaxFeePerGas
+	prepared.MaxPriorityFeePerGas = fees.MaxPriorityFeePerGas
+
+	// The node only accepts the transaction if the wallet covers its value
+	// and maximum fee
+	needed := model.NewAmount(value).Add(prepared.MaxFee())
+	sufficient, err := ethClient.HasSufficientBalance(ctx, from, needed.BigInt(), false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check balance: %w", err)
+	}
+	if !sufficient {
+		return nil, fmt.Errorf("%w: %s needs up to %s ETH including fees", ErrInsufficientBalance, from, needed.Format(model.Ether))
+	}
+
+	chainID, err := ethClient.GetChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID: %w", err)
+	}
+	prepared.ChainID = chainID.Int64()
+
+	nonce, err := ethClient.GetPendingNonce(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nonce: %w", err)
+	}
+	prepared.Nonce = nonce
+
+	if err := repository.CreatePreparedPayment(ctx, prepared); err != nil {
+		slog.Error("Failed to create prepared payment", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to save prepared payment: %w", err)
+	}
+
+	transaction, err := buildPreparedTransaction(prepared)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Payment prepared",
+		slog.String("preparedPaymentID", prepared.ID.String()),
+		slog.String("from", prepared.FromAddress),
+		slog.Uint64("nonce", prepared.Nonce))
+
+	response := prepared.ToResponse()
+	response.Transaction = transaction
+	return &response, nil
+}
+
+// preparedCall returns the recipient, value and call data of a prepared
+// payment's transaction: a plain ETH transfer, or a transfer call on the
+// token contract
+func preparedCall(prepared *model.PreparedPayment) (string, *big.Int, []byte, error) {
+	if prepared.TokenAddress == nil {
+		return prepared.ToAddress, prepared.Amount.BigInt(), nil, nil
+	}
+
+	data, err := ethclient.EncodeTokenTransfer(prepared.ToAddress, prepared.Amount.BigInt())
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to encode token transfer: %w", err)
+	}
+	return *prepared.TokenAddress, new(big.Int), data, nil
+}
+
+// buildPreparedTransaction returns the unsigned transaction of a prepared payment
+func buildPreparedTransaction(prepared *model.PreparedPayment) (*model.UnsignedTransaction, error) {
+	to, value, data, err := preparedCall(prepared)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := &model.UnsignedTransaction{
+		Type:                 2,
+		ChainID:              big.NewInt(prepared.ChainID).String(),
+		Nonce:                prepared.Nonce,
+		From:                 prepared.FromAddress,
+		To:                   to,
+		Value:                model.NewAmount(value),
+		Gas:                  prepared.GasLimit,
+		MaxFeePerGas:         prepared.MaxFeePerGas,
+		MaxPriorityFeePerGas: prepared.MaxPriorityFeePerGas,
+	}
+	if data != nil {
+		transaction.Data = hexutil.Encode(data)
+	}
+	return transaction, nil
+}
+
+// loadBindablePreparation fetches the prepared payment a payment request
+// refers to and checks that the request pays it: same recipient, amount and
+// currency. Currency and token address default to the preparation's when
+// omitted.
+func loadBindablePreparation(ctx context.Context, userID uuid.UUID, req *model.CreatePaymentRequest) (*model.PreparedPayment, error) {
+	preparedUUID, err := uuid.Parse(*req.PreparedPaymentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prepared payment ID format: %w", err)
+	}
+
+	prepared, err := repository.GetPreparedPaymentByID(ctx, userID, preparedUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !prepared.IsBindable() {
+		return nil, repository.ErrorPreparedPaymentNotBindable
+	}
+
+	if req.ToAddress == "" {
+		req.ToAddress = prepared.ToAddress
+	}
+	if !equalAddresses(req.ToAddress, prepared.ToAddress) {
+		return nil, fmt.Errorf("to address %s does not match the prepared payment recipient %s", req.ToAddress, prepared.ToAddress)
+	}
+
+	amount, err := model.ParseAmount(req.Amount)
+	if err != nil || amount.Cmp(prepared.Amount) != 0 {
+		return nil, fmt.Errorf("amount %s does not match the prepared payment amount %s", req.Amount, prepared.Amount)
+	}
+
+	if req.Currency == "" {
+		req.Currency = prepared.Currency
+	}
+	if !strings.EqualFold(req.Currency, prepared.Currency) {
+		return nil, fmt.Errorf("currency %s does not match the prepared payment currency %s", req.Currency, prepared.Currency)
+	}
+
+	if prepared.TokenAddress == nil {
+		if req.TokenAddress != "" {
+			return nil, fmt.Errorf("token address does not match the prepared payment")
+		}
+	} else if req.TokenAddress == "" {
+		req.TokenAddress = *prepared.TokenAddress
+	} else if !strings.EqualFold(req.TokenAddress, *prepared.TokenAddress) {
+		return nil, fmt.Errorf("token address does not match the prepared payment")
+	}
+
+	if req.Description == nil {
+		req.Description = prepared.Description
+	}
+
+	return prepared, nil
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"backend/internal/ethclient"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrRefundVerificationFailed is returned when a refund transaction does not
+// return funds from the payment's recipient to its sender
+var ErrRefundVerificationFailed = errors.New("refund transaction verification failed")
+
+// CreateRefund records a refund of one of the user's confirmed payments. The
+// refund transaction must be successful, have as many confirmations as the
+// payment required and move the payment's currency from the original
+// recipient back to the original sender; the amount moved is what is
+// refunded. Several partial refunds may be recorded
+// until they add up to the payment amount.
+func CreateRefund(ctx context.Context, userID string, paymentID string, req *model.CreateRefundRequest) (*model.RefundResponse, *model.PaymentResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	paymentUUID, err := uuid.Parse(paymentID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid payment ID format: %w", err)
+	}
+
+	payment, err := repository.GetPaymentByID(ctx, paymentUUID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Verify user owns this payment
+	if payment.UserID != userUUID {
+		return nil, nil, repository.ErrorPaymentNotFound
+	}
+
+	if payment.Status != model.PaymentStatusConfirmed {
+		return nil, nil, repository.ErrorPaymentNotRefundable
+	}
+
+	if strings.EqualFold(req.TransactionHash, payment.TransactionHash) {
+		return nil, nil, fmt.Errorf("%w: a payment cannot refund itself", ErrRefundVerificationFailed)
+	}
+
+	ethClient, err := ethclient.NewClient()
+	if err != nil {
+		slog.Error("Failed to create Ethereum client for refund", slog.Any("error", err))
+		return nil, nil, fmt.Errorf("failed to connect to blockchain: %w", err)
+	}
+	defer ethClient.Close()
+
+	txDetails, err := ethClient.VerifyTransaction(ctx, req.TransactionHash)
+	if err != nil {
+		slog.Error("Failed to verify refund transaction", slog.Any("error", err), slog.String("txHash", req.TransactionHash))
+		return nil, nil, fmt.Errorf("failed to verify transaction: %w", err)
+	}
+
+	// The refund must come after the payment, or an earlier transfer between
+	// the two parties could be passed off as one
+	paymentTx, err := ethClient.VerifyTransaction(ctx, payment.TransactionHash)
+	if err != nil {
+		slog.Error("Failed to verify refunded payment transaction", slog.Any("error", err), slog.String("txHash", payment.TransactionHash))
+		return nil, nil, fmt.Errorf("failed to verify payment transaction: %w", err)
+	}
+
+	amount, err := refundedAmount(txDetails, paymentTx, payment)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// A refund is held to the same depth as the payment, so a reorg cannot
+	// undo it once recorded
+	confirmations, err := ethClient.GetTransactionConfirmations(ctx, txDetails.Hash)
+	if err != nil {
+		slog.Error("Failed to get refund transaction confirmations", slog.Any("error", err), slog.String("txHash", txDetails.Hash))
+		return nil, nil, fmt.Errorf("failed to get transaction confirmations: %w", err)
+	}
+
+	required := payment.RequiredConfirmations
+	if required < 1 {
+		required = requiredConfirmationsFor(payment)
+	}
+	if int64(confirmations) < required {
+		return nil, nil, fmt.Errorf("%w: transaction has %d of %d required confirmations",
+			ErrRefundVerificationFailed, confirmations, required)
+	}
+
+	refund := &model.Refund{
+		ID:              uuid.New(),
+		PaymentID:       payment.ID,
+		UserID:          userUUID,
+		FromAddress:     payment.ToAddress,
+		ToAddress:       payment.FromAddress,
+		Amount:          amount,
+		TransactionHash: txDetails.Hash,
+		BlockNumber:     txDetails.BlockNumber,
+		Reason:          req.Reason,
+		CreatedAt:       time.Now(),
+	}
+
+	updated, err := repository.CreateRefund(ctx, refund)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	slog.Info("Refund recorded",
+		slog.String("paymentID", payment.ID.String()),
+		slog.String("refundID", refund.ID.String()),
+		slog.String("txHash", refund.TransactionHash),
+		slog.String("amount", refund.Amount.String()))
+
+	refundResponse := refund.ToResponse(updated)
+	paymentResponse := updated.ToResponse()
+	return &refundResponse, &paymentResponse, nil
+}
+
+// refundedAmount returns how much of the payment's currency a mined
+// transaction, made after the payment's transaction paymentTx, returned from
+// the payment's recipient to its sender
+func refundedAmount(txDetails, paymentTx *model.TransactionDetails, payment *model.Payment) (model.Amount, error) {
+	if txDetails.BlockNumber == nil {
+		return model.Amount{}, fmt.Errorf("%w: transaction is not mined yet", ErrRefundVerificationFailed)
+	}
+	if txDetails.Status != 1 {
+		return model.Amount{}, fmt.Errorf("%w: transaction failed", ErrRefundVerificationFailed)
+	}
+	if !txDetails.MinedAfter(paymentTx) {
+		return model.Amount{}, fmt.Errorf("%w: transaction was not mined after the payment", ErrRefundVerificationFailed)
+	}
+
+	var amount model.Amount
+	if payment.TokenAddress == nil {
+		if !equalAddresses(txDetails.From, payment.ToAddress) || !equalAddresses(txDetails.To, payment.FromAddress) {
+			return model.Amount{}, fmt.Errorf("%w: transaction must be sent from %s to %s",
+				ErrRefundVerificationFailed, payment.ToAddress, payment.FromAddress)
+		}
+		amount = txDetails.Value
+	} else {
+		amount = ethclient.SumTokenTransfers(txDetails.TokenTransfers, *payment.TokenAddress, payment.ToAddress, payment.FromAddress)
+	}
+
+	if amount.Sign() <= 0 {
+		return model.Amount{}, fmt.Errorf("%w: transaction returns no %s from %s to %s",
+			ErrRefundVerificationFailed, payment.Currency, payment.ToAddress, payment.FromAddress)
+	}
+
+	return amount, nil
+}
+
+// GetPaymentRefunds lists the refunds recorded against one of the user's payments
+func GetPaymentRefunds(ctx context.Context, userID string, paymentID string) (*model.PaymentRefundsResponse, error) {
+	paymentUUID, err := uuid.Parse(paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payment ID format: %w", err)
+	}
+
+	payment, err := repository.GetPaymentByID(ctx, paymentUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify user owns this payment
+	if payment.UserID.String() != userID {
+		return nil, repository.ErrorPaymentNotFound
+	}
+
+	refunds, err := repository.GetRefundsByPaymentID(ctx, payment.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.PaymentRefundsResponse{
+		Payment: payment.ToResponse(),
+		Refunds: make([]model.RefundResponse, 0, len(refunds)),
+	}
+	for _, refund := range refunds {
+		response.Refunds = append(response.Refunds, refund.ToResponse(payment))
+	}
+
+	return response, nil
+}
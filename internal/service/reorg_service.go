@@ -0,0 +1,126 @@
+package service
+
+import (
+	"backend/internal/ethclient"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultReorgDepth is how many blocks behind the chain head payments are
+// re-checked for reorganizations when PAYMENT_REORG_DEPTH is not set
+const defaultReorgDepth = 64
+
+// reorgDepth returns the number of recent blocks to re-check for reorgs
+func reorgDepth() int64 {
+	value := os.Getenv("PAYMENT_REORG_DEPTH")
+	if value == "" {
+		return defaultReorgDepth
+	}
+
+	depth, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || depth < 1 {
+		slog.Warn("Invalid PAYMENT_REORG_DEPTH, using default",
+			slog.String("value", value),
+			slog.Int64("default", defaultReorgDepth))
+		return defaultReorgDepth
+	}
+
+	return depth
+}
+
+// canonicalBlock is the node's answer for the canonical block at a height
+type canonicalBlock struct {
+	hash  string
+	found bool
+}
+
+// DetectReorgs compares the block hash recorded for recently mined payments
+// with the canonical chain. Payments whose block was orphaned are rolled back
+// to pending, the event is recorded, and the transaction is re-verified
+// immediately. Payments above a height the node has no block for yet are left
+// alone. It returns the number of payments rolled back.
+func DetectReorgs(ctx context.Context) (int, error) {
+	ethClient, err := ethclient.NewClient()
+	if err != nil {
+		return 0, fmt.Errorf("failed to connect to blockchain: %w", err)
+	}
+	defer ethClient.Close()
+
+	head, err := ethClient.GetBlockNumber(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest block number: %w", err)
+	}
+
+	fromBlock := max(int64(head)-reorgDepth(), 0)
+	payments, err := repository.GetPaymentsMinedSince(ctx, fromBlock)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get recently mined payments: %w", err)
+	}
+
+	// Several payments are often mined in the same block
+	canonicalBlocks := make(map[int64]canonicalBlock)
+
+	rolledBack := 0
+	for _, payment := range payments {
+		if ctx.Err() != nil {
+			return rolledBack, ctx.Err()
+		}
+
+		blockNumber := *payment.BlockNumber
+		canonical, cached := canonicalBlocks[blockNumber]
+		if !cached {
+			hash, found, err := ethClient.GetCanonicalBlockHash(ctx, blockNumber)
+			if err != nil {
+				slog.Warn("Failed to get canonical block hash",
+					slog.Any("error", err),
+					slog.Int64("blockNumber", blockNumber))
+				continue
+			}
+			canonical = canonicalBlock{hash: hash, found: found}
+			canonicalBlocks[blockNumber] = canonical
+		}
+
+		event := model.DetectPaymentReorg(payment, canonical.hash, canonical.found, time.Now())
+		if event == nil {
+			continue
+		}
+
+		err := repository.RollbackReorgedPayment(ctx, event)
+		if err != nil {
+			if !errors.Is(err, repository.ErrorPaymentBlockChanged) {
+				slog.Error("Failed to roll back reorged payment", slog.Any("error", err), slog.String("paymentID", payment.ID.String()))
+			}
+			continue
+		}
+		rolledBack++
+
+		slog.Warn("Payment block was orphaned by a chain reorganization",
+			slog.String("paymentID", payment.ID.String()),
+			slog.String("txHash", payment.TransactionHash),
+			slog.Int64("blockNumber", blockNumber),
+			slog.String("orphanedBlockHash", event.OrphanedBlockHash),
+			slog.String("canonicalBlockHash", event.CanonicalBlockHash),
+			slog.String("previousStatus", string(event.PreviousStatus)))
+
+		// Re-verify right away; the transaction is usually included again in
+		// the canonical chain
+		payment.Status = model.PaymentStatusPending
+		payment.BlockNumber = nil
+		payment.BlockHash = nil
+		payment.Confirmations = 0
+		payment.ConfirmedAt = nil
+		payment.RefundedAmount = model.Amount{}
+		if _, err := reconcilePayment(ctx, ethClient, payment); err != nil {
+			slog.Warn("Failed to re-verify reorged payment", slog.Any("error", err), slog.String("paymentID", payment.ID.String()))
+		}
+	}
+
+	return rolledBack, nil
+}
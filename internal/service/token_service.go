@@ -0,0 +1,110 @@
+package service
+
+import (
+	"backend/internal/ethclient"
+	"backend/internal/model"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// NativeCurrency is the currency of payments that move ETH rather than a token
+const NativeCurrency = "ETH"
+
+// maxCurrencyLength matches the payments.currency column
+const maxCurrencyLength = 10
+
+// supportedTokens maps the checksummed contract addresses of the ERC-20
+// tokens payments may be made in to their upper-cased symbols, loaded once
+// from SUPPORTED_TOKENS, e.g. "USDC:0xA0b8...eB48,DAI:0x6B17...1d0F". A token
+// is identified by its address; the symbol only selects it by name.
+var supportedTokens = sync.OnceValue(func() map[string]string {
+	tokens := make(map[string]string)
+	symbols := make(map[string]bool)
+
+	for _, pair := range strings.Split(os.Getenv("SUPPORTED_TOKENS"), ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		symbol, address, found := strings.Cut(pair, ":")
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		address = ethclient.NormalizeAddress(strings.TrimSpace(address))
+		if !found || symbol == "" || address == "" || symbol == NativeCurrency || symbols[symbol] {
+			slog.Warn("Ignoring invalid SUPPORTED_TOKENS entry", slog.String("entry", pair))
+			continue
+		}
+
+		tokens[address] = symbol
+		symbols[symbol] = true
+	}
+
+	return tokens
+})
+
+// supportedTokenSymbol returns the configured symbol of an allowlisted token
+// address
+func supportedTokenSymbol(address string) (string, bool) {
+	symbol, ok := supportedTokens()[ethclient.NormalizeAddress(address)]
+	return symbol, ok
+}
+
+// supportedTokenAddress returns the allowlisted token address configured for
+// a currency symbol
+func supportedTokenAddress(currency string) (string, bool) {
+	for address, symbol := range supportedTokens() {
+		if strings.EqualFold(symbol, currency) {
+			return address, true
+		}
+	}
+	return "", false
+}
+
+// resolveToken determines which allowlisted ERC-20 token a currency symbol
+// and/or token contract address refer to. It returns nil for native ETH. The
+// token's symbol and decimals are always read from the contract itself, and a
+// token claiming the native currency's symbol is refused.
+func resolveToken(ctx context.Context, ethClient *ethclient.Client, currency string, tokenAddress string) (*model.Token, error) {
+	if tokenAddress == "" {
+		if currency == "" || strings.EqualFold(currency, NativeCurrency) {
+			return nil, nil
+		}
+
+		address, ok := supportedTokenAddress(currency)
+		if !ok {
+			return nil, fmt.Errorf("unsupported currency %s", currency)
+		}
+		tokenAddress = address
+	}
+
+	if !ethclient.ValidateAddress(tokenAddress) {
+		return nil, fmt.Errorf("invalid token address: %s", tokenAddress)
+	}
+
+	if _, ok := supportedTokenSymbol(tokenAddress); !ok {
+		return nil, fmt.Errorf("unsupported token %s", tokenAddress)
+	}
+
+	token, err := ethClient.GetTokenMetadata(ctx, tokenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token contract: %w", err)
+	}
+
+	if strings.EqualFold(token.Symbol, NativeCurrency) {
+		return nil, fmt.Errorf("token %s uses the native currency symbol %s", token.Address, NativeCurrency)
+	}
+
+	if currency != "" && !strings.EqualFold(currency, token.Symbol) {
+		return nil, fmt.Errorf("currency %s does not match token symbol %s", currency, token.Symbol)
+	}
+
+	if len(token.Symbol) == 0 || len(token.Symbol) > maxCurrencyLength {
+		return nil, fmt.Errorf("unsupported token symbol %q", token.Symbol)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/internal/pricing"
+	"backend/internal/repository"
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultPriceAPIURL is the CoinGecko-compatible API queried by the http price source
+	defaultPriceAPIURL = "https://api.coingecko.com/api/v3"
+
+	// defaultPriceCacheTTL is how long quotes of the http price source are reused
+	defaultPriceCacheTTL = 5 * time.Minute
+
+	// priceAPITimeout bounds a price request, so valuing a payment cannot
+	// hold up recording it for long
+	priceAPITimeout = 5 * time.Second
+)
+
+// priceSource is configured once from the environment; nil disables fiat
+// valuation:
+//
+//	PRICE_SOURCE        "static" or "http"; unset disables valuation
+//	PRICE_TABLE_FILE    JSON rate table of the static source, e.g. {"ETH": {"USD": "3012.45"}}
+//	PRICE_API_URL       CoinGecko-compatible API of the http source (default defaultPriceAPIURL)
+//	PRICE_API_COIN_IDS  API coin IDs of currency symbols, e.g. "ETH:ethereum,USDC:usd-coin" (default "ETH:ethereum")
+//	PRICE_CACHE_TTL     how long http quotes are reused (default defaultPriceCacheTTL)
+var priceSource = sync.OnceValue(func() pricing.Source {
+	switch kind := strings.ToLower(strings.TrimSpace(os.Getenv("PRICE_SOURCE"))); kind {
+	case "":
+		return nil
+	case "static":
+		source, err := pricing.LoadStaticSource(os.Getenv("PRICE_TABLE_FILE"))
+		if err != nil {
+			slog.Error("Failed to load PRICE_TABLE_FILE, fiat valuation disabled", slog.Any("error", err))
+			return nil
+		}
+		return source
+	case "http":
+		baseURL := os.Getenv("PRICE_API_URL")
+		if baseURL == "" {
+			baseURL = defaultPriceAPIURL
+		}
+		client := &http.Client{Timeout: priceAPITimeout}
+		return pricing.NewCachedSource(pricing.NewHTTPSource(baseURL, priceCoinIDs(), client), priceCacheTTL())
+	default:
+		slog.Error("Unknown PRICE_SOURCE, fiat valuation disabled", slog.String("value", kind))
+		return nil
+	}
+})
+
+// priceCoinIDs parses PRICE_API_COIN_IDS
+func priceCoinIDs() map[string]string {
+	value := os.Getenv("PRICE_API_COIN_IDS")
+	if value == "" {
+		value = NativeCurrency + ":ethereum"
+	}
+
+	ids := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		symbol, id, found := strings.Cut(pair, ":")
+		symbol, id = strings.TrimSpace(symbol), strings.TrimSpace(id)
+		if !found || symbol == "" || id == "" {
+			slog.Warn("Ignoring invalid PRICE_API_COIN_IDS entry", slog.String("entry", pair))
+			continue
+		}
+
+		ids[symbol] = id
+	}
+
+	return ids
+}
+
+// priceCacheTTL parses PRICE_CACHE_TTL
+func priceCacheTTL() time.Duration {
+	value := os.Getenv("PRICE_CACHE_TTL")
+	if value == "" {
+		return defaultPriceCacheTTL
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		slog.Warn("Invalid PRICE_CACHE_TTL, using default",
+			slog.String("value", value),
+			slog.Duration("default", defaultPriceCacheTTL))
+		return defaultPriceCacheTTL
+	}
+
+	return ttl
+}
+
+// pricedCurrency returns the symbol a payment's currency is quoted under:
+// the native currency, or the configured symbol of an allowlisted token
+// address. A token outside the allowlist is not priced, whatever symbol its
+// contract reports.
+func pricedCurrency(payment *model.Payment) (string, bool) {
+	if payment.TokenAddress == nil {
+		return NativeCurrency, true
+	}
+	return supportedTokenSymbol(*payment.TokenAddress)
+}
+
+// valuePayment values a payment about to be recorded in its owner's display
+// currency. The payment is left unvalued, never rejected, when no price is
+// available.
+func valuePayment(ctx context.Context, payment *model.Payment) {
+	source := priceSource()
+	if source == nil {
+		return
+	}
+
+	currency, ok := pricedCurrency(payment)
+	if !ok {
+		return
+	}
+
+	fiat, err := repository.GetUserDisplayCurrency(ctx, payment.UserID)
+	if err != nil {
+		slog.Warn("Failed to get display currency to value payment", slog.Any("error", err), slog.String("userID", payment.UserID.String()))
+		return
+	}
+
+	quote, err := source.Quote(ctx, currency, fiat)
+	if err != nil {
+		slog.Warn("Failed to quote payment currency",
+			slog.Any("error", err),
+			slog.String("currency", currency),
+			slog.String("fiat", string(fiat)))
+		return
+	}
+
+	payment.Fiat = model.NewFiatValuation(payment, fiat, quote.Rate, quote.Source, quote.QuotedAt)
+}
+
+// revaluePayment values a payment again once it has confirmed, at the rate of
+// the moment it settled. The earlier valuation is kept when no price is
+// available.
+func revaluePayment(ctx context.Context, paymentID uuid.UUID) {
+	payment, err := repository.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		slog.Warn("Failed to load confirmed payment to value it", slog.Any("error", err), slog.String("paymentID", paymentID.String()))
+		return
+	}
+
+	previous := payment.Fiat
+	valuePayment(ctx, payment)
+	if payment.Fiat == previous {
+		return
+	}
+
+	if err := repository.UpdatePaymentFiatValuation(ctx, payment.ID, payment.Fiat); err != nil {
+		slog.Warn("Failed to record payment valuation", slog.Any("error", err), slog.String("paymentID", payment.ID.String()))
+	}
+}
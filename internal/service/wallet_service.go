@@ -3,10 +3,64 @@ package service
 import (
 	"backend/internal/model"
 	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 
 	"github.com/gin-gonic/gin"
 )
 
+// ErrWalletNotOwned is returned when a wallet address is not one of the
+// user's connected wallets
+var ErrWalletNotOwned = errors.New("wallet address is not one of your connected wallets")
+
 func GetWalletAddressFromPhone(c *gin.Context, phone string) ([]model.WalletAddress, error) {
 	return repository.GetWalletAddressesFromPhone(c, phone)
 }
+
+// SetPreferredWallet chooses which of the user's wallets receives payments
+// sent to their phone number
+func SetPreferredWallet(ctx context.Context, userID string, address string) error {
+	phoneNumber, err := repository.GetPhoneNumberByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return repository.SetPreferredWalletAddress(ctx, phoneNumber, address)
+}
+
+// connectedWalletAddress returns the user's wallet to send or receive with:
+// the requested one, which must be connected, or their preferred wallet when
+// none is requested
+func connectedWalletAddress(ctx context.Context, userID string, requested string) (string, error) {
+	if requested == "" {
+		phoneNumber, err := repository.GetPhoneNumberByUserID(ctx, userID)
+		if err != nil {
+			return "", err
+		}
+
+		wallet, err := repository.GetPreferredWalletAddress(ctx, phoneNumber)
+		if err != nil {
+			if errors.Is(err, repository.ErrorWalletAddressNotFound) {
+				return "", fmt.Errorf("connect a wallet first: %w", err)
+			}
+			return "", err
+		}
+		return wallet, nil
+	}
+
+	userWallets, err := repository.GetUserWalletAddresses(ctx, userID)
+	if err != nil {
+		slog.Error("Failed to get user wallet addresses", slog.Any("error", err), slog.String("userID", userID))
+		return "", fmt.Errorf("failed to get user wallet addresses: %w", err)
+	}
+
+	for _, wallet := range userWallets {
+		if equalAddresses(wallet, requested) {
+			return wallet, nil
+		}
+	}
+
+	return "", ErrWalletNotOwned
+}
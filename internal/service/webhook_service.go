@@ -0,0 +1,516 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/internal/repository"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook request headers. The signature header has the form
+// "t=<unix timestamp>,v1=<hex HMAC-SHA256>" where the HMAC is computed with
+// the endpoint's secret over "<timestamp>.<body>".
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookIDHeader        = "X-Webhook-Id"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+)
+
+const (
+	// MaxWebhookAttempts is how many times a delivery is tried before it is
+	// marked failed
+	MaxWebhookAttempts = 8
+
+	// webhookBaseRetryDelay is the wait after the first failed attempt; it
+	// doubles with every further failure up to webhookMaxRetryDelay
+	webhookBaseRetryDelay = 30 * time.Second
+	webhookMaxRetryDelay  = 6 * time.Hour
+
+	// webhookBatchSize bounds how many deliveries a single sweep sends
+	webhookBatchSize = 50
+
+	// webhookTimeout bounds a single delivery request; deliveries are leased
+	// for longer so that a slow endpoint is not posted to twice
+	webhookTimeout = 10 * time.Second
+	webhookLease   = time.Minute
+)
+
+var (
+	ErrInvalidWebhookURL       = errors.New("webhook URL must be an absolute http or https URL")
+	ErrPrivateWebhookTarget    = errors.New("webhook URL must resolve to a public address")
+	ErrInvalidWebhookEventType = errors.New("unsupported webhook event type")
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+)
+
+// webhookClient posts webhook deliveries
+var webhookClient = NewWebhookClient()
+
+// NewWebhookClient returns the client webhook deliveries are posted with.
+// Every connection is checked against the address actually dialled, so a
+// hostname re-pointed at an internal address after registration is still
+// refused, and redirects are not followed. Proxies are not used since they
+// would dial on our behalf.
+func NewWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: checkWebhookDial,
+	}
+
+	return &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: webhookTimeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// privateWebhookTargetsAllowed reports whether webhooks may be delivered to
+// loopback and private addresses, which is only meant for local development
+// and tests
+func privateWebhookTargetsAllowed() bool {
+	allowed, _ := strconv.ParseBool(os.Getenv("WEBHOOK_ALLOW_PRIVATE_TARGETS"))
+	return allowed
+}
+
+// nonPublicWebhookPrefixes are the special-purpose ranges the netip helpers
+// do not cover: "this network", carrier-grade NAT, IETF protocol
+// assignments, benchmarking and reserved IPv4 space, and the NAT64 and 6to4
+// prefixes, whose addresses can translate to private IPv4 addresses
+var nonPublicWebhookPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+// isPublicWebhookAddr reports whether a webhook may be delivered to addr
+func isPublicWebhookAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() ||
+		addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() ||
+		addr.IsUnspecified() {
+		return false
+	}
+
+	for _, prefix := range nonPublicWebhookPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkWebhookDial refuses connections to non-public addresses unless
+// private targets are allowed
+func checkWebhookDial(network string, address string, _ syscall.RawConn) error {
+	if privateWebhookTargetsAllowed() {
+		return nil
+	}
+
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPrivateWebhookTarget, err)
+	}
+	if !isPublicWebhookAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrPrivateWebhookTarget, addrPort.Addr())
+	}
+	return nil
+}
+
+// ValidateWebhookURL checks that a webhook URL is an absolute http or https
+// URL whose host only resolves to public addresses
+func ValidateWebhookURL(ctx context.Context, rawURL string) (*url.URL, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" || parsed.User != nil {
+		return nil, ErrInvalidWebhookURL
+	}
+
+	if privateWebhookTargetsAllowed() {
+		return parsed, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", parsed.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return nil, fmt.Errorf("%w: %s does not resolve", ErrPrivateWebhookTarget, parsed.Hostname())
+	}
+	for _, addr := range addrs {
+		if !isPublicWebhookAddr(addr) {
+			return nil, fmt.Errorf("%w: %s resolves to %s", ErrPrivateWebhookTarget, parsed.Hostname(), addr)
+		}
+	}
+
+	return parsed, nil
+}
+
+// SignWebhookPayload returns the signature header value for a payload sent
+// at the given time
+func SignWebhookPayload(secret string, timestamp time.Time, payload []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + ts + ",v1=" + webhookHMAC(secret, ts, payload)
+}
+
+// VerifyWebhookSignature checks a signature header produced by
+// SignWebhookPayload. Signatures older than tolerance are rejected to limit
+// replays; a zero tolerance accepts any age.
+func VerifyWebhookSignature(secret string, header string, payload []byte, tolerance time.Duration, now time.Time) error {
+	var ts string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidWebhookSignature
+	}
+
+	if tolerance > 0 {
+		age := now.Sub(time.Unix(unix, 0))
+		if age > tolerance || age < -tolerance {
+			return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidWebhookSignature)
+		}
+	}
+
+	expected := webhookHMAC(secret, ts, payload)
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrInvalidWebhookSignature
+}
+
+func webhookHMAC(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookRetryDelay returns how long to wait before retrying a delivery that
+// has failed the given number of times
+func WebhookRetryDelay(failedAttempts int) time.Duration {
+	if failedAttempts < 1 {
+		failedAttempts = 1
+	}
+
+	delay := webhookBaseRetryDelay
+	for i := 1; i < failedAttempts; i++ {
+		delay *= 2
+		if delay >= webhookMaxRetryDelay {
+			return webhookMaxRetryDelay
+		}
+	}
+
+	return delay
+}
+
+// PostWebhook signs and posts a claimed delivery to its endpoint and returns
+// the log entry of the attempt. It never fails: transport errors are recorded
+// on the attempt.
+func PostWebhook(ctx context.Context, client *http.Client, webhook *model.PendingWebhook) model.WebhookAttempt {
+	started := time.Now()
+	attempt := model.WebhookAttempt{AttemptedAt: started.UTC()}
+
+	fail := func(err error) model.WebhookAttempt {
+		message := err.Error()
+		attempt.Error = &message
+		attempt.DurationMs = time.Since(started).Milliseconds()
+		return attempt
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(webhook.Payload))
+	if err != nil {
+		return fail(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "payments-webhooks/1.0")
+	req.Header.Set(WebhookIDHeader, webhook.EventID.String())
+	req.Header.Set(WebhookEventHeader, string(webhook.EventType))
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(started.Unix(), 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, started, webhook.Payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fail(err)
+	}
+	// Only the status is kept; the response body is never read back
+	resp.Body.Close()
+
+	attempt.DurationMs = time.Since(started).Milliseconds()
+	attempt.StatusCode = &resp.StatusCode
+	if !attempt.Succeeded() {
+		message := "unexpected response status " + resp.Status
+		attempt.Error = &message
+	}
+
+	return attempt
+}
+
+// DeliverPendingWebhooks posts the deliveries that are due and schedules
+// failed ones for a retry with exponential backoff. It returns how many
+// deliveries succeeded.
+func DeliverPendingWebhooks(ctx context.Context) (int, error) {
+	pending, err := repository.ClaimPendingWebhooks(ctx, webhookBatchSize, webhookLease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim pending webhooks: %w", err)
+	}
+
+	delivered := 0
+	for _, webhook := range pending {
+		if ctx.Err() != nil {
+			return delivered, ctx.Err()
+		}
+
+		attempt := PostWebhook(ctx, webhookClient, webhook)
+		attempts := webhook.Attempts + 1
+
+		status := model.WebhookDeliveryPending
+		nextAttemptAt := time.Now().Add(WebhookRetryDelay(attempts))
+		switch {
+		case attempt.Succeeded():
+			status = model.WebhookDeliverySucceeded
+			delivered++
+		case attempts >= MaxWebhookAttempts:
+			status = model.WebhookDeliveryFailed
+		}
+
+		if err := repository.RecordWebhookAttempt(ctx, webhook.DeliveryID, &attempt, status, nextAttemptAt); err != nil {
+			slog.Error("Failed to record webhook attempt", slog.Any("error", err), slog.String("deliveryID", webhook.DeliveryID.String()))
+			continue
+		}
+
+		if status != model.WebhookDeliverySucceeded {
+			slog.Warn("Webhook delivery failed",
+				slog.String("deliveryID", webhook.DeliveryID.String()),
+				slog.String("url", webhook.URL),
+				slog.Int("attempts", attempts),
+				slog.String("status", string(status)))
+		}
+	}
+
+	return delivered, nil
+}
+
+// CreateWebhookEndpoint registers a webhook endpoint for the user. The
+// response is the only place its signing secret is ever returned.
+func CreateWebhookEndpoint(ctx context.Context, userID string, req *model.CreateWebhookEndpointRequest) (*model.WebhookEndpointResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	parsed, err := ValidateWebhookURL(ctx, req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	eventTypes := []model.WebhookEventType{}
+	seen := make(map[model.WebhookEventType]bool)
+	for _, eventType := range req.EventTypes {
+		if !eventType.IsValid() {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidWebhookEventType, eventType)
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	now := time.Now()
+	endpoint := &model.WebhookEndpoint{
+		ID:          uuid.New(),
+		UserID:      userUUID,
+		URL:         parsed.String(),
+		Secret:      secret,
+		EventTypes:  eventTypes,
+		Description: req.Description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := repository.CreateWebhookEndpoint(ctx, endpoint); err != nil {
+		slog.Error("Failed to create webhook endpoint record", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to save webhook endpoint: %w", err)
+	}
+
+	slog.Info("Webhook endpoint registered",
+		slog.String("endpointID", endpoint.ID.String()),
+		slog.String("userID", userID),
+		slog.String("url", endpoint.URL))
+
+	response := endpoint.ToResponse()
+	response.Secret = endpoint.Secret
+	return &response, nil
+}
+
+// generateWebhookSecret returns a random secret for signing payloads
+func generateWebhookSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(raw), nil
+}
+
+// GetWebhookEndpoints retrieves the user's webhook endpoints
+func GetWebhookEndpoints(ctx context.Context, userID string) ([]model.WebhookEndpointResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	endpoints, err := repository.GetWebhookEndpointsByUserID(ctx, userUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook endpoints: %w", err)
+	}
+
+	responses := make([]model.WebhookEndpointResponse, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		responses = append(responses, endpoint.ToResponse())
+	}
+
+	return responses, nil
+}
+
+// GetWebhookEndpoint retrieves one of the user's webhook endpoints
+func GetWebhookEndpoint(ctx context.Context, userID string, endpointID string) (*model.WebhookEndpointResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	endpointUUID, err := uuid.Parse(endpointID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook endpoint ID format: %w", err)
+	}
+
+	endpoint, err := repository.GetWebhookEndpoint(ctx, userUUID, endpointUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := endpoint.ToResponse()
+	return &response, nil
+}
+
+// DeleteWebhookEndpoint removes one of the user's webhook endpoints. Its
+// undelivered events are dropped.
+func DeleteWebhookEndpoint(ctx context.Context, userID string, endpointID string) error {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	endpointUUID, err := uuid.Parse(endpointID)
+	if err != nil {
+		return fmt.Errorf("invalid webhook endpoint ID format: %w", err)
+	}
+
+	return repository.DeleteWebhookEndpoint(ctx, userUUID, endpointUUID)
+}
+
+// GetWebhookDeliveries retrieves the delivery log of one of the user's
+// webhook endpoints
+func GetWebhookDeliveries(ctx context.Context, userID string, endpointID string, query *model.WebhookDeliveryQuery) ([]*model.WebhookDelivery, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	endpointUUID, err := uuid.Parse(endpointID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook endpoint ID format: %w", err)
+	}
+
+	// Only the owner may read an endpoint's deliveries
+	if _, err := repository.GetWebhookEndpoint(ctx, userUUID, endpointUUID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := repository.GetWebhookDeliveries(ctx, endpointUUID, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	if deliveries == nil {
+		deliveries = []*model.WebhookDelivery{}
+	}
+
+	return deliveries, nil
+}
+
+// RedeliverWebhook queues one of the user's webhook deliveries to be sent
+// again on the dispatcher's next sweep, whatever its current status
+func RedeliverWebhook(ctx context.Context, userID string, deliveryID string) (*model.WebhookDelivery, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	deliveryUUID, err := uuid.Parse(deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook delivery ID format: %w", err)
+	}
+
+	delivery, err := repository.RedeliverWebhook(ctx, userUUID, deliveryUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Webhook delivery queued for redelivery",
+		slog.String("deliveryID", delivery.ID.String()),
+		slog.String("userID", userID))
+
+	return delivery, nil
+}
@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"backend/internal/service"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// DefaultBillingInterval is used when BILLING_SCHEDULE_INTERVAL is not set or
+// cannot be parsed.
+const DefaultBillingInterval = 15 * time.Second
+
+// NewBillingScheduler creates a worker that periodically generates the
+// billing periods that have started, marks unpaid ones overdue and matches
+// newly mined transfers to them. A non-positive interval falls back to
+// DefaultBillingInterval.
+func NewBillingScheduler(interval time.Duration) *PeriodicWorker {
+	if interval <= 0 {
+		interval = DefaultBillingInterval
+	}
+
+	return NewPeriodicWorker("billing scheduler", interval, processBillingSchedules)
+}
+
+// BillingIntervalFromEnv reads the sweep interval from the
+// BILLING_SCHEDULE_INTERVAL environment variable (e.g. "15s", "1m").
+func BillingIntervalFromEnv() time.Duration {
+	return intervalFromEnv("BILLING_SCHEDULE_INTERVAL", DefaultBillingInterval)
+}
+
+func processBillingSchedules(ctx context.Context) {
+	matched, err := service.ProcessBillingSchedules(ctx)
+	if err != nil && ctx.Err() == nil {
+		slog.Error("Billing schedule sweep failed", slog.Any("error", err))
+	}
+
+	if matched > 0 {
+		slog.Info("Billing schedule sweep finished", slog.Int("matched", matched))
+	}
+}
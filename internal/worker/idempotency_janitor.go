@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"backend/internal/service"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// DefaultIdempotencyCleanupInterval is used when IDEMPOTENCY_CLEANUP_INTERVAL
+// is not set or cannot be parsed.
+const DefaultIdempotencyCleanupInterval = time.Hour
+
+// NewIdempotencyJanitor creates a worker that periodically deletes
+// idempotency keys whose retention window has passed. A non-positive interval
+// falls back to DefaultIdempotencyCleanupInterval.
+func NewIdempotencyJanitor(interval time.Duration) *PeriodicWorker {
+	if interval <= 0 {
+		interval = DefaultIdempotencyCleanupInterval
+	}
+
+	return NewPeriodicWorker("idempotency janitor", interval, purgeIdempotencyKeys)
+}
+
+// IdempotencyCleanupIntervalFromEnv reads the cleanup interval from the
+// IDEMPOTENCY_CLEANUP_INTERVAL environment variable (e.g. "30m", "1h").
+func IdempotencyCleanupIntervalFromEnv() time.Duration {
+	return intervalFromEnv("IDEMPOTENCY_CLEANUP_INTERVAL", DefaultIdempotencyCleanupInterval)
+}
+
+func purgeIdempotencyKeys(ctx context.Context) {
+	deleted, err := service.PurgeExpiredIdempotencyKeys(ctx)
+	if err != nil && ctx.Err() == nil {
+		slog.Error("Idempotency key cleanup failed", slog.Any("error", err))
+	}
+
+	if deleted > 0 {
+		slog.Info("Expired idempotency keys deleted", slog.Int64("deleted", deleted))
+	}
+}
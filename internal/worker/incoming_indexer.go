@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"backend/internal/service"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// DefaultIncomingIndexInterval is used when INCOMING_INDEX_INTERVAL is not set
+// or cannot be parsed.
+const DefaultIncomingIndexInterval = 15 * time.Second
+
+// NewIncomingPaymentIndexer creates a worker that periodically scans newly
+// mined blocks for ETH sent to connected wallets and records it as incoming
+// payments. A non-positive interval falls back to DefaultIncomingIndexInterval.
+func NewIncomingPaymentIndexer(interval time.Duration) *PeriodicWorker {
+	if interval <= 0 {
+		interval = DefaultIncomingIndexInterval
+	}
+
+	return NewPeriodicWorker("incoming payment indexer", interval, indexIncomingPayments)
+}
+
+// IncomingIndexIntervalFromEnv reads the sweep interval from the
+// INCOMING_INDEX_INTERVAL environment variable (e.g. "15s", "1m").
+func IncomingIndexIntervalFromEnv() time.Duration {
+	return intervalFromEnv("INCOMING_INDEX_INTERVAL", DefaultIncomingIndexInterval)
+}
+
+func indexIncomingPayments(ctx context.Context) {
+	indexed, err := service.IndexIncomingPayments(ctx)
+	if err != nil && ctx.Err() == nil {
+		slog.Error("Incoming payment indexing sweep failed", slog.Any("error", err))
+	}
+
+	if indexed > 0 {
+		slog.Info("Incoming payment indexing sweep finished", slog.Int("indexed", indexed))
+	}
+}
@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"backend/internal/service"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// DefaultInvoiceMatchInterval is used when INVOICE_MATCH_INTERVAL is not set
+// or cannot be parsed.
+const DefaultInvoiceMatchInterval = 15 * time.Second
+
+// NewInvoiceMatcher creates a worker that periodically expires overdue
+// invoices and matches newly mined transfers to open ones. A non-positive
+// interval falls back to DefaultInvoiceMatchInterval.
+func NewInvoiceMatcher(interval time.Duration) *PeriodicWorker {
+	if interval <= 0 {
+		interval = DefaultInvoiceMatchInterval
+	}
+
+	return NewPeriodicWorker("invoice matcher", interval, matchInvoices)
+}
+
+// InvoiceMatchIntervalFromEnv reads the sweep interval from the
+// INVOICE_MATCH_INTERVAL environment variable (e.g. "15s", "1m").
+func InvoiceMatchIntervalFromEnv() time.Duration {
+	return intervalFromEnv("INVOICE_MATCH_INTERVAL", DefaultInvoiceMatchInterval)
+}
+
+func matchInvoices(ctx context.Context) {
+	matched, err := service.MatchInvoicePayments(ctx)
+	if err != nil && ctx.Err() == nil {
+		slog.Error("Invoice matching sweep failed", slog.Any("error", err))
+	}
+
+	if matched > 0 {
+		slog.Info("Invoice matching sweep finished", slog.Int("matched", matched))
+	}
+}
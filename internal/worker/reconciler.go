@@ -0,0 +1,77 @@
+package worker
+
+import (
+	"backend/internal/service"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultReconcileInterval is used when PAYMENT_RECONCILE_INTERVAL is not set
+// or cannot be parsed.
+const DefaultReconcileInterval = 30 * time.Second
+
+// NewPaymentReconciler creates a worker that periodically sweeps pending
+// payments and confirms or fails them based on their on-chain state. Each
+// sweep first checks recently mined payments for chain reorganizations, and
+// ends by reading the gas fees of payments recorded without them.
+// A non-positive interval falls back to DefaultReconcileInterval.
+func NewPaymentReconciler(interval time.Duration) *PeriodicWorker {
+	if interval <= 0 {
+		interval = DefaultReconcileInterval
+	}
+
+	// Unsettled payments and payments missing gas fees are visited a batch
+	// per sweep, starting over once every one has been visited
+	var reconcileCursor, gasFeeCursor uuid.UUID
+	return NewPeriodicWorker("payment reconciler", interval, func(ctx context.Context) {
+		reconcileCursor = reconcilePayments(ctx, reconcileCursor)
+		gasFeeCursor = backfillGasFees(ctx, gasFeeCursor)
+	})
+}
+
+// ReconcileIntervalFromEnv reads the sweep interval from the
+// PAYMENT_RECONCILE_INTERVAL environment variable (e.g. "30s", "1m").
+func ReconcileIntervalFromEnv() time.Duration {
+	return intervalFromEnv("PAYMENT_RECONCILE_INTERVAL", DefaultReconcileInterval)
+}
+
+func reconcilePayments(ctx context.Context, after uuid.UUID) uuid.UUID {
+	rolledBack, err := service.DetectReorgs(ctx)
+	if err != nil && ctx.Err() == nil {
+		slog.Error("Payment reorg detection failed", slog.Any("error", err))
+	}
+	if rolledBack > 0 {
+		slog.Warn("Payments rolled back after chain reorganization", slog.Int("count", rolledBack))
+	}
+
+	next, updated, err := service.ReconcilePendingPayments(ctx, after)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Error("Payment reconciliation sweep failed", slog.Any("error", err))
+		}
+		return next
+	}
+
+	if updated > 0 {
+		slog.Info("Payment reconciliation sweep finished", slog.Int("updated", updated))
+	}
+	return next
+}
+
+func backfillGasFees(ctx context.Context, after uuid.UUID) uuid.UUID {
+	next, updated, err := service.BackfillGasFees(ctx, after)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Error("Payment gas fee backfill failed", slog.Any("error", err))
+		}
+		return next
+	}
+
+	if updated > 0 {
+		slog.Info("Payment gas fees backfilled", slog.Int("updated", updated))
+	}
+	return next
+}
@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"backend/internal/service"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// DefaultWebhookDeliveryInterval is used when WEBHOOK_DELIVERY_INTERVAL is not
+// set or cannot be parsed.
+const DefaultWebhookDeliveryInterval = 5 * time.Second
+
+// NewWebhookDispatcher creates a worker that periodically posts queued
+// webhook events to their endpoints, retrying failures with exponential
+// backoff. A non-positive interval falls back to DefaultWebhookDeliveryInterval.
+func NewWebhookDispatcher(interval time.Duration) *PeriodicWorker {
+	if interval <= 0 {
+		interval = DefaultWebhookDeliveryInterval
+	}
+
+	return NewPeriodicWorker("webhook dispatcher", interval, deliverWebhooks)
+}
+
+// WebhookDeliveryIntervalFromEnv reads the sweep interval from the
+// WEBHOOK_DELIVERY_INTERVAL environment variable (e.g. "5s", "1m").
+func WebhookDeliveryIntervalFromEnv() time.Duration {
+	return intervalFromEnv("WEBHOOK_DELIVERY_INTERVAL", DefaultWebhookDeliveryInterval)
+}
+
+func deliverWebhooks(ctx context.Context) {
+	delivered, err := service.DeliverPendingWebhooks(ctx)
+	if err != nil && ctx.Err() == nil {
+		slog.Error("Webhook delivery sweep failed", slog.Any("error", err))
+	}
+
+	if delivered > 0 {
+		slog.Info("Webhook delivery sweep finished", slog.Int("delivered", delivered))
+	}
+}
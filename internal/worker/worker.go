@@ -0,0 +1,100 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// PeriodicWorker runs a task on a fixed interval in a background goroutine
+// until it is stopped.
+type PeriodicWorker struct {
+	name     string
+	interval time.Duration
+	task     func(ctx context.Context)
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPeriodicWorker creates a worker that runs task every interval. The
+// name is only used for logging.
+func NewPeriodicWorker(name string, interval time.Duration, task func(ctx context.Context)) *PeriodicWorker {
+	return &PeriodicWorker{name: name, interval: interval, task: task}
+}
+
+// Start launches the worker loop in a background goroutine.
+func (w *PeriodicWorker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	w.wg.Add(1)
+	go w.run(ctx)
+
+	slog.Info("Worker started", slog.String("worker", w.name), slog.Duration("interval", w.interval))
+}
+
+// Stop cancels any in-flight run and waits for the loop to exit, or for
+// ctx to expire, whichever comes first.
+func (w *PeriodicWorker) Stop(ctx context.Context) error {
+	if w.cancel == nil {
+		return nil
+	}
+	w.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		slog.Info("Worker stopped", slog.String("worker", w.name))
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Name returns the worker's name
+func (w *PeriodicWorker) Name() string {
+	return w.name
+}
+
+func (w *PeriodicWorker) run(ctx context.Context) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.task(ctx)
+		}
+	}
+}
+
+// intervalFromEnv reads a duration (e.g. "30s", "1m") from the environment
+// variable key, falling back to def if it is unset or invalid.
+func intervalFromEnv(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		slog.Warn("Invalid "+key+", using default",
+			slog.String("value", value),
+			slog.Duration("default", def))
+		return def
+	}
+
+	return interval
+}
@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"backend/internal/model"
+)
+
+func TestParseDecimalAmount(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		unit    model.Unit
+		want    string
+		wantErr bool
+	}{
+		{name: "whole ether", value: "2", unit: model.Ether, want: "2000000000000000000"},
+		{name: "fractional ether", value: "1.5", unit: model.Ether, want: "1500000000000000000"},
+		{name: "one wei in ether", value: "0.000000000000000001", unit: model.Ether, want: "1"},
+		{name: "gwei", value: "30.5", unit: model.Gwei, want: "30500000000"},
+		{name: "beyond uint64", value: "100000", unit: model.Ether, want: "100000000000000000000000"},
+		{name: "too many decimals", value: "0.0000000000000000001", unit: model.Ether, wantErr: true},
+		{name: "not a number", value: "abc", unit: model.Ether, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := model.ParseDecimalAmount(tt.value, tt.unit)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDecimalAmount(%q) = %s, want error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDecimalAmount(%q) returned error: %v", tt.value, err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("ParseDecimalAmount(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmountFormat(t *testing.T) {
+	large, err := model.ParseAmount("123456789012345678901234567890")
+	if err != nil {
+		t.Fatalf("ParseAmount returned error: %v", err)
+	}
+
+	if got := large.Format(model.Ether); got != "123456789012.34567890123456789" {
+		t.Errorf("Format(Ether) = %s", got)
+	}
+	if got := large.FormatFixed(model.Ether, 4); got != "123456789012.3456" {
+		t.Errorf("FormatFixed(Ether, 4) = %s", got)
+	}
+	if got := model.AmountFromInt64(1).FormatFixed(model.Ether, 6); got != "0.000000" {
+		t.Errorf("FormatFixed of one wei = %s", got)
+	}
+	if got := model.AmountFromInt64(-1500000).Format(6); got != "-1.5" {
+		t.Errorf("Format of a negative amount = %s", got)
+	}
+	if got := (model.Amount{}).Format(model.Ether); got != "0" {
+		t.Errorf("Format of the zero value = %s", got)
+	}
+}
+
+func TestAmountArithmetic(t *testing.T) {
+	// 10 ETH overflows int64 wei when summed; the total must stay exact
+	tenEther, _ := model.ParseDecimalAmount("10", model.Ether)
+	total := tenEther.Add(tenEther)
+
+	if total.String() != "20000000000000000000" {
+		t.Errorf("Add = %s, want 20000000000000000000", total)
+	}
+	if total.Sub(tenEther).Cmp(tenEther) != 0 {
+		t.Errorf("Sub = %s, want %s", total.Sub(tenEther), tenEther)
+	}
+	if tenEther.String() != "10000000000000000000" {
+		t.Errorf("Add modified its receiver: %s", tenEther)
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	var payload struct {
+		Amount model.Amount `json:"amount"`
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount":"20000000000000000000"}`), &payload); err != nil {
+		t.Fatalf("Unmarshal string returned error: %v", err)
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+	if string(encoded) != `{"amount":"20000000000000000000"}` {
+		t.Errorf("Marshal = %s", encoded)
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount":42}`), &payload); err != nil {
+		t.Fatalf("Unmarshal number returned error: %v", err)
+	}
+	if payload.Amount.String() != "42" {
+		t.Errorf("Unmarshal number = %s, want 42", payload.Amount)
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount":"1.5"}`), &payload); err == nil {
+		t.Error("expected an error for a fractional amount")
+	}
+}
+
+func TestAmountScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     any
+		want    string
+		wantErr bool
+	}{
+		{name: "numeric text", src: []byte("20000000000000000000"), want: "20000000000000000000"},
+		{name: "string", src: "42", want: "42"},
+		{name: "int64", src: int64(7), want: "7"},
+		{name: "integral numeric with scale", src: "1000.000", want: "1000"},
+		{name: "fractional numeric", src: "1.5", wantErr: true},
+		{name: "null", src: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var amount model.Amount
+			err := amount.Scan(tt.src)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Scan(%v) = %s, want error", tt.src, amount)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Scan(%v) returned error: %v", tt.src, err)
+			}
+			if amount.String() != tt.want {
+				t.Errorf("Scan(%v) = %s, want %s", tt.src, amount, tt.want)
+			}
+		})
+	}
+}
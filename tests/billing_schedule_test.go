@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestBillingIntervalPeriodStart(t *testing.T) {
+	start := time.Date(2024, 1, 31, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		interval model.BillingInterval
+		n        int
+		want     time.Time
+	}{
+		{model.BillingIntervalMonthly, 0, start},
+		{model.BillingIntervalMonthly, 1, time.Date(2024, 2, 29, 9, 30, 0, 0, time.UTC)}, // Leap year
+		{model.BillingIntervalMonthly, 2, time.Date(2024, 3, 31, 9, 30, 0, 0, time.UTC)},
+		{model.BillingIntervalMonthly, 3, time.Date(2024, 4, 30, 9, 30, 0, 0, time.UTC)},
+		{model.BillingIntervalMonthly, 13, time.Date(2025, 2, 28, 9, 30, 0, 0, time.UTC)},
+		{model.BillingIntervalWeekly, 1, time.Date(2024, 2, 7, 9, 30, 0, 0, time.UTC)},
+		{model.BillingIntervalWeekly, 5, time.Date(2024, 3, 6, 9, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		if got := tt.interval.PeriodStart(start, tt.n); !got.Equal(tt.want) {
+			t.Errorf("%s period %d starts %v, want %v", tt.interval, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestBillingScheduleNewPeriod(t *testing.T) {
+	start := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)
+	schedule := &model.BillingSchedule{
+		ID:              uuid.New(),
+		Amount:          model.AmountFromInt64(5_000_000),
+		Interval:        model.BillingIntervalMonthly,
+		GracePeriodDays: 3,
+		StartsAt:        start,
+		EndsAt:          &end,
+	}
+
+	now := time.Now()
+	period := schedule.NewPeriod(1, now)
+	if !period.PeriodStart.Equal(time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC)) ||
+		!period.PeriodEnd.Equal(end) ||
+		!period.DueAt.Equal(time.Date(2024, 4, 18, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected period bounds: %+v", period)
+	}
+	if period.ScheduleID != schedule.ID || period.Status != model.BillingPeriodStatusDue || period.Amount.Cmp(schedule.Amount) != 0 {
+		t.Errorf("Unexpected period: %+v", period)
+	}
+
+	// The schedule ends before its third period would start
+	if !schedule.HasPeriod(1) || schedule.HasPeriod(2) {
+		t.Error("Expected exactly two periods before ends_at")
+	}
+
+	schedule.EndsAt = nil
+	if !schedule.HasPeriod(1000) {
+		t.Error("Expected an open-ended schedule to keep billing")
+	}
+}
+
+func TestBillingPeriodSettle(t *testing.T) {
+	due := time.Date(2024, 4, 18, 0, 0, 0, 0, time.UTC)
+	period := &model.BillingPeriod{Amount: model.AmountFromInt64(100), DueAt: due}
+
+	tests := []struct {
+		paid int64
+		now  time.Time
+		want model.BillingPeriodStatus
+	}{
+		{0, due.Add(-time.Hour), model.BillingPeriodStatusDue},
+		{60, due.Add(-time.Hour), model.BillingPeriodStatusDue},
+		{60, due, model.BillingPeriodStatusOverdue},
+		{100, due.Add(time.Hour), model.BillingPeriodStatusPaid},
+		{150, due.Add(-time.Hour), model.BillingPeriodStatusPaid},
+	}
+
+	for _, tt := range tests {
+		if got := period.Settle(model.AmountFromInt64(tt.paid), tt.now); got != tt.want {
+			t.Errorf("Settle(%d, %v) = %s, want %s", tt.paid, tt.now, got, tt.want)
+		}
+		if !tt.want.IsValid() {
+			t.Errorf("%s is not a valid status", tt.want)
+		}
+	}
+
+	if !model.BillingPeriodStatusOverdue.AcceptsPayments() || model.BillingPeriodStatusPaid.AcceptsPayments() {
+		t.Error("Expected overdue periods to accept payments and paid ones not to")
+	}
+}
@@ -1,10 +1,13 @@
 package tests
 
 import (
+	"backend/internal/model"
 	"context"
 	"log/slog"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -47,3 +50,32 @@ func Cleanup(teardown func(context context.Context, opts ...testcontainers.Termi
 		}
 	}
 }
+
+// testPayment returns a fully populated outgoing ETH payment, shared by the
+// tests of code that serializes or publishes payments
+func testPayment(status model.PaymentStatus) *model.Payment {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	confirmed := created.Add(2 * time.Minute)
+	gasUsed := int64(21000)
+	gasPrice := model.AmountFromInt64(30_000_000_000)
+	description := `Rent, March "flat 2"`
+
+	amount, _ := model.ParseDecimalAmount("1.5", model.Ether)
+	return &model.Payment{
+		ID:              uuid.MustParse("6f1c2d3e-4b5a-4c6d-8e7f-9a0b1c2d3e4f"),
+		FromAddress:     "0x742d35Cc6633C0532925a3b8D4C0f56e3c4D6329",
+		ToAddress:       "0x1111111111111111111111111111111111111111",
+		Amount:          amount,
+		Currency:        "ETH",
+		TokenDecimals:   18,
+		TransactionHash: "0x" + strings.Repeat("ab", 32),
+		GasUsed:         &gasUsed,
+		GasPrice:        &gasPrice,
+		Status:          status,
+		Direction:       model.PaymentDirectionOutgoing,
+		Description:     &description,
+		Tags:            []string{"housing", "monthly"},
+		CreatedAt:       created,
+		ConfirmedAt:     &confirmed,
+	}
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"testing"
+
+	"backend/internal/ethclient"
+	"backend/internal/model"
+)
+
+func TestConfirmationPolicyRequired(t *testing.T) {
+	policy, err := model.ParseConfirmationPolicy(3, "10000000000000000000:32, 1000000000000000000:12")
+	if err != nil {
+		t.Fatalf("unexpected error parsing policy: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		amount string
+		want   int64
+	}{
+		{name: "below every threshold", amount: "500000000000000000", want: 3},
+		{name: "exactly at first threshold", amount: "1000000000000000000", want: 12},
+		{name: "between thresholds", amount: "5000000000000000000", want: 12},
+		{name: "above highest threshold", amount: "25000000000000000000", want: 32},
+		{name: "zero amount uses default", amount: "0", want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, err := model.ParseAmount(tt.amount)
+			if err != nil {
+				t.Fatalf("ParseAmount(%q) returned error: %v", tt.amount, err)
+			}
+			if got := policy.Required(amount); got != tt.want {
+				t.Errorf("Required(%q) = %d, want %d", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConfirmationPolicyErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		defaultVal int64
+		thresholds string
+	}{
+		{name: "zero default", defaultVal: 0, thresholds: ""},
+		{name: "missing separator", defaultVal: 1, thresholds: "1000"},
+		{name: "invalid amount", defaultVal: 1, thresholds: "abc:3"},
+		{name: "invalid confirmations", defaultVal: 1, thresholds: "1000:zero"},
+		{name: "zero confirmations", defaultVal: 1, thresholds: "1000:0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := model.ParseConfirmationPolicy(tt.defaultVal, tt.thresholds); err == nil {
+				t.Errorf("expected error for thresholds %q with default %d", tt.thresholds, tt.defaultVal)
+			}
+		})
+	}
+}
+
+func TestConfirmationsAt(t *testing.T) {
+	tests := []struct {
+		name        string
+		head, block uint64
+		want        uint64
+	}{
+		{name: "block at head", head: 100, block: 100, want: 1},
+		{name: "buried block", head: 111, block: 100, want: 12},
+		{name: "head behind block", head: 99, block: 100, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ethclient.ConfirmationsAt(tt.head, tt.block); got != tt.want {
+				t.Errorf("ConfirmationsAt(%d, %d) = %d, want %d", tt.head, tt.block, got, tt.want)
+			}
+		})
+	}
+}
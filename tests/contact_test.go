@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"backend/internal/model"
+)
+
+func TestAddressesLookAlike(t *testing.T) {
+	const saved = "0x742d35Cc6633C0532925a3b8D4C0f56e3c4D6329"
+
+	tests := []struct {
+		name    string
+		address string
+		expect  bool
+	}{
+		{"same address", saved, false},
+		{"same address in other casing", strings.ToLower(saved), false},
+		{"same leading and trailing characters", "0x742dffffffffffffffffffffffffffffffff6329", true},
+		{"one character changed", "0x742d35Cc6633C0532925a3b8D4C0f56e3c4D6328", true},
+		{"three characters changed", "0x842d35Cc6633C0532925a3b8D4C0f56e3c4D6318", true},
+		{"four characters changed", "0x852d35Cc6633C0532925a3b8D4C0f56e3c4D6318", false},
+		{"unrelated address", "0x1111111111111111111111111111111111111111", false},
+		{"invalid address", "0x742d", false},
+	}
+
+	for _, tt := range tests {
+		if got := model.AddressesLookAlike(saved, tt.address); got != tt.expect {
+			t.Errorf("%s: AddressesLookAlike = %v, want %v", tt.name, got, tt.expect)
+		}
+	}
+}
+
+func TestCheckAddress(t *testing.T) {
+	contacts := []*model.Contact{
+		{Name: "Alice", Address: "0x742d35Cc6633C0532925a3b8D4C0f56e3c4D6329"},
+		{Name: "Bob", Address: "0x1111111111111111111111111111111111111111"},
+	}
+
+	// A poisoned lookalike of Alice's address
+	check := model.CheckAddress("0x742dAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA6329", contacts)
+	if check.Contact != nil {
+		t.Errorf("Expected no exact contact, got %+v", check.Contact)
+	}
+	if len(check.Lookalikes) != 1 || check.Lookalikes[0].Name != "Alice" {
+		t.Errorf("Expected Alice as lookalike, got %+v", check.Lookalikes)
+	}
+	if check.Warning == nil || !strings.Contains(*check.Warning, "Alice") {
+		t.Errorf("Expected a warning naming Alice, got %v", check.Warning)
+	}
+
+	// Bob's own address is safe
+	check = model.CheckAddress("0x1111111111111111111111111111111111111111", contacts)
+	if check.Contact == nil || check.Contact.Name != "Bob" || check.Warning != nil {
+		t.Errorf("Expected Bob without warning, got %+v", check)
+	}
+
+	// An unknown address that resembles nobody is neither
+	check = model.CheckAddress("0x2222222222222222222222222222222222222222", contacts)
+	if check.Contact != nil || len(check.Lookalikes) != 0 || check.Warning != nil {
+		t.Errorf("Expected an unknown address, got %+v", check)
+	}
+}
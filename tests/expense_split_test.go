@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"backend/internal/model"
+)
+
+func TestSplitEqually(t *testing.T) {
+	tests := []struct {
+		total int64
+		parts int
+		want  []int64
+	}{
+		{90, 3, []int64{30, 30, 30}},
+		{100, 3, []int64{34, 33, 33}},
+		{101, 4, []int64{26, 25, 25, 25}},
+		{2, 3, []int64{1, 1, 0}},
+		{7, 1, []int64{7}},
+	}
+
+	for _, tt := range tests {
+		shares := model.SplitEqually(model.AmountFromInt64(tt.total), tt.parts)
+		if len(shares) != len(tt.want) {
+			t.Fatalf("SplitEqually(%d, %d) returned %d shares, want %d", tt.total, tt.parts, len(shares), len(tt.want))
+		}
+
+		sum := model.Amount{}
+		for i, share := range shares {
+			if share.Cmp(model.AmountFromInt64(tt.want[i])) != 0 {
+				t.Errorf("SplitEqually(%d, %d)[%d] = %s, want %d", tt.total, tt.parts, i, share, tt.want[i])
+			}
+			sum = sum.Add(share)
+		}
+		if sum.Cmp(model.AmountFromInt64(tt.total)) != 0 {
+			t.Errorf("SplitEqually(%d, %d) shares add up to %s", tt.total, tt.parts, sum)
+		}
+	}
+
+	if shares := model.SplitEqually(model.AmountFromInt64(10), 0); shares != nil {
+		t.Errorf("Expected no shares for zero parts, got %v", shares)
+	}
+}
+
+func TestSplitShareStatusFor(t *testing.T) {
+	tests := map[model.PaymentStatus]model.SplitShareStatus{
+		model.PaymentStatusPending:    model.SplitShareStatusPending,
+		model.PaymentStatusConfirming: model.SplitShareStatusPending,
+		model.PaymentStatusConfirmed:  model.SplitShareStatusSettled,
+		model.PaymentStatusFailed:     model.SplitShareStatusOutstanding,
+		model.PaymentStatusCancelled:  model.SplitShareStatusOutstanding,
+	}
+
+	for paymentStatus, want := range tests {
+		if got := model.SplitShareStatusFor(paymentStatus); got != want {
+			t.Errorf("SplitShareStatusFor(%s) = %s, want %s", paymentStatus, got, want)
+		}
+	}
+}
+
+func TestSplitShareCanRemind(t *testing.T) {
+	now := time.Now()
+	recently := now.Add(-time.Hour)
+	longAgo := now.Add(-48 * time.Hour)
+
+	tests := []struct {
+		name   string
+		share  model.SplitShare
+		expect bool
+	}{
+		{"never reminded", model.SplitShare{Status: model.SplitShareStatusOutstanding}, true},
+		{"reminded recently", model.SplitShare{Status: model.SplitShareStatusOutstanding, LastRemindedAt: &recently}, false},
+		{"reminded long ago", model.SplitShare{Status: model.SplitShareStatusOutstanding, LastRemindedAt: &longAgo}, true},
+		{"pending", model.SplitShare{Status: model.SplitShareStatusPending}, false},
+		{"settled", model.SplitShare{Status: model.SplitShareStatusSettled}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.share.CanRemind(now, 24*time.Hour); got != tt.expect {
+			t.Errorf("%s: CanRemind = %v, want %v", tt.name, got, tt.expect)
+		}
+	}
+}
+
+func TestExpenseSplitToResponse(t *testing.T) {
+	split := &model.ExpenseSplit{
+		TotalAmount:   model.AmountFromInt64(3_000_000),
+		Currency:      "USDC",
+		TokenDecimals: 6,
+		Method:        model.SplitMethodEqual,
+	}
+	shares := []*model.SplitShare{
+		{Amount: model.AmountFromInt64(1_000_000), Status: model.SplitShareStatusSettled, ParticipantUsername: "alice"},
+		{Amount: model.AmountFromInt64(1_000_000), Status: model.SplitShareStatusPending, ParticipantUsername: "bob"},
+		{Amount: model.AmountFromInt64(1_000_000), Status: model.SplitShareStatusOutstanding, ParticipantUsername: "carol"},
+	}
+
+	response := split.ToResponse(shares)
+	if response.Status != model.ExpenseSplitStatusOpen {
+		t.Errorf("Expected open split, got %s", response.Status)
+	}
+	if response.OutstandingAmountFormatted != "2" || response.TotalAmountFormatted != "3" {
+		t.Errorf("Unexpected amounts: outstanding %s, total %s", response.OutstandingAmountFormatted, response.TotalAmountFormatted)
+	}
+	if len(response.Shares) != 3 || response.Shares[2].Participant.Username != "carol" || response.Shares[2].AmountFormatted != "1" {
+		t.Errorf("Unexpected shares: %+v", response.Shares)
+	}
+
+	for _, share := range shares {
+		share.Status = model.SplitShareStatusSettled
+	}
+	if response := split.ToResponse(shares); response.Status != model.ExpenseSplitStatusSettled || !response.OutstandingAmount.IsZero() {
+		t.Errorf("Expected settled split, got %s with %s outstanding", response.Status, response.OutstandingAmount)
+	}
+}
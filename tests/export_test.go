@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/export"
+	"backend/internal/model"
+)
+
+// exportPayments writes payments with a fresh writer for the format
+func exportPayments(t *testing.T, format export.Format, statement export.Statement, payments ...*model.Payment) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer, err := export.NewPaymentWriter(format, &buf, statement)
+	if err != nil {
+		t.Fatalf("NewPaymentWriter(%s) returned error: %v", format, err)
+	}
+	for _, payment := range payments {
+		if err := writer.WritePayment(payment); err != nil {
+			t.Fatalf("WritePayment returned error: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	return buf.String()
+}
+
+func TestParseExportFormat(t *testing.T) {
+	for _, name := range []string{"csv", "NDJSON", " ofx "} {
+		if _, err := export.ParseFormat(name); err != nil {
+			t.Errorf("ParseFormat(%q) returned error: %v", name, err)
+		}
+	}
+	if _, err := export.ParseFormat("xlsx"); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	out := exportPayments(t, export.FormatCSV, export.Statement{}, testPayment(model.PaymentStatusConfirmed))
+
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected a header and one row, got %d records", len(records))
+	}
+
+	row := make(map[string]string)
+	for i, column := range records[0] {
+		row[column] = records[1][i]
+	}
+
+	want := map[string]string{
+		"amount":           "1500000000000000000",
+		"amount_formatted": "1.5",
+		"direction":        "outgoing",
+		"confirmed_at":     "2024-03-01T12:02:00Z",
+		"gas_fee":          "630000000000000",
+		"gas_fee_eth":      "0.00063",
+		"description":      `Rent, March "flat 2"`,
+		"token_address":    "",
+		"tags":             "housing;monthly",
+	}
+	for column, value := range want {
+		if row[column] != value {
+			t.Errorf("%s = %q, want %q", column, row[column], value)
+		}
+	}
+}
+
+func TestExportCSVEscapesFormulas(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{`=HYPERLINK("http://example.com","Invoice")`, `'=HYPERLINK("http://example.com","Invoice")`},
+		{"+1+2", "'+1+2"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1:A2)", "'@SUM(A1:A2)"},
+		{"\tcmd", "'\tcmd"},
+		{"Dinner = 40", "Dinner = 40"},
+	}
+
+	for _, tt := range tests {
+		payment := testPayment(model.PaymentStatusConfirmed)
+		payment.Description = &tt.description
+
+		records, err := csv.NewReader(strings.NewReader(exportPayments(t, export.FormatCSV, export.Statement{}, payment))).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to parse CSV: %v", err)
+		}
+		for i, column := range records[0] {
+			if column == "description" && records[1][i] != tt.want {
+				t.Errorf("description = %q, want %q", records[1][i], tt.want)
+			}
+		}
+	}
+}
+
+func TestExportNDJSON(t *testing.T) {
+	pending := testPayment(model.PaymentStatusPending)
+	pending.GasUsed = nil
+	out := exportPayments(t, export.FormatNDJSON, export.Statement{}, testPayment(model.PaymentStatusConfirmed), pending)
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d: %s", len(lines), out)
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("Failed to parse line: %v", err)
+	}
+	if record["amount"] != "1500000000000000000" || record["amount_formatted"] != "1.5" || record["gas_fee"] != "630000000000000" {
+		t.Errorf("Unexpected record: %v", record)
+	}
+
+	record = nil
+	if err := json.Unmarshal([]byte(lines[1]), &record); err != nil {
+		t.Fatalf("Failed to parse line: %v", err)
+	}
+	if _, ok := record["gas_fee"]; ok {
+		t.Errorf("Expected no gas fee without gas details, got %v", record["gas_fee"])
+	}
+}
+
+func TestExportOFX(t *testing.T) {
+	statement := export.Statement{
+		AccountID: "user-1",
+		Currency:  "ETH",
+		Start:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:       time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+	}
+	incoming := testPayment(model.PaymentStatusConfirmed)
+	incoming.Direction = model.PaymentDirectionIncoming
+	incoming.FromAddress = "0x2222222222222222222222222222222222222222"
+
+	out := exportPayments(t, export.FormatOFX, statement,
+		testPayment(model.PaymentStatusConfirmed),
+		testPayment(model.PaymentStatusFailed),
+		incoming)
+
+	for _, want := range []string{
+		`<?OFX OFXHEADER="200" VERSION="220"`,
+		"<CURDEF>ETH</CURDEF>",
+		"<DTSTART>20240101000000.000[0:GMT]</DTSTART>",
+		"<TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20240301120200.000[0:GMT]</DTPOSTED>",
+		"<TRNAMT>-1.5</TRNAMT>",
+		"<TRNAMT>-0.00063</TRNAMT>",
+		"<TRNTYPE>CREDIT</TRNTYPE>",
+		"<TRNAMT>1.5</TRNAMT>",
+		"<NAME>0x222222222222222222222222222222</NAME>",
+		"<MEMO>Rent, March &#34;flat 2&#34;</MEMO>",
+		"</OFX>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("OFX output is missing %q:\n%s", want, out)
+		}
+	}
+
+	// The failed payment contributes only its gas fee, and the incoming
+	// payment's gas was paid by its sender
+	if got := strings.Count(out, "<TRNTYPE>DEBIT</TRNTYPE>"); got != 1 {
+		t.Errorf("Expected 1 debit, got %d", got)
+	}
+	if got := strings.Count(out, "<TRNTYPE>FEE</TRNTYPE>"); got != 2 {
+		t.Errorf("Expected 2 fees, got %d", got)
+	}
+}
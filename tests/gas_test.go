@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"backend/internal/ethclient"
+	"backend/internal/model"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+func TestNewGasFees(t *testing.T) {
+	// An EIP-1559 transfer mined with a 12 gwei base fee at an effective 13.5 gwei
+	fees := model.NewGasFees(21000, big.NewInt(13_500_000_000), big.NewInt(12_000_000_000))
+
+	if fees.BaseFee == nil || fees.BaseFee.String() != "12000000000" {
+		t.Errorf("BaseFee = %v, want 12000000000", fees.BaseFee)
+	}
+	if fees.PriorityFee == nil || fees.PriorityFee.String() != "1500000000" {
+		t.Errorf("PriorityFee = %v, want 1500000000", fees.PriorityFee)
+	}
+	if total := fees.Total().String(); total != "283500000000000" {
+		t.Errorf("Total = %s, want 283500000000000", total)
+	}
+
+	// Chains without a base fee have no split
+	legacy := model.NewGasFees(21000, big.NewInt(20_000_000_000), nil)
+	if legacy.BaseFee != nil || legacy.PriorityFee != nil {
+		t.Errorf("Expected no fee split without a base fee, got %v and %v", legacy.BaseFee, legacy.PriorityFee)
+	}
+}
+
+func TestPaymentGasFees(t *testing.T) {
+	payment := &model.Payment{}
+	if payment.GasFees() != nil || payment.GasFee() != nil {
+		t.Fatal("Expected no gas details on a new payment")
+	}
+
+	// Transactions not mined yet leave the payment's gas details unset
+	payment.SetGasFees(nil)
+	if payment.GasUsed != nil {
+		t.Fatal("Expected no gas details without fees")
+	}
+
+	fees := model.NewGasFees(52000, big.NewInt(13_500_000_000), big.NewInt(12_000_000_000))
+	payment.SetGasFees(fees)
+	if *payment.GasUsed != 52000 || payment.GasPrice.String() != "13500000000" || payment.PriorityFeePerGas.String() != "1500000000" {
+		t.Errorf("Unexpected gas details: %d at %s", *payment.GasUsed, payment.GasPrice)
+	}
+	if fee := payment.GasFee(); fee == nil || fee.Cmp(fees.Total()) != 0 {
+		t.Errorf("GasFee = %v, want %s", fee, fees.Total())
+	}
+
+	recorded := payment.GasFees()
+	if recorded == nil || recorded.GasUsed != fees.GasUsed || recorded.BaseFee.Cmp(*fees.BaseFee) != 0 {
+		t.Errorf("GasFees = %+v, want %+v", recorded, fees)
+	}
+}
+
+func TestNewFeeReport(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	report := model.NewFeeReport(from, to, []model.MonthlyFees{
+		{Month: from, Payments: 3, GasUsed: 63000, TotalFee: model.AmountFromInt64(1_000_000_000_000_000)},
+		{Month: from.AddDate(0, 1, 0), Payments: 1, GasUsed: 21000, TotalFee: model.AmountFromInt64(500_000_000_000_000)},
+	})
+
+	if report.Payments != 4 || report.GasUsed != 84000 {
+		t.Errorf("Expected 4 payments using 84000 gas, got %d using %d", report.Payments, report.GasUsed)
+	}
+	if report.TotalFee.String() != "1500000000000000" || report.TotalFeeFormatted != "0.0015" {
+		t.Errorf("TotalFee = %s (%s), want 1500000000000000 (0.0015)", report.TotalFee, report.TotalFeeFormatted)
+	}
+	if report.AverageFee.String() != "375000000000000" || report.AverageFeeFormatted != "0.000375" {
+		t.Errorf("AverageFee = %s (%s), want 375000000000000 (0.000375)", report.AverageFee, report.AverageFeeFormatted)
+	}
+
+	// Averages round down to whole wei
+	if average := report.Months[0].AverageFee.String(); average != "333333333333333" {
+		t.Errorf("January AverageFee = %s, want 333333333333333", average)
+	}
+
+	empty := model.NewFeeReport(from, to, []model.MonthlyFees{})
+	if !empty.AverageFee.IsZero() || empty.Payments != 0 {
+		t.Errorf("Expected an empty report, got %+v", empty)
+	}
+}
+
+func gwei(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1_000_000_000))
+}
+
+func TestFeeSuggestionsFromHistory(t *testing.T) {
+	history := &ethereum.FeeHistory{
+		OldestBlock: big.NewInt(100),
+		Reward: [][]*big.Int{
+			{gwei(1), gwei(2), gwei(5)},
+			{big.NewInt(0), big.NewInt(0), big.NewInt(0)}, // Empty block
+			{gwei(1), gwei(3), gwei(4)},
+			{gwei(2), gwei(3), gwei(9)},
+		},
+		BaseFee:      []*big.Int{gwei(10), gwei(11), gwei(10), gwei(12), gwei(13)},
+		GasUsedRatio: []float64{0.6, 0, 0.4, 0.9},
+	}
+
+	suggestions, err := ethclient.FeeSuggestionsFromHistory(history)
+	if err != nil {
+		t.Fatalf("FeeSuggestionsFromHistory returned error: %v", err)
+	}
+	if suggestions.BlockNumber != 103 || suggestions.BaseFee.String() != gwei(13).String() {
+		t.Errorf("Expected block 103 with a 13 gwei next base fee, got block %d at %s", suggestions.BlockNumber, suggestions.BaseFee)
+	}
+
+	// Medians of each percentile over the non-empty blocks, on top of twice
+	// the next base fee
+	tests := []struct {
+		speed    model.FeeSpeed
+		priority *big.Int
+	}{
+		{model.FeeSpeedSlow, gwei(1)},
+		{model.FeeSpeedStandard, gwei(3)},
+		{model.FeeSpeedFast, gwei(5)},
+	}
+	for _, tt := range tests {
+		suggestion := suggestions.Suggestion(tt.speed)
+		maxFee := new(big.Int).Add(gwei(26), tt.priority)
+		if suggestion.MaxPriorityFeePerGas.String() != tt.priority.String() || suggestion.MaxFeePerGas.String() != maxFee.String() {
+			t.Errorf("%s: got max fee %s with priority %s, want %s with %s",
+				tt.speed, suggestion.MaxFeePerGas, suggestion.MaxPriorityFeePerGas, maxFee, tt.priority)
+		}
+	}
+
+	if _, err := ethclient.FeeSuggestionsFromHistory(&ethereum.FeeHistory{}); err == nil {
+		t.Error("Expected an error for a fee history without base fees")
+	}
+}
+
+func TestFeeSuggestionsSetGasLimit(t *testing.T) {
+	suggestions := &model.FeeSuggestions{
+		BaseFee:  model.NewAmount(gwei(10)),
+		Standard: model.FeeSuggestion{MaxFeePerGas: model.NewAmount(gwei(22)), MaxPriorityFeePerGas: model.NewAmount(gwei(2))},
+		// A max fee too low to pay the full priority fee at the current base fee
+		Fast: model.FeeSuggestion{MaxFeePerGas: model.NewAmount(gwei(11)), MaxPriorityFeePerGas: model.NewAmount(gwei(3))},
+	}
+	suggestions.SetGasLimit(ethclient.TransferGas)
+
+	if cost := suggestions.Standard.EstimatedCost.String(); cost != "252000000000000" {
+		t.Errorf("Standard EstimatedCost = %s, want 252000000000000", cost)
+	}
+	if cost := suggestions.Standard.MaxCost.String(); cost != "462000000000000" {
+		t.Errorf("Standard MaxCost = %s, want 462000000000000", cost)
+	}
+	if cost := suggestions.Fast.EstimatedCost.String(); cost != suggestions.Fast.MaxCost.String() {
+		t.Errorf("Expected the fast cost to be capped at its max cost, got %s and %s", cost, suggestions.Fast.MaxCost)
+	}
+	if model.FeeSpeed("turbo").IsValid() {
+		t.Error("Expected turbo to be an invalid speed")
+	}
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/internal/middleware"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestIdempotencyFingerprint(t *testing.T) {
+	base := service.IdempotencyFingerprint("POST", "/payments", []byte(`{"amount":"1","currency":"ETH"}`))
+
+	same := service.IdempotencyFingerprint("POST", "/payments", []byte("{\n  \"amount\": \"1\",\n  \"currency\": \"ETH\"\n}"))
+	if same != base {
+		t.Error("Expected bodies differing only in whitespace to share a fingerprint")
+	}
+
+	for name, other := range map[string]string{
+		"body":   service.IdempotencyFingerprint("POST", "/payments", []byte(`{"amount":"2","currency":"ETH"}`)),
+		"path":   service.IdempotencyFingerprint("POST", "/invoices", []byte(`{"amount":"1","currency":"ETH"}`)),
+		"method": service.IdempotencyFingerprint("PUT", "/payments", []byte(`{"amount":"1","currency":"ETH"}`)),
+	} {
+		if other == base {
+			t.Errorf("Expected a different %s to change the fingerprint", name)
+		}
+	}
+}
+
+func TestIdempotencyMiddlewareWithoutStore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handled := 0
+	newRouter := func(userID string) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			if userID != "" {
+				c.Set(string(middleware.UserIDKey), userID)
+			}
+		})
+		r.POST("/payments", middleware.Idempotency(), func(c *gin.Context) {
+			handled++
+			c.JSON(http.StatusCreated, gin.H{"ok": true})
+		})
+		return r
+	}
+
+	tests := []struct {
+		name        string
+		userID      string
+		key         string
+		wantStatus  int
+		wantHandled bool
+	}{
+		{"no key passes through", uuid.NewString(), "", http.StatusCreated, true},
+		{"key too long", uuid.NewString(), strings.Repeat("k", service.MaxIdempotencyKeyLength+1), http.StatusBadRequest, false},
+		{"key without user", "", "retry-1", http.StatusUnauthorized, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handled = 0
+			req := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(`{}`))
+			if tt.key != "" {
+				req.Header.Set(middleware.IdempotencyKeyHeader, tt.key)
+			}
+
+			rr := httptest.NewRecorder()
+			newRouter(tt.userID).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			if (handled > 0) != tt.wantHandled {
+				t.Errorf("Expected handler called = %v, got %d calls", tt.wantHandled, handled)
+			}
+		})
+	}
+}
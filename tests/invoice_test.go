@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"testing"
+
+	"backend/internal/model"
+)
+
+func TestSettlementStatus(t *testing.T) {
+	amount := model.AmountFromInt64(1000)
+
+	tests := []struct {
+		name string
+		paid int64
+		want model.InvoiceStatus
+	}{
+		{name: "nothing received", paid: 0, want: model.InvoiceStatusOpen},
+		{name: "partial payment", paid: 999, want: model.InvoiceStatusUnderpaid},
+		{name: "exact payment", paid: 1000, want: model.InvoiceStatusPaid},
+		{name: "too much received", paid: 1001, want: model.InvoiceStatusOverpaid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := model.SettlementStatus(amount, model.AmountFromInt64(tt.paid)); got != tt.want {
+				t.Errorf("SettlementStatus(1000, %d) = %s, want %s", tt.paid, got, tt.want)
+			}
+		})
+	}
+
+	if !model.InvoiceStatusUnderpaid.AcceptsPayments() {
+		t.Error("underpaid invoices should still accept payments")
+	}
+	if model.InvoiceStatusPaid.AcceptsPayments() {
+		t.Error("paid invoices should not accept payments")
+	}
+}
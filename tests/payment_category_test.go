@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"backend/internal/model"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := model.NormalizeTags([]string{" Travel ", "client-x", "travel", "TRAVEL", "Q3"})
+	if err != nil {
+		t.Fatalf("NormalizeTags returned error: %v", err)
+	}
+
+	want := []string{"travel", "client-x", "q3"}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("NormalizeTags = %v, want %v", tags, want)
+	}
+
+	// No tags normalize to an empty list rather than nil, so a payment's
+	// tags can be cleared
+	tags, err = model.NormalizeTags(nil)
+	if err != nil || tags == nil || len(tags) != 0 {
+		t.Errorf("NormalizeTags(nil) = %v, %v, want an empty list", tags, err)
+	}
+}
+
+func TestNormalizeTagsRejectsInvalidTags(t *testing.T) {
+	tooMany := make([]string, model.MaxPaymentTags+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag-%d", i)
+	}
+
+	tests := []struct {
+		name string
+		tags []string
+	}{
+		{"blank tag", []string{"travel", "  "}},
+		{"overlong tag", []string{strings.Repeat("a", model.MaxPaymentTagLength+1)}},
+		{"too many tags", tooMany},
+	}
+
+	for _, tt := range tests {
+		if _, err := model.NormalizeTags(tt.tags); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+
+	// Duplicates do not count towards the limit
+	duplicates := append(tooMany[:model.MaxPaymentTags], "TAG-0")
+	if _, err := model.NormalizeTags(duplicates); err != nil {
+		t.Errorf("Expected duplicates to be dropped before the limit applies, got %v", err)
+	}
+}
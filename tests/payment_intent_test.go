@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+
+	"backend/internal/ethclient"
+	"backend/internal/model"
+)
+
+func TestPaymentIntentExpiry(t *testing.T) {
+	intent := &model.PaymentIntent{
+		Status:    model.PaymentIntentStatusPending,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+	if !intent.IsPayable() {
+		t.Error("expected an unexpired pending intent to be payable")
+	}
+	if got := intent.ToResponse().Status; got != model.PaymentIntentStatusPending {
+		t.Errorf("status = %s, want %s", got, model.PaymentIntentStatusPending)
+	}
+
+	intent.ExpiresAt = time.Now().Add(-time.Minute)
+	if intent.IsPayable() {
+		t.Error("expected an expired intent not to be payable")
+	}
+	if got := intent.ToResponse().Status; got != model.PaymentIntentStatusExpired {
+		t.Errorf("status = %s, want %s", got, model.PaymentIntentStatusExpired)
+	}
+
+	// Completed intents keep their status after expiry
+	intent.Status = model.PaymentIntentStatusCompleted
+	if got := intent.ToResponse().Status; got != model.PaymentIntentStatusCompleted {
+		t.Errorf("status = %s, want %s", got, model.PaymentIntentStatusCompleted)
+	}
+}
+
+func TestPaymentIntentStatusFor(t *testing.T) {
+	tests := map[model.PaymentStatus]model.PaymentIntentStatus{
+		model.PaymentStatusPending:    model.PaymentIntentStatusProcessing,
+		model.PaymentStatusConfirming: model.PaymentIntentStatusProcessing,
+		model.PaymentStatusConfirmed:  model.PaymentIntentStatusCompleted,
+		model.PaymentStatusFailed:     model.PaymentIntentStatusPending,
+		model.PaymentStatusCancelled:  model.PaymentIntentStatusPending,
+	}
+
+	for paymentStatus, want := range tests {
+		if got := model.PaymentIntentStatusFor(paymentStatus); got != want {
+			t.Errorf("PaymentIntentStatusFor(%s) = %s, want %s", paymentStatus, got, want)
+		}
+	}
+}
+
+func TestEncodeTokenTransfer(t *testing.T) {
+	data, err := ethclient.EncodeTokenTransfer("0x1111111111111111111111111111111111111111", big.NewInt(1000000))
+	if err != nil {
+		t.Fatalf("EncodeTokenTransfer returned error: %v", err)
+	}
+
+	want := "a9059cbb" +
+		"0000000000000000000000001111111111111111111111111111111111111111" +
+		"00000000000000000000000000000000000000000000000000000000000f4240"
+	if got := hex.EncodeToString(data); got != want {
+		t.Errorf("calldata = %s, want %s", got, want)
+	}
+
+	if _, err := ethclient.EncodeTokenTransfer("not-an-address", big.NewInt(1)); err == nil {
+		t.Error("expected an error for an invalid recipient")
+	}
+}
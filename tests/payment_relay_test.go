@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"backend/internal/ethclient"
+	"backend/internal/model"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// signedRawTransaction signs an EIP-1559 transaction with a new key and
+// returns it hex encoded, with the signer's address
+func signedRawTransaction(t *testing.T, tx *types.DynamicFeeTx) (string, common.Address) {
+	t.Helper()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	signed, err := types.SignNewTx(key, types.LatestSignerForChainID(tx.ChainID), tx)
+	if err != nil {
+		t.Fatalf("Failed to sign transaction: %v", err)
+	}
+
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to encode transaction: %v", err)
+	}
+	return hexutil.Encode(raw), crypto.PubkeyToAddress(key.PublicKey)
+}
+
+func TestDecodeRawTransaction(t *testing.T) {
+	to := common.HexToAddress("0x742d35Cc6634C0532925a3b844Bc454e4438f44e")
+	raw, sender := signedRawTransaction(t, &types.DynamicFeeTx{
+		ChainID:   big.NewInt(11155111),
+		Nonce:     7,
+		GasTipCap: gwei(2),
+		GasFeeCap: gwei(30),
+		Gas:       ethclient.TransferGas,
+		To:        &to,
+		Value:     big.NewInt(1_000_000_000_000_000),
+	})
+
+	tx, err := ethclient.DecodeRawTransaction(raw)
+	if err != nil {
+		t.Fatalf("DecodeRawTransaction returned error: %v", err)
+	}
+	if tx.ChainId().Int64() != 11155111 || tx.Nonce() != 7 {
+		t.Errorf("Expected chain 11155111 and nonce 7, got %s and %d", tx.ChainId(), tx.Nonce())
+	}
+
+	details := ethclient.PendingTransactionDetails(tx)
+	if details.From != sender.Hex() || details.To != to.Hex() {
+		t.Errorf("Expected %s to %s, got %s to %s", sender.Hex(), to.Hex(), details.From, details.To)
+	}
+	if details.Value.String() != "1000000000000000" || details.BlockNumber != nil {
+		t.Errorf("Expected a pending transfer of 1000000000000000 wei, got %s in block %v", details.Value, details.BlockNumber)
+	}
+	if details.Hash != tx.Hash().Hex() {
+		t.Errorf("Hash = %s, want %s", details.Hash, tx.Hash().Hex())
+	}
+
+	for _, invalid := range []string{"", "0xzz", "0x02f8"} {
+		if _, err := ethclient.DecodeRawTransaction(invalid); err == nil {
+			t.Errorf("Expected an error decoding %q", invalid)
+		}
+	}
+}
+
+func TestPendingTransactionDetailsTokenTransfer(t *testing.T) {
+	token := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	recipient := "0x742d35Cc6634C0532925a3b844Bc454e4438f44e"
+	data, err := ethclient.EncodeTokenTransfer(recipient, big.NewInt(2_500_000))
+	if err != nil {
+		t.Fatalf("EncodeTokenTransfer returned error: %v", err)
+	}
+
+	raw, sender := signedRawTransaction(t, &types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		GasTipCap: gwei(1),
+		GasFeeCap: gwei(20),
+		Gas:       65000,
+		To:        &token,
+		Value:     new(big.Int),
+		Data:      data,
+	})
+
+	tx, err := ethclient.DecodeRawTransaction(raw)
+	if err != nil {
+		t.Fatalf("DecodeRawTransaction returned error: %v", err)
+	}
+
+	// The transfer is decoded from the call, as the transaction has no logs yet
+	transfers := ethclient.PendingTransactionDetails(tx).TokenTransfers
+	if transfer := ethclient.MatchTokenTransfer(transfers, token.Hex(), sender.Hex(), recipient, model.AmountFromInt64(2_500_000)); transfer == nil {
+		t.Errorf("Expected a transfer of 2500000 to %s, got %+v", recipient, transfers)
+	}
+}
+
+func TestRelayPaymentRequestPaymentRequest(t *testing.T) {
+	description := "Rent"
+	preparedID := "6f1c1d2e-9f3a-4b6e-8c2d-1a2b3c4d5e6f"
+	relay := &model.RelayPaymentRequest{
+		RawTransaction:    "0x02",
+		ToAddress:         "0x742d35Cc6634C0532925a3b844Bc454e4438f44e",
+		Amount:            "1000",
+		Currency:          "USDC",
+		Description:       &description,
+		PreparedPaymentID: &preparedID,
+		Tags:              []string{"home"},
+	}
+
+	hash := "0x" + strings.Repeat("ab", 32)
+	req := relay.PaymentRequest(hash)
+	if req.TransactionHash != hash || req.ToAddress != relay.ToAddress || req.Amount != "1000" || req.Currency != "USDC" {
+		t.Errorf("Unexpected payment request %+v", req)
+	}
+	if req.PreparedPaymentID != &preparedID || req.Description != &description || len(req.Tags) != 1 {
+		t.Errorf("Expected the preparation, description and tags to carry over, got %+v", req)
+	}
+	if req.IntentID != nil || req.SplitShareID != nil {
+		t.Error("Expected no intent or split share")
+	}
+}
+
+// rpcTestError is a JSON-RPC error response from the node
+type rpcTestError struct {
+	code    int
+	message string
+}
+
+func (e rpcTestError) Error() string  { return e.message }
+func (e rpcTestError) ErrorCode() int { return e.code }
+
+func TestIsTransactionRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nonce too low", rpcTestError{-32000, "nonce too low"}, true},
+		{"wrapped rejection", fmt.Errorf("send: %w", rpcTestError{-32000, "insufficient funds for gas * price + value"}), true},
+		{"internal node error", rpcTestError{-32603, "internal error"}, false},
+		{"rate limited", rpcTestError{-32005, "limit exceeded"}, false},
+		{"timeout", context.DeadlineExceeded, false},
+		{"gateway error", rpc.HTTPError{StatusCode: 502, Status: "502 Bad Gateway"}, false},
+		{"connection refused", errors.New("dial tcp: connection refused"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ethclient.IsTransactionRejected(tt.err); got != tt.want {
+				t.Errorf("IsTransactionRejected(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/service"
+)
+
+func TestPaymentStatusTransitions(t *testing.T) {
+	statuses := []model.PaymentStatus{
+		model.PaymentStatusPending,
+		model.PaymentStatusConfirming,
+		model.PaymentStatusConfirmed,
+		model.PaymentStatusFailed,
+		model.PaymentStatusCancelled,
+	}
+
+	allowed := map[model.PaymentStatus][]model.PaymentStatus{
+		model.PaymentStatusPending: {
+			model.PaymentStatusPending, model.PaymentStatusConfirming, model.PaymentStatusConfirmed,
+			model.PaymentStatusFailed, model.PaymentStatusCancelled,
+		},
+		model.PaymentStatusConfirming: {
+			model.PaymentStatusConfirming, model.PaymentStatusConfirmed, model.PaymentStatusFailed,
+		},
+	}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			want := false
+			for _, next := range allowed[from] {
+				if next == to {
+					want = true
+				}
+			}
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%s -> %s: CanTransitionTo = %v, want %v", from, to, got, want)
+			}
+		}
+
+		if got, want := from.IsFinal(), len(allowed[from]) == 0; got != want {
+			t.Errorf("%s: IsFinal = %v, want %v", from, got, want)
+		}
+	}
+}
+
+func TestReconcilePaymentStatus(t *testing.T) {
+	const (
+		block   = "0x1111111111111111111111111111111111111111111111111111111111111111"
+		reorged = "0x2222222222222222222222222222222222222222222222222222222222222222"
+	)
+
+	tests := []struct {
+		name          string
+		status        model.PaymentStatus
+		recordedHash  string // Block the payment was last seen in, if any
+		paid          bool
+		confirmations int64
+		wantStatus    model.PaymentStatus
+		wantChanged   bool
+	}{
+		{"just mined", model.PaymentStatusPending, "", true, 1, model.PaymentStatusConfirming, true},
+		{"mined deep enough at once", model.PaymentStatusPending, "", true, 12, model.PaymentStatusConfirmed, true},
+		{"still confirming", model.PaymentStatusConfirming, block, true, 5, model.PaymentStatusConfirming, false},
+		{"reaches required depth", model.PaymentStatusConfirming, block, true, 12, model.PaymentStatusConfirmed, true},
+		{"moved to another block", model.PaymentStatusConfirming, reorged, true, 5, model.PaymentStatusConfirming, true},
+		{"reverted", model.PaymentStatusPending, "", false, 0, model.PaymentStatusFailed, true},
+		{"no matching token transfer", model.PaymentStatusConfirming, block, false, 0, model.PaymentStatusFailed, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payment := testPayment(tt.status)
+			if tt.recordedHash != "" {
+				payment.BlockHash = &tt.recordedHash
+			}
+
+			status, changed := service.ReconcilePaymentStatus(payment, tt.paid, tt.confirmations, 12, block)
+			if status != tt.wantStatus || changed != tt.wantChanged {
+				t.Errorf("ReconcilePaymentStatus() = %s, %v, want %s, %v", status, changed, tt.wantStatus, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestTransactionAbandoned(t *testing.T) {
+	const timeout = 3 * time.Hour
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		status model.PaymentStatus
+		age    time.Duration
+		want   bool
+	}{
+		{name: "recently recorded", status: model.PaymentStatusPending, age: time.Minute, want: false},
+		{name: "just under the timeout", status: model.PaymentStatusPending, age: timeout - time.Second, want: false},
+		{name: "at the timeout", status: model.PaymentStatusPending, age: timeout, want: true},
+		{name: "long unknown", status: model.PaymentStatusPending, age: 48 * time.Hour, want: true},
+		{name: "confirming is left to reorg detection", status: model.PaymentStatusConfirming, age: 48 * time.Hour, want: false},
+		{name: "already failed", status: model.PaymentStatusFailed, age: 48 * time.Hour, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payment := testPayment(tt.status)
+			payment.UpdatedAt = now.Add(-tt.age)
+			if got := service.TransactionAbandoned(payment, now, timeout); got != tt.want {
+				t.Errorf("TransactionAbandoned() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/api/handler"
+	"backend/internal/middleware"
+	"backend/internal/model"
+	"backend/internal/pubsub"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+func streamTestEvent(userID uuid.UUID, status model.PaymentStatus) model.PaymentEvent {
+	payment := testPayment(status)
+	payment.UserID = userID
+	return model.NewPaymentStatusChangedEvent(model.PaymentStatusConfirming, payment)
+}
+
+func TestPubSubHub(t *testing.T) {
+	hub := pubsub.NewHub(2)
+	alice, bob := uuid.New(), uuid.New()
+
+	aliceSub := hub.Subscribe(alice)
+	bobSub := hub.Subscribe(bob)
+	defer bobSub.Close()
+
+	hub.Publish(streamTestEvent(alice, model.PaymentStatusConfirmed))
+
+	select {
+	case event := <-aliceSub.Events():
+		if event.Payment.Status != model.PaymentStatusConfirmed || *event.PreviousStatus != model.PaymentStatusConfirming {
+			t.Errorf("Unexpected event: %+v", event)
+		}
+	default:
+		t.Fatal("Expected alice to receive her event")
+	}
+
+	select {
+	case event := <-bobSub.Events():
+		t.Fatalf("Bob received alice's event: %+v", event)
+	default:
+	}
+
+	aliceSub.Close()
+	aliceSub.Close() // Closing twice is harmless
+	if _, open := <-aliceSub.Events(); open {
+		t.Error("Expected a closed subscription's channel to be closed")
+	}
+	if hub.Subscribers(alice) != 0 {
+		t.Errorf("Expected no subscribers left for alice, got %d", hub.Subscribers(alice))
+	}
+}
+
+func TestPubSubHubDropsSlowSubscribers(t *testing.T) {
+	hub := pubsub.NewHub(2)
+	userID := uuid.New()
+	sub := hub.Subscribe(userID)
+
+	// The third event overflows the buffer; publishing must not block
+	for i := 0; i < 3; i++ {
+		hub.Publish(streamTestEvent(userID, model.PaymentStatusConfirmed))
+	}
+
+	received := 0
+	for range sub.Events() {
+		received++
+	}
+	if received != 2 {
+		t.Errorf("Expected the 2 buffered events before the channel closed, got %d", received)
+	}
+	if hub.Subscribers(userID) != 0 {
+		t.Error("Expected the slow subscriber to be dropped")
+	}
+}
+
+// streamTestRouter serves the payment streams for a fixed user
+func streamTestRouter(userID uuid.UUID) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(string(middleware.UserIDKey), userID.String())
+	})
+	r.GET("/payments/stream", handler.StreamPaymentsHandler)
+	r.GET("/payments/ws", handler.StreamPaymentsWebSocketHandler)
+	return r
+}
+
+// waitForSubscriber waits until the stream handler has subscribed to the hub
+func waitForSubscriber(t *testing.T, userID uuid.UUID) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for pubsub.Default().Subscribers(userID) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Stream did not subscribe in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPaymentStreamSSE(t *testing.T) {
+	userID := uuid.New()
+	server := httptest.NewServer(streamTestRouter(userID))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/payments/stream", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("Unexpected content type %q", ct)
+	}
+
+	waitForSubscriber(t, userID)
+	pubsub.Default().Publish(streamTestEvent(uuid.New(), model.PaymentStatusFailed)) // Another user's
+	pubsub.Default().Publish(streamTestEvent(userID, model.PaymentStatusConfirmed))
+
+	var eventName, data string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, ok := strings.CutPrefix(line, "event:"); ok {
+			eventName = name
+		}
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			data = value
+			break
+		}
+	}
+
+	if eventName != string(model.PaymentEventStatusChanged) {
+		t.Errorf("Expected a %s event, got %q", model.PaymentEventStatusChanged, eventName)
+	}
+
+	var event model.PaymentEvent
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		t.Fatalf("Failed to decode event %q: %v", data, err)
+	}
+	if event.Payment.Status != model.PaymentStatusConfirmed {
+		t.Errorf("Expected the user's confirmed payment, got %+v", event)
+	}
+}
+
+func TestPaymentStreamWebSocket(t *testing.T) {
+	userID := uuid.New()
+	server := httptest.NewServer(streamTestRouter(userID))
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/payments/ws"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to open WebSocket: %v", err)
+	}
+	defer conn.Close()
+
+	waitForSubscriber(t, userID)
+	payment := testPayment(model.PaymentStatusPending)
+	payment.UserID = userID
+	pubsub.Default().Publish(model.NewPaymentCreatedEvent(payment))
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var event model.PaymentEvent
+	if err := conn.ReadJSON(&event); err != nil {
+		t.Fatalf("Failed to read event: %v", err)
+	}
+	if event.Type != model.PaymentEventCreated || event.Payment.ID != payment.ID || event.PreviousStatus != nil {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+
+	// Closing the connection releases the subscription
+	conn.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for pubsub.Default().Subscribers(userID) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Subscription was not released after the client disconnected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPaymentStreamSSEOutlivesWriteTimeout(t *testing.T) {
+	userID := uuid.New()
+	server := httptest.NewUnstartedServer(streamTestRouter(userID))
+	server.Config.WriteTimeout = 200 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/payments/stream", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Publish once the server's write timeout has passed
+	waitForSubscriber(t, userID)
+	time.Sleep(500 * time.Millisecond)
+	pubsub.Default().Publish(streamTestEvent(userID, model.PaymentStatusConfirmed))
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "data:") {
+			return
+		}
+	}
+	t.Fatalf("Stream ended before the event arrived: %v", scanner.Err())
+}
+
+func TestStructuredLoggerRedactsAccessToken(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer slog.SetDefault(previous)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.StructuredLogger())
+	r.GET("/payments/stream", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	// Malformed queries are redacted too
+	for _, query := range []string{"access_token=secret-token&x=1", "access_token=secret-token&y=%zz", "x=1&access%5Ftoken=secret-token"} {
+		logs.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/payments/stream?"+query, nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		if strings.Contains(logs.String(), "secret-token") {
+			t.Errorf("Access token logged for query %q: %s", query, logs.String())
+		}
+		if !strings.Contains(logs.String(), "REDACTED") {
+			t.Errorf("Expected the token to be redacted for query %q: %s", query, logs.String())
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -53,10 +54,15 @@ func TestPaymentAPI(t *testing.T) {
 	// Add payment routes with auth middleware
 	payments := r.Group("/payments")
 	payments.Use(middleware.AuthMiddleware())
-	payments.POST("", handler.CreatePaymentHandler)
+	payments.POST("", middleware.Idempotency(), handler.CreatePaymentHandler)
 	payments.GET("", handler.GetUserPaymentsHandler)
 	payments.GET("/:id", handler.GetPaymentHandler)
 	payments.GET("/stats", handler.GetPaymentStatsHandler)
+	payments.GET("/analytics", handler.GetPaymentAnalyticsHandler)
+	payments.GET("/export", handler.ExportPaymentsHandler)
+	payments.POST("/:id/cancel", handler.CancelPaymentHandler)
+	payments.POST("/:id/refunds", handler.CreateRefundHandler)
+	payments.GET("/:id/refunds", handler.GetPaymentRefundsHandler)
 
 	// Create test user
 	userJSON, err := json.Marshal(user)
@@ -171,6 +177,64 @@ func TestPaymentAPI(t *testing.T) {
 		}
 	})
 
+	// Test filtering and sorting options are accepted
+	t.Run("GetUserPayments_Filters", func(t *testing.T) {
+		url := "/payments?status=confirmed&direction=incoming&currency=ETH&created_after=2024-01-01T00:00:00Z" +
+			"&counterparty=0x742d35Cc6633C0532925a3b8D4C0f56e3c4D6329&min_amount=1&max_amount=1000000000000000000" +
+			"&sort_by=amount&sort_order=asc&page_size=5"
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+mockToken)
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("Get filtered payments failed: got %v want %v, body: %s", status, http.StatusOK, rr.Body.String())
+			return
+		}
+
+		var response model.PaymentListResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Errorf("Failed to unmarshal response: %v", err)
+			return
+		}
+
+		if response.PageSize != 5 || response.NextCursor != nil {
+			t.Errorf("Unexpected pagination for empty result: page_size=%d next_cursor=%v", response.PageSize, response.NextCursor)
+		}
+	})
+
+	// Test invalid list queries are rejected
+	t.Run("GetUserPayments_InvalidQuery", func(t *testing.T) {
+		invalidQueries := []string{
+			"sort_by=status",
+			"sort_order=sideways",
+			"direction=sideways",
+			"min_amount=-1",
+			"min_amount=10&max_amount=1",
+			"created_after=2024-02-01T00:00:00Z&created_before=2024-01-01T00:00:00Z",
+			"cursor=not-a-cursor",
+		}
+
+		for _, query := range invalidQueries {
+			req, err := http.NewRequest("GET", "/payments?"+query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+mockToken)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("Expected 400 for %q, got %v, body: %s", query, status, rr.Body.String())
+			}
+		}
+	})
+
 	// Test Get Payment Stats
 	t.Run("GetPaymentStats", func(t *testing.T) {
 		req, err := http.NewRequest("GET", "/payments/stats", nil)
@@ -203,6 +267,110 @@ func TestPaymentAPI(t *testing.T) {
 		}
 	})
 
+	// Test Get Payment Analytics
+	t.Run("GetPaymentAnalytics", func(t *testing.T) {
+		url := "/payments/analytics?from=2024-01-01T00:00:00Z&to=2024-04-01T00:00:00Z&interval=week&currency=ETH&top_counterparties=3"
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+mockToken)
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("Get payment analytics failed: got %v want %v, body: %s", status, http.StatusOK, rr.Body.String())
+			return
+		}
+
+		var analytics model.PaymentAnalytics
+		if err := json.Unmarshal(rr.Body.Bytes(), &analytics); err != nil {
+			t.Errorf("Failed to unmarshal analytics response: %v", err)
+			return
+		}
+
+		if analytics.Interval != model.AnalyticsIntervalWeek {
+			t.Errorf("Expected interval week, got %s", analytics.Interval)
+		}
+		if len(analytics.Totals) != 0 || len(analytics.Series) != 0 || len(analytics.TopCounterparties) != 0 {
+			t.Errorf("Expected empty analytics for new user, got %+v", analytics)
+		}
+		if !analytics.Gas.Fee.IsZero() || analytics.ConfirmationTime.ConfirmedPayments != 0 {
+			t.Errorf("Expected no gas or confirmations for new user, got %+v", analytics)
+		}
+	})
+
+	// Test invalid analytics queries are rejected
+	t.Run("GetPaymentAnalytics_InvalidQuery", func(t *testing.T) {
+		invalidQueries := []string{
+			"interval=year",
+			"from=2024-02-01T00:00:00Z&to=2024-01-01T00:00:00Z",
+			"top_counterparties=500",
+			"from=yesterday",
+		}
+
+		for _, query := range invalidQueries {
+			req, err := http.NewRequest("GET", "/payments/analytics?"+query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+mockToken)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("Expected 400 for %q, got %v, body: %s", query, status, rr.Body.String())
+			}
+		}
+	})
+
+	// Test Export Payments
+	t.Run("ExportPayments", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/payments/export?format=csv&status=confirmed", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+mockToken)
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("Export payments failed: got %v want %v, body: %s", status, http.StatusOK, rr.Body.String())
+			return
+		}
+
+		if contentType := rr.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/csv") {
+			t.Errorf("Expected a CSV content type, got %q", contentType)
+		}
+
+		// A new user's export holds only the header row
+		lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+		if len(lines) != 1 || !strings.HasPrefix(lines[0], "id,created_at,") {
+			t.Errorf("Unexpected CSV export: %q", rr.Body.String())
+		}
+	})
+
+	// Test invalid export requests are rejected
+	t.Run("ExportPayments_InvalidQuery", func(t *testing.T) {
+		for _, query := range []string{"format=xlsx", "format=csv&sort_by=status"} {
+			req, err := http.NewRequest("GET", "/payments/export?"+query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+mockToken)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("Expected 400 for %q, got %v, body: %s", query, status, rr.Body.String())
+			}
+		}
+	})
+
 	// Test Invalid Payment ID
 	t.Run("GetPayment_InvalidID", func(t *testing.T) {
 		req, err := http.NewRequest("GET", "/payments/invalid-id", nil)
@@ -219,6 +387,57 @@ func TestPaymentAPI(t *testing.T) {
 		}
 	})
 
+	// Test Cancelling Payments
+	t.Run("CancelPayment_NotFound", func(t *testing.T) {
+		for path, want := range map[string]int{
+			"/payments/invalid-id/cancel":                  http.StatusBadRequest,
+			"/payments/" + uuid.New().String() + "/cancel": http.StatusNotFound,
+		} {
+			req, err := http.NewRequest("POST", path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+mockToken)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != want {
+				t.Errorf("POST %s: expected %v, got %v, body: %s", path, want, status, rr.Body.String())
+			}
+		}
+	})
+
+	// Test Refunds
+	t.Run("Refunds_NotFound", func(t *testing.T) {
+		missing := "/payments/" + uuid.New().String() + "/refunds"
+		refundJSON := `{"transaction_hash":"0x` + strings.Repeat("ab", 32) + `"}`
+
+		for _, tc := range []struct {
+			method, path, body string
+			want               int
+		}{
+			{"GET", "/payments/invalid-id/refunds", "", http.StatusBadRequest},
+			{"GET", missing, "", http.StatusNotFound},
+			{"POST", missing, `{"transaction_hash":"0x1234"}`, http.StatusBadRequest},
+			{"POST", missing, refundJSON, http.StatusNotFound},
+		} {
+			req, err := http.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+mockToken)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tc.want {
+				t.Errorf("%s %s: expected %v, got %v, body: %s", tc.method, tc.path, tc.want, status, rr.Body.String())
+			}
+		}
+	})
+
 	// Test Unauthorized Access
 	t.Run("UnauthorizedAccess", func(t *testing.T) {
 		req, err := http.NewRequest("GET", "/payments", nil)
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"backend/internal/model"
+)
+
+func TestPreparedPaymentMaxFee(t *testing.T) {
+	prepared := &model.PreparedPayment{
+		GasLimit:     65000,
+		MaxFeePerGas: model.NewAmount(gwei(30)),
+	}
+
+	if fee := prepared.MaxFee().String(); fee != "1950000000000000" {
+		t.Errorf("MaxFee = %s, want 1950000000000000", fee)
+	}
+}
+
+func TestPreparedPaymentToResponse(t *testing.T) {
+	prepared := &model.PreparedPayment{
+		Status:    model.PreparedPaymentStatusPending,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	if status := prepared.ToResponse().Status; status != model.PreparedPaymentStatusPending {
+		t.Errorf("Status = %s, want pending", status)
+	}
+
+	// Preparations past their expiry no longer reserve their nonce
+	prepared.ExpiresAt = time.Now().Add(-time.Minute)
+	if status := prepared.ToResponse().Status; status != model.PreparedPaymentStatusExpired {
+		t.Errorf("Status = %s, want expired", status)
+	}
+
+	prepared.Status = model.PreparedPaymentStatusCompleted
+	if status := prepared.ToResponse().Status; status != model.PreparedPaymentStatusCompleted {
+		t.Errorf("Status = %s, want completed", status)
+	}
+}
+
+func TestPreparedPaymentIsBindable(t *testing.T) {
+	// A transaction broadcast after expiry still binds to its preparation
+	prepared := &model.PreparedPayment{
+		Status:    model.PreparedPaymentStatusPending,
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}
+	if !prepared.IsBindable() {
+		t.Error("Expected an expired pending preparation to be bindable")
+	}
+
+	prepared.Status = model.PreparedPaymentStatusCompleted
+	if prepared.IsBindable() {
+		t.Error("Expected a completed preparation not to be bindable")
+	}
+}
+
+func TestPreparedPaymentStatusFor(t *testing.T) {
+	tests := map[model.PaymentStatus]model.PreparedPaymentStatus{
+		model.PaymentStatusPending:    model.PreparedPaymentStatusProcessing,
+		model.PaymentStatusConfirming: model.PreparedPaymentStatusProcessing,
+		model.PaymentStatusConfirmed:  model.PreparedPaymentStatusCompleted,
+		model.PaymentStatusFailed:     model.PreparedPaymentStatusPending,
+		model.PaymentStatusCancelled:  model.PreparedPaymentStatusPending,
+	}
+
+	for paymentStatus, want := range tests {
+		if got := model.PreparedPaymentStatusFor(paymentStatus); got != want {
+			t.Errorf("PreparedPaymentStatusFor(%s) = %s, want %s", paymentStatus, got, want)
+		}
+	}
+}
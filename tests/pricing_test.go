@@ -0,0 +1,206 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/pricing"
+)
+
+func TestParseExchangeRate(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+		valid  bool
+	}{
+		{"3012.45", "3012.45", true},
+		{"3012.4500", "3012.45", true},
+		{"1", "1", true},
+		{"0.000012345678901234567", "0.000012345678901235", true},
+		{"0", "", false},
+		{"-1.5", "", false},
+		{"1/3", "", false},
+		{"1e3", "", false},
+		{"abc", "", false},
+	}
+
+	for _, tt := range tests {
+		rate, err := model.ParseExchangeRate(tt.input)
+		if tt.valid != (err == nil) {
+			t.Errorf("ParseExchangeRate(%q) error = %v, want valid %v", tt.input, err, tt.valid)
+			continue
+		}
+		if tt.valid && rate.String() != tt.expect {
+			t.Errorf("ParseExchangeRate(%q) = %s, want %s", tt.input, rate, tt.expect)
+		}
+	}
+}
+
+func TestExchangeRateConvert(t *testing.T) {
+	rate, _ := model.ParseExchangeRate("3012.45")
+
+	tests := []struct {
+		name   string
+		amount string
+		unit   model.Unit
+		fiat   model.FiatCurrency
+		expect string
+	}{
+		{"one ether in cents", "1000000000000000000", 18, "USD", "301245"},
+		{"1.5 ether in cents", "1500000000000000000", 18, "USD", "451868"}, // 4518.675 rounds up
+		{"one wei rounds to zero", "1", 18, "USD", "0"},
+		{"yen have no minor unit", "1000000000000000000", 18, "JPY", "3012"},
+		{"six decimal token", "2500000", 6, "INR", "753113"}, // 7531.125 rounds up
+	}
+
+	for _, tt := range tests {
+		amount, _ := model.ParseAmount(tt.amount)
+		if got := rate.Convert(amount, tt.unit, tt.fiat).String(); got != tt.expect {
+			t.Errorf("%s: Convert = %s, want %s", tt.name, got, tt.expect)
+		}
+	}
+}
+
+func TestFiatValuationResponse(t *testing.T) {
+	rate, _ := model.ParseExchangeRate("2000")
+	payment := &model.Payment{Amount: model.AmountFromInt64(1_234_500_000_000_000), TokenDecimals: 18}
+
+	response := model.NewFiatValuation(payment, "USD", rate, "file", time.Now()).ToResponse()
+	if response.Value.String() != "247" || response.ValueFormatted != "2.47" {
+		t.Errorf("Expected 247 cents formatted as 2.47, got %s formatted as %s", response.Value, response.ValueFormatted)
+	}
+
+	var unvalued *model.FiatValuation
+	if unvalued.ToResponse() != nil {
+		t.Error("Expected no response for an unvalued payment")
+	}
+}
+
+func TestParseFiatCurrency(t *testing.T) {
+	if currency, err := model.ParseFiatCurrency(" inr "); err != nil || currency != "INR" {
+		t.Errorf("ParseFiatCurrency(inr) = %q, %v, want INR", currency, err)
+	}
+	if _, err := model.ParseFiatCurrency("XYZ"); err == nil {
+		t.Error("Expected an error for an unsupported currency")
+	}
+}
+
+func TestStaticSource(t *testing.T) {
+	rates, err := pricing.ParseStaticRates(strings.NewReader(`{"ETH": {"USD": "3012.45", "inr": 251000}, "usdc": {"USD": "1"}}`))
+	if err != nil {
+		t.Fatalf("ParseStaticRates returned error: %v", err)
+	}
+	source := pricing.NewStaticSource("file", rates)
+
+	quote, err := source.Quote(context.Background(), "eth", "INR")
+	if err != nil || quote.Rate.String() != "251000" || quote.Source != "file" {
+		t.Errorf("Quote(eth, INR) = %+v, %v", quote, err)
+	}
+	if quote, err := source.Quote(context.Background(), "USDC", "USD"); err != nil || quote.Rate.String() != "1" {
+		t.Errorf("Quote(USDC, USD) = %+v, %v", quote, err)
+	}
+	if _, err := source.Quote(context.Background(), "ETH", "EUR"); !errors.Is(err, pricing.ErrRateUnavailable) {
+		t.Errorf("Expected ErrRateUnavailable for a missing rate, got %v", err)
+	}
+
+	if _, err := pricing.ParseStaticRates(strings.NewReader(`{"ETH": {"XYZ": "1"}}`)); err == nil {
+		t.Error("Expected an error for an unsupported fiat currency")
+	}
+	if _, err := pricing.ParseStaticRates(strings.NewReader(`{"ETH": {"USD": "-1"}}`)); err == nil {
+		t.Error("Expected an error for a negative rate")
+	}
+}
+
+func TestHTTPSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/simple/price" || r.URL.Query().Get("ids") != "ethereum" {
+			http.NotFound(w, r)
+			return
+		}
+		// A price with more digits than a float64 holds
+		fmt.Fprintf(w, `{"ethereum": {"%s": 3012.123456789012345678}}`, r.URL.Query().Get("vs_currencies"))
+	}))
+	defer server.Close()
+
+	source := pricing.NewHTTPSource(server.URL, map[string]string{"eth": "ethereum", "DAI": "dai"}, server.Client())
+
+	quote, err := source.Quote(context.Background(), "ETH", "USD")
+	if err != nil {
+		t.Fatalf("Quote returned error: %v", err)
+	}
+	if quote.Rate.String() != "3012.123456789012345678" || quote.Source != "http" {
+		t.Errorf("Unexpected quote: %+v", quote)
+	}
+
+	if _, err := source.Quote(context.Background(), "WBTC", "USD"); !errors.Is(err, pricing.ErrRateUnavailable) {
+		t.Errorf("Expected ErrRateUnavailable for an unmapped symbol, got %v", err)
+	}
+	if _, err := source.Quote(context.Background(), "DAI", "USD"); err == nil {
+		t.Error("Expected an error when the API does not know the coin")
+	}
+}
+
+// countingSource quotes a fixed rate and counts how often it is asked
+type countingSource struct {
+	calls atomic.Int32
+	fail  bool
+}
+
+func (s *countingSource) Quote(_ context.Context, currency string, fiat model.FiatCurrency) (pricing.Quote, error) {
+	s.calls.Add(1)
+	if s.fail {
+		return pricing.Quote{}, errors.New("price API down")
+	}
+	rate, _ := model.ParseExchangeRate("3000")
+	return pricing.Quote{Currency: currency, Fiat: fiat, Rate: rate, Source: "counting", QuotedAt: time.Now()}, nil
+}
+
+func TestCachedSource(t *testing.T) {
+	upstream := &countingSource{}
+	cached := pricing.NewCachedSource(upstream, time.Minute)
+
+	for range 3 {
+		if _, err := cached.Quote(context.Background(), "eth", "USD"); err != nil {
+			t.Fatalf("Quote returned error: %v", err)
+		}
+	}
+	if _, err := cached.Quote(context.Background(), "ETH", "INR"); err != nil {
+		t.Fatalf("Quote returned error: %v", err)
+	}
+
+	// One call per currency pair, regardless of symbol casing
+	if calls := upstream.calls.Load(); calls != 2 {
+		t.Errorf("Expected 2 upstream calls, got %d", calls)
+	}
+
+	// Expired quotes are fetched again
+	upstream = &countingSource{}
+	expiring := pricing.NewCachedSource(upstream, time.Nanosecond)
+	for range 2 {
+		expiring.Quote(context.Background(), "ETH", "USD")
+		time.Sleep(time.Millisecond)
+	}
+	if calls := upstream.calls.Load(); calls != 2 {
+		t.Errorf("Expected expired quotes to be fetched again, got %d upstream calls", calls)
+	}
+
+	// Failures are not cached
+	down := &countingSource{fail: true}
+	failing := pricing.NewCachedSource(down, time.Minute)
+	for range 2 {
+		if _, err := failing.Quote(context.Background(), "ETH", "USD"); err == nil {
+			t.Error("Expected the upstream error")
+		}
+	}
+	if calls := down.calls.Load(); calls != 2 {
+		t.Errorf("Expected failed quotes to be retried, got %d upstream calls", calls)
+	}
+}
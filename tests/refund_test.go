@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"testing"
+
+	"backend/internal/ethclient"
+	"backend/internal/model"
+)
+
+func TestPaymentRefundStatus(t *testing.T) {
+	tests := []struct {
+		refunded string
+		want     *model.RefundStatus
+	}{
+		{"0", nil},
+		{"0.5", refundStatusPtr(model.RefundStatusPartiallyRefunded)},
+		{"1.5", refundStatusPtr(model.RefundStatusRefunded)},
+	}
+
+	for _, tt := range tests {
+		payment := testPayment(model.PaymentStatusConfirmed)
+		payment.RefundedAmount, _ = model.ParseDecimalAmount(tt.refunded, model.Ether)
+
+		response := payment.ToResponse()
+		if response.RefundedAmount.Cmp(payment.RefundedAmount) != 0 {
+			t.Errorf("refunded %s: response refunded_amount = %s", tt.refunded, response.RefundedAmount)
+		}
+
+		got := response.RefundStatus
+		switch {
+		case got == nil && tt.want == nil:
+		case got == nil || tt.want == nil || *got != *tt.want:
+			t.Errorf("refunded %s: refund_status = %v, want %v", tt.refunded, got, tt.want)
+		}
+	}
+}
+
+func TestSumTokenTransfers(t *testing.T) {
+	const (
+		token    = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+		merchant = "0x1111111111111111111111111111111111111111"
+		customer = "0x742d35Cc6633C0532925a3b8D4C0f56e3c4D6329"
+	)
+
+	transfers := []model.TokenTransfer{
+		{Token: token, From: merchant, To: customer, Value: model.AmountFromInt64(1_000_000)},
+		{Token: token, From: merchant, To: customer, Value: model.AmountFromInt64(500_000)},
+		{Token: token, From: customer, To: merchant, Value: model.AmountFromInt64(7)}, // Wrong direction
+		{Token: "0xdAC17F958D2ee523a2206206994597C13D831ec7", From: merchant, To: customer, Value: model.AmountFromInt64(9)},
+	}
+
+	// Addresses are compared case-insensitively
+	got := ethclient.SumTokenTransfers(transfers, token, merchant, "0x742d35cc6633c0532925a3b8d4c0f56e3c4d6329")
+	if got.Cmp(model.AmountFromInt64(1_500_000)) != 0 {
+		t.Errorf("SumTokenTransfers = %s, want 1500000", got)
+	}
+
+	if got := ethclient.SumTokenTransfers(nil, token, merchant, customer); !got.IsZero() {
+		t.Errorf("SumTokenTransfers(nil) = %s, want 0", got)
+	}
+}
+
+func refundStatusPtr(status model.RefundStatus) *model.RefundStatus {
+	return &status
+}
+
+func TestTransactionDetailsMinedAfter(t *testing.T) {
+	mined := func(block int64, index uint) *model.TransactionDetails {
+		return &model.TransactionDetails{BlockNumber: &block, TransactionIndex: &index}
+	}
+	payment := mined(100, 5)
+
+	tests := []struct {
+		name   string
+		refund *model.TransactionDetails
+		want   bool
+	}{
+		{"later block", mined(101, 0), true},
+		{"later in the same block", mined(100, 6), true},
+		{"earlier in the same block", mined(100, 4), false},
+		{"earlier block", mined(99, 9), false},
+		{"not mined", &model.TransactionDetails{}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.refund.MinedAfter(payment); got != tt.want {
+			t.Errorf("%s: MinedAfter = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"backend/internal/model"
+)
+
+func TestDetectPaymentReorg(t *testing.T) {
+	const (
+		recorded  = "0xaaaa000000000000000000000000000000000000000000000000000000000001"
+		canonical = "0xbbbb000000000000000000000000000000000000000000000000000000000002"
+	)
+
+	payment := testPayment(model.PaymentStatusConfirmed)
+	blockNumber := int64(1200)
+	blockHash := recorded
+	payment.BlockNumber = &blockNumber
+	payment.BlockHash = &blockHash
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		canonicalHash string
+		found         bool
+		wantReorg     bool
+	}{
+		{name: "still canonical", canonicalHash: recorded, found: true, wantReorg: false},
+		{name: "hashes compared case-insensitively", canonicalHash: "0xAAAA000000000000000000000000000000000000000000000000000000000001", found: true, wantReorg: false},
+		{name: "orphaned", canonicalHash: canonical, found: true, wantReorg: true},
+		{name: "height not filled yet", canonicalHash: "", found: false, wantReorg: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := model.DetectPaymentReorg(payment, tt.canonicalHash, tt.found, now)
+			if (event != nil) != tt.wantReorg {
+				t.Fatalf("DetectPaymentReorg() = %+v, want reorg %v", event, tt.wantReorg)
+			}
+			if event == nil {
+				return
+			}
+
+			if event.PaymentID != payment.ID || event.BlockNumber != blockNumber || event.DetectedAt != now {
+				t.Errorf("Unexpected event: %+v", event)
+			}
+			if event.OrphanedBlockHash != recorded || event.CanonicalBlockHash != tt.canonicalHash {
+				t.Errorf("Unexpected block hashes: orphaned %s, canonical %s", event.OrphanedBlockHash, event.CanonicalBlockHash)
+			}
+			if event.PreviousStatus != model.PaymentStatusConfirmed {
+				t.Errorf("Expected the previous status to be confirmed, got %s", event.PreviousStatus)
+			}
+		})
+	}
+
+	// Payments that are not mined have no block to orphan
+	pending := testPayment(model.PaymentStatusPending)
+	if event := model.DetectPaymentReorg(pending, canonical, true, now); event != nil {
+		t.Errorf("Expected no reorg for an unmined payment, got %+v", event)
+	}
+}
+
+func TestSettlementChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous model.PaymentStatus
+		next     model.PaymentStatus
+		want     int
+	}{
+		{name: "confirms from pending", previous: model.PaymentStatusPending, next: model.PaymentStatusConfirmed, want: 1},
+		{name: "confirms from confirming", previous: model.PaymentStatusConfirming, next: model.PaymentStatusConfirmed, want: 1},
+		{name: "still confirming", previous: model.PaymentStatusPending, next: model.PaymentStatusConfirming, want: 0},
+		{name: "fails before confirming", previous: model.PaymentStatusConfirming, next: model.PaymentStatusFailed, want: 0},
+		{name: "cancelled while pending", previous: model.PaymentStatusPending, next: model.PaymentStatusCancelled, want: 0},
+		{name: "rolled back by a reorg", previous: model.PaymentStatusConfirmed, next: model.PaymentStatusPending, want: -1},
+		{name: "confirmed again", previous: model.PaymentStatusConfirmed, next: model.PaymentStatusConfirmed, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := model.SettlementChange(tt.previous, tt.next); got != tt.want {
+				t.Errorf("SettlementChange(%s, %s) = %d, want %d", tt.previous, tt.next, got, tt.want)
+			}
+		})
+	}
+}
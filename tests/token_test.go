@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"math/big"
+	"testing"
+
+	"backend/internal/ethclient"
+	"backend/internal/model"
+)
+
+func TestFormatTokenAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		decimals uint8
+		want     string
+	}{
+		{name: "whole USDC", value: "25000000", decimals: 6, want: "25"},
+		{name: "fractional USDC", value: "1500000", decimals: 6, want: "1.5"},
+		{name: "below one unit", value: "42", decimals: 6, want: "0.000042"},
+		{name: "one wei", value: "1", decimals: 18, want: "0.000000000000000001"},
+		{name: "zero decimals", value: "7", decimals: 0, want: "7"},
+		{name: "zero", value: "0", decimals: 18, want: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, _ := new(big.Int).SetString(tt.value, 10)
+			if got := ethclient.FormatTokenAmount(value, tt.decimals); got != tt.want {
+				t.Errorf("FormatTokenAmount(%s, %d) = %s, want %s", tt.value, tt.decimals, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchTokenTransfer(t *testing.T) {
+	const (
+		usdc  = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+		dai   = "0x6B175474E89094C44Da98b954EedeAC495271d0F"
+		payer = "0x742d35Cc6633C0532925a3b8D4C0f56e3c4D6329"
+		payee = "0x1111111111111111111111111111111111111111"
+	)
+
+	transfers := []model.TokenTransfer{
+		{Token: dai, From: payer, To: payee, Value: model.AmountFromInt64(1000000)},
+		{Token: usdc, From: payer, To: payee, Value: model.AmountFromInt64(999999)},
+		{Token: usdc, From: payer, To: payee, Value: model.AmountFromInt64(1000000)},
+	}
+
+	// Addresses are compared case-insensitively
+	match := ethclient.MatchTokenTransfer(transfers, "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", payer, payee, model.AmountFromInt64(1000000))
+	if match == nil {
+		t.Fatal("expected a matching USDC transfer")
+	}
+	if match != &transfers[2] {
+		t.Errorf("matched the wrong transfer: %+v", match)
+	}
+
+	if ethclient.MatchTokenTransfer(transfers, usdc, payee, payer, model.AmountFromInt64(1000000)) != nil {
+		t.Error("expected no match when sender and recipient are swapped")
+	}
+
+	if ethclient.MatchTokenTransfer(transfers, usdc, payer, payee, model.AmountFromInt64(5)) != nil {
+		t.Error("expected no match for a different amount")
+	}
+}
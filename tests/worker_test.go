@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend/internal/worker"
+)
+
+// signal notifies a buffered channel without blocking if a notification is
+// already pending
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func TestPeriodicWorkerRunsUntilStopped(t *testing.T) {
+	var runs atomic.Int32
+	ran := make(chan struct{}, 1)
+	w := worker.NewPeriodicWorker("test", 10*time.Millisecond, func(ctx context.Context) {
+		runs.Add(1)
+		signal(ran)
+	})
+
+	w.Start()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-ran:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Task ran %d times, want at least 2", runs.Load())
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := w.Stop(ctx); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+
+	stopped := runs.Load()
+	time.Sleep(50 * time.Millisecond)
+	if n := runs.Load(); n != stopped {
+		t.Errorf("Task ran %d more times after Stop", n-stopped)
+	}
+}
+
+func TestPeriodicWorkerStopCancelsRun(t *testing.T) {
+	started := make(chan struct{}, 1)
+	cancelled := make(chan struct{}, 1)
+	w := worker.NewPeriodicWorker("test", 10*time.Millisecond, func(ctx context.Context) {
+		signal(started)
+		<-ctx.Done()
+		signal(cancelled)
+	})
+
+	w.Start()
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Task did not run")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := w.Stop(ctx); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+
+	select {
+	case <-cancelled:
+	default:
+		t.Error("Expected Stop to cancel the in-flight run")
+	}
+}
+
+func TestPeriodicWorkerStopTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	started := make(chan struct{}, 1)
+	w := worker.NewPeriodicWorker("test", 10*time.Millisecond, func(ctx context.Context) {
+		signal(started)
+		<-release // Ignores cancellation
+	})
+
+	w.Start()
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Task did not run")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := w.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestPeriodicWorkerStopBeforeStart(t *testing.T) {
+	w := worker.NewPeriodicWorker("test", time.Hour, func(ctx context.Context) {})
+	if err := w.Stop(context.Background()); err != nil {
+		t.Errorf("Stop before Start returned error: %v", err)
+	}
+	if w.Name() != "test" {
+		t.Errorf("Name = %q, want %q", w.Name(), "test")
+	}
+}
+
+func TestReconcileIntervalFromEnv(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", worker.DefaultReconcileInterval},
+		{"1m", time.Minute},
+		{"45s", 45 * time.Second},
+		{"soon", worker.DefaultReconcileInterval},
+		{"-5s", worker.DefaultReconcileInterval},
+		{"0s", worker.DefaultReconcileInterval},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("PAYMENT_RECONCILE_INTERVAL", tt.value)
+		if got := worker.ReconcileIntervalFromEnv(); got != tt.want {
+			t.Errorf("ReconcileIntervalFromEnv() with %q = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}